@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/service/gophermart"
+)
+
+// exportFileName is the name of the file with the personal data suggested to the client.
+const exportFileName = "gophermart-data-export.json"
+
+// DeleteAccountRequest represents json request for deleting the account.
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+	// Code is a TOTP or recovery code required if two-factor authentication is enabled.
+	Code string `json:"code"`
+}
+
+// ExportData — download everything stored about the user as a JSON file.
+//
+// GET /api/user/data-export
+func (h Handlers) ExportData(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "ExportData").Logger()
+
+	export, err := h.svc.ExportData(r.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("exporting user's data")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+
+		return
+	}
+	w.Header().Set("Content-Disposition", `attachment; filename="`+exportFileName+`"`)
+	writeJSON(w, log, http.StatusOK, export)
+}
+
+// DeleteAccount — delete the account after the password (and the second factor) confirmation. The financial logs are kept anonymously.
+//
+// DELETE /api/user
+func (h Handlers) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "DeleteAccount").Logger()
+	if !checkContentType(r, "application/json") {
+		log.Error().Msg("wrong Content-type")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+	req := DeleteAccountRequest{}
+	dec := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	if err := dec.Decode(&req); err != nil {
+		log.Error().Err(err).Msg("unmarshalling request body")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+
+	err := h.svc.DeleteAccount(r.Context(), req.Password, req.Code)
+	switch {
+	case err == nil:
+		clearSessionCookie(w)
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, gophermart.ErrWrongPassword):
+		log.Error().Err(err).Msg("deleting the account")
+		http.Error(w, "Wrong password", http.StatusForbidden)
+	case errors.Is(err, gophermart.ErrInvalidCode):
+		log.Error().Err(err).Msg("deleting the account")
+		http.Error(w, "Invalid code", http.StatusUnprocessableEntity)
+	default:
+		log.Error().Err(err).Msg("deleting the account")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/vanamelnik/gophermart/model"
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/service/gophermart"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+type (
+	// AdjustmentRequest represents json request for a manual balance adjustment.
+	AdjustmentRequest struct {
+		Sum    float32 `json:"sum"`
+		Reason string  `json:"reason"`
+	}
+
+	// RoleRequest represents json request for setting the user's role.
+	RoleRequest struct {
+		Role model.Role `json:"role"`
+		// Permissions are granted in addition to the permissions of the role.
+		Permissions []model.Permission `json:"permissions"`
+	}
+)
+
+// SearchUsers — search users by login.
+//
+// GET /api/admin/users?login=<substring>
+func (h Handlers) SearchUsers(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "SearchUsers").Logger()
+
+	users, err := h.svc.SearchUsers(r.Context(), r.URL.Query().Get("login"))
+	if err != nil {
+		log.Error().Err(err).Msg("searching users")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+
+		return
+	}
+
+	writeJSON(w, log, http.StatusOK, users)
+}
+
+// GetUserInfo — get user's data, balance, orders, withdrawals and adjustments.
+//
+// GET /api/admin/users/{id}
+func (h Handlers) GetUserInfo(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "GetUserInfo").Logger()
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error().Err(err).Msg("parsing user id")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+
+	info, err := h.svc.UserInfo(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			log.Error().Err(err).Msg("fetching user info")
+			http.Error(w, "Not found", http.StatusNotFound)
+
+			return
+		}
+		log.Error().Err(err).Msg("fetching user info")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+
+		return
+	}
+
+	writeJSON(w, log, http.StatusOK, info)
+}
+
+// AdjustBalance — manually credit (positive sum) or debit (negative sum) user's points.
+//
+// POST /api/admin/users/{id}/adjustments
+func (h Handlers) AdjustBalance(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "AdjustBalance").Logger()
+	if !checkContentType(r, "application/json") {
+		log.Error().Msg("wrong Content-type")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error().Err(err).Msg("parsing user id")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+	req := AdjustmentRequest{}
+	dec := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	if err := dec.Decode(&req); err != nil {
+		log.Error().Err(err).Msg("unmarshalling request body")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+
+	adj, err := h.svc.AdjustBalance(r.Context(), id, req.Sum, req.Reason)
+	switch {
+	case err == nil:
+		writeJSON(w, log, http.StatusCreated, adj)
+	case errors.Is(err, gophermart.ErrReasonRequired), errors.Is(err, gophermart.ErrInvalidAmount):
+		log.Error().Err(err).Msg("adjusting balance")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, storage.ErrNotFound):
+		log.Error().Err(err).Msg("adjusting balance")
+		http.Error(w, "Not found", http.StatusNotFound)
+	case errors.Is(err, storage.ErrInsufficientPoints):
+		log.Error().Err(err).Msg("adjusting balance")
+		http.Error(w, "Insufficient points", http.StatusConflict)
+	default:
+		log.Error().Err(err).Msg("adjusting balance")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// BlockUser — block the user's account.
+//
+// POST /api/admin/users/{id}/block
+func (h Handlers) BlockUser(w http.ResponseWriter, r *http.Request) {
+	h.setUserBlocked(w, r, true)
+}
+
+// UnblockUser — unblock the user's account.
+//
+// POST /api/admin/users/{id}/unblock
+func (h Handlers) UnblockUser(w http.ResponseWriter, r *http.Request) {
+	h.setUserBlocked(w, r, false)
+}
+
+func (h Handlers) setUserBlocked(w http.ResponseWriter, r *http.Request, blocked bool) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "SetUserBlocked").Bool("blocked", blocked).Logger()
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error().Err(err).Msg("parsing user id")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+
+	if err := h.svc.SetUserBlocked(r.Context(), id, blocked); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			log.Error().Err(err).Msg("updating user")
+			http.Error(w, "Not found", http.StatusNotFound)
+
+			return
+		}
+		log.Error().Err(err).Msg("updating user")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnlockUser — reset failed login attempts of the user and unlock the login.
+//
+// POST /api/admin/users/{id}/unlock
+func (h Handlers) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "UnlockUser").Logger()
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error().Err(err).Msg("parsing user id")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+
+	if err := h.svc.UnlockUser(r.Context(), id); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			log.Error().Err(err).Msg("unlocking user")
+			http.Error(w, "Not found", http.StatusNotFound)
+
+			return
+		}
+		log.Error().Err(err).Msg("unlocking user")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetUserRole — set the user's role and additional permissions.
+//
+// PUT /api/admin/users/{id}/role
+func (h Handlers) SetUserRole(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "SetUserRole").Logger()
+	if !checkContentType(r, "application/json") {
+		log.Error().Msg("wrong Content-type")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error().Err(err).Msg("parsing user id")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+	req := RoleRequest{}
+	dec := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	if err := dec.Decode(&req); err != nil {
+		log.Error().Err(err).Msg("unmarshalling request body")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+
+	err = h.svc.SetUserRole(r.Context(), id, req.Role, req.Permissions)
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, gophermart.ErrInvalidRole), errors.Is(err, gophermart.ErrOwnRole):
+		log.Error().Err(err).Msg("setting user's role")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, storage.ErrNotFound):
+		log.Error().Err(err).Msg("setting user's role")
+		http.Error(w, "Not found", http.StatusNotFound)
+	default:
+		log.Error().Err(err).Msg("setting user's role")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// RepollOrder — force the order to be polled from the accrual service again.
+//
+// POST /api/admin/orders/{number}/repoll
+func (h Handlers) RepollOrder(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "RepollOrder").Logger()
+	orderID := model.OrderID(chi.URLParam(r, "number"))
+	log = log.With().Str("orderID", orderID.String()).Logger()
+
+	err := h.svc.RepollOrder(r.Context(), orderID)
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusAccepted)
+	case errors.Is(err, storage.ErrNotFound):
+		log.Error().Err(err).Msg("re-polling order")
+		http.Error(w, "Not found", http.StatusNotFound)
+	case errors.Is(err, gophermart.ErrOrderAlreadyProcessed):
+		log.Error().Err(err).Msg("re-polling order")
+		http.Error(w, "The order is already processed", http.StatusConflict)
+	default:
+		log.Error().Err(err).Msg("re-polling order")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,189 @@
+package handlers_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/service/gophermart"
+	mockgophermart "github.com/vanamelnik/gophermart/service/gophermart/mock"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+)
+
+func TestAdmin(t *testing.T) {
+	userURL := "/api/admin/users/" + user.ID.String()
+	unknownID := uuid.New()
+	blocked := admin
+	blocked.Blocked = true
+	// The user is granted the permission personally.
+	moderator := user
+	moderator.Permissions = []model.Permission{model.PermUsersBlock}
+
+	runHandlerTests(t, []handlerTest{
+		{
+			name:       "#1 Not signed in",
+			method:     http.MethodGet,
+			target:     userURL,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "#2 Blocked administrator",
+			user:       &blocked,
+			method:     http.MethodGet,
+			target:     userURL,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "#3 Permission denied to the user",
+			user:       &user,
+			method:     http.MethodGet,
+			target:     userURL,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "#4 Permission denied to the support",
+			user:       &support,
+			method:     http.MethodPost,
+			target:     userURL + "/block",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:   "#5 Permission granted personally",
+			user:   &moderator,
+			method: http.MethodPost,
+			target: userURL + "/block",
+			mock: func(svc *mockgophermart.MockService) {
+				svc.EXPECT().SetUserBlocked(gomock.Any(), user.ID, true).Return(nil).Times(1)
+			},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:   "#6 Support reads user info",
+			user:   &support,
+			method: http.MethodGet,
+			target: userURL,
+			mock: func(svc *mockgophermart.MockService) {
+				svc.EXPECT().UserInfo(gomock.Any(), user.ID).Return(gophermart.UserInfo{}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "#7 Unknown user",
+			user:   &admin,
+			method: http.MethodGet,
+			target: userURL,
+			mock: func(svc *mockgophermart.MockService) {
+				svc.EXPECT().UserInfo(gomock.Any(), user.ID).Return(gophermart.UserInfo{}, storage.ErrNotFound).Times(1)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "#8 Invalid user id",
+			user:       &admin,
+			method:     http.MethodGet,
+			target:     "/api/admin/users/frodo",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "#9 Adjustment without reason",
+			user:   &admin,
+			method: http.MethodPost,
+			target: userURL + "/adjustments",
+			body:   `{"sum":100}`,
+			mock: func(svc *mockgophermart.MockService) {
+				svc.EXPECT().AdjustBalance(gomock.Any(), user.ID, float32(100), "").
+					Return(model.Adjustment{}, gophermart.ErrReasonRequired).Times(1)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "#10 Adjustment exceeds the balance",
+			user:   &admin,
+			method: http.MethodPost,
+			target: userURL + "/adjustments",
+			body:   `{"sum":-100,"reason":"refund"}`,
+			mock: func(svc *mockgophermart.MockService) {
+				svc.EXPECT().AdjustBalance(gomock.Any(), user.ID, float32(-100), "refund").
+					Return(model.Adjustment{}, storage.ErrInsufficientPoints).Times(1)
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:   "#11 Own role",
+			user:   &admin,
+			method: http.MethodPut,
+			target: "/api/admin/users/" + admin.ID.String() + "/role",
+			body:   `{"role":"user"}`,
+			mock: func(svc *mockgophermart.MockService) {
+				svc.EXPECT().SetUserRole(gomock.Any(), admin.ID, model.RoleUser, gomock.Any()).
+					Return(gophermart.ErrOwnRole).Times(1)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "#12 Invalid role",
+			user:   &admin,
+			method: http.MethodPut,
+			target: userURL + "/role",
+			body:   `{"role":"wizard"}`,
+			mock: func(svc *mockgophermart.MockService) {
+				svc.EXPECT().SetUserRole(gomock.Any(), user.ID, model.Role("wizard"), gomock.Any()).
+					Return(gophermart.ErrInvalidRole).Times(1)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "#13 Roles can't be managed by the support",
+			user:       &support,
+			method:     http.MethodPut,
+			target:     userURL + "/role",
+			body:       `{"role":"admin"}`,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:   "#14 Unlock",
+			user:   &admin,
+			method: http.MethodPost,
+			target: userURL + "/unlock",
+			mock: func(svc *mockgophermart.MockService) {
+				svc.EXPECT().UnlockUser(gomock.Any(), user.ID).Return(nil).Times(1)
+			},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:   "#15 Repoll processed order",
+			user:   &support,
+			method: http.MethodPost,
+			target: "/api/admin/orders/12345678903/repoll",
+			mock: func(svc *mockgophermart.MockService) {
+				svc.EXPECT().RepollOrder(gomock.Any(), model.OrderID("12345678903")).
+					Return(gophermart.ErrOrderAlreadyProcessed).Times(1)
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:   "#16 Internal error",
+			user:   &admin,
+			method: http.MethodGet,
+			target: "/api/admin/users?login=frodo",
+			mock: func(svc *mockgophermart.MockService) {
+				svc.EXPECT().SearchUsers(gomock.Any(), "frodo").Return(nil, errors.New("db is down")).Times(1)
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:   "#17 Block unknown user",
+			user:   &admin,
+			method: http.MethodPost,
+			target: "/api/admin/users/" + unknownID.String() + "/block",
+			mock: func(svc *mockgophermart.MockService) {
+				svc.EXPECT().SetUserBlocked(gomock.Any(), unknownID, true).Return(storage.ErrNotFound).Times(1)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+	})
+}
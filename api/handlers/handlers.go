@@ -1,35 +1,38 @@
 package handlers
 
 import (
-	"crypto/rand"
-	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"io"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/vanamelnik/gophermart/model"
 	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/pkg/health"
+	"github.com/vanamelnik/gophermart/pkg/middleware"
 	"github.com/vanamelnik/gophermart/service/gophermart"
 	"github.com/vanamelnik/gophermart/storage"
-)
 
-const (
-	rememberTokenSize = 32
-	cookieName        = "gophermart_remember"
+	"github.com/rs/zerolog"
 )
 
 // Handlers represents API handlers.
 type Handlers struct {
 	svc gophermart.Service
 	db  storage.Storage
+	// health runs the readiness checks.
+	health *health.Checker
 }
 
-func New(svc gophermart.Service, db storage.Storage) Handlers {
+func New(svc gophermart.Service, db storage.Storage, checker *health.Checker) Handlers {
 	return Handlers{
-		svc: svc,
-		db:  db,
+		svc:    svc,
+		db:     db,
+		health: checker,
 	}
 }
 
@@ -69,6 +72,18 @@ func (h Handlers) Register(w http.ResponseWriter, r *http.Request) {
 
 			return
 		}
+		if errors.Is(err, gophermart.ErrInvalidLogin) {
+			log.Error().Err(err).Msg("could not create the user")
+			http.Error(w, "Invalid login", http.StatusBadRequest)
+
+			return
+		}
+		if errors.Is(err, gophermart.ErrInvalidPassword) {
+			log.Error().Err(err).Msg("could not create the user")
+			writeInvalidPassword(w, log, err)
+
+			return
+		}
 		log.Error().Err(err).Msg("creating a new user")
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
@@ -79,11 +94,10 @@ func (h Handlers) Register(w http.ResponseWriter, r *http.Request) {
 
 		return
 	}
-
-	w.WriteHeader(http.StatusOK)
 }
 
-// Login — user authentication.
+// Login — user authentication. If the user has two-factor authentication enabled, the login challenge
+// is returned with 202 status and the sign-in is completed by LoginTwoFactor.
 //
 // POST /api/user/login
 func (h Handlers) Login(w http.ResponseWriter, r *http.Request) {
@@ -105,16 +119,46 @@ func (h Handlers) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.svc.Authenticate(r.Context(), u.Login, u.Password)
+	user, err := h.svc.Authenticate(r.Context(), u.Login, u.Password, remoteIP(r))
 	if err != nil {
-		if errors.Is(err, gophermart.ErrWrongPassword) {
+		var throttled *gophermart.LoginThrottledError
+		if errors.As(err, &throttled) {
+			log.Error().Err(err).Msg("authenticate: ")
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+
+			return
+		}
+		if errors.Is(err, gophermart.ErrWrongCredentials) {
 			log.Error().Err(err).Msg("authenticate: ")
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 
 			return
 		}
+		if errors.Is(err, gophermart.ErrUserBlocked) {
+			log.Error().Err(err).Msg("authenticate: ")
+			http.Error(w, "Forbidden", http.StatusForbidden)
+
+			return
+		}
 		log.Error().Err(err).Msg("authenticate: ")
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
+
+		return
+	}
+
+	challenge, err := h.svc.TwoFactorChallenge(r.Context(), user)
+	if err != nil {
+		log.Error().Err(err).Msg("authenticate: ")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+
+		return
+	}
+	if challenge != "" {
+		// The sign-in is completed by LoginTwoFactor.
+		writeJSON(w, log, http.StatusAccepted, TwoFactorChallengeResponse{TwoFactorRequired: true, Challenge: challenge})
+
+		return
 	}
 
 	if err := h.signIn(w, r, user); err != nil {
@@ -123,8 +167,6 @@ func (h Handlers) Login(w http.ResponseWriter, r *http.Request) {
 
 		return
 	}
-
-	w.WriteHeader(http.StatusOK)
 }
 
 // PostOrder — load an order number to calculate.
@@ -298,33 +340,56 @@ func (h Handlers) GetWithdrawals(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// signIn creates a remember token and stores it in DB and in the user's cookie.
+// signIn starts a new session of the user, stores the session token in the user's cookie and
+// writes the tokens to the response.
 func (h Handlers) signIn(w http.ResponseWriter, r *http.Request, user model.User) error {
-	remember, err := generateToken()
+	log := appContext.Logger(r.Context())
+	sessionToken, session, err := h.svc.CreateSession(r.Context(), user, r.UserAgent(), remoteIP(r))
 	if err != nil {
 		return err
 	}
-	user.RememberToken = remember
-	if err := h.db.UpdateUser(r.Context(), user); err != nil {
+	resp := TokenResponse{RefreshToken: sessionToken}
+	accessToken, expiresAt, err := h.svc.IssueAccessToken(r.Context(), user, session.ID)
+	switch {
+	case err == nil:
+		resp.setAccessToken(accessToken, expiresAt)
+	case errors.Is(err, gophermart.ErrAccessTokensDisabled):
+		// Only the cookie is used.
+	default:
 		return err
 	}
+
 	cookie := http.Cookie{
-		Name:  cookieName,
-		Value: remember,
+		Name:     middleware.SessionCookieName,
+		Value:    sessionToken,
+		Path:     "/",
+		Expires:  session.ExpiresAt,
+		HttpOnly: true,
 	}
 	http.SetCookie(w, &cookie)
+	writeJSON(w, log, http.StatusOK, resp)
 
 	return nil
 }
 
-// generateToken creates a random token.
-func generateToken() (string, error) {
-	b := make([]byte, rememberTokenSize)
-	if _, err := rand.Read(b); err != nil {
-		return "", err
+// remoteIP returns the IP address of the client.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
 	}
 
-	return base64.URLEncoding.EncodeToString(b), nil
+	return host
+}
+
+// writeJSON writes the status code provided and the value encoded to json.
+func writeJSON(w http.ResponseWriter, log zerolog.Logger, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(v); err != nil {
+		log.Error().Err(err).Msg("marshalling response")
+	}
 }
 
 // checkContentType checks whether "Content-type" field in the request contains the string provided.
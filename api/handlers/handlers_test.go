@@ -0,0 +1,86 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vanamelnik/gophermart/api/rest"
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/pkg/logging"
+	"github.com/vanamelnik/gophermart/pkg/middleware"
+	mockgophermart "github.com/vanamelnik/gophermart/service/gophermart/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	sessionToken = "session token"
+	apiKey       = "gm_live_secret"
+)
+
+var (
+	admin   = model.User{ID: uuid.New(), Login: "gandalf@middle.earth", Role: model.RoleAdmin}
+	support = model.User{ID: uuid.New(), Login: "sam@middle.earth", Role: model.RoleSupport}
+	user    = model.User{ID: uuid.New(), Login: "frodo@middle.earth", Role: model.RoleUser}
+)
+
+// handlerTest is a request to the routes of the service mock. The request is signed in by the user's
+// session or the merchant's API key if provided.
+type handlerTest struct {
+	name   string
+	user   *model.User
+	apiKey *model.APIKey
+	method string
+	target string
+	body   string
+	header map[string]string
+	// mock sets the expectations of the service called by the handler.
+	mock       func(svc *mockgophermart.MockService)
+	wantStatus int
+	// check checks the response.
+	check func(t *testing.T, w *httptest.ResponseRecorder)
+}
+
+func runHandlerTests(t *testing.T, tt []handlerTest) {
+	t.Helper()
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			svc := mockgophermart.NewMockService(mockCtrl)
+			router := rest.SetupRoutes(svc, nil, nil, logging.NewLogger(logging.WithConsoleOutput(true)), nil)
+
+			req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			req.RemoteAddr = "192.0.2.1:54321"
+			if tc.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			for k, v := range tc.header {
+				req.Header.Set(k, v)
+			}
+			if tc.user != nil {
+				req.AddCookie(&http.Cookie{Name: middleware.SessionCookieName, Value: sessionToken})
+				svc.EXPECT().SessionUser(gomock.Any(), sessionToken).
+					Return(tc.user, &model.Session{ID: uuid.New(), UserID: tc.user.ID}, nil).Times(1)
+			}
+			if tc.apiKey != nil {
+				req.Header.Set(middleware.APIKeyHeader, apiKey)
+				svc.EXPECT().AuthenticateAPIKey(gomock.Any(), apiKey).Return(tc.apiKey, nil).Times(1)
+			}
+			if tc.mock != nil {
+				tc.mock(svc)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantStatus, w.Code, w.Body.String())
+			if tc.check != nil {
+				tc.check(t, w)
+			}
+		})
+	}
+}
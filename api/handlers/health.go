@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/pkg/health"
+)
+
+// Healthz reports that the process is up.
+//
+// GET /healthz
+func (h Handlers) Healthz(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "Healthz").Logger()
+	writeJSON(w, log, http.StatusOK, health.Result{Status: health.StatusOK})
+}
+
+// Readyz runs the readiness checks and returns their breakdown. If any check fails or the service
+// is shutting down, 503 status is returned.
+//
+// GET /readyz
+func (h Handlers) Readyz(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "Readyz").Logger()
+	report := h.health.Check(r.Context())
+	status := http.StatusOK
+	if report.Status != health.StatusOK {
+		log.Warn().Interface("report", report).Msg("not ready")
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, log, status, report)
+}
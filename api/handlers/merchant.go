@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/vanamelnik/gophermart/model"
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/service/gophermart"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+type (
+	// MerchantOrderRequest represents json request for submitting the user's order by the merchant.
+	MerchantOrderRequest struct {
+		Login string        `json:"login"`
+		Order model.OrderID `json:"order"`
+	}
+
+	// MerchantRequest represents json request for creating a merchant.
+	MerchantRequest struct {
+		Name string `json:"name"`
+	}
+
+	// APIKeyRequest represents json request for creating an API key.
+	APIKeyRequest struct {
+		Scopes []model.Scope `json:"scopes"`
+	}
+
+	// APIKeyResponse represents json response with the new API key. The key is shown only once.
+	APIKeyResponse struct {
+		Key string `json:"key"`
+		model.APIKey
+	}
+)
+
+// MerchantPostOrder — submit the order number of the user on behalf of the merchant.
+//
+// POST /api/merchant/orders
+func (h Handlers) MerchantPostOrder(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "MerchantPostOrder").Logger()
+	if !checkContentType(r, "application/json") {
+		log.Error().Msg("wrong Content-type")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+	req := MerchantOrderRequest{}
+	dec := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	if err := dec.Decode(&req); err != nil {
+		log.Error().Err(err).Msg("unmarshalling request body")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+	log = log.With().Str("orderID", req.Order.String()).Logger()
+
+	err := h.svc.SubmitMerchantOrder(r.Context(), req.Login, req.Order)
+	switch {
+	case err == nil:
+		log.Info().Msg("order processed")
+		w.WriteHeader(http.StatusAccepted)
+	case errors.Is(err, gophermart.ErrOrderExecutedBySameUser):
+		log.Warn().Err(err).Msg("process order")
+		w.WriteHeader(http.StatusOK)
+	case errors.Is(err, gophermart.ErrOrderExecutedByAnotherUser):
+		log.Error().Err(err).Msg("process order:")
+		http.Error(w, "The order is already executed by another user", http.StatusConflict)
+	case errors.Is(err, gophermart.ErrInvalidOrderNumber):
+		log.Error().Err(err).Msg("process order:")
+		http.Error(w, "Incorrect order number format", http.StatusUnprocessableEntity)
+	case errors.Is(err, storage.ErrNotFound):
+		log.Error().Err(err).Msg("process order:")
+		http.Error(w, "User not found", http.StatusNotFound)
+	case errors.Is(err, gophermart.ErrUserBlocked):
+		log.Error().Err(err).Msg("process order:")
+		http.Error(w, "User is blocked", http.StatusForbidden)
+	default:
+		log.Error().Err(err).Msg("process order:")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// CreateMerchant — add a new merchant.
+//
+// POST /api/admin/merchants
+func (h Handlers) CreateMerchant(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "CreateMerchant").Logger()
+	if !checkContentType(r, "application/json") {
+		log.Error().Msg("wrong Content-type")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+	req := MerchantRequest{}
+	dec := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	if err := dec.Decode(&req); err != nil {
+		log.Error().Err(err).Msg("unmarshalling request body")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+
+	merchant, err := h.svc.CreateMerchant(r.Context(), req.Name)
+	switch {
+	case err == nil:
+		writeJSON(w, log, http.StatusCreated, merchant)
+	case errors.Is(err, gophermart.ErrInvalidMerchant):
+		log.Error().Err(err).Msg("creating merchant")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, storage.ErrMerchantAlreadyExists):
+		log.Error().Err(err).Msg("creating merchant")
+		http.Error(w, "Merchant already exists", http.StatusConflict)
+	default:
+		log.Error().Err(err).Msg("creating merchant")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// GetMerchants — list the merchants.
+//
+// GET /api/admin/merchants
+func (h Handlers) GetMerchants(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "GetMerchants").Logger()
+
+	merchants, err := h.svc.Merchants(r.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("fetching merchants")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+
+		return
+	}
+
+	writeJSON(w, log, http.StatusOK, merchants)
+}
+
+// CreateAPIKey — create a new API key of the merchant. The key is returned only once.
+//
+// POST /api/admin/merchants/{id}/keys
+func (h Handlers) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "CreateAPIKey").Logger()
+	if !checkContentType(r, "application/json") {
+		log.Error().Msg("wrong Content-type")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+	merchantID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error().Err(err).Msg("parsing merchant id")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+	req := APIKeyRequest{}
+	dec := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	if err := dec.Decode(&req); err != nil {
+		log.Error().Err(err).Msg("unmarshalling request body")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+
+	key, apiKey, err := h.svc.CreateAPIKey(r.Context(), merchantID, req.Scopes)
+	switch {
+	case err == nil:
+		writeJSON(w, log, http.StatusCreated, APIKeyResponse{Key: key, APIKey: apiKey})
+	case errors.Is(err, gophermart.ErrInvalidScope):
+		log.Error().Err(err).Msg("creating API key")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, storage.ErrNotFound):
+		log.Error().Err(err).Msg("creating API key")
+		http.Error(w, "Not found", http.StatusNotFound)
+	default:
+		log.Error().Err(err).Msg("creating API key")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// GetAPIKeys — list the merchant's API keys.
+//
+// GET /api/admin/merchants/{id}/keys
+func (h Handlers) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "GetAPIKeys").Logger()
+	merchantID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error().Err(err).Msg("parsing merchant id")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+
+	keys, err := h.svc.MerchantAPIKeys(r.Context(), merchantID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			log.Error().Err(err).Msg("fetching API keys")
+			http.Error(w, "Not found", http.StatusNotFound)
+
+			return
+		}
+		log.Error().Err(err).Msg("fetching API keys")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+
+		return
+	}
+
+	writeJSON(w, log, http.StatusOK, keys)
+}
+
+// RevokeAPIKey — revoke the merchant's API key.
+//
+// DELETE /api/admin/merchants/{id}/keys/{keyID}
+func (h Handlers) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "RevokeAPIKey").Logger()
+	merchantID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error().Err(err).Msg("parsing merchant id")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+	keyID, err := uuid.Parse(chi.URLParam(r, "keyID"))
+	if err != nil {
+		log.Error().Err(err).Msg("parsing key id")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+
+	if err := h.svc.RevokeAPIKey(r.Context(), merchantID, keyID); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			log.Error().Err(err).Msg("revoking API key")
+			http.Error(w, "Not found", http.StatusNotFound)
+
+			return
+		}
+		log.Error().Err(err).Msg("revoking API key")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
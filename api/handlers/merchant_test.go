@@ -0,0 +1,243 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/pkg/middleware"
+	"github.com/vanamelnik/gophermart/service/gophermart"
+	mockgophermart "github.com/vanamelnik/gophermart/service/gophermart/mock"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerchantPostOrder(t *testing.T) {
+	const body = `{"login":"frodo@middle.earth","order":"12345678903"}`
+	key := &model.APIKey{ID: uuid.New(), MerchantID: uuid.New(), Prefix: "gm_live", Scopes: []model.Scope{model.ScopeOrdersSubmit}}
+	submit := func(err error) func(svc *mockgophermart.MockService) {
+		return func(svc *mockgophermart.MockService) {
+			svc.EXPECT().SubmitMerchantOrder(gomock.Any(), "frodo@middle.earth", model.OrderID("12345678903")).
+				Return(err).Times(1)
+		}
+	}
+
+	runHandlerTests(t, []handlerTest{
+		{
+			name:       "#1 No API key",
+			method:     http.MethodPost,
+			target:     "/api/merchant/orders",
+			body:       body,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:   "#2 Invalid API key",
+			method: http.MethodPost,
+			target: "/api/merchant/orders",
+			body:   body,
+			header: map[string]string{middleware.APIKeyHeader: "gm_live_revoked"},
+			mock: func(svc *mockgophermart.MockService) {
+				svc.EXPECT().AuthenticateAPIKey(gomock.Any(), "gm_live_revoked").
+					Return(nil, gophermart.ErrInvalidAPIKey).Times(1)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "#3 The key isn't granted the scope",
+			apiKey:     &model.APIKey{ID: uuid.New(), MerchantID: uuid.New(), Prefix: "gm_live", Scopes: []model.Scope{}},
+			method:     http.MethodPost,
+			target:     "/api/merchant/orders",
+			body:       body,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "#4 Normal case",
+			apiKey:     key,
+			method:     http.MethodPost,
+			target:     "/api/merchant/orders",
+			body:       body,
+			mock:       submit(nil),
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name:       "#5 Order executed by the same user",
+			apiKey:     key,
+			method:     http.MethodPost,
+			target:     "/api/merchant/orders",
+			body:       body,
+			mock:       submit(gophermart.ErrOrderExecutedBySameUser),
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "#6 Order executed by another user",
+			apiKey:     key,
+			method:     http.MethodPost,
+			target:     "/api/merchant/orders",
+			body:       body,
+			mock:       submit(gophermart.ErrOrderExecutedByAnotherUser),
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "#7 Invalid order number",
+			apiKey:     key,
+			method:     http.MethodPost,
+			target:     "/api/merchant/orders",
+			body:       body,
+			mock:       submit(gophermart.ErrInvalidOrderNumber),
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "#8 Unknown user",
+			apiKey:     key,
+			method:     http.MethodPost,
+			target:     "/api/merchant/orders",
+			body:       body,
+			mock:       submit(storage.ErrNotFound),
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "#9 Blocked user",
+			apiKey:     key,
+			method:     http.MethodPost,
+			target:     "/api/merchant/orders",
+			body:       body,
+			mock:       submit(gophermart.ErrUserBlocked),
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "#10 Internal error",
+			apiKey:     key,
+			method:     http.MethodPost,
+			target:     "/api/merchant/orders",
+			body:       body,
+			mock:       submit(errors.New("db is down")),
+			wantStatus: http.StatusInternalServerError,
+		},
+	})
+}
+
+func TestMerchants(t *testing.T) {
+	merchantID := uuid.New()
+	keyID := uuid.New()
+	keysURL := "/api/admin/merchants/" + merchantID.String() + "/keys"
+
+	runHandlerTests(t, []handlerTest{
+		{
+			name:       "#1 Permission denied",
+			user:       &support,
+			method:     http.MethodGet,
+			target:     "/api/admin/merchants",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:   "#2 Create merchant",
+			user:   &admin,
+			method: http.MethodPost,
+			target: "/api/admin/merchants",
+			body:   `{"name":"Prancing Pony"}`,
+			mock: func(svc *mockgophermart.MockService) {
+				svc.EXPECT().CreateMerchant(gomock.Any(), "Prancing Pony").
+					Return(model.Merchant{ID: merchantID, Name: "Prancing Pony"}, nil).Times(1)
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:   "#3 Invalid merchant",
+			user:   &admin,
+			method: http.MethodPost,
+			target: "/api/admin/merchants",
+			body:   `{"name":""}`,
+			mock: func(svc *mockgophermart.MockService) {
+				svc.EXPECT().CreateMerchant(gomock.Any(), "").Return(model.Merchant{}, gophermart.ErrInvalidMerchant).Times(1)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "#4 Merchant already exists",
+			user:   &admin,
+			method: http.MethodPost,
+			target: "/api/admin/merchants",
+			body:   `{"name":"Prancing Pony"}`,
+			mock: func(svc *mockgophermart.MockService) {
+				svc.EXPECT().CreateMerchant(gomock.Any(), "Prancing Pony").
+					Return(model.Merchant{}, storage.ErrMerchantAlreadyExists).Times(1)
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:   "#5 Create API key",
+			user:   &admin,
+			method: http.MethodPost,
+			target: keysURL,
+			body:   `{"scopes":["orders:submit"]}`,
+			mock: func(svc *mockgophermart.MockService) {
+				svc.EXPECT().CreateAPIKey(gomock.Any(), merchantID, []model.Scope{model.ScopeOrdersSubmit}).
+					Return(apiKey, model.APIKey{ID: keyID, MerchantID: merchantID}, nil).Times(1)
+			},
+			wantStatus: http.StatusCreated,
+			check: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var resp map[string]interface{}
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+				assert.Equal(t, apiKey, resp["key"], "the key is returned once")
+			},
+		},
+		{
+			name:   "#6 Invalid scope",
+			user:   &admin,
+			method: http.MethodPost,
+			target: keysURL,
+			body:   `{"scopes":["orders:delete"]}`,
+			mock: func(svc *mockgophermart.MockService) {
+				svc.EXPECT().CreateAPIKey(gomock.Any(), merchantID, []model.Scope{"orders:delete"}).
+					Return("", model.APIKey{}, gophermart.ErrInvalidScope).Times(1)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "#7 API key of unknown merchant",
+			user:   &admin,
+			method: http.MethodPost,
+			target: keysURL,
+			body:   `{"scopes":["orders:submit"]}`,
+			mock: func(svc *mockgophermart.MockService) {
+				svc.EXPECT().CreateAPIKey(gomock.Any(), merchantID, gomock.Any()).
+					Return("", model.APIKey{}, storage.ErrNotFound).Times(1)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "#8 Invalid merchant id",
+			user:       &admin,
+			method:     http.MethodGet,
+			target:     "/api/admin/merchants/pony/keys",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "#9 Revoke API key",
+			user:   &admin,
+			method: http.MethodDelete,
+			target: keysURL + "/" + keyID.String(),
+			mock: func(svc *mockgophermart.MockService) {
+				svc.EXPECT().RevokeAPIKey(gomock.Any(), merchantID, keyID).Return(nil).Times(1)
+			},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:   "#10 Revoke unknown API key",
+			user:   &admin,
+			method: http.MethodDelete,
+			target: keysURL + "/" + keyID.String(),
+			mock: func(svc *mockgophermart.MockService) {
+				svc.EXPECT().RevokeAPIKey(gomock.Any(), merchantID, keyID).Return(storage.ErrNotFound).Times(1)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+	})
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/pkg/pwpolicy"
+	"github.com/vanamelnik/gophermart/service/gophermart"
+
+	"github.com/rs/zerolog"
+)
+
+type (
+	// ChangePasswordRequest represents json request for changing the password.
+	ChangePasswordRequest struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+
+	// PasswordResetRequest represents json request for a password reset token.
+	PasswordResetRequest struct {
+		Login string `json:"login"`
+	}
+
+	// ResetPasswordRequest represents json request for setting a new password by the reset token.
+	ResetPasswordRequest struct {
+		Token       string `json:"token"`
+		NewPassword string `json:"new_password"`
+	}
+
+	// InvalidPasswordResponse lists the requirements of the password policy the new password doesn't meet.
+	InvalidPasswordResponse struct {
+		Error   string            `json:"error"`
+		Reasons []pwpolicy.Reason `json:"reasons"`
+	}
+)
+
+// ChangePassword — change the password. All the other sessions are ended.
+//
+// POST /api/user/password
+func (h Handlers) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "ChangePassword").Logger()
+	if !checkContentType(r, "application/json") {
+		log.Error().Msg("wrong Content-type")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+	req := ChangePasswordRequest{}
+	dec := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	if err := dec.Decode(&req); err != nil {
+		log.Error().Err(err).Msg("unmarshalling request body")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+
+	err := h.svc.ChangePassword(r.Context(), req.CurrentPassword, req.NewPassword)
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, gophermart.ErrWrongPassword):
+		log.Error().Err(err).Msg("changing password")
+		http.Error(w, "Wrong current password", http.StatusForbidden)
+	case errors.Is(err, gophermart.ErrInvalidPassword):
+		log.Error().Err(err).Msg("changing password")
+		writeInvalidPassword(w, log, err)
+	default:
+		log.Error().Err(err).Msg("changing password")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// RequestPasswordReset — send a password reset token to the user.
+// The response is the same whether the login exists or not.
+//
+// POST /api/user/password/reset/request
+func (h Handlers) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "RequestPasswordReset").Logger()
+	if !checkContentType(r, "application/json") {
+		log.Error().Msg("wrong Content-type")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+	req := PasswordResetRequest{}
+	dec := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	if err := dec.Decode(&req); err != nil {
+		log.Error().Err(err).Msg("unmarshalling request body")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+
+	err := h.svc.RequestPasswordReset(r.Context(), req.Login)
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusAccepted)
+	case errors.Is(err, gophermart.ErrPasswordResetDisabled):
+		log.Error().Err(err).Msg("requesting password reset")
+		http.Error(w, "Not found", http.StatusNotFound)
+	default:
+		log.Error().Err(err).Msg("requesting password reset")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// ResetPassword — set a new password by the reset token. All the sessions are ended.
+//
+// POST /api/user/password/reset
+func (h Handlers) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "ResetPassword").Logger()
+	if !checkContentType(r, "application/json") {
+		log.Error().Msg("wrong Content-type")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+	req := ResetPasswordRequest{}
+	dec := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	if err := dec.Decode(&req); err != nil {
+		log.Error().Err(err).Msg("unmarshalling request body")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+
+	err := h.svc.ResetPassword(r.Context(), req.Token, req.NewPassword)
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, gophermart.ErrInvalidToken):
+		log.Error().Err(err).Msg("resetting password")
+		http.Error(w, "Invalid or expired token", http.StatusBadRequest)
+	case errors.Is(err, gophermart.ErrInvalidPassword):
+		log.Error().Err(err).Msg("resetting password")
+		writeInvalidPassword(w, log, err)
+	default:
+		log.Error().Err(err).Msg("resetting password")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// writeInvalidPassword responds with 400 status and the reasons why the new password was refused.
+func writeInvalidPassword(w http.ResponseWriter, log zerolog.Logger, err error) {
+	resp := InvalidPasswordResponse{Error: "Invalid password", Reasons: []pwpolicy.Reason{}}
+	var pwErr *gophermart.InvalidPasswordError
+	if errors.As(err, &pwErr) {
+		resp.Reasons = pwErr.Reasons
+	}
+	writeJSON(w, log, http.StatusBadRequest, resp)
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/pkg/middleware"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+// Logout — end the current session.
+//
+// POST /api/user/logout
+func (h Handlers) Logout(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "Logout").Logger()
+
+	if err := h.svc.Logout(r.Context()); err != nil {
+		log.Error().Err(err).Msg("logging out")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+
+		return
+	}
+	clearSessionCookie(w)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// clearSessionCookie asks the client to delete the session cookie.
+func clearSessionCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     middleware.SessionCookieName,
+		Value:    "",
+		Path:     "/",
+		Expires:  time.Unix(0, 0),
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+}
+
+// GetSessions — get the list of active sessions.
+//
+// GET /api/user/sessions
+func (h Handlers) GetSessions(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "GetSessions").Logger()
+
+	sessions, err := h.svc.Sessions(r.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("fetching user's sessions")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+
+		return
+	}
+
+	writeJSON(w, log, http.StatusOK, sessions)
+}
+
+// RevokeSession — end the session on another device.
+//
+// DELETE /api/user/sessions/{id}
+func (h Handlers) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "RevokeSession").Logger()
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error().Err(err).Msg("parsing session id")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+
+	if err := h.svc.RevokeSession(r.Context(), id); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			log.Error().Err(err).Msg("revoking session")
+			http.Error(w, "Not found", http.StatusNotFound)
+
+			return
+		}
+		log.Error().Err(err).Msg("revoking session")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
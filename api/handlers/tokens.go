@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/service/gophermart"
+)
+
+const tokenTypeBearer = "Bearer"
+
+type (
+	// TokenResponse is returned on successful registration, login and access token refresh.
+	// Access token fields are empty if access tokens are disabled.
+	TokenResponse struct {
+		AccessToken string `json:"access_token,omitempty"`
+		TokenType   string `json:"token_type,omitempty"`
+		// ExpiresIn is the lifetime of the access token in seconds.
+		ExpiresIn int `json:"expires_in,omitempty"`
+		// RefreshToken is the session token. It's the same as the value of the session cookie.
+		RefreshToken string `json:"refresh_token,omitempty"`
+	}
+
+	// RefreshRequest represents json request for a new access token.
+	RefreshRequest struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+)
+
+func (t *TokenResponse) setAccessToken(accessToken string, expiresAt time.Time) {
+	t.AccessToken = accessToken
+	t.TokenType = tokenTypeBearer
+	t.ExpiresIn = int(time.Until(expiresAt).Seconds())
+}
+
+// RefreshToken — get a new access token by the refresh token.
+//
+// POST /api/user/token/refresh
+func (h Handlers) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "RefreshToken").Logger()
+	if !checkContentType(r, "application/json") {
+		log.Error().Msg("wrong Content-type")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+	req := RefreshRequest{}
+	dec := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	if err := dec.Decode(&req); err != nil || req.RefreshToken == "" {
+		log.Error().Err(err).Msg("unmarshalling request body")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+
+	accessToken, expiresAt, err := h.svc.RefreshAccessToken(r.Context(), req.RefreshToken)
+	switch {
+	case err == nil:
+		resp := TokenResponse{}
+		resp.setAccessToken(accessToken, expiresAt)
+		writeJSON(w, log, http.StatusOK, resp)
+	case errors.Is(err, gophermart.ErrInvalidToken):
+		log.Error().Err(err).Msg("refreshing access token")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	case errors.Is(err, gophermart.ErrUserBlocked):
+		log.Error().Err(err).Msg("refreshing access token")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+	case errors.Is(err, gophermart.ErrAccessTokensDisabled):
+		log.Error().Err(err).Msg("refreshing access token")
+		http.Error(w, "Not found", http.StatusNotFound)
+	default:
+		log.Error().Err(err).Msg("refreshing access token")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/service/gophermart"
+)
+
+type (
+	// TwoFactorCodeRequest represents json request with a TOTP or recovery code.
+	TwoFactorCodeRequest struct {
+		Code string `json:"code"`
+	}
+
+	// TwoFactorLoginRequest represents json request completing the sign-in with the second factor.
+	TwoFactorLoginRequest struct {
+		Challenge string `json:"challenge"`
+		Code      string `json:"code"`
+	}
+
+	// TwoFactorChallengeResponse is returned by Login when the user has two-factor authentication enabled.
+	TwoFactorChallengeResponse struct {
+		TwoFactorRequired bool   `json:"two_factor_required"`
+		Challenge         string `json:"challenge"`
+	}
+
+	// RecoveryCodesResponse represents json response with the recovery codes.
+	RecoveryCodesResponse struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+)
+
+// LoginTwoFactor — complete the sign-in with the challenge returned by Login and a TOTP or recovery code.
+//
+// POST /api/user/login/2fa
+func (h Handlers) LoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "LoginTwoFactor").Logger()
+	if !checkContentType(r, "application/json") {
+		log.Error().Msg("wrong Content-type")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+	req := TwoFactorLoginRequest{}
+	dec := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	if err := dec.Decode(&req); err != nil {
+		log.Error().Err(err).Msg("unmarshalling request body")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+
+	user, err := h.svc.CompleteTwoFactorLogin(r.Context(), req.Challenge, req.Code, remoteIP(r))
+	var throttled *gophermart.LoginThrottledError
+	switch {
+	case err == nil:
+	case errors.As(err, &throttled):
+		log.Error().Err(err).Msg("two-factor authentication")
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		http.Error(w, "Too many requests", http.StatusTooManyRequests)
+
+		return
+	case errors.Is(err, gophermart.ErrInvalidCode), errors.Is(err, gophermart.ErrInvalidChallenge):
+		log.Error().Err(err).Msg("two-factor authentication")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+
+		return
+	case errors.Is(err, gophermart.ErrUserBlocked):
+		log.Error().Err(err).Msg("two-factor authentication")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+
+		return
+	default:
+		log.Error().Err(err).Msg("two-factor authentication")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+
+		return
+	}
+
+	if err := h.signIn(w, r, user); err != nil {
+		log.Error().Err(err).Msg("two-factor authentication")
+		http.Error(w, "Internal server error - could not authenticate the user.", http.StatusInternalServerError)
+
+		return
+	}
+}
+
+// EnrollTwoFactor — generate a new TOTP secret. Two-factor authentication is enabled after the confirmation.
+//
+// POST /api/user/2fa/enroll
+func (h Handlers) EnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "EnrollTwoFactor").Logger()
+
+	enrollment, err := h.svc.EnrollTwoFactor(r.Context())
+	switch {
+	case err == nil:
+		writeJSON(w, log, http.StatusOK, enrollment)
+	case errors.Is(err, gophermart.ErrTwoFactorEnabled):
+		log.Error().Err(err).Msg("enrolling two-factor authentication")
+		http.Error(w, "Two-factor authentication already enabled", http.StatusConflict)
+	default:
+		log.Error().Err(err).Msg("enrolling two-factor authentication")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// ConfirmTwoFactor — enable two-factor authentication with a code from the authenticator app.
+// The recovery codes are returned only once.
+//
+// POST /api/user/2fa/confirm
+func (h Handlers) ConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "ConfirmTwoFactor").Logger()
+	if !checkContentType(r, "application/json") {
+		log.Error().Msg("wrong Content-type")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+	req := TwoFactorCodeRequest{}
+	dec := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	if err := dec.Decode(&req); err != nil {
+		log.Error().Err(err).Msg("unmarshalling request body")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+
+	codes, err := h.svc.ConfirmTwoFactor(r.Context(), req.Code)
+	switch {
+	case err == nil:
+		writeJSON(w, log, http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+	case errors.Is(err, gophermart.ErrInvalidCode):
+		log.Error().Err(err).Msg("confirming two-factor authentication")
+		http.Error(w, "Invalid code", http.StatusUnprocessableEntity)
+	case errors.Is(err, gophermart.ErrTwoFactorNotEnabled):
+		log.Error().Err(err).Msg("confirming two-factor authentication")
+		http.Error(w, "Two-factor authentication not enrolled", http.StatusConflict)
+	case errors.Is(err, gophermart.ErrTwoFactorEnabled):
+		log.Error().Err(err).Msg("confirming two-factor authentication")
+		http.Error(w, "Two-factor authentication already enabled", http.StatusConflict)
+	default:
+		log.Error().Err(err).Msg("confirming two-factor authentication")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// DisableTwoFactor — disable two-factor authentication with a TOTP or recovery code.
+//
+// POST /api/user/2fa/disable
+func (h Handlers) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "DisableTwoFactor").Logger()
+	if !checkContentType(r, "application/json") {
+		log.Error().Msg("wrong Content-type")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+	req := TwoFactorCodeRequest{}
+	dec := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	if err := dec.Decode(&req); err != nil {
+		log.Error().Err(err).Msg("unmarshalling request body")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+
+	err := h.svc.DisableTwoFactor(r.Context(), req.Code)
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, gophermart.ErrInvalidCode):
+		log.Error().Err(err).Msg("disabling two-factor authentication")
+		http.Error(w, "Invalid code", http.StatusUnprocessableEntity)
+	case errors.Is(err, gophermart.ErrTwoFactorNotEnabled):
+		log.Error().Err(err).Msg("disabling two-factor authentication")
+		http.Error(w, "Two-factor authentication not enabled", http.StatusConflict)
+	default:
+		log.Error().Err(err).Msg("disabling two-factor authentication")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
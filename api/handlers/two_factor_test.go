@@ -0,0 +1,213 @@
+package handlers_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/pkg/middleware"
+	"github.com/vanamelnik/gophermart/service/gophermart"
+	mockgophermart "github.com/vanamelnik/gophermart/service/gophermart/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginTwoFactor(t *testing.T) {
+	const body = `{"challenge":"challenge","code":"123456"}`
+	complete := func(err error) func(svc *mockgophermart.MockService) {
+		return func(svc *mockgophermart.MockService) {
+			svc.EXPECT().CompleteTwoFactorLogin(gomock.Any(), "challenge", "123456", "192.0.2.1").
+				Return(model.User{}, err).Times(1)
+		}
+	}
+
+	runHandlerTests(t, []handlerTest{
+		{
+			name:   "#1 Normal case",
+			method: http.MethodPost,
+			target: "/api/user/login/2fa",
+			body:   body,
+			mock: func(svc *mockgophermart.MockService) {
+				sessionID := uuid.New()
+				svc.EXPECT().CompleteTwoFactorLogin(gomock.Any(), "challenge", "123456", "192.0.2.1").
+					Return(user, nil).Times(1)
+				svc.EXPECT().CreateSession(gomock.Any(), user, gomock.Any(), "192.0.2.1").
+					Return(sessionToken, model.Session{ID: sessionID, ExpiresAt: time.Now().Add(time.Hour)}, nil).Times(1)
+				svc.EXPECT().IssueAccessToken(gomock.Any(), user, sessionID).
+					Return("", time.Time{}, gophermart.ErrAccessTokensDisabled).Times(1)
+			},
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, w *httptest.ResponseRecorder) {
+				cookies := w.Result().Cookies()
+				if assert.Len(t, cookies, 1) {
+					assert.Equal(t, middleware.SessionCookieName, cookies[0].Name)
+					assert.Equal(t, sessionToken, cookies[0].Value)
+				}
+			},
+		},
+		{
+			name:   "#2 Too many attempts",
+			method: http.MethodPost,
+			target: "/api/user/login/2fa",
+			body:   body,
+			mock:   complete(&gophermart.LoginThrottledError{RetryAfter: 1500 * time.Millisecond}),
+			check: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, "2", w.Header().Get("Retry-After"))
+			},
+			wantStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:       "#3 Invalid code",
+			method:     http.MethodPost,
+			target:     "/api/user/login/2fa",
+			body:       body,
+			mock:       complete(gophermart.ErrInvalidCode),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "#4 Invalid challenge",
+			method:     http.MethodPost,
+			target:     "/api/user/login/2fa",
+			body:       body,
+			mock:       complete(gophermart.ErrInvalidChallenge),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "#5 Blocked user",
+			method:     http.MethodPost,
+			target:     "/api/user/login/2fa",
+			body:       body,
+			mock:       complete(gophermart.ErrUserBlocked),
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "#6 Internal error",
+			method:     http.MethodPost,
+			target:     "/api/user/login/2fa",
+			body:       body,
+			mock:       complete(errors.New("db is down")),
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "#7 Wrong content type",
+			method:     http.MethodPost,
+			target:     "/api/user/login/2fa",
+			wantStatus: http.StatusBadRequest,
+		},
+	})
+}
+
+func TestTwoFactorSettings(t *testing.T) {
+	const body = `{"code":"123456"}`
+	confirm := func(err error) func(svc *mockgophermart.MockService) {
+		return func(svc *mockgophermart.MockService) {
+			svc.EXPECT().ConfirmTwoFactor(gomock.Any(), "123456").Return(nil, err).Times(1)
+		}
+	}
+	disable := func(err error) func(svc *mockgophermart.MockService) {
+		return func(svc *mockgophermart.MockService) {
+			svc.EXPECT().DisableTwoFactor(gomock.Any(), "123456").Return(err).Times(1)
+		}
+	}
+
+	runHandlerTests(t, []handlerTest{
+		{
+			name:       "#1 Not signed in",
+			method:     http.MethodPost,
+			target:     "/api/user/2fa/enroll",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:   "#2 Enroll",
+			user:   &user,
+			method: http.MethodPost,
+			target: "/api/user/2fa/enroll",
+			mock: func(svc *mockgophermart.MockService) {
+				svc.EXPECT().EnrollTwoFactor(gomock.Any()).
+					Return(gophermart.TwoFactorEnrollment{Secret: "SECRET", URI: "otpauth://totp/gophermart"}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "#3 Enroll when already enabled",
+			user:   &user,
+			method: http.MethodPost,
+			target: "/api/user/2fa/enroll",
+			mock: func(svc *mockgophermart.MockService) {
+				svc.EXPECT().EnrollTwoFactor(gomock.Any()).
+					Return(gophermart.TwoFactorEnrollment{}, gophermart.ErrTwoFactorEnabled).Times(1)
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:   "#4 Confirm",
+			user:   &user,
+			method: http.MethodPost,
+			target: "/api/user/2fa/confirm",
+			body:   body,
+			mock: func(svc *mockgophermart.MockService) {
+				svc.EXPECT().ConfirmTwoFactor(gomock.Any(), "123456").Return([]string{"AAAA-BBBB-CCCC-DDDD"}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "#5 Confirm with invalid code",
+			user:       &user,
+			method:     http.MethodPost,
+			target:     "/api/user/2fa/confirm",
+			body:       body,
+			mock:       confirm(gophermart.ErrInvalidCode),
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "#6 Confirm without enrollment",
+			user:       &user,
+			method:     http.MethodPost,
+			target:     "/api/user/2fa/confirm",
+			body:       body,
+			mock:       confirm(gophermart.ErrTwoFactorNotEnabled),
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "#7 Confirm when already enabled",
+			user:       &user,
+			method:     http.MethodPost,
+			target:     "/api/user/2fa/confirm",
+			body:       body,
+			mock:       confirm(gophermart.ErrTwoFactorEnabled),
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "#8 Disable",
+			user:       &user,
+			method:     http.MethodPost,
+			target:     "/api/user/2fa/disable",
+			body:       body,
+			mock:       disable(nil),
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "#9 Disable with invalid code",
+			user:       &user,
+			method:     http.MethodPost,
+			target:     "/api/user/2fa/disable",
+			body:       body,
+			mock:       disable(gophermart.ErrInvalidCode),
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "#10 Disable when not enabled",
+			user:       &user,
+			method:     http.MethodPost,
+			target:     "/api/user/2fa/disable",
+			body:       body,
+			mock:       disable(gophermart.ErrTwoFactorNotEnabled),
+			wantStatus: http.StatusConflict,
+		},
+	})
+}
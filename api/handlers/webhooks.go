@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/service/gophermart"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+type (
+	// WebhookRequest represents json request for a new webhook subscription.
+	WebhookRequest struct {
+		URL        string            `json:"url"`
+		Secret     string            `json:"secret,omitempty"`
+		EventTypes []model.EventType `json:"event_types"`
+	}
+
+	// WebhookResponse is returned once after the subscription is created. It's the only
+	// response containing the secret.
+	WebhookResponse struct {
+		ID         uuid.UUID         `json:"id"`
+		URL        string            `json:"url"`
+		Secret     string            `json:"secret"`
+		EventTypes []model.EventType `json:"event_types"`
+		CreatedAt  time.Time         `json:"created_at"`
+	}
+)
+
+// CreateWebhook — subscribe to the service events.
+//
+// POST /api/admin/webhooks
+func (h Handlers) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "CreateWebhook").Logger()
+	if !checkContentType(r, "application/json") {
+		log.Error().Msg("wrong Content-type")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+	req := WebhookRequest{}
+	dec := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	if err := dec.Decode(&req); err != nil {
+		log.Error().Err(err).Msg("unmarshalling request body")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+
+	webhook, err := h.svc.CreateWebhook(r.Context(), req.URL, req.Secret, req.EventTypes)
+	if err != nil {
+		if errors.Is(err, gophermart.ErrInvalidWebhook) {
+			log.Error().Err(err).Msg("creating webhook")
+			http.Error(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+		log.Error().Err(err).Msg("creating webhook")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+
+		return
+	}
+
+	writeJSON(w, log, http.StatusCreated, WebhookResponse{
+		ID:         webhook.ID,
+		URL:        webhook.URL,
+		Secret:     webhook.Secret,
+		EventTypes: webhook.EventTypes,
+		CreatedAt:  webhook.CreatedAt,
+	})
+}
+
+// GetWebhooks — get the list of webhook subscriptions.
+//
+// GET /api/admin/webhooks
+func (h Handlers) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "GetWebhooks").Logger()
+
+	webhooks, err := h.svc.Webhooks(r.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("fetching webhooks")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+
+		return
+	}
+
+	writeJSON(w, log, http.StatusOK, webhooks)
+}
+
+// DeleteWebhook — unsubscribe the webhook.
+//
+// DELETE /api/admin/webhooks/{id}
+func (h Handlers) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "DeleteWebhook").Logger()
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error().Err(err).Msg("parsing webhook id")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+
+	if err := h.svc.DeleteWebhook(r.Context(), id); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			log.Error().Err(err).Msg("deleting webhook")
+			http.Error(w, "Not found", http.StatusNotFound)
+
+			return
+		}
+		log.Error().Err(err).Msg("deleting webhook")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetWebhookDeliveries — get the delivery log of the webhook.
+//
+// GET /api/admin/webhooks/{id}/deliveries
+func (h Handlers) GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "GetWebhookDeliveries").Logger()
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error().Err(err).Msg("parsing webhook id")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+
+	deliveries, err := h.svc.WebhookDeliveries(r.Context(), id)
+	if err != nil {
+		log.Error().Err(err).Msg("fetching webhook deliveries")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+
+		return
+	}
+
+	writeJSON(w, log, http.StatusOK, deliveries)
+}
+
+// ReplayWebhookDelivery — put the failed delivery back into the queue.
+//
+// POST /api/admin/webhooks/deliveries/{id}/replay
+func (h Handlers) ReplayWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	log := appContext.Logger(r.Context()).With().Str("handler", "ReplayWebhookDelivery").Logger()
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error().Err(err).Msg("parsing delivery id")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+
+		return
+	}
+
+	err = h.svc.ReplayWebhookDelivery(r.Context(), id)
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusAccepted)
+	case errors.Is(err, storage.ErrNotFound):
+		log.Error().Err(err).Msg("replaying delivery")
+		http.Error(w, "Not found", http.StatusNotFound)
+	case errors.Is(err, gophermart.ErrDeliveryNotFailed):
+		log.Error().Err(err).Msg("replaying delivery")
+		http.Error(w, "Only failed deliveries can be replayed", http.StatusConflict)
+	default:
+		log.Error().Err(err).Msg("replaying delivery")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
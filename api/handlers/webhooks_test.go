@@ -0,0 +1,127 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/service/gophermart"
+	mockgophermart "github.com/vanamelnik/gophermart/service/gophermart/mock"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhooks(t *testing.T) {
+	webhookID := uuid.New()
+	deliveryID := uuid.New()
+	replayURL := "/api/admin/webhooks/deliveries/" + deliveryID.String() + "/replay"
+	replay := func(err error) func(svc *mockgophermart.MockService) {
+		return func(svc *mockgophermart.MockService) {
+			svc.EXPECT().ReplayWebhookDelivery(gomock.Any(), deliveryID).Return(err).Times(1)
+		}
+	}
+
+	runHandlerTests(t, []handlerTest{
+		{
+			name:       "#1 Permission denied",
+			user:       &support,
+			method:     http.MethodGet,
+			target:     "/api/admin/webhooks",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:   "#2 Create webhook",
+			user:   &admin,
+			method: http.MethodPost,
+			target: "/api/admin/webhooks",
+			body:   `{"url":"https://partner.example/hook","event_types":["order.processed"]}`,
+			mock: func(svc *mockgophermart.MockService) {
+				svc.EXPECT().CreateWebhook(gomock.Any(), "https://partner.example/hook", "",
+					[]model.EventType{model.EventOrderProcessed}).
+					Return(model.Webhook{ID: webhookID, URL: "https://partner.example/hook", Secret: "generated"}, nil).Times(1)
+			},
+			wantStatus: http.StatusCreated,
+			check: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var resp map[string]interface{}
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+				assert.Equal(t, "generated", resp["secret"], "the secret is returned once")
+			},
+		},
+		{
+			name:   "#3 Invalid webhook",
+			user:   &admin,
+			method: http.MethodPost,
+			target: "/api/admin/webhooks",
+			body:   `{"url":"ftp://partner.example","event_types":["order.processed"]}`,
+			mock: func(svc *mockgophermart.MockService) {
+				svc.EXPECT().CreateWebhook(gomock.Any(), "ftp://partner.example", "", gomock.Any()).
+					Return(model.Webhook{}, gophermart.ErrInvalidWebhook).Times(1)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "#4 Wrong content type",
+			user:       &admin,
+			method:     http.MethodPost,
+			target:     "/api/admin/webhooks",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "#5 List webhooks",
+			user:   &admin,
+			method: http.MethodGet,
+			target: "/api/admin/webhooks",
+			mock: func(svc *mockgophermart.MockService) {
+				svc.EXPECT().Webhooks(gomock.Any()).Return([]model.Webhook{{ID: webhookID}}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "#6 Delete unknown webhook",
+			user:   &admin,
+			method: http.MethodDelete,
+			target: "/api/admin/webhooks/" + webhookID.String(),
+			mock: func(svc *mockgophermart.MockService) {
+				svc.EXPECT().DeleteWebhook(gomock.Any(), webhookID).Return(storage.ErrNotFound).Times(1)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "#7 Invalid webhook id",
+			user:       &admin,
+			method:     http.MethodGet,
+			target:     "/api/admin/webhooks/hook/deliveries",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "#8 Replay",
+			user:       &admin,
+			method:     http.MethodPost,
+			target:     replayURL,
+			mock:       replay(nil),
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name:       "#9 Replay delivery that hasn't failed",
+			user:       &admin,
+			method:     http.MethodPost,
+			target:     replayURL,
+			mock:       replay(gophermart.ErrDeliveryNotFailed),
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "#10 Replay unknown delivery",
+			user:       &admin,
+			method:     http.MethodPost,
+			target:     replayURL,
+			mock:       replay(storage.ErrNotFound),
+			wantStatus: http.StatusNotFound,
+		},
+	})
+}
@@ -4,32 +4,111 @@ import (
 	"github.com/go-chi/chi"
 	"github.com/rs/zerolog"
 	"github.com/vanamelnik/gophermart/api/handlers"
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/pkg/health"
+	"github.com/vanamelnik/gophermart/pkg/metrics"
 	"github.com/vanamelnik/gophermart/pkg/middleware"
 	"github.com/vanamelnik/gophermart/service/gophermart"
 	"github.com/vanamelnik/gophermart/storage"
 )
 
-// SetupRoutes configures mux.
-func SetupRoutes(service gophermart.Service, db storage.Storage, log zerolog.Logger) *chi.Mux {
-	h := handlers.New(service, db)
+// SetupRoutes configures mux. If limiter is nil, the requests aren't rate limited.
+func SetupRoutes(service gophermart.Service, db storage.Storage, checker *health.Checker, log zerolog.Logger,
+	limiter *middleware.RateLimiter) *chi.Mux {
+	h := handlers.New(service, db, checker)
 
 	// Setup routes
 	r := chi.NewRouter()
 	r.Use(middleware.WithLogger(log))
+	r.Use(middleware.RequestID)
+	r.Use(middleware.AccessLog)
+	r.Use(middleware.Tracing)
+	r.Use(middleware.Metrics)
+	r.Use(middleware.Recoverer)
 	r.Use(middleware.GzipMdlw)
 
+	r.Handle("/metrics", metrics.Handler())
+	r.Get("/healthz", h.Healthz)
+	r.Get("/readyz", h.Readyz)
+
+	// limit applies the limit configured for the route.
+	limit := limiter.Route
+
 	r.Route("/api/user", func(r chi.Router) {
-		r.Post("/register", h.Register)
-		r.Post("/login", h.Login)
+		r.Group(func(r chi.Router) {
+			r.Use(limiter.Default())
+
+			r.With(limit("POST /api/user/register")).Post("/register", h.Register)
+			r.With(limit("POST /api/user/login")).Post("/login", h.Login)
+			r.With(limit("POST /api/user/login/2fa")).Post("/login/2fa", h.LoginTwoFactor)
+			r.With(limit("POST /api/user/token/refresh")).Post("/token/refresh", h.RefreshToken)
+			r.With(limit("POST /api/user/password/reset/request")).Post("/password/reset/request", h.RequestPasswordReset)
+			r.With(limit("POST /api/user/password/reset")).Post("/password/reset", h.ResetPassword)
+		})
 
 		r.Route("/", func(r chi.Router) {
-			r.Use(middleware.UserCtx(db))
+			r.Use(limiter.IP())
+			r.Use(middleware.UserCtx(service))
+			r.Use(limiter.Default())
 
-			r.Post("/orders", h.PostOrder)
+			r.With(limit("POST /api/user/orders")).Post("/orders", h.PostOrder)
 			r.Get("/orders", h.GetOrders)
 			r.Get("/balance", h.GetBalance)
-			r.Post("/balance/withdraw", h.Withdraw)
+			r.With(limit("POST /api/user/balance/withdraw")).Post("/balance/withdraw", h.Withdraw)
 			r.Get("/balance/withdrawals", h.GetWithdrawals)
+			r.With(limit("POST /api/user/password")).Post("/password", h.ChangePassword)
+			r.Post("/logout", h.Logout)
+			r.Post("/2fa/enroll", h.EnrollTwoFactor)
+			r.With(limit("POST /api/user/2fa/confirm")).Post("/2fa/confirm", h.ConfirmTwoFactor)
+			r.With(limit("POST /api/user/2fa/disable")).Post("/2fa/disable", h.DisableTwoFactor)
+			r.Get("/sessions", h.GetSessions)
+			r.Delete("/sessions/{id}", h.RevokeSession)
+			r.With(limit("GET /api/user/data-export")).Get("/data-export", h.ExportData)
+			r.With(limit("DELETE /api/user")).Delete("/", h.DeleteAccount)
+		})
+	})
+
+	r.Route("/api/merchant", func(r chi.Router) {
+		r.Use(limiter.IP())
+		r.Use(middleware.MerchantCtx(service))
+		r.Use(limiter.Default())
+
+		r.With(middleware.RequireScope(model.ScopeOrdersSubmit), limit("POST /api/merchant/orders")).
+			Post("/orders", h.MerchantPostOrder)
+	})
+
+	r.Route("/api/admin", func(r chi.Router) {
+		r.Use(limiter.IP())
+		r.Use(middleware.UserCtx(service))
+		r.Use(limiter.Default())
+
+		r.With(middleware.RequirePermission(model.PermUsersRead)).Get("/users", h.SearchUsers)
+		r.With(middleware.RequirePermission(model.PermUsersRead)).Get("/users/{id}", h.GetUserInfo)
+		r.With(middleware.RequirePermission(model.PermBalanceAdjust)).Post("/users/{id}/adjustments", h.AdjustBalance)
+		r.With(middleware.RequirePermission(model.PermUsersBlock)).Post("/users/{id}/block", h.BlockUser)
+		r.With(middleware.RequirePermission(model.PermUsersBlock)).Post("/users/{id}/unblock", h.UnblockUser)
+		r.With(middleware.RequirePermission(model.PermUsersBlock)).Post("/users/{id}/unlock", h.UnlockUser)
+		r.With(middleware.RequirePermission(model.PermRolesManage)).Put("/users/{id}/role", h.SetUserRole)
+		r.With(middleware.RequirePermission(model.PermOrdersRepoll)).Post("/orders/{number}/repoll", h.RepollOrder)
+
+		r.Route("/merchants", func(r chi.Router) {
+			r.Use(middleware.RequirePermission(model.PermMerchantsManage))
+
+			r.Post("/", h.CreateMerchant)
+			r.Get("/", h.GetMerchants)
+			r.Post("/{id}/keys", h.CreateAPIKey)
+			r.Get("/{id}/keys", h.GetAPIKeys)
+			r.Delete("/{id}/keys/{keyID}", h.RevokeAPIKey)
+		})
+
+		r.Route("/webhooks", func(r chi.Router) {
+			r.Use(middleware.RequirePermission(model.PermWebhooksManage))
+
+			r.Post("/", h.CreateWebhook)
+			r.Get("/", h.GetWebhooks)
+			r.Delete("/{id}", h.DeleteWebhook)
+			r.Get("/{id}/deliveries", h.GetWebhookDeliveries)
+			r.Post("/deliveries/{id}/replay", h.ReplayWebhookDelivery)
 		})
 	})
 
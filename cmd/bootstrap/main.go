@@ -0,0 +1,61 @@
+// Bootstrap creates the first administrator of the service or promotes an existing user to admins.
+//
+// Usage:
+//
+//	BOOTSTRAP_PASSWORD=<password> go run ./cmd/bootstrap --login <login>
+//
+// The password is used only if there's no user with such login. All the other settings
+// (database_uri, service.password_pepper etc) are taken from the service configuration.
+// The in-memory storage can't be bootstrapped: its data is lost on exit.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/vanamelnik/gophermart/cmd/gophermart/config"
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/pkg/logging"
+	"github.com/vanamelnik/gophermart/service/gophermart"
+	"github.com/vanamelnik/gophermart/storage/backend"
+	"github.com/vanamelnik/gophermart/storage/memory"
+
+	flag "github.com/spf13/pflag"
+)
+
+func main() {
+	login := flag.String("login", "", "login of the administrator")
+	// The flags are parsed by LoadConfig.
+	cfg := config.LoadConfig("./config.toml")
+	must(cfg.Validate())
+	if *login == "" {
+		fmt.Fprintln(os.Stderr, "bootstrap: --login is required")
+		os.Exit(2)
+	}
+	if memory.IsDSN(cfg.DatabaseURI) {
+		fmt.Fprintln(os.Stderr, "bootstrap: the in-memory storage can't be bootstrapped: its data is lost on exit")
+		os.Exit(2)
+	}
+
+	log := logging.NewLogger(logging.WithConsoleOutput(true), logging.WithLevel(cfg.Logger.Level))
+	ctx := appContext.WithLogger(context.Background(), log)
+
+	// The storage is closed by the service.
+	db, err := backend.Open(cfg.DatabaseURI, log)
+	must(err)
+
+	service, err := gophermart.New(ctx, db, gophermart.WithConfig(cfg.Service), gophermart.WithoutWorkers())
+	must(err)
+	defer service.Close()
+
+	user, err := service.BootstrapAdmin(ctx, *login, os.Getenv("BOOTSTRAP_PASSWORD"))
+	must(err)
+	log.Info().Str("login", user.Login).Str("id", user.ID.String()).Msg("bootstrap: the administrator is ready")
+}
+
+func must(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
@@ -2,19 +2,41 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/vanamelnik/gophermart/api/rest"
 	"github.com/vanamelnik/gophermart/cmd/gophermart/config"
+	"github.com/vanamelnik/gophermart/pkg/accesstoken"
 	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/pkg/health"
 	"github.com/vanamelnik/gophermart/pkg/logging"
+	"github.com/vanamelnik/gophermart/pkg/metrics"
+	"github.com/vanamelnik/gophermart/pkg/middleware"
+	"github.com/vanamelnik/gophermart/pkg/ratelimit"
+	"github.com/vanamelnik/gophermart/pkg/tracing"
 	"github.com/vanamelnik/gophermart/provider/accrual"
+	"github.com/vanamelnik/gophermart/provider/notifier"
+	"github.com/vanamelnik/gophermart/provider/publisher"
 	"github.com/vanamelnik/gophermart/service/gophermart"
+	"github.com/vanamelnik/gophermart/storage"
+	"github.com/vanamelnik/gophermart/storage/backend"
+	"github.com/vanamelnik/gophermart/storage/migration"
 	"github.com/vanamelnik/gophermart/storage/psql"
+	"github.com/vanamelnik/gophermart/storage/sqlite"
 )
 
+// sqlDatabase is a storage on SQL database. Its connection and schema version are checked by the readiness checks.
+type sqlDatabase interface {
+	Ping(ctx context.Context) error
+	MigrationVersion(ctx context.Context) (version uint, dirty bool, err error)
+}
+
 func main() {
 	// Load config.
 	cfg := config.LoadConfig("./config.toml")
@@ -23,33 +45,67 @@ func main() {
 	// Create the logger.
 	log := logging.NewLogger(logging.WithConsoleOutput(cfg.Logger.Console), logging.WithLevel(cfg.Logger.Level))
 	ctx := appContext.WithLogger(context.Background(), log)
-	log.Trace().Msgf("config loaded: %+v", cfg)
+	log.Trace().Msgf("config loaded: %+v", cfg.Redacted())
 
-	// Connect to the database.
-	db, err := psql.New(
-		psql.WithDSN(cfg.DatabaseURI),
-		psql.WithAutoMigrate(log, "file://storage/psql/migration"),
-	)
+	// Setup tracing.
+	tp, err := tracing.New(ctx, cfg.Tracing)
 	must(err)
-	defer db.Close()
+	if tp != nil {
+		defer func() {
+			if err := tp.Shutdown(ctx); err != nil {
+				log.Error().Err(err).Msg("tracing shutdown")
+			}
+		}()
+	}
 
-	// Start Gophermart Service
-	service, err := gophermart.New(
-		ctx, db,
+	// Connect to the database. The storage is closed by the service.
+	db, err := backend.Open(cfg.DatabaseURI, log)
+	must(err)
+	if s, ok := db.(interface{ Stats() sql.DBStats }); ok {
+		must(metrics.RegisterDBStats(s.Stats))
+	}
+	store := storage.WithTracing(db)
+
+	// Setup access tokens, the notifier and the publisher of domain events.
+	opts := []gophermart.ServiceOption{
 		gophermart.WithConfig(cfg.Service),
 		gophermart.WithAccrualClient(accrual.New(cfg.AccrualSystemAddr)),
-	)
+	}
+	if len(cfg.AccessTokens.Keys) > 0 {
+		tokens, err := accesstoken.New(cfg.AccessTokens.Keys, cfg.AccessTokens.CurrentKey, cfg.AccessTokens.TTL)
+		must(err)
+		opts = append(opts, gophermart.WithAccessTokens(tokens))
+	}
+	n, err := newNotifier(cfg.Notifier)
+	must(err)
+	if n != nil {
+		defer n.Close()
+		opts = append(opts, gophermart.WithNotifier(n))
+	}
+	pub, err := newPublisher(cfg.Outbox)
+	must(err)
+	if pub != nil {
+		defer pub.Close()
+		opts = append(opts, gophermart.WithPublisher(pub))
+	}
+
+	// Start Gophermart Service
+	service, err := gophermart.New(ctx, store, opts...)
 	must(err)
 	defer service.Close()
+	must(service.UpgradeSessionHashes(ctx))
+	must(service.RecoverWithdrawals(ctx))
 
-	// Setup routes
-	router := rest.SetupRoutes(service, db, log)
+	// Setup readiness checks and routes
+	checker, err := newHealthChecker(cfg.Health, db, service)
+	must(err)
+	router := rest.SetupRoutes(gophermart.WithTracing(service), store, checker, log, newRateLimiter(cfg.RateLimit, db))
 	server := http.Server{
 		Addr:    cfg.RunAddr,
 		Handler: router,
 	}
-	sigint := make(chan os.Signal, 1)
-	signal.Notify(sigint, os.Interrupt)
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
 
 	go func() {
 		err := server.ListenAndServe()
@@ -61,11 +117,137 @@ func main() {
 	}()
 	log.Info().Msgf("main: the server is listening at %s", cfg.RunAddr)
 
-	<-sigint
-	log.Info().Msg("main: shutting down... ")
-	if err := server.Shutdown(ctx); err != nil {
+	s := <-sig
+	log.Info().Str("signal", s.String()).Msg("main: shutting down... ")
+	// Fail the readiness checks and keep serving until the load balancer notices it. The second signal
+	// skips the delay.
+	checker.Shutdown()
+	select {
+	case <-time.After(cfg.ShutdownDrainDelay):
+	case <-sig:
+	}
+	// Stop accepting new connections and drain the requests in flight.
+	shutdownCtx, cancel := context.WithTimeout(ctx, cfg.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
 		log.Error().Err(err).Msg("server shutdown")
 	}
+	// Stop the poller and the other workers and flush the balance updates. The storage, the publisher,
+	// the notifier and the tracer provider are closed by the deferred calls after the service.
+	if err := service.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("service shutdown")
+	}
+	log.Info().Msg("main: stopped")
+}
+
+// newHealthChecker creates the readiness checks of the database connection, the schema version
+// and the progress of the service's workers. The in-memory storage has no checks.
+func newHealthChecker(cfg config.HealthConfig, db storage.Storage, service *gophermart.GopherMart) (*health.Checker, error) {
+	checker := health.NewChecker(cfg.Timeout)
+	var (
+		sqlDB  sqlDatabase
+		latest uint
+		err    error
+	)
+	switch db := db.(type) {
+	case *psql.Psql:
+		sqlDB = db
+		latest, err = migration.Latest(backend.PsqlMigrationsURL)
+	case *sqlite.Sqlite:
+		sqlDB = db
+		latest, err = sqliteLatestMigration()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if sqlDB != nil {
+		addDatabaseChecks(checker, sqlDB, latest)
+	}
+	for name, heartbeat := range service.Heartbeats() {
+		checker.Add("worker:"+name, heartbeat.Check(cfg.WorkerWindow))
+	}
+
+	return checker, nil
+}
+
+// sqliteLatestMigration returns the version of the last migration embedded into SQLite storage.
+func sqliteLatestMigration() (uint, error) {
+	src, err := sqlite.Migrations()
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	return migration.LatestOf(src)
+}
+
+// addDatabaseChecks adds the checks of the database connection and the schema version. The schema must be
+// migrated up to the latest version provided.
+func addDatabaseChecks(checker *health.Checker, db sqlDatabase, latest uint) {
+	checker.Add("database", db.Ping)
+	checker.Add("migrations", func(ctx context.Context) error {
+		version, dirty, err := db.MigrationVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("migration %d is dirty", version)
+		}
+		if version < latest {
+			return fmt.Errorf("schema version is %d, expected %d", version, latest)
+		}
+
+		return nil
+	})
+}
+
+// newPublisher creates the publisher of domain events configured. If no publisher is configured, nil is returned.
+func newPublisher(cfg config.OutboxConfig) (publisher.Publisher, error) {
+	switch cfg.Publisher {
+	case "file":
+		p, err := publisher.NewFile(cfg.FilePath)
+		if err != nil {
+			return nil, err
+		}
+
+		return p, nil
+	case "memory":
+		return publisher.NewMemory(), nil
+	default:
+		return nil, nil
+	}
+}
+
+// newNotifier creates the notifier configured. If no notifier is configured, nil is returned.
+func newNotifier(cfg config.NotifierConfig) (notifier.Notifier, error) {
+	switch cfg.Type {
+	case "file":
+		n, err := notifier.NewFile(cfg.FilePath)
+		if err != nil {
+			return nil, err
+		}
+
+		return n, nil
+	case "log":
+		return notifier.NewWriter(os.Stderr), nil
+	default:
+		return nil, nil
+	}
+}
+
+// newRateLimiter creates the rate limiter configured. If rate limiting is disabled, nil is returned.
+func newRateLimiter(cfg config.RateLimitConfig, db storage.Storage) *middleware.RateLimiter {
+	var store ratelimit.Store
+	switch cfg.Store {
+	case "memory":
+		store = ratelimit.NewMemory()
+	case "postgres":
+		store = ratelimit.StoreFunc(db.TakeRateLimitToken)
+	default:
+		return nil
+	}
+
+	return middleware.NewRateLimiter(store, cfg.Default, cfg.Routes)
 }
 
 func must(err error) {
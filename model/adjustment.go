@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Adjustment represents a manual correction of the user's bonus balance made by an administrator.
+// Positive Sum credits the points, negative Sum debits them.
+type Adjustment struct {
+	ID      uuid.UUID `json:"id"`
+	UserID  uuid.UUID `json:"user_id"`
+	AdminID uuid.UUID `json:"admin_id"`
+	Sum     float32   `json:"sum"`
+	// Reason is mandatory and explains why the balance was corrected.
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
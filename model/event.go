@@ -0,0 +1,68 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EventOrderCreated        EventType = "order.created"        // the order is uploaded by the user
+	EventOrderStatusChanged  EventType = "order.status_changed" // the order status is updated
+	EventOrderProcessed      EventType = "order.processed"      // accrual for the order is calculated
+	EventOrderInvalid        EventType = "order.invalid"        // the order is rejected by the accrual service
+	EventBalanceCredited     EventType = "balance.credited"     // accrual points are added to the user's balance
+	EventBalanceAdjusted     EventType = "balance.adjusted"     // the balance is corrected by an administrator
+	EventWithdrawalProcessed EventType = "withdrawal.processed" // points are successfully withdrawn
+	EventWithdrawalRejected  EventType = "withdrawal.rejected"  // the withdrawal is rejected
+)
+
+type (
+	// Event is a domain event stored in the transactional outbox. It's written in the same
+	// transaction as the state change it describes.
+	Event struct {
+		// ID is a sequence number of the event.
+		ID   int64     `json:"id"`
+		Type EventType `json:"type"`
+		// AggregateID identifies the entity changed (order number, user ID etc).
+		AggregateID string          `json:"aggregate_id"`
+		Payload     json.RawMessage `json:"payload"`
+		CreatedAt   time.Time       `json:"created_at"`
+	}
+
+	// EventType is a type of the domain event.
+	EventType string
+
+	// OrderEvent is a payload of order.* events.
+	OrderEvent struct {
+		Order          OrderID   `json:"order"`
+		UserID         uuid.UUID `json:"user_id"`
+		Status         Status    `json:"status"`
+		PreviousStatus Status    `json:"previous_status,omitempty"`
+		Accrual        float32   `json:"accrual,omitempty"`
+	}
+
+	// BalanceEvent is a payload of balance.* events.
+	BalanceEvent struct {
+		Order  OrderID   `json:"order"`
+		UserID uuid.UUID `json:"user_id"`
+		Sum    float32   `json:"sum"`
+	}
+
+	// WithdrawalEvent is a payload of withdrawal.* events.
+	WithdrawalEvent struct {
+		Order       OrderID   `json:"order"`
+		UserID      uuid.UUID `json:"user_id"`
+		Sum         float32   `json:"sum"`
+		Status      Status    `json:"status"`
+		ProcessedAt time.Time `json:"processed_at"`
+	}
+)
+
+// Valid validates the type of the event a partner can subscribe to with a webhook.
+func (e EventType) Valid() bool {
+	return e == EventOrderProcessed ||
+		e == EventOrderInvalid ||
+		e == EventWithdrawalProcessed
+}
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// LoginThrottle keeps track of failed login attempts by the key (the login or the client's IP).
+type LoginThrottle struct {
+	Key           string    `json:"key"`
+	Failures      int       `json:"failures"`
+	LastFailureAt time.Time `json:"last_failure_at"`
+	// LockedUntil is nil if the key isn't locked.
+	LockedUntil *time.Time `json:"locked_until"`
+}
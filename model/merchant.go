@@ -0,0 +1,67 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ScopeOrdersSubmit Scope = "orders:submit" // submit order numbers on behalf of the users
+)
+
+type (
+	// Scope represents the right of a merchant's API key.
+	Scope string
+
+	// Merchant is a store backend that calls the API with its API keys.
+	Merchant struct {
+		ID        uuid.UUID `json:"id"`
+		Name      string    `json:"name"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	// APIKey is a merchant's key. Only the hash of the key is stored, the key itself is shown once.
+	APIKey struct {
+		ID         uuid.UUID `json:"id"`
+		MerchantID uuid.UUID `json:"merchant_id"`
+		// Prefix is the beginning of the key to help to recognize it.
+		Prefix     string     `json:"prefix"`
+		KeyHash    string     `json:"-"`
+		Scopes     []Scope    `json:"scopes"`
+		CreatedAt  time.Time  `json:"created_at"`
+		LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+		// RevokedAt is nil until the key is revoked.
+		RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	}
+)
+
+// scopes is the list of known scopes.
+var scopes = []Scope{ScopeOrdersSubmit}
+
+// Valid validates the scope.
+func (s Scope) Valid() bool {
+	for _, scope := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Can checks whether the key is granted the scope.
+func (k APIKey) Can(scope Scope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Revoked reports whether the key is revoked.
+func (k APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
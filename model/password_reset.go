@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PasswordReset is a single-use request to reset the user's password. Only the hash of the
+// reset token is stored; the token itself is sent to the user.
+type PasswordReset struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"-"`
+	TokenHash string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// UsedAt is nil until the password is reset.
+	UsedAt *time.Time `json:"used_at"`
+}
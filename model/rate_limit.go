@@ -0,0 +1,80 @@
+package model
+
+import (
+	"math"
+	"time"
+)
+
+type (
+	// RateLimit allows Requests requests per Per period with bursts up to Burst requests.
+	// A zero RateLimit doesn't limit anything.
+	RateLimit struct {
+		Requests int           `mapstructure:"requests"`
+		Per      time.Duration `mapstructure:"per"`
+		// Burst is the size of the bucket. If zero, Requests is used.
+		Burst int `mapstructure:"burst"`
+	}
+
+	// RateLimitBucket is the state of the token bucket of a key. A zero RateLimitBucket is full.
+	RateLimitBucket struct {
+		Tokens    float64
+		UpdatedAt time.Time
+	}
+
+	// RateLimitResult is the result of taking a token from the bucket.
+	RateLimitResult struct {
+		Allowed bool
+		// Limit is the size of the bucket.
+		Limit int
+		// Remaining is the number of requests allowed right now.
+		Remaining int
+		// RetryAfter is the time until the next request is allowed. Zero if the request is allowed.
+		RetryAfter time.Duration
+		// Reset is the time until the bucket is full again.
+		Reset time.Duration
+	}
+)
+
+// Unlimited reports whether the limit doesn't limit anything.
+func (l RateLimit) Unlimited() bool {
+	return l.Requests <= 0 || l.Per <= 0
+}
+
+// Size returns the size of the bucket.
+func (l RateLimit) Size() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+
+	return l.Requests
+}
+
+// rate returns the number of tokens added to the bucket per second.
+func (l RateLimit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// Take refills the bucket by the time passed, takes a token if there is one and returns the new state of the bucket.
+func (l RateLimit) Take(b RateLimitBucket, now time.Time) (RateLimitBucket, RateLimitResult) {
+	size := float64(l.Size())
+	tokens := size
+	if !b.UpdatedAt.IsZero() {
+		tokens = math.Min(size, b.Tokens+math.Max(0, now.Sub(b.UpdatedAt).Seconds())*l.rate())
+	}
+	res := RateLimitResult{Limit: l.Size()}
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = l.duration(1 - tokens)
+	}
+	res.Remaining = int(tokens)
+	res.Reset = l.duration(size - tokens)
+
+	return RateLimitBucket{Tokens: tokens, UpdatedAt: now}, res
+}
+
+// duration returns the time needed to add the number of tokens provided.
+func (l RateLimit) duration(tokens float64) time.Duration {
+	return time.Duration(math.Ceil(tokens / l.rate() * float64(time.Second)))
+}
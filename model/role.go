@@ -0,0 +1,63 @@
+package model
+
+const (
+	RoleUser    Role = "user"    // a customer of the loyalty system
+	RoleSupport Role = "support" // a member of the support team with read access to the admin API
+	RoleAdmin   Role = "admin"   // an administrator of the service
+)
+
+const (
+	PermUsersRead       Permission = "users:read"       // search users and view their data
+	PermUsersBlock      Permission = "users:block"      // block and unblock users
+	PermBalanceAdjust   Permission = "balance:adjust"   // credit and debit users' balances
+	PermOrdersRepoll    Permission = "orders:repoll"    // poll the accrual system for an order once again
+	PermWebhooksManage  Permission = "webhooks:manage"  // manage webhook subscriptions
+	PermRolesManage     Permission = "roles:manage"     // grant roles and permissions to users
+	PermMerchantsManage Permission = "merchants:manage" // manage merchants and their API keys
+)
+
+type (
+	// Role represents the role of the user. Each role has a set of permissions.
+	Role string
+
+	// Permission represents the right to perform an operation.
+	Permission string
+)
+
+// rolePermissions defines the permissions granted to each role.
+var rolePermissions = map[Role][]Permission{
+	RoleUser:    {},
+	RoleSupport: {PermUsersRead, PermOrdersRepoll},
+	RoleAdmin: {
+		PermUsersRead,
+		PermUsersBlock,
+		PermBalanceAdjust,
+		PermOrdersRepoll,
+		PermWebhooksManage,
+		PermRolesManage,
+		PermMerchantsManage,
+	},
+}
+
+// Valid validates the role.
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+
+	return ok
+}
+
+// Permissions returns the permissions granted to the role.
+func (r Role) Permissions() []Permission {
+	return rolePermissions[r]
+}
+
+// Valid validates the permission.
+func (p Permission) Valid() bool {
+	for _, perm := range rolePermissions[RoleAdmin] {
+		if p == perm {
+			return true
+		}
+	}
+
+	return false
+}
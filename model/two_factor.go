@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type (
+	// TwoFactor is the user's TOTP two-factor authentication. It's pending until the user confirms
+	// the enrollment with a valid code.
+	TwoFactor struct {
+		UserID uuid.UUID
+		// Secret is the base32 encoded TOTP secret shared with the user's authenticator app.
+		Secret string
+		// EnabledAt is nil until the enrollment is confirmed.
+		EnabledAt *time.Time
+		// LastUsedStep is the time step of the last accepted code. The codes of this and earlier
+		// steps are refused, so a code can't be replayed.
+		LastUsedStep int64
+	}
+
+	// LoginChallenge is issued after the password check when the user has two-factor authentication enabled.
+	// The sign-in is completed with the challenge token and a TOTP or recovery code. Only the hash of
+	// the token is stored.
+	LoginChallenge struct {
+		TokenHash string
+		UserID    uuid.UUID
+		CreatedAt time.Time
+		ExpiresAt time.Time
+		// Attempts is the number of the codes entered.
+		Attempts int
+	}
+)
+
+// Enabled reports whether the enrollment is confirmed.
+func (t TwoFactor) Enabled() bool {
+	return t.EnabledAt != nil
+}
+
+// Expired reports whether the challenge is expired at the time provided.
+func (c LoginChallenge) Expired(now time.Time) bool {
+	return !now.Before(c.ExpiresAt)
+}
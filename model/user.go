@@ -2,15 +2,12 @@ package model
 
 import (
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/hashicorp/go-multierror"
 )
 
-const passwordMinLength = 8
-const passwordMaxLength = 256
-
 // User represents the user of the service.
 // First the user is registered in the GopherMart loyality system. When authenticated
 // user makes a purchase in GopherMart store, the order (with information about the goods and
@@ -18,49 +15,65 @@ const passwordMaxLength = 256
 // and that service makes a request to the GopherPoint service with a user ID and order number and
 // receives a number of bonus G-Points. And those points are added to user's bonus balance.
 type User struct {
-	ID    uuid.UUID
-	Login string
+	ID    uuid.UUID `json:"id"`
+	Login string    `json:"login"`
 	// Password value is deleted after encrypting.
-	Password string
+	Password string `json:"-"`
 	// PasswordHash is bcrypt hashed user's password.
-	PasswordHash  string
-	CreatedAt     time.Time
-	RememberToken string
+	PasswordHash string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
 	// GPointsBalance is user's bonus account balance
-	GPointsBalance float32
+	GPointsBalance float32 `json:"balance"`
+	Role           Role    `json:"role"`
+	// Permissions are granted to the user in addition to the permissions of the role.
+	Permissions []Permission `json:"permissions"`
+	// Blocked user can neither log in nor use the API.
+	Blocked bool `json:"blocked"`
+	// DeletedAt is set for the anonymous tombstone of the deleted account that keeps its financial logs.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
-// Validate performs User fields checking.
-func (c User) Validate() error {
-	var result *multierror.Error
-
-	if err := validateLogin(c.Login); err != nil {
-		result = multierror.Append(result, err)
+// Can checks whether the user is granted the permission either by the role or personally.
+func (c User) Can(perm Permission) bool {
+	for _, p := range c.Role.Permissions() {
+		if p == perm {
+			return true
+		}
 	}
-	if err := validatePassword(c.Password); err != nil {
-		result = multierror.Append(result, err)
+	for _, p := range c.Permissions {
+		if p == perm {
+			return true
+		}
 	}
 
-	return result.ErrorOrNil()
+	return false
 }
 
-func validateLogin(login string) error {
-	if len(login) < 3 || len(login) > 64 {
-		return errors.New("validate login: invalid length")
-	}
+// Deleted checks whether the user is the tombstone of a deleted account.
+func (c User) Deleted() bool {
+	return c.DeletedAt != nil
+}
 
-	return nil
+// deletedLoginPrefix is the prefix of the tombstones' logins. It's reserved, so no one can register such a login.
+const deletedLoginPrefix = "deleted:"
+
+// DeletedUserLogin returns the login of the deleted account's tombstone with the pseudonymous id provided.
+func DeletedUserLogin(id uuid.UUID) string {
+	return deletedLoginPrefix + id.String()
+}
+
+// Validate performs User fields checking. The password is checked by the password policy of the service.
+func (c User) Validate() error {
+	return validateLogin(c.Login)
 }
 
-func validatePassword(password string) error {
-	if password == "" {
-		return errors.New("valdate password: empty")
+func validateLogin(login string) error {
+	if len(login) < 3 || len(login) > 64 {
+		return errors.New("validate login: invalid length")
 	}
-	if len(password) < passwordMinLength || len(password) > passwordMaxLength {
-		return errors.New("validate password: invalid length")
+	if strings.HasPrefix(strings.ToLower(login), deletedLoginPrefix) {
+		return errors.New("validate login: the prefix is reserved")
 	}
 
-	// TODO: we can check if there is at least one digit, capital letter etc.
-
 	return nil
 }
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DeliveryPending   DeliveryStatus = "PENDING"   // the delivery is waiting for the (next) attempt
+	DeliveryDelivered DeliveryStatus = "DELIVERED" // the receiver responded with 2xx status
+	DeliveryFailed    DeliveryStatus = "FAILED"    // all attempts are exhausted
+)
+
+type (
+	// Webhook represents a partner's subscription to the service events.
+	// Each payload sent to the URL is signed with HMAC-SHA256 using the Secret.
+	Webhook struct {
+		ID         uuid.UUID   `json:"id"`
+		URL        string      `json:"url"`
+		Secret     string      `json:"-"`
+		EventTypes []EventType `json:"event_types"`
+		CreatedAt  time.Time   `json:"created_at"`
+	}
+
+	// WebhookDelivery is an entry of the persistent delivery queue. It's also used as a delivery log.
+	WebhookDelivery struct {
+		ID        uuid.UUID       `json:"id"`
+		WebhookID uuid.UUID       `json:"webhook_id"`
+		EventType EventType       `json:"event_type"`
+		Payload   json.RawMessage `json:"payload"`
+		Status    DeliveryStatus  `json:"status"`
+		Attempts  int             `json:"attempts"`
+		// LastStatusCode is the HTTP status code of the last attempt (0 if the receiver wasn't reached).
+		LastStatusCode int       `json:"last_status_code,omitempty"`
+		LastError      string    `json:"last_error,omitempty"`
+		NextAttemptAt  time.Time `json:"next_attempt_at"`
+		CreatedAt      time.Time `json:"created_at"`
+	}
+
+	// DeliveryStatus represents the status of the webhook delivery.
+	DeliveryStatus string
+)
+
+// Subscribed checks whether the webhook is subscribed to the event provided.
+func (w Webhook) Subscribed(event EventType) bool {
+	for _, e := range w.EventTypes {
+		if e == event {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,126 @@
+// Package accesstoken issues and verifies short-lived access tokens (JWT signed with HS256).
+// Each token has "kid" header with the id of the signing key, so the keys can be rotated:
+// new tokens are signed with the current key while the tokens signed with the previous keys
+// are accepted until they expire or the keys are removed.
+package accesstoken
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+const issuer = "gophermart"
+
+// ErrInvalidToken is returned when the token is malformed, expired or has a wrong signature.
+var ErrInvalidToken = errors.New("accesstoken: invalid token")
+
+type (
+	// Claims are the claims of the access token. The subject is the user's ID.
+	Claims struct {
+		jwt.RegisteredClaims
+		SessionID   string             `json:"sid"`
+		Role        model.Role         `json:"role"`
+		Permissions []model.Permission `json:"permissions,omitempty"`
+	}
+
+	// Manager issues and verifies access tokens.
+	Manager struct {
+		keys       map[string][]byte
+		currentKID string
+		ttl        time.Duration
+		parser     *jwt.Parser
+	}
+)
+
+// New creates a new Manager. The keys is a map of key ids to secrets, the tokens are signed
+// with the key currentKID.
+func New(keys map[string]string, currentKID string, ttl time.Duration) (*Manager, error) {
+	if _, ok := keys[currentKID]; !ok {
+		return nil, fmt.Errorf("accesstoken: key %q not found", currentKID)
+	}
+	if ttl <= 0 {
+		return nil, errors.New("accesstoken: ttl must be positive")
+	}
+	m := &Manager{
+		keys:       make(map[string][]byte, len(keys)),
+		currentKID: currentKID,
+		ttl:        ttl,
+		parser:     jwt.NewParser(jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()})),
+	}
+	for kid, secret := range keys {
+		if secret == "" {
+			return nil, fmt.Errorf("accesstoken: empty secret of key %q", kid)
+		}
+		m.keys[kid] = []byte(secret)
+	}
+
+	return m, nil
+}
+
+// TTL returns the lifetime of the tokens.
+func (m *Manager) TTL() time.Duration {
+	return m.ttl
+}
+
+// Issue creates an access token of the user's session.
+func (m *Manager) Issue(user model.User, sessionID uuid.UUID, now time.Time) (string, time.Time, error) {
+	expiresAt := now.Add(m.ttl)
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   user.ID.String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		SessionID:   sessionID.String(),
+		Role:        user.Role,
+		Permissions: user.Permissions,
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = m.currentKID
+	signed, err := token.SignedString(m.keys[m.currentKID])
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return signed, expiresAt, nil
+}
+
+// Parse verifies the token and returns its claims.
+func (m *Manager) Parse(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := m.parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := m.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key %q", kid)
+		}
+
+		return key, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if !claims.VerifyIssuer(issuer, true) {
+		return nil, fmt.Errorf("%w: wrong issuer", ErrInvalidToken)
+	}
+
+	return claims, nil
+}
+
+// UserID returns the id of the token's owner.
+func (c Claims) UserID() (uuid.UUID, error) {
+	return uuid.Parse(c.Subject)
+}
+
+// SessionUUID returns the id of the session the token was issued for.
+func (c Claims) SessionUUID() (uuid.UUID, error) {
+	return uuid.Parse(c.SessionID)
+}
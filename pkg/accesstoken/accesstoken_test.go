@@ -0,0 +1,81 @@
+package accesstoken
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssueAndParse(t *testing.T) {
+	user := model.User{
+		ID:          uuid.New(),
+		Role:        model.RoleSupport,
+		Permissions: []model.Permission{model.PermUsersBlock},
+	}
+	sessionID := uuid.New()
+	now := time.Now()
+
+	old, err := New(map[string]string{"2021-12": "old secret"}, "2021-12", time.Minute)
+	require.NoError(t, err)
+	rotated, err := New(map[string]string{"2021-12": "old secret", "2022-01": "new secret"}, "2022-01", time.Minute)
+	require.NoError(t, err)
+	oldToken, _, err := old.Issue(user, sessionID, now)
+	require.NoError(t, err)
+	newToken, expiresAt, err := rotated.Issue(user, sessionID, now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(time.Minute), expiresAt)
+
+	t.Run("#1 Normal case", func(t *testing.T) {
+		claims, err := rotated.Parse(newToken)
+		require.NoError(t, err)
+		userID, err := claims.UserID()
+		require.NoError(t, err)
+		sid, err := claims.SessionUUID()
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, userID)
+		assert.Equal(t, sessionID, sid)
+		assert.Equal(t, user.Role, claims.Role)
+		assert.Equal(t, user.Permissions, claims.Permissions)
+	})
+	t.Run("#2 Token signed with the previous key is accepted after rotation", func(t *testing.T) {
+		_, err := rotated.Parse(oldToken)
+		assert.NoError(t, err)
+	})
+	t.Run("#3 Token signed with unknown key", func(t *testing.T) {
+		_, err := old.Parse(newToken)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+	t.Run("#4 Expired token", func(t *testing.T) {
+		expired, _, err := rotated.Issue(user, sessionID, now.Add(-time.Hour))
+		require.NoError(t, err)
+		_, err = rotated.Parse(expired)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+	t.Run("#5 Unsigned token", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodNone, Claims{SessionID: sessionID.String()})
+		token.Header["kid"] = "2022-01"
+		unsigned, err := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+		require.NoError(t, err)
+		_, err = rotated.Parse(unsigned)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+	t.Run("#6 Garbage", func(t *testing.T) {
+		_, err := rotated.Parse("not.a.token")
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(map[string]string{"k1": "secret"}, "k2", time.Minute)
+	assert.Error(t, err)
+	_, err = New(map[string]string{"k1": ""}, "k1", time.Minute)
+	assert.Error(t, err)
+	_, err = New(map[string]string{"k1": "secret"}, "k1", 0)
+	assert.Error(t, err)
+}
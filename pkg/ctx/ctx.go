@@ -9,8 +9,12 @@ import (
 )
 
 const (
-	userKey   ctxKey = "user"
-	loggerKey ctxKey = "logger"
+	userKey    ctxKey = "user"
+	sessionKey ctxKey = "session"
+	apiKeyKey  ctxKey = "api key"
+	loggerKey  ctxKey = "logger"
+
+	requestIDKey ctxKey = "request id"
 )
 
 type ctxKey string
@@ -31,6 +35,52 @@ func User(ctx context.Context) *model.User {
 	return nil
 }
 
+// WithSession adds the session of authenticated user to the provided context.
+func WithSession(ctx context.Context, session *model.Session) context.Context {
+	return context.WithValue(ctx, sessionKey, session)
+}
+
+// Session fetches the session of authenticated user from the provided context.
+func Session(ctx context.Context) *model.Session {
+	if ctxValue := ctx.Value(sessionKey); ctxValue != nil {
+		if session, ok := ctxValue.(*model.Session); ok {
+			return session
+		}
+	}
+
+	return nil
+}
+
+// WithAPIKey adds the authenticated merchant's API key to the provided context.
+func WithAPIKey(ctx context.Context, key *model.APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyKey, key)
+}
+
+// APIKey fetches the authenticated merchant's API key from the provided context.
+func APIKey(ctx context.Context) *model.APIKey {
+	if ctxValue := ctx.Value(apiKeyKey); ctxValue != nil {
+		if key, ok := ctxValue.(*model.APIKey); ok {
+			return key
+		}
+	}
+
+	return nil
+}
+
+// WithRequestID adds the ID of the request to the provided context.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID fetches the ID of the request from the provided context. If there's no ID, empty string is returned.
+func RequestID(ctx context.Context) string {
+	if id, ok := ctx.Value(requestIDKey).(string); ok {
+		return id
+	}
+
+	return ""
+}
+
 // WithLogger applies a logger to the context provided.
 func WithLogger(ctx context.Context, logger zerolog.Logger) context.Context {
 	return context.WithValue(ctx, loggerKey, logger)
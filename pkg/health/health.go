@@ -0,0 +1,121 @@
+// Package health checks whether the service is ready to serve the requests.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+
+	defaultTimeout = 2 * time.Second
+)
+
+type (
+	// Check returns an error if the dependency checked isn't healthy. It must respect the context deadline.
+	Check func(ctx context.Context) error
+
+	// Checker runs the readiness checks. It's safe for concurrent use.
+	Checker struct {
+		timeout      time.Duration
+		mu           sync.RWMutex
+		checks       map[string]Check
+		shuttingDown int32
+	}
+
+	// Result is the result of a single check.
+	Result struct {
+		Status string `json:"status"`
+		Error  string `json:"error,omitempty"`
+	}
+
+	// Report is the breakdown of the readiness checks.
+	Report struct {
+		Status string `json:"status"`
+		// ShuttingDown is true once the service has started graceful shutdown. The service isn't ready then.
+		ShuttingDown bool              `json:"shutting_down,omitempty"`
+		Checks       map[string]Result `json:"checks"`
+	}
+)
+
+// NewChecker creates a new checker. Each check is cancelled after the timeout. Zero timeout is replaced by the default.
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	return &Checker{
+		timeout: timeout,
+		checks:  make(map[string]Check),
+	}
+}
+
+// Add adds the check with the name provided. The check with the same name is replaced.
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks[name] = check
+}
+
+// Shutdown marks the service as shutting down, so it's not ready anymore.
+func (c *Checker) Shutdown() {
+	atomic.StoreInt32(&c.shuttingDown, 1)
+}
+
+// ShuttingDown reports whether Shutdown has been called.
+func (c *Checker) ShuttingDown() bool {
+	return atomic.LoadInt32(&c.shuttingDown) == 1
+}
+
+// Check runs all the checks concurrently and returns the report. The service is ready if all the checks
+// are passed and it isn't shutting down.
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := make(map[string]Check, len(c.checks))
+	for name, check := range c.checks {
+		checks[name] = check
+	}
+	c.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	report := Report{
+		Status:       StatusOK,
+		ShuttingDown: c.ShuttingDown(),
+		Checks:       make(map[string]Result, len(checks)),
+	}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+			res := Result{Status: StatusOK}
+			if err := check(ctx); err != nil {
+				res = Result{Status: StatusFail, Error: err.Error()}
+			}
+			mu.Lock()
+			report.Checks[name] = res
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	for _, res := range report.Checks {
+		if res.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	if report.ShuttingDown {
+		report.Status = StatusFail
+	}
+
+	return report
+}
@@ -0,0 +1,50 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChecker(t *testing.T) {
+	c := NewChecker(50 * time.Millisecond)
+	c.Add("database", func(ctx context.Context) error { return nil })
+	report := c.Check(context.Background())
+	assert.Equal(t, StatusOK, report.Status)
+	assert.Equal(t, map[string]Result{"database": {Status: StatusOK}}, report.Checks)
+
+	t.Run("#1 Failed check", func(t *testing.T) {
+		c.Add("migrations", func(ctx context.Context) error { return errors.New("migration 13 is dirty") })
+		report := c.Check(context.Background())
+		assert.Equal(t, StatusFail, report.Status)
+		assert.Equal(t, Result{Status: StatusFail, Error: "migration 13 is dirty"}, report.Checks["migrations"])
+		assert.Equal(t, StatusOK, report.Checks["database"].Status)
+		c.Add("migrations", func(ctx context.Context) error { return nil })
+	})
+	t.Run("#2 Check times out", func(t *testing.T) {
+		c.Add("slow", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		report := c.Check(context.Background())
+		assert.Equal(t, StatusFail, report.Checks["slow"].Status)
+		c.Add("slow", func(ctx context.Context) error { return nil })
+	})
+	t.Run("#3 Worker without progress", func(t *testing.T) {
+		hb := NewHeartbeat(time.Now().Add(-2 * time.Minute))
+		check := hb.Check(time.Minute)
+		assert.Error(t, check(context.Background()))
+		hb.Beat(time.Now())
+		assert.NoError(t, check(context.Background()))
+	})
+	t.Run("#4 Shutting down", func(t *testing.T) {
+		assert.Equal(t, StatusOK, c.Check(context.Background()).Status)
+		c.Shutdown()
+		report := c.Check(context.Background())
+		assert.Equal(t, StatusFail, report.Status)
+		assert.True(t, report.ShuttingDown)
+	})
+}
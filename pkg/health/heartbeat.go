@@ -0,0 +1,39 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Heartbeat records the last time a background worker has made progress. It's safe for concurrent use.
+type Heartbeat struct {
+	last int64 // unix nanoseconds
+}
+
+// NewHeartbeat creates a heartbeat that has beaten at the time provided (usually the start of the worker).
+func NewHeartbeat(now time.Time) *Heartbeat {
+	return &Heartbeat{last: now.UnixNano()}
+}
+
+// Beat records the progress made at the time provided.
+func (h *Heartbeat) Beat(now time.Time) {
+	atomic.StoreInt64(&h.last, now.UnixNano())
+}
+
+// Last returns the time of the last beat.
+func (h *Heartbeat) Last() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&h.last))
+}
+
+// Check returns the check that fails if there was no beat within the window.
+func (h *Heartbeat) Check(window time.Duration) Check {
+	return func(ctx context.Context) error {
+		if since := time.Since(h.Last()); since > window {
+			return fmt.Errorf("no progress for %s", since.Round(time.Second))
+		}
+
+		return nil
+	}
+}
@@ -2,4 +2,9 @@ package logging
 
 const (
 	UserKey = "user"
+
+	RequestIDKey = "request_id"
+	MethodKey    = "method"
+	RouteKey     = "route"
+	RemoteIPKey  = "remote_ip"
 )
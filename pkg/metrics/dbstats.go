@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// dbStatsCollector collects the stats of the database connection pool.
+type dbStatsCollector struct {
+	stats func() sql.DBStats
+}
+
+var (
+	dbMaxOpen = newDBDesc("max_open_connections", "Maximum number of open connections to the database.")
+	dbOpen    = newDBDesc("open_connections", "Number of established connections both in use and idle.")
+	dbInUse   = newDBDesc("in_use_connections", "Number of connections currently in use.")
+	dbIdle    = newDBDesc("idle_connections", "Number of idle connections.")
+	dbWaits   = newDBDesc("wait_count_total", "Total number of connections waited for.")
+	dbWaitDur = newDBDesc("wait_duration_seconds_total", "Total time blocked waiting for a new connection.")
+	dbClosed  = newDBDesc("closed_total", "Total number of connections closed by reason.", "reason")
+)
+
+func newDBDesc(name, help string, labels ...string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db", name), help, labels, nil)
+}
+
+// Describe implements prometheus.Collector interface.
+func (c *dbStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{dbMaxOpen, dbOpen, dbInUse, dbIdle, dbWaits, dbWaitDur, dbClosed} {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector interface.
+func (c *dbStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.stats()
+	ch <- prometheus.MustNewConstMetric(dbMaxOpen, prometheus.GaugeValue, float64(s.MaxOpenConnections))
+	ch <- prometheus.MustNewConstMetric(dbOpen, prometheus.GaugeValue, float64(s.OpenConnections))
+	ch <- prometheus.MustNewConstMetric(dbInUse, prometheus.GaugeValue, float64(s.InUse))
+	ch <- prometheus.MustNewConstMetric(dbIdle, prometheus.GaugeValue, float64(s.Idle))
+	ch <- prometheus.MustNewConstMetric(dbWaits, prometheus.CounterValue, float64(s.WaitCount))
+	ch <- prometheus.MustNewConstMetric(dbWaitDur, prometheus.CounterValue, s.WaitDuration.Seconds())
+	ch <- prometheus.MustNewConstMetric(dbClosed, prometheus.CounterValue, float64(s.MaxIdleClosed), "max_idle")
+	ch <- prometheus.MustNewConstMetric(dbClosed, prometheus.CounterValue, float64(s.MaxIdleTimeClosed), "max_idle_time")
+	ch <- prometheus.MustNewConstMetric(dbClosed, prometheus.CounterValue, float64(s.MaxLifetimeClosed), "max_lifetime")
+}
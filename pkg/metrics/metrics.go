@@ -0,0 +1,120 @@
+// Package metrics contains Prometheus metrics of Gophermart. All the metrics are registered in Registry,
+// which is exposed by Handler.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "gophermart"
+
+// Registry is the registry of Gophermart metrics. It also contains Go runtime and process metrics.
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+// HTTP server metrics.
+var (
+	// HTTPRequests counts the requests by the method, chi route pattern and response status code.
+	HTTPRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of HTTP requests by method, route pattern and status code.",
+	}, []string{"method", "route", "code"})
+	// HTTPRequestDuration is the latency of the requests by the method and chi route pattern.
+	HTTPRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of HTTP requests by method and route pattern.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+// Accrual service metrics.
+var (
+	// AccrualRequestDuration is the latency of GopherAccrualService requests by the response status code.
+	// The requests failed without a response have code "error".
+	AccrualRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "accrual",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of accrual service requests by status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"code"})
+	// PollerBacklog is the number of the orders waiting for the accrual calculation by the order status.
+	PollerBacklog = factory.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "accrual",
+		Name:      "poller_backlog_orders",
+		Help:      "Number of orders waiting for the accrual calculation by status.",
+	}, []string{"status"})
+	// BalanceUpdaterBatchSize is the number of the accrual operations processed by one balance update.
+	BalanceUpdaterBatchSize = factory.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "balance_updater",
+		Name:      "batch_size",
+		Help:      "Number of accrual operations processed by one balance update.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 11),
+	})
+	// BalanceUpdaterErrors counts failed balance updates.
+	BalanceUpdaterErrors = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "balance_updater",
+		Name:      "errors_total",
+		Help:      "Number of failed balance updates.",
+	})
+)
+
+// WorkerRestarts counts the restarts of the background workers after panics by the worker name.
+var WorkerRestarts = factory.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "worker_restarts_total",
+	Help:      "Number of background worker restarts after panics by worker.",
+}, []string{"worker"})
+
+// Business metrics.
+var (
+	// PointsAccrued counts the loyalty points accrued for the orders.
+	PointsAccrued = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "points_accrued_total",
+		Help:      "Loyalty points accrued for the orders.",
+	})
+	// PointsWithdrawn counts the loyalty points withdrawn by the users.
+	PointsWithdrawn = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "points_withdrawn_total",
+		Help:      "Loyalty points withdrawn by the users.",
+	})
+	// Registrations counts the users registered.
+	Registrations = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "registrations_total",
+		Help:      "Number of registered users.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler returns the handler that exposes the metrics in Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// RegisterDBStats registers the metrics of the database connection pool. The stats func is called on each scrape.
+func RegisterDBStats(stats func() sql.DBStats) error {
+	return Registry.Register(&dbStatsCollector{stats: stats})
+}
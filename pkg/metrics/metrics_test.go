@@ -0,0 +1,89 @@
+package metrics_test
+
+import (
+	"bufio"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/vanamelnik/gophermart/pkg/metrics"
+	"github.com/vanamelnik/gophermart/pkg/middleware"
+
+	"github.com/go-chi/chi"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The metrics are registered in the package registry, so the test checks the increments of the values
+// and registers the database stats once to be repeatable.
+var registerDBStats sync.Once
+
+func TestHTTPMetrics(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(middleware.Metrics)
+	r.Handle("/metrics", metrics.Handler())
+	r.Get("/api/orders/{number}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	r.Get("/api/balance", func(w http.ResponseWriter, r *http.Request) {})
+
+	orders := metrics.HTTPRequests.WithLabelValues("GET", "/api/orders/{number}", "202")
+	balance := metrics.HTTPRequests.WithLabelValues("GET", "/api/balance", "200")
+	unmatched := metrics.HTTPRequests.WithLabelValues("GET", "unmatched", "404")
+	const ordersDuration = `gophermart_http_request_duration_seconds_count{method="GET",route="/api/orders/{number}"}`
+	ordersBefore, balanceBefore, unmatchedBefore := testutil.ToFloat64(orders), testutil.ToFloat64(balance),
+		testutil.ToFloat64(unmatched)
+	registrationsBefore := testutil.ToFloat64(metrics.Registrations)
+	before := scrape(t, r)
+
+	for _, target := range []string{"/api/orders/12345678903", "/api/orders/2377225624", "/api/balance", "/nowhere"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(orders)-ordersBefore, "the requests are counted by the route pattern")
+	assert.Equal(t, 1.0, testutil.ToFloat64(balance)-balanceBefore)
+	assert.Equal(t, 1.0, testutil.ToFloat64(unmatched)-unmatchedBefore)
+
+	registerDBStats.Do(func() {
+		require.NoError(t, metrics.RegisterDBStats(func() sql.DBStats {
+			return sql.DBStats{MaxOpenConnections: 10, OpenConnections: 3, InUse: 2, Idle: 1}
+		}))
+	})
+	metrics.Registrations.Inc()
+
+	after := scrape(t, r)
+	assert.Equal(t, 2.0, after[ordersDuration]-before[ordersDuration])
+	assert.Equal(t, 3.0, after["gophermart_db_open_connections"])
+	assert.Equal(t, 2.0, after["gophermart_db_in_use_connections"])
+	assert.Equal(t, registrationsBefore+1, after["gophermart_registrations_total"])
+	assert.Contains(t, after, "go_goroutines")
+}
+
+// scrape requests the metrics and returns the values by the series.
+func scrape(t *testing.T, r http.Handler) map[string]float64 {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	values := make(map[string]float64)
+	s := bufio.NewScanner(w.Body)
+	for s.Scan() {
+		line := s.Text()
+		i := strings.LastIndex(line, " ")
+		if strings.HasPrefix(line, "#") || i < 0 {
+			continue
+		}
+		v, err := strconv.ParseFloat(line[i+1:], 64)
+		require.NoError(t, err, line)
+		values[line[:i]] = v
+	}
+	require.NoError(t, s.Err())
+
+	return values
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/pkg/logging"
+
+	chiMiddleware "github.com/go-chi/chi/middleware"
+)
+
+type ctxKey string
+
+const accessLogKey ctxKey = "access log"
+
+// accessLogEntry collects the data of the request known only deeper in the middlewares chain.
+type accessLogEntry struct {
+	login string
+}
+
+// AccessLog logs each request served with the response status, the number of bytes written, the latency
+// and the login of authenticated user. The middleware must follow WithLogger and RequestID.
+func AccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		entry := &accessLogEntry{}
+		ww := chiMiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(context.WithValue(r.Context(), accessLogKey, entry)))
+
+		log := appContext.Logger(r.Context())
+		e := log.Info().
+			Str("path", r.URL.Path).
+			Int("status", responseStatus(ww)).
+			Int("bytes", ww.BytesWritten()).
+			Dur("latency", time.Since(start))
+		if entry.login != "" {
+			e = e.Str(logging.UserKey, entry.login)
+		}
+		e.Msg("access")
+	})
+}
+
+// setAccessLogUser records the login of authenticated user in the access log entry of the request.
+func setAccessLogUser(ctx context.Context, login string) {
+	if entry, ok := ctx.Value(accessLogKey).(*accessLogEntry); ok {
+		entry.login = login
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessLogAndRecoverer(t *testing.T) {
+	var buf bytes.Buffer
+	handler := WithLogger(zerolog.New(&buf))(AccessLog(Recoverer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/panic" {
+				panic("one ring to rule them all")
+			}
+			setAccessLogUser(r.Context(), "frodo")
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = w.Write([]byte("hello"))
+		}))))
+
+	serve := func(path string) (int, []map[string]interface{}) {
+		buf.Reset()
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var entries []map[string]interface{}
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			entry := make(map[string]interface{})
+			require.NoError(t, json.Unmarshal([]byte(line), &entry))
+			entries = append(entries, entry)
+		}
+
+		return w.Code, entries
+	}
+
+	t.Run("#1 Access log", func(t *testing.T) {
+		code, entries := serve("/ok")
+		assert.Equal(t, http.StatusAccepted, code)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "access", entries[0]["message"])
+		assert.Equal(t, "/ok", entries[0]["path"])
+		assert.Equal(t, float64(http.StatusAccepted), entries[0]["status"])
+		assert.Equal(t, float64(len("hello")), entries[0]["bytes"])
+		assert.Equal(t, "frodo", entries[0]["user"])
+		assert.Contains(t, entries[0], "latency")
+	})
+	t.Run("#2 Panic recovered", func(t *testing.T) {
+		code, entries := serve("/panic")
+		assert.Equal(t, http.StatusInternalServerError, code)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "error", entries[0]["level"])
+		assert.Equal(t, "one ring to rule them all", entries[0]["panic"])
+		assert.Contains(t, entries[0]["stack"], "recoverer.go")
+		assert.Equal(t, float64(http.StatusInternalServerError), entries[1]["status"])
+		assert.NotContains(t, entries[1], "user")
+	})
+	t.Run("#3 Aborted handler", func(t *testing.T) {
+		abort := Recoverer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		}))
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			abort.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		})
+	})
+}
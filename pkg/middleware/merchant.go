@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/vanamelnik/gophermart/model"
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+)
+
+// APIKeyHeader is the header with the merchant's API key.
+const APIKeyHeader = "X-API-Key"
+
+// MerchantAuthenticator finds the merchant's API key.
+type MerchantAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*model.APIKey, error)
+}
+
+// MerchantCtx returns a middleware function that authenticates the merchant by the API key from
+// X-API-Key header. If OK, the API key is attached to the request context.
+func MerchantCtx(auth MerchantAuthenticator) MwFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			log := appContext.Logger(r.Context())
+
+			key := r.Header.Get(APIKeyHeader)
+			if key == "" {
+				log.Error().Msg("MerchantCtx: no API key found")
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+
+				return
+			}
+			apiKey, err := auth.AuthenticateAPIKey(r.Context(), key)
+			if err != nil {
+				log.Error().Err(err).Msg("MerchantCtx: could not authenticate the merchant")
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(appContext.WithAPIKey(r.Context(), apiKey)))
+		})
+	}
+}
+
+// RequireScope returns a middleware function that checks whether the merchant's API key is granted
+// the scope provided. This middleware must be used after MerchantCtx.
+func RequireScope(scope model.Scope) MwFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			log := appContext.Logger(r.Context())
+			apiKey := appContext.APIKey(r.Context())
+			if apiKey == nil {
+				log.Error().Msg("RequireScope: no API key found in the context")
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+
+				return
+			}
+			if !apiKey.Can(scope) {
+				log.Error().Str("prefix", apiKey.Prefix).Msgf("RequireScope: scope %s required", scope)
+				http.Error(w, "Forbidden", http.StatusForbidden)
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/vanamelnik/gophermart/pkg/metrics"
+
+	"github.com/go-chi/chi"
+	chiMiddleware "github.com/go-chi/chi/middleware"
+)
+
+// unmatchedRoute is the route label of the requests that don't match any route.
+const unmatchedRoute = "unmatched"
+
+// Metrics records the number and the latency of the requests by chi route pattern. The route pattern is
+// known only after routing, so the middleware must be used with chi.Mux.
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := chiMiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		route, status := routePattern(r), responseStatus(ww)
+		metrics.HTTPRequests.WithLabelValues(r.Method, route, strconv.Itoa(status)).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// routePattern returns chi route pattern of the request served.
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+		return rctx.RoutePattern()
+	}
+
+	return unmatchedRoute
+}
+
+// responseStatus returns the status code written. If the handler hasn't written the header, it's 200.
+func responseStatus(ww chiMiddleware.WrapResponseWriter) int {
+	if ww.Status() == 0 {
+		return http.StatusOK
+	}
+
+	return ww.Status()
+}
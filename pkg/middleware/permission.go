@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/vanamelnik/gophermart/model"
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+)
+
+// RequirePermission returns a middleware function that checks whether the authenticated user
+// is granted all the permissions provided. This middleware must be used after UserCtx.
+func RequirePermission(perms ...model.Permission) MwFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			log := appContext.Logger(r.Context())
+			user := appContext.User(r.Context())
+			if user == nil {
+				log.Error().Msg("RequirePermission: no authenticated user found in the context")
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+
+				return
+			}
+			for _, perm := range perms {
+				if !user.Can(perm) {
+					log.Error().
+						Str("user", user.Login).
+						Str("role", string(user.Role)).
+						Msgf("RequirePermission: permission %s required", perm)
+					http.Error(w, "Forbidden", http.StatusForbidden)
+
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
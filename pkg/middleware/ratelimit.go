@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/pkg/ratelimit"
+)
+
+// RateLimiter limits the number of requests of each client. The client is identified by the authenticated
+// user's ID, the merchant's ID or the IP address.
+type RateLimiter struct {
+	store ratelimit.Store
+	def   ratelimit.Limit
+	// routes are the limits of the routes identified by "METHOD /path/pattern" in lower case.
+	routes map[string]ratelimit.Limit
+}
+
+// NewRateLimiter creates a new rate limiter with the default limit and the limits of the routes.
+func NewRateLimiter(store ratelimit.Store, def ratelimit.Limit, routes map[string]ratelimit.Limit) *RateLimiter {
+	rl := &RateLimiter{
+		store:  store,
+		def:    def,
+		routes: make(map[string]ratelimit.Limit, len(routes)),
+	}
+	for route, limit := range routes {
+		rl.routes[strings.ToLower(route)] = limit
+	}
+
+	return rl
+}
+
+// Default returns a middleware function that applies the default limit to all the requests of the client.
+// If the rate limiter is nil, the requests aren't limited.
+func (rl *RateLimiter) Default() MwFunc {
+	if rl == nil {
+		return passThrough
+	}
+
+	return rl.limit("*", rl.def, clientKey)
+}
+
+// IP returns a middleware function that applies the default limit to all the requests from the client's IP address.
+// It's used before the authentication, so that the requests with invalid credentials are limited too.
+// If the rate limiter is nil, the requests aren't limited.
+func (rl *RateLimiter) IP() MwFunc {
+	if rl == nil {
+		return passThrough
+	}
+
+	return rl.limit("ip", rl.def, ipKey)
+}
+
+// Route returns a middleware function that applies the limit configured for the route ("METHOD /path/pattern").
+// If the rate limiter is nil or the route has no limit, the requests aren't limited.
+func (rl *RateLimiter) Route(route string) MwFunc {
+	if rl == nil {
+		return passThrough
+	}
+	route = strings.ToLower(route)
+
+	return rl.limit(route, rl.routes[route], clientKey)
+}
+
+// limit returns a middleware function that takes a token from the bucket of the client identified by key function.
+func (rl *RateLimiter) limit(name string, limit ratelimit.Limit, key func(r *http.Request) string) MwFunc {
+	if limit.Unlimited() {
+		return passThrough
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			log := appContext.Logger(r.Context())
+
+			res, err := rl.store.Take(r.Context(), name+"|"+key(r), limit, time.Now())
+			if err != nil {
+				// Don't refuse the requests if the store is unavailable.
+				log.Error().Err(err).Msg("RateLimit: could not take a token")
+				next.ServeHTTP(w, r)
+
+				return
+			}
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			w.Header().Set("RateLimit-Reset", seconds(res.Reset))
+			if !res.Allowed {
+				log.Error().Str("route", name).Msg("RateLimit: too many requests")
+				w.Header().Set("Retry-After", seconds(res.RetryAfter))
+				http.Error(w, "Too many requests", http.StatusTooManyRequests)
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientKey returns the authenticated user's ID, the merchant's ID or the client's IP address.
+func clientKey(r *http.Request) string {
+	if user := appContext.User(r.Context()); user != nil {
+		return "user:" + user.ID.String()
+	}
+	if apiKey := appContext.APIKey(r.Context()); apiKey != nil {
+		return "merchant:" + apiKey.MerchantID.String()
+	}
+
+	return ipKey(r)
+}
+
+// ipKey returns the client's IP address.
+func ipKey(r *http.Request) string {
+	return "ip:" + remoteIP(r)
+}
+
+// seconds formats the duration as a whole number of seconds rounded up.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+func passThrough(next http.Handler) http.Handler {
+	return next
+}
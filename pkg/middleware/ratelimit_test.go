@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vanamelnik/gophermart/pkg/ratelimit"
+
+	"github.com/go-chi/chi"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limit := ratelimit.Limit{Requests: 2, Per: time.Minute}
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	newRouter := func() *chi.Mux {
+		r := chi.NewRouter()
+		r.Use(WithLogger(zerolog.Nop()))
+
+		return r
+	}
+	serve := func(h http.Handler, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/user/orders", nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		return w
+	}
+
+	t.Run("#1 Too many requests", func(t *testing.T) {
+		r := newRouter()
+		r.Use(NewRateLimiter(ratelimit.NewMemory(), limit, nil).Default())
+		r.Post("/api/user/orders", ok)
+
+		for _, remaining := range []string{"1", "0"} {
+			w := serve(r, "192.0.2.1:54321")
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+			assert.Equal(t, remaining, w.Header().Get("RateLimit-Remaining"))
+			assert.NotEmpty(t, w.Header().Get("RateLimit-Reset"))
+			assert.Empty(t, w.Header().Get("Retry-After"))
+		}
+		w := serve(r, "192.0.2.1:54321")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "30", w.Header().Get("Retry-After"))
+
+		// Other clients have their own buckets.
+		assert.Equal(t, http.StatusOK, serve(r, "192.0.2.2:54321").Code)
+	})
+	t.Run("#2 Route limit", func(t *testing.T) {
+		rl := NewRateLimiter(ratelimit.NewMemory(), ratelimit.Limit{}, map[string]ratelimit.Limit{
+			"POST /api/user/orders": {Requests: 1, Per: time.Minute},
+		})
+		r := newRouter()
+		r.Use(rl.Default())
+		r.With(rl.Route("POST /API/user/orders")).Post("/api/user/orders", ok)
+		r.With(rl.Route("GET /api/user/orders")).Get("/api/user/orders", ok)
+
+		assert.Equal(t, http.StatusOK, serve(r, "192.0.2.1:54321").Code)
+		assert.Equal(t, http.StatusTooManyRequests, serve(r, "192.0.2.1:54321").Code)
+		for i := 0; i < 3; i++ {
+			req := httptest.NewRequest(http.MethodGet, "/api/user/orders", nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusOK, w.Code, "the route has no limit")
+			assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+		}
+	})
+	t.Run("#3 Invalid credentials are limited by IP", func(t *testing.T) {
+		r := newRouter()
+		r.Use(NewRateLimiter(ratelimit.NewMemory(), limit, nil).IP())
+		r.Use(func(http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			})
+		})
+		r.Post("/api/user/orders", ok)
+
+		assert.Equal(t, http.StatusUnauthorized, serve(r, "192.0.2.1:54321").Code)
+		assert.Equal(t, http.StatusUnauthorized, serve(r, "192.0.2.1:54321").Code)
+		w := serve(r, "192.0.2.1:54321")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	})
+	t.Run("#4 Rate limiting is disabled", func(t *testing.T) {
+		var rl *RateLimiter
+		r := newRouter()
+		r.Use(rl.IP())
+		r.Use(rl.Default())
+		r.With(rl.Route("POST /api/user/orders")).Post("/api/user/orders", ok)
+
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusOK, serve(r, "192.0.2.1:54321").Code)
+		}
+	})
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+	"runtime/debug"
+
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+)
+
+// Recoverer recovers from panics in the handlers, logs them with the stack trace and responds with 500 status.
+// The middleware must follow WithLogger.
+func Recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// http.ErrAbortHandler is used to abort the response deliberately.
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+			log := appContext.Logger(r.Context())
+			log.Error().Interface("panic", rec).Str("stack", string(debug.Stack())).Msg("recovered from panic")
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
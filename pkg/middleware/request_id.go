@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/pkg/logging"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// RequestIDHeader is the header with the ID of the request. The ID is echoed in the response.
+	RequestIDHeader = "X-Request-ID"
+
+	maxRequestIDLength = 128
+)
+
+// RequestID takes the request ID from X-Request-ID header or generates a new one, adds it to the request context
+// and echoes it in the response. The context logger is enriched with the request ID, the method, the route pattern
+// and the client's IP address. The middleware must follow WithLogger.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		ctx := appContext.WithRequestID(r.Context(), id)
+		log := appContext.Logger(ctx).With().
+			Str(logging.RequestIDKey, id).
+			Str(logging.MethodKey, r.Method).
+			Str(logging.RemoteIPKey, remoteIP(r)).
+			Logger()
+		if rctx := chi.RouteContext(ctx); rctx != nil {
+			log = log.Hook(routeHook{rctx: rctx})
+		}
+		next.ServeHTTP(w, r.WithContext(appContext.WithLogger(ctx, log)))
+	})
+}
+
+// routeHook adds chi route pattern to the log events. The pattern is known only after routing,
+// so it's read when the event is logged.
+type routeHook struct {
+	rctx *chi.Context
+}
+
+// Run implements zerolog.Hook interface.
+func (h routeHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	if pattern := h.rctx.RoutePattern(); pattern != "" {
+		e.Str(logging.RouteKey, pattern)
+	}
+}
+
+// validRequestID checks that the ID provided by the client is not empty, not too long and contains only
+// printable ASCII characters without spaces, so it's safe to log and echo.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// remoteIP returns the IP address of the client.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vanamelnik/gophermart/model"
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/provider/accrual"
+
+	"github.com/go-chi/chi"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	// The accrual service records the request ID received.
+	var accrualRequestID string
+	accrualSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accrualRequestID = r.Header.Get(RequestIDHeader)
+		_ = json.NewEncoder(w).Encode(accrual.AccrualResponse{Order: "12345678903", Status: model.StatusProcessing})
+	}))
+	defer accrualSrv.Close()
+	client := accrual.New(accrualSrv.URL)
+
+	var buf bytes.Buffer
+	r := chi.NewRouter()
+	r.Use(WithLogger(zerolog.New(&buf)))
+	r.Use(RequestID)
+	r.Get("/api/orders/{number}", func(w http.ResponseWriter, r *http.Request) {
+		log := appContext.Logger(r.Context())
+		log.Info().Msg("polling the order")
+		_, err := client.Request(r.Context(), model.OrderID(chi.URLParam(r, "number")))
+		assert.NoError(t, err)
+	})
+
+	serve := func(requestID string) (string, map[string]interface{}) {
+		buf.Reset()
+		req := httptest.NewRequest(http.MethodGet, "/api/orders/12345678903", nil)
+		req.RemoteAddr = "192.0.2.1:54321"
+		if requestID != "" {
+			req.Header.Set(RequestIDHeader, requestID)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		entry := make(map[string]interface{})
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+
+		return w.Header().Get(RequestIDHeader), entry
+	}
+
+	t.Run("#1 Request ID provided", func(t *testing.T) {
+		id, entry := serve("frodo-42")
+		assert.Equal(t, "frodo-42", id)
+		assert.Equal(t, "frodo-42", accrualRequestID)
+		assert.Equal(t, map[string]interface{}{
+			"level":      "info",
+			"message":    "polling the order",
+			"request_id": "frodo-42",
+			"method":     "GET",
+			"route":      "/api/orders/{number}",
+			"remote_ip":  "192.0.2.1",
+		}, entry)
+	})
+	t.Run("#2 Request ID generated", func(t *testing.T) {
+		for _, provided := range []string{"", "bad id", strings.Repeat("x", maxRequestIDLength+1)} {
+			id, entry := serve(provided)
+			assert.Len(t, id, 36, "uuid is generated instead of %q", provided)
+			assert.Equal(t, id, entry["request_id"])
+			assert.Equal(t, id, accrualRequestID)
+		}
+	})
+	t.Run("#3 No request ID outside of a request", func(t *testing.T) {
+		assert.Empty(t, appContext.RequestID(context.Background()))
+	})
+}
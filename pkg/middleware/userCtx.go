@@ -1,36 +1,74 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
+	"strings"
 
+	"github.com/vanamelnik/gophermart/model"
 	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
-	"github.com/vanamelnik/gophermart/storage"
 )
 
-// UserCtx returns a middleware function that checks if there's a user's remember token
-// in client's cookies. If OK and the user found in the storage, its object is attached
-// to the requst context.
-func UserCtx(db storage.Storage) func(http.Handler) http.Handler {
+// SessionCookieName is the name of the cookie with the session token.
+const SessionCookieName = "gophermart_remember"
+
+// Authenticator finds the user and the session by the credentials provided.
+type Authenticator interface {
+	// SessionUser finds the user by the session token.
+	SessionUser(ctx context.Context, sessionToken string) (*model.User, *model.Session, error)
+	// AccessTokenUser finds the user by the access token.
+	AccessTokenUser(ctx context.Context, accessToken string) (*model.User, *model.Session, error)
+}
+
+// UserCtx returns a middleware function that authenticates the user either by the access token
+// from "Authorization: Bearer" header or by the session token from client's cookies.
+// If OK, the user and the session objects are attached to the requst context.
+func UserCtx(auth Authenticator) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			log := appContext.Logger(r.Context())
-			cookie, err := r.Cookie("gophermart_remember")
+
+			var (
+				user    *model.User
+				session *model.Session
+				err     error
+			)
+			if header := r.Header.Get("Authorization"); header != "" {
+				accessToken := strings.TrimPrefix(header, "Bearer ")
+				if accessToken == header {
+					log.Error().Msg("RequireUser: unsupported authorization scheme")
+					http.Error(w, "Unauthorized", http.StatusUnauthorized)
+
+					return
+				}
+				user, session, err = auth.AccessTokenUser(r.Context(), accessToken)
+			} else {
+				cookie, cookieErr := r.Cookie(SessionCookieName)
+				if cookieErr != nil {
+					log.Error().Err(cookieErr).Msg("RequireUser: neither access token nor cookie with session token found")
+					http.Error(w, "Unauthorized", http.StatusUnauthorized)
+
+					return
+				}
+				user, session, err = auth.SessionUser(r.Context(), cookie.Value)
+			}
 			if err != nil {
-				log.Error().Err(err).Msg("RequireUser: cookie with remember token not found")
+				log.Error().Err(err).Msg("RequireUser: could not authenticate the user")
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 
 				return
 			}
-			remember := cookie.Value
-			user, err := db.UserByRemember(r.Context(), remember)
-			if err != nil {
-				log.Error().Err(err).Msgf("RequireUser: user with remember token %s not found", remember)
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+
+			if user.Blocked {
+				log.Error().Str("user", user.Login).Msg("RequireUser: user is blocked")
+				http.Error(w, "Forbidden", http.StatusForbidden)
 
 				return
 			}
 
+			setAccessLogUser(r.Context(), user.Login)
 			ctx := appContext.WithUser(r.Context(), user)
+			ctx = appContext.WithSession(ctx, session)
 			log.Info().Str("user", user.Login).Msg("RequireUser: successfully authorized")
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
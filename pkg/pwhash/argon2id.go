@@ -0,0 +1,137 @@
+package pwhash
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const argon2idPrefix = "$argon2id$"
+
+// defaultArgon2id are the parameters recommended by RFC 9106 for memory-constrained environments.
+var defaultArgon2id = Argon2idParams{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+type (
+	// Argon2idParams are the parameters of Argon2id hashes.
+	Argon2idParams struct {
+		// Memory is the memory used in KiB.
+		Memory      uint32 `mapstructure:"memory"`
+		Iterations  uint32 `mapstructure:"iterations"`
+		Parallelism uint8  `mapstructure:"parallelism"`
+		SaltLength  uint32 `mapstructure:"salt_length"`
+		KeyLength   uint32 `mapstructure:"key_length"`
+	}
+
+	// Argon2id hashes the passwords with Argon2id. The hashes are encoded in PHC string format:
+	// "$argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>".
+	Argon2id struct {
+		params Argon2idParams
+	}
+)
+
+var _ Algorithm = (*Argon2id)(nil)
+
+// NewArgon2id creates Argon2id algorithm with the parameters provided. Zero values are replaced by the defaults.
+func NewArgon2id(params Argon2idParams) (*Argon2id, error) {
+	if params.Memory == 0 {
+		params.Memory = defaultArgon2id.Memory
+	}
+	if params.Iterations == 0 {
+		params.Iterations = defaultArgon2id.Iterations
+	}
+	if params.Parallelism == 0 {
+		params.Parallelism = defaultArgon2id.Parallelism
+	}
+	if params.SaltLength == 0 {
+		params.SaltLength = defaultArgon2id.SaltLength
+	}
+	if params.KeyLength == 0 {
+		params.KeyLength = defaultArgon2id.KeyLength
+	}
+	if params.Memory < 8*uint32(params.Parallelism) {
+		return nil, errors.New("pwhash: argon2id memory must be at least 8*parallelism KiB")
+	}
+
+	return &Argon2id{params: params}, nil
+}
+
+// Hash implements Algorithm interface.
+func (a *Argon2id) Hash(password []byte) (string, error) {
+	salt := make([]byte, a.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("argon2id: %w", err)
+	}
+	key := argon2.IDKey(password, salt, a.params.Iterations, a.params.Memory, a.params.Parallelism, a.params.KeyLength)
+
+	return encodeArgon2id(a.params, salt, key), nil
+}
+
+// Identify implements Algorithm interface.
+func (a *Argon2id) Identify(encoded string) bool {
+	return strings.HasPrefix(encoded, argon2idPrefix)
+}
+
+// Verify implements Algorithm interface.
+func (a *Argon2id) Verify(password []byte, encoded string) error {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return err
+	}
+	other := argon2.IDKey(password, salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return ErrMismatchedHashAndPassword
+	}
+
+	return nil
+}
+
+// Current implements Algorithm interface.
+func (a *Argon2id) Current(encoded string) bool {
+	params, _, _, err := decodeArgon2id(encoded)
+
+	return err == nil && params == a.params
+}
+
+func encodeArgon2id(p Argon2idParams, salt, key []byte) string {
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version,
+		p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+func decodeArgon2id(encoded string) (p Argon2idParams, salt, key []byte, err error) {
+	// "", "argon2id", "v=19", "m=65536,t=3,p=2", salt, key
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return p, nil, nil, ErrUnknownAlgorithm
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return p, nil, nil, fmt.Errorf("argon2id: invalid version: %w", err)
+	}
+	if version != argon2.Version {
+		return p, nil, nil, fmt.Errorf("argon2id: unsupported version %d", version)
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return p, nil, nil, fmt.Errorf("argon2id: invalid parameters: %w", err)
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return p, nil, nil, fmt.Errorf("argon2id: invalid salt: %w", err)
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return p, nil, nil, fmt.Errorf("argon2id: invalid key: %w", err)
+	}
+	p.SaltLength, p.KeyLength = uint32(len(salt)), uint32(len(key))
+
+	return p, salt, key, nil
+}
@@ -0,0 +1,63 @@
+package pwhash
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Bcrypt hashes the passwords with bcrypt.
+type Bcrypt struct {
+	cost int
+}
+
+var _ Algorithm = (*Bcrypt)(nil)
+
+// NewBcrypt creates bcrypt algorithm with the cost provided. Zero cost is replaced by bcrypt.DefaultCost.
+func NewBcrypt(cost int) (*Bcrypt, error) {
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
+	}
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("pwhash: bcrypt cost %d is outside allowed range (%d,%d)", cost, bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	return &Bcrypt{cost: cost}, nil
+}
+
+// Hash implements Algorithm interface.
+func (b *Bcrypt) Hash(password []byte) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword(password, b.cost)
+	if err != nil {
+		return "", fmt.Errorf("bcrypt: %w", err)
+	}
+
+	return string(hash), nil
+}
+
+// Identify implements Algorithm interface.
+func (b *Bcrypt) Identify(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+// Verify implements Algorithm interface.
+func (b *Bcrypt) Verify(password []byte, encoded string) error {
+	if err := bcrypt.CompareHashAndPassword([]byte(encoded), password); err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrMismatchedHashAndPassword
+		}
+
+		return fmt.Errorf("bcrypt: %w", err)
+	}
+
+	return nil
+}
+
+// Current implements Algorithm interface.
+func (b *Bcrypt) Current(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+
+	return err == nil && cost == b.cost
+}
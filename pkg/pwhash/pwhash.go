@@ -0,0 +1,164 @@
+// Package pwhash hashes and verifies users' passwords with a pluggable algorithm (bcrypt or Argon2id) and
+// rotatable peppers.
+//
+// The encoded hash contains the algorithm and its parameters. If the password was hashed with a pepper
+// other than the legacy one (id ""), the hash is prefixed with the pepper id: "$p=<id>$2a$10$...".
+// Verify reports whether the hash must be upgraded: it was made by another algorithm, with other parameters
+// or with an outdated pepper.
+package pwhash
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+
+	pepperPrefix = "$p="
+)
+
+var (
+	// ErrMismatchedHashAndPassword is returned by Verify when the password doesn't match the hash.
+	ErrMismatchedHashAndPassword = errors.New("pwhash: hashedPassword is not the hash of the given password")
+	// ErrUnknownAlgorithm is returned when the hash format isn't recognized.
+	ErrUnknownAlgorithm = errors.New("pwhash: unknown hash algorithm")
+	// ErrUnknownPepper is returned when the hash was made with a pepper that isn't configured anymore.
+	ErrUnknownPepper = errors.New("pwhash: unknown pepper")
+)
+
+type (
+	// Algorithm hashes the passwords.
+	Algorithm interface {
+		// Hash returns the encoded hash of the password with the algorithm's parameters.
+		Hash(password []byte) (string, error)
+		// Identify reports whether the encoded hash was made by the algorithm with any parameters.
+		Identify(encoded string) bool
+		// Verify compares the password with the encoded hash made by the algorithm with any parameters.
+		// If they mismatch, ErrMismatchedHashAndPassword is returned.
+		Verify(password []byte, encoded string) error
+		// Current reports whether the encoded hash was made with the algorithm's current parameters.
+		Current(encoded string) bool
+	}
+
+	// Config configures the hasher.
+	Config struct {
+		// Algorithm of new hashes: "bcrypt" (default) or "argon2id".
+		Algorithm string `mapstructure:"algorithm"`
+		// BcryptCost is the cost of new bcrypt hashes.
+		BcryptCost int `mapstructure:"bcrypt_cost"`
+		// Argon2id are the parameters of new Argon2id hashes. Zero values are replaced by the defaults.
+		Argon2id Argon2idParams `mapstructure:"argon2id"`
+		// Peppers is a map of pepper ids to peppers. The hashes without a pepper id are verified
+		// with the pepper with empty id.
+		Peppers map[string]string `mapstructure:"peppers"`
+		// CurrentPepper is the id of the pepper of new hashes. To rotate the pepper add a new one and make it
+		// current: the hashes are upgraded on the users' sign-in. The previous pepper can't be removed
+		// while there're hashes made with it.
+		CurrentPepper string `mapstructure:"current_pepper"`
+	}
+
+	// Hasher hashes the passwords with the current algorithm and pepper and verifies the hashes made
+	// by any supported algorithm with any configured pepper.
+	Hasher struct {
+		current       Algorithm
+		algorithms    []Algorithm
+		peppers       map[string]string
+		currentPepper string
+	}
+)
+
+// New creates a new hasher with the algorithm and the peppers configured.
+func New(cfg Config) (*Hasher, error) {
+	bcryptAlg, err := NewBcrypt(cfg.BcryptCost)
+	if err != nil {
+		return nil, err
+	}
+	argon2idAlg, err := NewArgon2id(cfg.Argon2id)
+	if err != nil {
+		return nil, err
+	}
+	var current Algorithm
+	switch cfg.Algorithm {
+	case "", AlgorithmBcrypt:
+		current = bcryptAlg
+	case AlgorithmArgon2id:
+		current = argon2idAlg
+	default:
+		return nil, fmt.Errorf("pwhash: unknown algorithm %q", cfg.Algorithm)
+	}
+
+	return NewHasher(current, cfg.Peppers, cfg.CurrentPepper, bcryptAlg, argon2idAlg)
+}
+
+// NewHasher creates a new hasher with the current algorithm of new hashes and other algorithms supported
+// for verification.
+func NewHasher(current Algorithm, peppers map[string]string, currentPepper string, others ...Algorithm) (*Hasher, error) {
+	if _, ok := peppers[currentPepper]; !ok && currentPepper != "" {
+		return nil, fmt.Errorf("pwhash: current pepper %q not found", currentPepper)
+	}
+	for id := range peppers {
+		if strings.Contains(id, "$") {
+			return nil, fmt.Errorf("pwhash: invalid pepper id %q", id)
+		}
+	}
+	h := &Hasher{
+		current:       current,
+		algorithms:    append([]Algorithm{current}, others...),
+		peppers:       peppers,
+		currentPepper: currentPepper,
+	}
+
+	return h, nil
+}
+
+// Hash hashes the password with the current algorithm and pepper.
+func (h *Hasher) Hash(password string) (string, error) {
+	hash, err := h.current.Hash([]byte(password + h.peppers[h.currentPepper]))
+	if err != nil {
+		return "", fmt.Errorf("pwhash: %w", err)
+	}
+	if h.currentPepper != "" {
+		hash = pepperPrefix + h.currentPepper + hash
+	}
+
+	return hash, nil
+}
+
+// Verify compares the password with the encoded hash. If they match, it's reported whether the hash must
+// be replaced by a new one made with the current algorithm, parameters and pepper.
+func (h *Hasher) Verify(password, encoded string) (needsRehash bool, err error) {
+	pepperID, hash := splitPepper(encoded)
+	pepper, ok := h.peppers[pepperID]
+	if !ok && pepperID != "" {
+		return false, fmt.Errorf("%w %q", ErrUnknownPepper, pepperID)
+	}
+	for _, alg := range h.algorithms {
+		if !alg.Identify(hash) {
+			continue
+		}
+		if err := alg.Verify([]byte(password+pepper), hash); err != nil {
+			return false, err
+		}
+
+		return pepperID != h.currentPepper || alg != h.current || !alg.Current(hash), nil
+	}
+
+	return false, ErrUnknownAlgorithm
+}
+
+// splitPepper splits the encoded hash into the pepper id and the hash of the algorithm.
+func splitPepper(encoded string) (pepperID, hash string) {
+	if !strings.HasPrefix(encoded, pepperPrefix) {
+		return "", encoded
+	}
+	rest := encoded[len(pepperPrefix):]
+	i := strings.IndexByte(rest, '$')
+	if i < 0 {
+		return "", encoded
+	}
+
+	return rest[:i], rest[i:]
+}
@@ -0,0 +1,129 @@
+package pwhash
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2id are cheap parameters for the tests.
+var testArgon2id = Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 1}
+
+func TestArgon2id(t *testing.T) {
+	a, err := NewArgon2id(testArgon2id)
+	require.NoError(t, err)
+
+	hash, err := a.Hash([]byte("Mellon"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$"), hash)
+	assert.True(t, a.Identify(hash))
+	assert.True(t, a.Current(hash))
+	assert.NoError(t, a.Verify([]byte("Mellon"), hash))
+	assert.ErrorIs(t, a.Verify([]byte("mellon"), hash), ErrMismatchedHashAndPassword)
+
+	another, err := a.Hash([]byte("Mellon"))
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, another, "the salt must be random")
+
+	stronger, err := NewArgon2id(Argon2idParams{Memory: 128, Iterations: 1, Parallelism: 1})
+	require.NoError(t, err)
+	assert.False(t, stronger.Current(hash))
+	assert.NoError(t, stronger.Verify([]byte("Mellon"), hash), "the parameters are taken from the hash")
+
+	_, err = NewArgon2id(Argon2idParams{Memory: 8, Parallelism: 4})
+	assert.Error(t, err)
+}
+
+func TestHasher(t *testing.T) {
+	peppers := map[string]string{"": "legacy", "2022-01": "first", "2022-06": "second"}
+	hasher := func(t *testing.T, alg, currentPepper string) *Hasher {
+		h, err := New(Config{
+			Algorithm:     alg,
+			BcryptCost:    4,
+			Argon2id:      testArgon2id,
+			Peppers:       peppers,
+			CurrentPepper: currentPepper,
+		})
+		require.NoError(t, err)
+
+		return h
+	}
+
+	// The hash made by the former implementation: bcrypt with the default cost and the legacy pepper.
+	legacy, err := bcryptHash("Ho0o0o0orse!", "legacy")
+	require.NoError(t, err)
+
+	t.Run("#1 Legacy hash is verified and upgraded", func(t *testing.T) {
+		h := hasher(t, "", "")
+		rehash, err := h.Verify("Ho0o0o0orse!", legacy)
+		require.NoError(t, err)
+		assert.True(t, rehash, "bcrypt cost differs")
+
+		_, err = h.Verify("Horse!", legacy)
+		assert.ErrorIs(t, err, ErrMismatchedHashAndPassword)
+
+		hash, err := h.Hash("Ho0o0o0orse!")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(hash, "$2a$04$"), hash)
+		rehash, err = h.Verify("Ho0o0o0orse!", hash)
+		require.NoError(t, err)
+		assert.False(t, rehash)
+	})
+	t.Run("#2 Switch to argon2id", func(t *testing.T) {
+		h := hasher(t, AlgorithmArgon2id, "")
+		rehash, err := h.Verify("Ho0o0o0orse!", legacy)
+		require.NoError(t, err)
+		assert.True(t, rehash)
+
+		hash, err := h.Hash("Ho0o0o0orse!")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(hash, "$argon2id$"), hash)
+		rehash, err = h.Verify("Ho0o0o0orse!", hash)
+		require.NoError(t, err)
+		assert.False(t, rehash)
+	})
+	t.Run("#3 Pepper rotation", func(t *testing.T) {
+		first := hasher(t, AlgorithmArgon2id, "2022-01")
+		hash, err := first.Hash("Ho0o0o0orse!")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(hash, "$p=2022-01$argon2id$"), hash)
+
+		second := hasher(t, AlgorithmArgon2id, "2022-06")
+		rehash, err := second.Verify("Ho0o0o0orse!", hash)
+		require.NoError(t, err)
+		assert.True(t, rehash, "the pepper is outdated")
+		_, err = second.Verify("Ho0o0o0orse", hash)
+		assert.ErrorIs(t, err, ErrMismatchedHashAndPassword)
+
+		withoutFirst, err := New(Config{
+			Algorithm:     AlgorithmArgon2id,
+			Argon2id:      testArgon2id,
+			Peppers:       map[string]string{"2022-06": "second"},
+			CurrentPepper: "2022-06",
+		})
+		require.NoError(t, err)
+		_, err = withoutFirst.Verify("Ho0o0o0orse!", hash)
+		assert.ErrorIs(t, err, ErrUnknownPepper)
+	})
+	t.Run("#4 Invalid config and hashes", func(t *testing.T) {
+		_, err := New(Config{Algorithm: "md5"})
+		assert.Error(t, err)
+		_, err = New(Config{CurrentPepper: "unknown"})
+		assert.Error(t, err)
+		_, err = New(Config{BcryptCost: 100})
+		assert.Error(t, err)
+
+		_, err = hasher(t, "", "").Verify("Ho0o0o0orse!", "5f4dcc3b5aa765d61d8327deb882cf99")
+		assert.ErrorIs(t, err, ErrUnknownAlgorithm)
+	})
+}
+
+// bcryptHash hashes the peppered password with bcrypt like the former implementation of the password hashing.
+func bcryptHash(password, pepper string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password+pepper), bcrypt.DefaultCost)
+
+	return string(hash), err
+}
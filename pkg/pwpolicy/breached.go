@@ -0,0 +1,77 @@
+package pwpolicy
+
+import (
+	"bufio"
+	"crypto/sha1" //nolint:gosec // the format of breached password lists
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// prefixLength is the number of hex characters of the SHA-1 hash used as the range file name.
+const prefixLength = 5
+
+// RangeDir is a locally supplied list of breached passwords in k-anonymity range format: the directory contains
+// files named by the first 5 hex characters of SHA-1 hashes of the passwords (with optional ".txt" extension).
+// Each line of the file is the rest of the hash followed by the number of breaches: "SUFFIX:COUNT".
+type RangeDir struct {
+	dir string
+}
+
+// NewRangeDir checks that the directory exists and returns the list of breached passwords stored there.
+func NewRangeDir(dir string) (*RangeDir, error) {
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("breached passwords: %w", err)
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("breached passwords: %s is not a directory", dir)
+	}
+
+	return &RangeDir{dir: dir}, nil
+}
+
+// Contains checks whether the password is in the list. Only the range file of the hash prefix is read.
+func (d *RangeDir) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:prefixLength], hash[prefixLength:]
+
+	f, err := d.open(prefix)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("breached passwords: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			line = line[:i]
+		}
+		if strings.EqualFold(line, suffix) {
+			return true, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("breached passwords: %w", err)
+	}
+
+	return false, nil
+}
+
+func (d *RangeDir) open(prefix string) (*os.File, error) {
+	f, err := os.Open(filepath.Join(d.dir, prefix+".txt"))
+	if errors.Is(err, os.ErrNotExist) {
+		return os.Open(filepath.Join(d.dir, prefix))
+	}
+
+	return f, err
+}
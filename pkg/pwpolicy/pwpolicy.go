@@ -0,0 +1,288 @@
+// Package pwpolicy checks new passwords against the configurable password policy: length, character classes,
+// banned substrings, estimated entropy and the list of breached passwords.
+package pwpolicy
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	defaultMinLength = 8
+	defaultMaxLength = 256
+
+	// minBannedLength is the minimal length of the login part banned in the password. Shorter parts
+	// are too common to be checked.
+	minBannedLength = 3
+)
+
+// Reason codes.
+const (
+	ReasonTooShort        = "too_short"
+	ReasonTooLong         = "too_long"
+	ReasonMissingLower    = "missing_lower"
+	ReasonMissingUpper    = "missing_upper"
+	ReasonMissingDigit    = "missing_digit"
+	ReasonMissingSymbol   = "missing_symbol"
+	ReasonTooFewClasses   = "too_few_classes"
+	ReasonContainsLogin   = "contains_login"
+	ReasonBannedSubstring = "banned_substring"
+	ReasonLowEntropy      = "low_entropy"
+	ReasonBreached        = "breached"
+)
+
+type (
+	// Config is the password policy. Zero values turn the checks off except the length limits
+	// that are replaced by the defaults.
+	Config struct {
+		MinLength int `mapstructure:"min_length"`
+		MaxLength int `mapstructure:"max_length"`
+		// RequireLower, RequireUpper, RequireDigit and RequireSymbol make the character class mandatory.
+		RequireLower  bool `mapstructure:"require_lower"`
+		RequireUpper  bool `mapstructure:"require_upper"`
+		RequireDigit  bool `mapstructure:"require_digit"`
+		RequireSymbol bool `mapstructure:"require_symbol"`
+		// MinClasses is the number of different character classes (lower case letters, upper case letters,
+		// digits and symbols) the password must contain.
+		MinClasses int `mapstructure:"min_classes"`
+		// MinEntropy is the minimal estimated entropy of the password in bits.
+		MinEntropy float64 `mapstructure:"min_entropy"`
+		// BannedSubstrings can't be used in passwords in any letter case. The user's login is always banned.
+		BannedSubstrings []string `mapstructure:"banned_substrings"`
+		// BreachedDir is the directory of breached password hashes in k-anonymity range format. If empty,
+		// the passwords aren't checked.
+		BreachedDir string `mapstructure:"breached_dir"`
+	}
+
+	// Policy checks the passwords.
+	Policy struct {
+		cfg      Config
+		banned   []string
+		breached *RangeDir
+	}
+
+	// Reason is a single unmet requirement of the policy.
+	Reason struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+
+	// Error is returned by Check with all the requirements the password doesn't meet.
+	Error struct {
+		Reasons []Reason
+	}
+)
+
+func (e *Error) Error() string {
+	msgs := make([]string, 0, len(e.Reasons))
+	for _, r := range e.Reasons {
+		msgs = append(msgs, r.Message)
+	}
+
+	return "password policy: " + strings.Join(msgs, "; ")
+}
+
+// New creates a password policy.
+func New(cfg Config) (*Policy, error) {
+	if cfg.MinLength <= 0 {
+		cfg.MinLength = defaultMinLength
+	}
+	if cfg.MaxLength <= 0 {
+		cfg.MaxLength = defaultMaxLength
+	}
+	if cfg.MinLength > cfg.MaxLength {
+		return nil, fmt.Errorf("pwpolicy: min length %d is greater than max length %d", cfg.MinLength, cfg.MaxLength)
+	}
+	p := &Policy{cfg: cfg}
+	for _, s := range cfg.BannedSubstrings {
+		if s = strings.ToLower(strings.TrimSpace(s)); s != "" {
+			p.banned = append(p.banned, s)
+		}
+	}
+	if cfg.BreachedDir != "" {
+		d, err := NewRangeDir(cfg.BreachedDir)
+		if err != nil {
+			return nil, fmt.Errorf("pwpolicy: %w", err)
+		}
+		p.breached = d
+	}
+
+	return p, nil
+}
+
+// Default returns the policy that checks the length of the password only.
+func Default() *Policy {
+	p, _ := New(Config{})
+
+	return p
+}
+
+// Check checks the password of the user with the login provided. If the password doesn't meet the policy,
+// *Error is returned.
+func (p *Policy) Check(password, login string) error {
+	var reasons []Reason
+	add := func(code, format string, args ...interface{}) {
+		reasons = append(reasons, Reason{Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if n := utf8.RuneCountInString(password); n < p.cfg.MinLength {
+		add(ReasonTooShort, "must be at least %d characters long", p.cfg.MinLength)
+	} else if n > p.cfg.MaxLength {
+		add(ReasonTooLong, "must be at most %d characters long", p.cfg.MaxLength)
+	}
+
+	c := classesOf(password)
+	if p.cfg.RequireLower && !c.lower {
+		add(ReasonMissingLower, "must contain a lower case letter")
+	}
+	if p.cfg.RequireUpper && !c.upper {
+		add(ReasonMissingUpper, "must contain an upper case letter")
+	}
+	if p.cfg.RequireDigit && !c.digit {
+		add(ReasonMissingDigit, "must contain a digit")
+	}
+	if p.cfg.RequireSymbol && !c.symbol {
+		add(ReasonMissingSymbol, "must contain a symbol")
+	}
+	if c.count() < p.cfg.MinClasses {
+		add(ReasonTooFewClasses,
+			"must contain at least %d of: lower case letters, upper case letters, digits, symbols", p.cfg.MinClasses)
+	}
+
+	lower := strings.ToLower(password)
+	if containsLogin(lower, login) {
+		add(ReasonContainsLogin, "must not contain the login")
+	}
+	for _, s := range p.banned {
+		if strings.Contains(lower, s) {
+			add(ReasonBannedSubstring, "must not contain %q", s)
+		}
+	}
+
+	if p.cfg.MinEntropy > 0 && Entropy(password) < p.cfg.MinEntropy {
+		add(ReasonLowEntropy, "is too easy to guess")
+	}
+
+	if p.breached != nil {
+		found, err := p.breached.Contains(password)
+		if err != nil {
+			return fmt.Errorf("pwpolicy: %w", err)
+		}
+		if found {
+			add(ReasonBreached, "has appeared in a data breach")
+		}
+	}
+
+	if len(reasons) > 0 {
+		return &Error{Reasons: reasons}
+	}
+
+	return nil
+}
+
+// containsLogin checks whether the lower case password contains the login or its local part if the login
+// is an email address.
+func containsLogin(password, login string) bool {
+	login = strings.ToLower(strings.TrimSpace(login))
+	parts := []string{login}
+	if i := strings.LastIndex(login, "@"); i > 0 {
+		parts = append(parts, login[:i])
+	}
+	for _, part := range parts {
+		if len(part) >= minBannedLength && strings.Contains(password, part) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type classes struct {
+	lower, upper, digit, symbol bool
+}
+
+func classesOf(s string) classes {
+	var c classes
+	for _, r := range s {
+		switch {
+		case unicode.IsUpper(r):
+			c.upper = true
+		case unicode.IsLetter(r):
+			c.lower = true
+		case unicode.IsDigit(r):
+			c.digit = true
+		default:
+			c.symbol = true
+		}
+	}
+
+	return c
+}
+
+func (c classes) count() int {
+	n := 0
+	for _, ok := range []bool{c.lower, c.upper, c.digit, c.symbol} {
+		if ok {
+			n++
+		}
+	}
+
+	return n
+}
+
+// poolSize returns the number of characters an attacker has to try for each position of the password.
+func (c classes) poolSize() int {
+	n := 0
+	if c.lower {
+		n += 26
+	}
+	if c.upper {
+		n += 26
+	}
+	if c.digit {
+		n += 10
+	}
+	if c.symbol {
+		n += 33
+	}
+
+	return n
+}
+
+// Entropy returns a rough estimate of the password's entropy in bits: log2 of the character pool size for
+// each character. Repeated characters and sequences like "abc" or "321" add almost nothing.
+func Entropy(password string) float64 {
+	pool := classesOf(password).poolSize()
+	if pool == 0 {
+		return 0
+	}
+	bitsPerChar := math.Log2(float64(pool))
+
+	var (
+		bits     float64
+		prev     rune
+		prevDiff rune
+	)
+	for i, r := range []rune(password) {
+		diff := unicode.ToLower(r) - unicode.ToLower(prev)
+		switch {
+		case i == 0:
+			bits += bitsPerChar
+		case diff == 0:
+			// repeated character
+		case (diff == 1 || diff == -1) && diff == prevDiff:
+			// continued sequence
+		default:
+			bits += bitsPerChar
+		}
+		if i > 0 {
+			prevDiff = diff
+		}
+		prev = r
+	}
+
+	return bits
+}
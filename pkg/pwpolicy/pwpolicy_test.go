@@ -0,0 +1,129 @@
+package pwpolicy
+
+import (
+	"crypto/sha1" //nolint:gosec
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func reasonCodes(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var pErr *Error
+	require.True(t, errors.As(err, &pErr), "unexpected error: %v", err)
+	codes := make([]string, 0, len(pErr.Reasons))
+	for _, r := range pErr.Reasons {
+		codes = append(codes, r.Code)
+	}
+
+	return codes
+}
+
+func TestDefault(t *testing.T) {
+	p := Default()
+	assert.Equal(t, []string{ReasonTooShort}, reasonCodes(t, p.Check("", "")))
+	assert.Equal(t, []string{ReasonTooShort}, reasonCodes(t, p.Check("short", "")))
+	assert.Equal(t, []string{ReasonTooLong}, reasonCodes(t, p.Check(strings.Repeat("x", 257), "")))
+	assert.NoError(t, p.Check("sidelinatrube", "harry@hogwarts.uk"))
+	// The length is counted in characters, not bytes.
+	assert.NoError(t, p.Check("пароль!!", ""))
+}
+
+func TestCheck(t *testing.T) {
+	p, err := New(Config{
+		MinLength:        10,
+		RequireUpper:     true,
+		RequireDigit:     true,
+		MinClasses:       3,
+		MinEntropy:       40,
+		BannedSubstrings: []string{"Gophermart", " "},
+	})
+	require.NoError(t, err)
+
+	tt := []struct {
+		name     string
+		password string
+		login    string
+		want     []string
+	}{
+		{
+			name:     "#1 Normal case",
+			password: "Mellon-Fr1end",
+			login:    "frodo@hobbyton.shire.me",
+		},
+		{
+			name:     "#2 Too short, no upper case letter",
+			password: "ab1!",
+			want:     []string{ReasonTooShort, ReasonMissingUpper, ReasonLowEntropy},
+		},
+		{
+			name:     "#3 Too few classes",
+			password: "Mellonfriend",
+			want:     []string{ReasonMissingDigit, ReasonTooFewClasses},
+		},
+		{
+			name:     "#4 Contains the local part of the login",
+			password: "1-FRODO-baggins",
+			login:    "frodo@hobbyton.shire.me",
+			want:     []string{ReasonContainsLogin},
+		},
+		{
+			name:     "#5 Banned substring in any case",
+			password: "MyGOPHERMART-2022",
+			want:     []string{ReasonBannedSubstring},
+		},
+		{
+			name:     "#6 Sequences and repeats",
+			password: "Aaaaaaaa1234567890",
+			want:     []string{ReasonLowEntropy},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, reasonCodes(t, p.Check(tc.password, tc.login)))
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Config{MinLength: 20, MaxLength: 10})
+	assert.Error(t, err)
+	_, err = New(Config{BreachedDir: filepath.Join(t.TempDir(), "missing")})
+	assert.Error(t, err)
+}
+
+func TestEntropy(t *testing.T) {
+	assert.Zero(t, Entropy(""))
+	assert.Less(t, Entropy("aaaaaaaaaaaa"), Entropy("qwrt"))
+	assert.Less(t, Entropy("abcdefghijkl"), Entropy("qwrt"))
+	assert.Less(t, Entropy("password"), Entropy("Password1!"))
+	assert.InDelta(t, 8*4.7, Entropy("qwrtzpsd"), 0.1)
+}
+
+func TestBreached(t *testing.T) {
+	dir := t.TempDir()
+	hash := func(password string) string {
+		sum := sha1.Sum([]byte(password)) //nolint:gosec
+		return strings.ToUpper(hex.EncodeToString(sum[:]))
+	}
+	h := hash("P@ssw0rd")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, h[:5]+".txt"),
+		[]byte("0000000000000000000000000000000000A:1\r\n"+strings.ToLower(h[5:])+":52342\r\n"), 0600))
+	h = hash("Tr0ub4dor&3")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, h[:5]), []byte(h[5:]+":7\n"), 0600))
+
+	p, err := New(Config{BreachedDir: dir})
+	require.NoError(t, err)
+	assert.Equal(t, []string{ReasonBreached}, reasonCodes(t, p.Check("P@ssw0rd", "")))
+	assert.Equal(t, []string{ReasonBreached}, reasonCodes(t, p.Check("Tr0ub4dor&3", "")))
+	assert.NoError(t, p.Check("correct horse battery staple", ""))
+}
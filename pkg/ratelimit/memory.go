@@ -0,0 +1,63 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepEvery is the number of Take calls between the sweeps of full buckets.
+const sweepEvery = 1000
+
+// Ensure MemoryStore implements Store interface.
+var _ Store = (*MemoryStore)(nil)
+
+// MemoryStore keeps the buckets in memory. It's suitable for a single instance of the service.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]memoryBucket
+	calls   int
+}
+
+type memoryBucket struct {
+	Bucket
+	// fullAt is the time the bucket is full again, so it can be forgotten.
+	fullAt time.Time
+}
+
+// NewMemory creates a new in-memory store.
+func NewMemory() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]memoryBucket)}
+}
+
+// Take implements Store interface.
+func (m *MemoryStore) Take(_ context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls++
+	if m.calls%sweepEvery == 0 {
+		m.sweep(now)
+	}
+	b, res := limit.Take(m.buckets[key].Bucket, now)
+	m.buckets[key] = memoryBucket{Bucket: b, fullAt: now.Add(res.Reset)}
+
+	return res, nil
+}
+
+// Len returns the number of buckets stored.
+func (m *MemoryStore) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.buckets)
+}
+
+// sweep deletes the buckets that are full: a missing bucket is full.
+func (m *MemoryStore) sweep(now time.Time) {
+	for key, b := range m.buckets {
+		if !now.Before(b.fullAt) {
+			delete(m.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+// Package ratelimit implements token bucket rate limiting. The token bucket itself is model.RateLimit,
+// so that the storage can keep the buckets without depending on this package.
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+)
+
+type (
+	// Limit allows Requests requests per Per period with bursts up to Burst requests.
+	// A zero Limit doesn't limit anything.
+	Limit = model.RateLimit
+
+	// Bucket is the state of the token bucket of a key. A zero Bucket is full.
+	Bucket = model.RateLimitBucket
+
+	// Result is the result of taking a token from the bucket.
+	Result = model.RateLimitResult
+
+	// Store keeps the buckets of the keys and takes the tokens from them atomically.
+	Store interface {
+		Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+	}
+
+	// StoreFunc is an adapter to use a function as a Store.
+	StoreFunc func(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+)
+
+// Take implements Store interface.
+func (f StoreFunc) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	return f(ctx, key, limit, now)
+}
@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTake(t *testing.T) {
+	limit := Limit{Requests: 2, Per: time.Second, Burst: 3}
+	now := time.Now()
+
+	b := Bucket{}
+	var res Result
+	for i := 2; i >= 0; i-- {
+		b, res = limit.Take(b, now)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, 3, res.Limit)
+		assert.Equal(t, i, res.Remaining)
+		assert.Zero(t, res.RetryAfter)
+	}
+	assert.Equal(t, 1500*time.Millisecond, res.Reset)
+
+	b, res = limit.Take(b, now)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+	assert.Equal(t, 500*time.Millisecond, res.RetryAfter)
+
+	// A token is added each 500ms.
+	b, res = limit.Take(b, now.Add(500*time.Millisecond))
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+
+	// The bucket isn't filled over its size.
+	_, res = limit.Take(b, now.Add(time.Hour))
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 2, res.Remaining)
+}
+
+func TestLimit(t *testing.T) {
+	assert.True(t, Limit{}.Unlimited())
+	assert.True(t, Limit{Requests: 10}.Unlimited())
+	assert.False(t, Limit{Requests: 10, Per: time.Minute}.Unlimited())
+	assert.Equal(t, 10, Limit{Requests: 10, Per: time.Minute}.Size())
+	assert.Equal(t, 20, Limit{Requests: 10, Per: time.Minute, Burst: 20}.Size())
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+	limit := Limit{Requests: 1, Per: time.Minute}
+	now := time.Now()
+
+	res, err := m.Take(ctx, "alice", limit, now)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+	res, err = m.Take(ctx, "alice", limit, now)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, time.Minute, res.RetryAfter)
+
+	// The keys have their own buckets.
+	res, err = m.Take(ctx, "bob", limit, now)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 2, m.Len())
+
+	// Full buckets are swept.
+	m.sweep(now.Add(time.Minute))
+	assert.Equal(t, 0, m.Len())
+}
@@ -0,0 +1,42 @@
+// Package token provides generation and hashing of random secret tokens.
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// Generate creates a random URL-safe token of size random bytes.
+func Generate(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.URLEncoding.EncodeToString(b), nil
+}
+
+// Hash returns hex encoded HMAC-SHA256 of the token keyed by the server secret. Only hashes of
+// the tokens are stored, so neither the leaked database nor the secret alone can be used to sign in.
+func Hash(secret, token string) string {
+	return HashDigest(secret, Digest(token))
+}
+
+// Digest returns hex encoded SHA-256 of the token. Session tokens used to be stored this way.
+func Digest(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// HashDigest returns hex encoded HMAC-SHA256 of the token's digest keyed by the server secret.
+// It's used to upgrade the stored digests: HashDigest(secret, Digest(token)) == Hash(secret, token).
+func HashDigest(secret, digest string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(digest))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,29 @@
+package token
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	t1, err := Generate(32)
+	require.NoError(t, err)
+	t2, err := Generate(32)
+	require.NoError(t, err)
+	assert.Len(t, t1, 44)
+	assert.NotEqual(t, t1, t2)
+}
+
+func TestHash(t *testing.T) {
+	// echo -n "remember" | sha256sum
+	assert.Equal(t, "35379d9c009e290d702b72abf3f5f9bdbaa7e00a63fc37b5174fc3825c8095e3", Digest("remember"))
+
+	h := Hash("secret", "remember")
+	assert.Len(t, h, 64)
+	assert.Equal(t, h, HashDigest("secret", Digest("remember")))
+	assert.NotEqual(t, h, Hash("another secret", "remember"))
+	assert.NotEqual(t, h, Hash("secret", "Remember"))
+	assert.NotEqual(t, h, Digest("remember"))
+}
@@ -0,0 +1,114 @@
+// Package tracing sets up OpenTelemetry tracing: the exporter, the global tracer provider and W3C trace context
+// propagation.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+
+	defaultServiceName = "gophermart"
+)
+
+// Config configures the export of the spans.
+type Config struct {
+	// Exporter is one of "otlp", "stdout" or empty string (tracing is disabled).
+	Exporter string `mapstructure:"exporter"`
+	// Endpoint is host:port of OTLP/HTTP collector. If empty, the exporter's default (localhost:4318) is used.
+	Endpoint string `mapstructure:"endpoint"`
+	// Insecure turns off TLS of the connection to OTLP collector.
+	Insecure bool `mapstructure:"insecure"`
+	// ServiceName is the name of the service in the spans' resource.
+	ServiceName string `mapstructure:"service_name"`
+	// SampleRatio is the fraction of the traces sampled unless the parent span is sampled. Zero means 1.
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
+
+// Validate checks the config.
+func (c Config) Validate() error {
+	switch c.Exporter {
+	case "", ExporterOTLP, ExporterStdout:
+	default:
+		return fmt.Errorf("tracing: unknown exporter %q", c.Exporter)
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		return errors.New("tracing: sample ratio must be between 0 and 1")
+	}
+
+	return nil
+}
+
+// New creates the tracer provider with the exporter configured and sets it and W3C trace context propagator
+// as the global ones. If tracing is disabled, nil is returned and the spans are no-op.
+// The provider must be shut down to flush the spans.
+func New(ctx context.Context, cfg Config) (*sdktrace.TracerProvider, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var (
+		exporter sdktrace.SpanExporter
+		err      error
+	)
+	switch cfg.Exporter {
+	case ExporterOTLP:
+		opts := []otlptracehttp.Option{}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tracing: %w", err)
+	}
+
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+	ratio := cfg.SampleRatio
+	if ratio == 0 {
+		ratio = 1
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName))),
+	)
+	otel.SetTracerProvider(tp)
+
+	return tp, nil
+}
+
+// End records the error, if any, in the span and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,122 @@
+package tracing_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/pkg/middleware"
+	"github.com/vanamelnik/gophermart/pkg/tracing"
+	"github.com/vanamelnik/gophermart/provider/accrual"
+	"github.com/vanamelnik/gophermart/storage"
+	mockstorage "github.com/vanamelnik/gophermart/storage/mock"
+
+	"github.com/go-chi/chi"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestConfig(t *testing.T) {
+	assert.NoError(t, tracing.Config{}.Validate())
+	assert.NoError(t, tracing.Config{Exporter: tracing.ExporterStdout, SampleRatio: 0.5}.Validate())
+	assert.Error(t, tracing.Config{Exporter: "jaeger"}.Validate())
+	assert.Error(t, tracing.Config{SampleRatio: 2}.Validate())
+
+	tp, err := tracing.New(context.Background(), tracing.Config{})
+	require.NoError(t, err)
+	assert.Nil(t, tp, "tracing is disabled")
+}
+
+// The package level tracers are bound to the first global provider set, so all the runs share it.
+var (
+	provider     *sdktrace.TracerProvider
+	providerOnce sync.Once
+)
+
+// recordSpans sets the global tracer provider and propagator for the test and returns the recorder
+// of the spans ended during the test. The previous provider and propagator are restored on cleanup.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	providerOnce.Do(func() { provider = sdktrace.NewTracerProvider() })
+	recorder := tracetest.NewSpanRecorder()
+	provider.RegisterSpanProcessor(recorder)
+
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		provider.UnregisterSpanProcessor(recorder)
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+
+	return recorder
+}
+
+func TestTracing(t *testing.T) {
+	recorder := recordSpans(t)
+
+	// The accrual service checks that the trace context is propagated.
+	var accrualTraceID trace.TraceID
+	accrualSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		accrualTraceID = trace.SpanContextFromContext(ctx).TraceID()
+		_ = json.NewEncoder(w).Encode(accrual.AccrualResponse{Order: "12345678903", Status: model.StatusProcessing})
+	}))
+	defer accrualSrv.Close()
+	client := accrual.New(accrualSrv.URL)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	db := mockstorage.NewMockStorage(mockCtrl)
+	db.EXPECT().OrderByID(gomock.Any(), model.OrderID("12345678903")).Return(nil, storage.ErrNotFound).Times(1)
+	db.EXPECT().UserOrders(gomock.Any(), gomock.Any()).Return(nil, errors.New("connection refused")).Times(1)
+	store := storage.WithTracing(db)
+
+	r := chi.NewRouter()
+	r.Use(middleware.Tracing)
+	r.Get("/orders/{number}", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		_, _ = store.OrderByID(ctx, model.OrderID(chi.URLParam(r, "number")))
+		_, _ = store.UserOrders(ctx, [16]byte{})
+		_, err := client.Request(ctx, model.OrderID(chi.URLParam(r, "number")))
+		assert.NoError(t, err)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := httptest.NewRequest(http.MethodGet, "/orders/12345678903", nil)
+	req.Header.Set("traceparent", traceparent)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, s := range recorder.Ended() {
+		spans[s.Name()] = s
+	}
+	require.Len(t, spans, 4)
+	server, ok := spans["GET /orders/{number}"]
+	require.True(t, ok, "the server span is named by the route pattern")
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String(),
+		"the trace is continued from the incoming request")
+	assert.Equal(t, codes.Error, server.Status().Code)
+
+	for _, name := range []string{"storage.OrderByID", "storage.UserOrders", "accrual.Request"} {
+		require.Contains(t, spans, name)
+		assert.Equal(t, server.SpanContext().SpanID(), spans[name].Parent().SpanID(), name)
+	}
+	assert.Equal(t, codes.Unset, spans["storage.OrderByID"].Status().Code, "not found isn't an error")
+	assert.Equal(t, codes.Error, spans["storage.UserOrders"].Status().Code)
+	assert.Equal(t, server.SpanContext().TraceID(), accrualTraceID)
+}
@@ -6,13 +6,30 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/vanamelnik/gophermart/model"
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/pkg/metrics"
+	"github.com/vanamelnik/gophermart/pkg/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var _ AccrualClient = (*HTTPClient)(nil)
 
-const accrualRequestAPI = "/api/orders/"
+const (
+	accrualRequestAPI = "/api/orders/"
+	// requestIDHeader carries the ID of the request the call is made on behalf of.
+	requestIDHeader = "X-Request-ID"
+)
+
+var tracer = otel.Tracer("github.com/vanamelnik/gophermart/provider/accrual")
 
 // HTTPClient is implementation of api.AccrualClient interface.
 type HTTPClient struct {
@@ -26,18 +43,32 @@ func New(accrualSystemURL string) HTTPClient {
 	}
 }
 
-// Request performs a request to GopherAccrualService.
-func (c HTTPClient) Request(ctx context.Context, orderID model.OrderID) (*AccrualResponse, error) {
+// Request performs a request to GopherAccrualService. The trace context is propagated in W3C traceparent header.
+func (c HTTPClient) Request(ctx context.Context, orderID model.OrderID) (_ *AccrualResponse, err error) {
+	ctx, span := tracer.Start(ctx, "accrual.Request",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("order.id", string(orderID))),
+	)
+	defer func() { tracing.End(span, err) }()
+
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.accrualAPI+string(orderID), nil)
 	if err != nil {
 		return nil, fmt.Errorf("client: AccrualRequest: %w", err)
 	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	if id := appContext.RequestID(ctx); id != "" {
+		req.Header.Set(requestIDHeader, id)
+	}
 
+	start := time.Now()
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
+		metrics.AccrualRequestDuration.WithLabelValues("error").Observe(time.Since(start).Seconds())
 		return nil, fmt.Errorf("client: AccrualRequest: %w", err)
 	}
 	defer resp.Body.Close()
+	metrics.AccrualRequestDuration.WithLabelValues(strconv.Itoa(resp.StatusCode)).Observe(time.Since(start).Seconds())
+	span.SetAttributes(semconv.HTTPStatusCode(resp.StatusCode))
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
@@ -0,0 +1,25 @@
+package notifier
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// Notifier delivers messages to the users (by e-mail, SMS etc).
+	Notifier interface {
+		// Notify delivers the message.
+		Notify(ctx context.Context, msg Message) error
+		// Close releases the resources.
+		Close() error
+	}
+
+	// Message is a notification for the user.
+	Message struct {
+		// To is the user's login.
+		To      string    `json:"to"`
+		Subject string    `json:"subject"`
+		Text    string    `json:"text"`
+		SentAt  time.Time `json:"sent_at"`
+	}
+)
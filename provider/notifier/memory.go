@@ -0,0 +1,45 @@
+package notifier
+
+import (
+	"context"
+	"sync"
+)
+
+var _ Notifier = (*MemoryNotifier)(nil)
+
+// MemoryNotifier keeps the messages in memory. It's used for testing.
+type MemoryNotifier struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewMemory creates a new MemoryNotifier.
+func NewMemory() *MemoryNotifier {
+	return &MemoryNotifier{messages: make([]Message, 0)}
+}
+
+// Notify implements Notifier interface.
+func (n *MemoryNotifier) Notify(_ context.Context, msg Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.messages = append(n.messages, msg)
+
+	return nil
+}
+
+// Messages returns a copy of the messages sent.
+func (n *MemoryNotifier) Messages() []Message {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	messages := make([]Message, len(n.messages))
+	copy(messages, n.messages)
+
+	return messages
+}
+
+// Close implements Notifier interface.
+func (n *MemoryNotifier) Close() error {
+	return nil
+}
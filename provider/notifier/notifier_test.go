@@ -0,0 +1,67 @@
+package notifier_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vanamelnik/gophermart/provider/notifier"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var msg = notifier.Message{
+	To:      "frodo@hobbyton.shire.me",
+	Subject: "Password reset",
+	Text:    "token",
+	SentAt:  time.Now().UTC(),
+}
+
+func TestFileNotifier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "messages.jsonl")
+	n, err := notifier.NewFile(path)
+	require.NoError(t, err)
+	require.NoError(t, n.Notify(context.Background(), msg))
+	require.NoError(t, n.Close())
+
+	// Reopen: the messages are appended.
+	n, err = notifier.NewFile(path)
+	require.NoError(t, err)
+	require.NoError(t, n.Notify(context.Background(), msg))
+	require.NoError(t, n.Close())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	got := make([]notifier.Message, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var m notifier.Message
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &m))
+		got = append(got, m)
+	}
+	require.Len(t, got, 2)
+	assert.Equal(t, msg.To, got[1].To)
+	assert.Equal(t, msg.Text, got[1].Text)
+	assert.True(t, msg.SentAt.Equal(got[1].SentAt))
+}
+
+func TestWriterNotifier(t *testing.T) {
+	buf := &bytes.Buffer{}
+	n := notifier.NewWriter(buf)
+	require.NoError(t, n.Notify(context.Background(), msg))
+	assert.NoError(t, n.Close())
+	assert.Contains(t, buf.String(), `"subject":"Password reset"`)
+}
+
+func TestMemoryNotifier(t *testing.T) {
+	n := notifier.NewMemory()
+	require.NoError(t, n.Notify(context.Background(), msg))
+	assert.Equal(t, []notifier.Message{msg}, n.Messages())
+}
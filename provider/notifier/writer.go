@@ -0,0 +1,59 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+var _ Notifier = (*WriterNotifier)(nil)
+
+// WriterNotifier writes the messages in JSON Lines format. It's used for local development:
+// the messages contain secrets (e.g. password reset tokens), so they must not get into the logs.
+type WriterNotifier struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// NewWriter creates a notifier writing the messages to w (e.g. os.Stderr).
+func NewWriter(w io.Writer) *WriterNotifier {
+	return &WriterNotifier{w: w}
+}
+
+// NewFile opens (or creates) the file provided for appending the messages.
+func NewFile(path string) (*WriterNotifier, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("notifier: %w", err)
+	}
+
+	return &WriterNotifier{w: f, closer: f}, nil
+}
+
+// Notify implements Notifier interface.
+func (n *WriterNotifier) Notify(_ context.Context, msg Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if err := json.NewEncoder(n.w).Encode(msg); err != nil {
+		return fmt.Errorf("notifier: %w", err)
+	}
+
+	return nil
+}
+
+// Close implements Notifier interface.
+func (n *WriterNotifier) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.closer == nil {
+		return nil
+	}
+
+	return n.closer.Close()
+}
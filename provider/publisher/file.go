@@ -0,0 +1,52 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/vanamelnik/gophermart/model"
+)
+
+var _ Publisher = (*FilePublisher)(nil)
+
+// FilePublisher appends the events to a file in JSON Lines format.
+type FilePublisher struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFile opens (or creates) the file provided for appending.
+func NewFile(path string) (*FilePublisher, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("publisher: %w", err)
+	}
+
+	return &FilePublisher{file: f}, nil
+}
+
+// Publish implements Publisher interface. The events are flushed to disk before returning.
+func (p *FilePublisher) Publish(_ context.Context, events []model.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	enc := json.NewEncoder(p.file)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return fmt.Errorf("publisher: %w", err)
+		}
+	}
+
+	return p.file.Sync()
+}
+
+// Close implements Publisher interface.
+func (p *FilePublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.file.Close()
+}
@@ -0,0 +1,17 @@
+package publisher
+
+import (
+	"context"
+
+	"github.com/vanamelnik/gophermart/model"
+)
+
+// Publisher delivers the domain events from the outbox to the downstream consumers.
+// The events are published at least once: a batch may be published again if the relay
+// fails to mark it as published.
+type Publisher interface {
+	// Publish publishes the events in the order provided.
+	Publish(ctx context.Context, events []model.Event) error
+	// Close releases the resources.
+	Close() error
+}
@@ -0,0 +1,47 @@
+package publisher
+
+import (
+	"context"
+	"sync"
+
+	"github.com/vanamelnik/gophermart/model"
+)
+
+var _ Publisher = (*MemoryPublisher)(nil)
+
+// MemoryPublisher keeps the published events in memory. It's used for testing and development.
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []model.Event
+}
+
+// NewMemory creates a new MemoryPublisher.
+func NewMemory() *MemoryPublisher {
+	return &MemoryPublisher{events: make([]model.Event, 0)}
+}
+
+// Publish implements Publisher interface.
+func (p *MemoryPublisher) Publish(_ context.Context, events []model.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.events = append(p.events, events...)
+
+	return nil
+}
+
+// Events returns a copy of all published events.
+func (p *MemoryPublisher) Events() []model.Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	events := make([]model.Event, len(p.events))
+	copy(events, p.events)
+
+	return events
+}
+
+// Close implements Publisher interface.
+func (p *MemoryPublisher) Close() error {
+	return nil
+}
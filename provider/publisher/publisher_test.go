@@ -0,0 +1,69 @@
+package publisher_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/provider/publisher"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var events = []model.Event{
+	{
+		ID:          1,
+		Type:        model.EventOrderCreated,
+		AggregateID: "117",
+		Payload:     json.RawMessage(`{"order":"117","status":"NEW"}`),
+		CreatedAt:   time.Now().UTC(),
+	},
+	{
+		ID:          2,
+		Type:        model.EventOrderProcessed,
+		AggregateID: "117",
+		Payload:     json.RawMessage(`{"order":"117","status":"PROCESSED","accrual":500}`),
+		CreatedAt:   time.Now().UTC(),
+	},
+}
+
+func TestFilePublisher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	p, err := publisher.NewFile(path)
+	require.NoError(t, err)
+	require.NoError(t, p.Publish(context.Background(), events[:1]))
+	require.NoError(t, p.Publish(context.Background(), events[1:]))
+	require.NoError(t, p.Close())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	got := make([]model.Event, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e model.Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		got = append(got, e)
+	}
+	require.NoError(t, scanner.Err())
+
+	require.Len(t, got, len(events))
+	for i := range events {
+		assert.Equal(t, events[i].ID, got[i].ID)
+		assert.Equal(t, events[i].Type, got[i].Type)
+		assert.JSONEq(t, string(events[i].Payload), string(got[i].Payload))
+	}
+}
+
+func TestMemoryPublisher(t *testing.T) {
+	p := publisher.NewMemory()
+	require.NoError(t, p.Publish(context.Background(), events))
+	assert.Equal(t, events, p.Events())
+	assert.NoError(t, p.Close())
+}
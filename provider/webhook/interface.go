@@ -0,0 +1,25 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vanamelnik/gophermart/model"
+)
+
+// Sender delivers webhook payloads to the partners.
+type Sender interface {
+	// Send posts the delivery payload to the url provided, signing it with the secret.
+	// The HTTP status code is returned even if the receiver has responded with an unexpected status.
+	Send(ctx context.Context, url, secret string, delivery model.WebhookDelivery) (int, error)
+}
+
+// ErrUnexpectedStatus is returned when the receiver responds with a non-2xx status code.
+type ErrUnexpectedStatus struct {
+	Code int
+	Body string
+}
+
+func (e ErrUnexpectedStatus) Error() string {
+	return fmt.Sprintf("webhook: unexpected status code %d, body: %s", e.Code, e.Body)
+}
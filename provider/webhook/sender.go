@@ -0,0 +1,92 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+)
+
+var _ Sender = (*HTTPSender)(nil)
+
+const (
+	HeaderEvent     = "X-Gophermart-Event"
+	HeaderDelivery  = "X-Gophermart-Delivery"
+	HeaderTimestamp = "X-Gophermart-Timestamp"
+	HeaderSignature = "X-Gophermart-Signature"
+
+	signaturePrefix = "sha256="
+	defaultTimeout  = 10 * time.Second
+	// maxBodyExcerpt limits the part of the receiver's response stored in the delivery log.
+	maxBodyExcerpt = 256
+)
+
+// HTTPSender is an implementation of Sender interface.
+type HTTPSender struct {
+	client *http.Client
+}
+
+// New creates a new HTTPSender. If timeout is zero, the default timeout is used.
+func New(timeout time.Duration) HTTPSender {
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+
+	return HTTPSender{
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Send implements Sender interface.
+func (s HTTPSender) Send(ctx context.Context, url, secret string, d model.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("webhook: send: %w", err)
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, string(d.EventType))
+	req.Header.Set(HeaderDelivery, d.ID.String())
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, d.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook: send: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxBodyExcerpt))
+		return resp.StatusCode, &ErrUnexpectedStatus{
+			Code: resp.StatusCode,
+			Body: string(body),
+		}
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Sign calculates the signature of the payload: HMAC-SHA256 of "<timestamp>.<payload>" keyed by the secret.
+// The timestamp is included to make replay attacks detectable by the receiver.
+func Sign(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature of the payload in constant time. It can be used by the receivers.
+func Verify(secret string, timestamp int64, payload []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, payload)), []byte(signature))
+}
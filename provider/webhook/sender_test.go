@@ -0,0 +1,86 @@
+package webhook_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/provider/webhook"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const secret = "partner secret"
+
+func TestSend(t *testing.T) {
+	tt := []struct {
+		name       string
+		respStatus int
+		wantErr    bool
+	}{
+		{
+			name:       "#1 Normal case",
+			respStatus: http.StatusOK,
+			wantErr:    false,
+		},
+		{
+			name:       "#2 Receiver accepted the event",
+			respStatus: http.StatusAccepted,
+			wantErr:    false,
+		},
+		{
+			name:       "#3 Receiver failed",
+			respStatus: http.StatusServiceUnavailable,
+			wantErr:    true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			delivery := model.WebhookDelivery{
+				ID:        uuid.New(),
+				EventType: model.EventOrderProcessed,
+				Payload:   []byte(`{"event":"order.processed"}`),
+			}
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				timestamp, err := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+				require.NoError(t, err)
+
+				assert.Equal(t, string(delivery.Payload), string(body))
+				assert.Equal(t, string(delivery.EventType), r.Header.Get(webhook.HeaderEvent))
+				assert.Equal(t, delivery.ID.String(), r.Header.Get(webhook.HeaderDelivery))
+				assert.True(t, webhook.Verify(secret, timestamp, body, r.Header.Get(webhook.HeaderSignature)))
+				assert.False(t, webhook.Verify("wrong secret", timestamp, body, r.Header.Get(webhook.HeaderSignature)))
+
+				w.WriteHeader(tc.respStatus)
+			}))
+			defer receiver.Close()
+
+			code, err := webhook.New(0).Send(context.Background(), receiver.URL, secret, delivery)
+			assert.Equal(t, tc.respStatus, code)
+			if tc.wantErr {
+				var statusErr *webhook.ErrUnexpectedStatus
+				assert.ErrorAs(t, err, &statusErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSendUnreachable(t *testing.T) {
+	receiver := httptest.NewServer(http.NotFoundHandler())
+	url := receiver.URL
+	receiver.Close()
+
+	code, err := webhook.New(0).Send(context.Background(), url, secret, model.WebhookDelivery{})
+	assert.Error(t, err)
+	assert.Zero(t, code)
+}
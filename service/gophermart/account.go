@@ -0,0 +1,113 @@
+package gophermart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/pkg/pwhash"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+// ExportData implements Service interface.
+func (g *GopherMart) ExportData(ctx context.Context) (DataExport, error) {
+	log := userLogger(ctx).With().Str("service:", "ExportData").Logger()
+
+	user := appContext.User(ctx)
+	if user == nil {
+		log.Trace().Err(ErrNotAuthenticated).Msg("")
+		return DataExport{}, ErrNotAuthenticated
+	}
+	// The user from the context may be cached by the session, so the fresh data is fetched.
+	info, err := g.UserInfo(ctx, user.ID)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return DataExport{}, fmt.Errorf("service: ExportData: %w", err)
+	}
+	sessions, err := g.db.UserSessions(ctx, user.ID)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return DataExport{}, fmt.Errorf("service: ExportData: %w", err)
+	}
+	resets, err := g.db.UserPasswordResets(ctx, user.ID)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return DataExport{}, fmt.Errorf("service: ExportData: %w", err)
+	}
+	export := DataExport{
+		ExportedAt:     time.Now(),
+		User:           info.User,
+		Balance:        info.Balance,
+		Orders:         info.Orders,
+		Withdrawals:    info.Withdrawals,
+		Adjustments:    info.Adjustments,
+		Sessions:       sessions,
+		PasswordResets: resets,
+	}
+
+	tf, err := g.db.TwoFactor(ctx, user.ID)
+	switch {
+	case err == nil:
+		export.TwoFactor = &TwoFactorInfo{Enabled: tf.Enabled(), EnabledAt: tf.EnabledAt}
+	case !errors.Is(err, storage.ErrNotFound):
+		log.Trace().Err(err).Msg("")
+		return DataExport{}, fmt.Errorf("service: ExportData: %w", err)
+	}
+	throttle, err := g.db.LoginThrottle(ctx, loginKey(user.Login))
+	switch {
+	case err == nil:
+		export.FailedLogins = throttle
+	case !errors.Is(err, storage.ErrNotFound):
+		log.Trace().Err(err).Msg("")
+		return DataExport{}, fmt.Errorf("service: ExportData: %w", err)
+	}
+	log.Info().Msg("personal data exported")
+
+	return export, nil
+}
+
+// DeleteAccount implements Service interface.
+func (g *GopherMart) DeleteAccount(ctx context.Context, password, code string) error {
+	log := userLogger(ctx).With().Str("service:", "DeleteAccount").Logger()
+
+	user := appContext.User(ctx)
+	if user == nil {
+		log.Trace().Err(ErrNotAuthenticated).Msg("")
+		return ErrNotAuthenticated
+	}
+	if _, err := g.hasher.Verify(password, user.PasswordHash); err != nil {
+		log.Trace().Err(err).Msg("")
+		if errors.Is(err, pwhash.ErrMismatchedHashAndPassword) {
+			return ErrWrongPassword
+		}
+
+		return fmt.Errorf("service: DeleteAccount: %w", err)
+	}
+	tf, err := g.enabledTwoFactor(ctx, *user)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return err
+	}
+	if tf != nil {
+		if err := g.checkSecondFactor(ctx, *tf, code); err != nil {
+			log.Trace().Err(err).Msg("")
+			return err
+		}
+	}
+
+	// The pseudonymous id isn't logged: it must not be linked to the user's id and login.
+	if err := g.db.DeleteUser(ctx, user.ID, uuid.New(), time.Now()); err != nil {
+		log.Trace().Err(err).Msg("")
+		return fmt.Errorf("service: DeleteAccount: %w", err)
+	}
+	if err := g.db.DeleteLoginThrottle(ctx, loginKey(user.Login)); err != nil {
+		log.Error().Err(err).Msg("could not delete failed login attempts")
+	}
+	log.Info().Str("userID", user.ID.String()).Msg("the account is deleted")
+
+	return nil
+}
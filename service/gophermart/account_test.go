@@ -0,0 +1,119 @@
+package gophermart_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/pkg/logging"
+	"github.com/vanamelnik/gophermart/service/gophermart"
+	"github.com/vanamelnik/gophermart/storage"
+	mockstorage "github.com/vanamelnik/gophermart/storage/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportData(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	db := mockstorage.NewMockStorage(mockCtrl)
+	ctx, s, err := initServices(db, pepper)
+	require.NoError(t, err)
+	user := appContext.User(ctx)
+	user.PasswordHash = "secret hash"
+
+	now := time.Now()
+	orders := []model.Order{{ID: "12345678903", UserID: user.ID, Status: model.StatusProcessed, AccrualPoints: 100}}
+	withdrawals := []model.Withdrawal{{UserID: user.ID, OrderID: "2377225624", Sum: 30, Status: model.StatusProcessed}}
+	sessions := []model.Session{{ID: uuid.New(), UserID: user.ID, TokenHash: "session hash", UserAgent: "Palantir/1.0"}}
+	resets := []model.PasswordReset{{ID: uuid.New(), UserID: user.ID, TokenHash: "reset hash", CreatedAt: now}}
+	db.EXPECT().UserByID(gomock.Any(), user.ID).Return(user, nil).Times(2)
+	db.EXPECT().WithdrawalsByUserID(gomock.Any(), user.ID).Return(withdrawals, nil).Times(2)
+	db.EXPECT().UpdateBalance(gomock.Any()).Return(0, nil).Times(1)
+	db.EXPECT().UserOrders(gomock.Any(), user.ID).Return(orders, nil).Times(1)
+	db.EXPECT().AdjustmentsByUserID(gomock.Any(), user.ID).Return([]model.Adjustment{}, nil).Times(1)
+	db.EXPECT().UserSessions(gomock.Any(), user.ID).Return(sessions, nil).Times(1)
+	db.EXPECT().UserPasswordResets(gomock.Any(), user.ID).Return(resets, nil).Times(1)
+	db.EXPECT().TwoFactor(gomock.Any(), user.ID).
+		Return(&model.TwoFactor{UserID: user.ID, Secret: "TOTP SECRET", EnabledAt: &now}, nil).Times(1)
+	db.EXPECT().LoginThrottle(gomock.Any(), "login:"+user.Login).Return(nil, storage.ErrNotFound).Times(1)
+
+	export, err := s.ExportData(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, user.Login, export.User.Login)
+	assert.Equal(t, float32(30), export.Balance.Withdrawn)
+	assert.Equal(t, orders, export.Orders)
+	assert.Equal(t, withdrawals, export.Withdrawals)
+	assert.Equal(t, sessions, export.Sessions)
+	assert.Equal(t, resets, export.PasswordResets)
+	require.NotNil(t, export.TwoFactor)
+	assert.True(t, export.TwoFactor.Enabled)
+	assert.Nil(t, export.FailedLogins)
+
+	// The secrets are never exported.
+	data, err := json.Marshal(export)
+	require.NoError(t, err)
+	for _, secret := range []string{"secret hash", "session hash", "reset hash", "TOTP SECRET"} {
+		assert.NotContains(t, string(data), secret)
+	}
+
+	_, err = s.ExportData(appContext.WithLogger(context.Background(),
+		logging.NewLogger(logging.WithConsoleOutput(true), logging.WithLevel("trace"))))
+	assert.ErrorIs(t, err, gophermart.ErrNotAuthenticated)
+}
+
+func TestDeleteAccount(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	db := mockstorage.NewMockStorage(mockCtrl)
+	ctx, s, err := initServices(db, pepper)
+	require.NoError(t, err)
+	user := appContext.User(ctx)
+	user.PasswordHash, err = bcryptHash("TheRingIsM1ne!", pepper)
+	require.NoError(t, err)
+
+	t.Run("#1 Wrong password", func(t *testing.T) {
+		assert.ErrorIs(t, s.DeleteAccount(ctx, "TheRingIsY0urs!", ""), gophermart.ErrWrongPassword)
+	})
+	t.Run("#2 Normal case", func(t *testing.T) {
+		db.EXPECT().DeleteUser(gomock.Any(), user.ID, gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, id, pseudonymID uuid.UUID, _ time.Time) error {
+				assert.NotEqual(t, uuid.Nil, pseudonymID)
+				assert.NotEqual(t, id, pseudonymID)
+
+				return nil
+			}).Times(1)
+		db.EXPECT().TwoFactor(gomock.Any(), user.ID).Return(nil, storage.ErrNotFound).Times(1)
+		db.EXPECT().DeleteLoginThrottle(gomock.Any(), "login:"+user.Login).Return(nil).Times(1)
+		assert.NoError(t, s.DeleteAccount(ctx, "TheRingIsM1ne!", ""))
+	})
+	t.Run("#3 Second factor is required", func(t *testing.T) {
+		enabledAt := time.Now()
+		tf := &model.TwoFactor{UserID: user.ID, Secret: "JBSWY3DPEHPK3PXP", EnabledAt: &enabledAt}
+		db.EXPECT().TwoFactor(gomock.Any(), user.ID).Return(tf, nil).Times(2)
+		db.EXPECT().UseRecoveryCode(gomock.Any(), user.ID, gomock.Any(), gomock.Any()).Return(storage.ErrNotFound).Times(1)
+		assert.ErrorIs(t, s.DeleteAccount(ctx, "TheRingIsM1ne!", "aaaa-bbbb-cccc-dddd"), gophermart.ErrInvalidCode)
+
+		db.EXPECT().UseRecoveryCode(gomock.Any(), user.ID, gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		db.EXPECT().DeleteUser(gomock.Any(), user.ID, gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		db.EXPECT().DeleteLoginThrottle(gomock.Any(), "login:"+user.Login).Return(nil).Times(1)
+		assert.NoError(t, s.DeleteAccount(ctx, "TheRingIsM1ne!", "AAAA-BBBB-CCCC-DDDD"))
+	})
+	t.Run("#4 Tombstone can't sign in", func(t *testing.T) {
+		deletedAt := time.Now()
+		pseudonymID := uuid.New()
+		tombstone := &model.User{ID: pseudonymID, Login: model.DeletedUserLogin(pseudonymID), DeletedAt: &deletedAt}
+		db.EXPECT().LoginThrottle(gomock.Any(), gomock.Any()).Return(nil, storage.ErrNotFound).AnyTimes()
+		db.EXPECT().UserByLogin(gomock.Any(), tombstone.Login).Return(tombstone, nil).Times(1)
+		db.EXPECT().TakeLoginAttempt(gomock.Any(), gomock.Any(), 0, gomock.Any(), gomock.Any()).
+			Return(&model.LoginThrottle{Failures: 1}, nil).Times(2)
+		_, err := s.Authenticate(ctx, tombstone.Login, "", "192.0.2.1")
+		assert.ErrorIs(t, err, gophermart.ErrWrongCredentials)
+	})
+}
@@ -3,11 +3,18 @@ package gophermart
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/vanamelnik/gophermart/model"
 	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/pkg/logging"
+	"github.com/vanamelnik/gophermart/pkg/metrics"
 	"github.com/vanamelnik/gophermart/provider/accrual"
 	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // accrualServicePoller looks in the storage for 'NEW', and 'PROCESSING' orders and sends requests to the
@@ -24,12 +31,18 @@ poller:
 		default:
 			orders := g.getOrders(ctx)
 			for _, order := range orders {
+				// Don't start polling the next order if the service is shutting down.
+				select {
+				case <-g.workersStop:
+					break poller
+				default:
+				}
 				g.processOrder(ctx, order)
 			}
+			g.pollerHeartbeat.Beat(time.Now())
 		}
 	}
 	log.Info().Msg("accrualServicePoller stopped")
-	g.workersWg.Done()
 }
 
 // getOrders returns the orders with statuses 'NEW' and 'PROCESSING' from the storage.
@@ -40,6 +53,8 @@ func (g *GopherMart) getOrders(ctx context.Context) []model.Order {
 		o, err := g.db.OrdersByStatus(ctx, status)
 		if err != nil { // if there're no orders, empty list is returned and err == nil.
 			log.Error().Err(err).Msgf("could not get orders with status %s", status)
+		} else {
+			metrics.PollerBacklog.WithLabelValues(string(status)).Set(float64(len(o)))
 		}
 		orders = append(orders, o...)
 	}
@@ -50,10 +65,16 @@ func (g *GopherMart) getOrders(ctx context.Context) []model.Order {
 // processOrder sends a request to GopherAccrualService and updates order status to 'PROCESSING'
 // or 'INVALID'. If the calculation is done, the new entry in accruals log is created.
 func (g *GopherMart) processOrder(ctx context.Context, order model.Order) {
+	// The calls to the accrual service are made on behalf of the poller, so each order gets its own request ID.
+	requestID := uuid.NewString()
+	ctx = appContext.WithRequestID(ctx, requestID)
 	log := appContext.Logger(ctx).With().
 		Str("orderID", order.ID.String()).
+		Str(logging.RequestIDKey, requestID).
 		Str("service:", "poller: process order:").
 		Logger()
+	ctx, span := tracer.Start(ctx, "poller.processOrder", trace.WithAttributes(attribute.String("order.id", order.ID.String())))
+	defer span.End()
 
 	// Send a request to the GopherAccualService
 	resp, err := g.accrualClient.Request(ctx, order.ID)
@@ -73,9 +94,9 @@ func (g *GopherMart) processOrder(ctx context.Context, order model.Order) {
 		if resp.Accrual < 0 {
 			if err := g.db.UpdateOrderStatus(ctx, order.ID, model.StatusInvalid); err != nil {
 				log.Error().Err(err).Msg("could not update order status, operation canceled")
-
-				return
 			}
+
+			return
 		}
 		// if all is OK, the order status is set to 'PROCESSED' within db transaction.
 		if err := g.db.CreateAccrual(ctx, order.ID, resp.Accrual); err != nil {
@@ -91,6 +112,7 @@ func (g *GopherMart) processOrder(ctx context.Context, order model.Order) {
 			return
 		}
 		log.Info().Float32("amount", resp.Accrual).Msg("a new entry in accruals log has been created")
+		metrics.PointsAccrued.Add(float64(resp.Accrual))
 
 		return
 	}
@@ -0,0 +1,214 @@
+package gophermart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+// searchUsersLimit is the maximum number of users returned by SearchUsers.
+const searchUsersLimit = 50
+
+// SearchUsers implements Service interface.
+func (g *GopherMart) SearchUsers(ctx context.Context, login string) ([]model.User, error) {
+	log := userLogger(ctx).With().Str("service:", "SearchUsers").Logger()
+
+	users, err := g.db.SearchUsers(ctx, login, searchUsersLimit)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return nil, fmt.Errorf("service: SearchUsers: %w", err)
+	}
+
+	return users, nil
+}
+
+// UserInfo implements Service interface.
+func (g *GopherMart) UserInfo(ctx context.Context, userID uuid.UUID) (UserInfo, error) {
+	log := userLogger(ctx).With().Str("service:", "UserInfo").Str("userID", userID.String()).Logger()
+
+	user, err := g.db.UserByID(ctx, userID)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return UserInfo{}, fmt.Errorf("service: UserInfo: %w", err)
+	}
+	balance, err := g.userBalance(ctx, user.ID)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return UserInfo{}, fmt.Errorf("service: UserInfo: %w", err)
+	}
+	orders, err := g.db.UserOrders(ctx, user.ID)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return UserInfo{}, fmt.Errorf("service: UserInfo: %w", err)
+	}
+	withdrawals, err := g.db.WithdrawalsByUserID(ctx, user.ID)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return UserInfo{}, fmt.Errorf("service: UserInfo: %w", err)
+	}
+	adjustments, err := g.db.AdjustmentsByUserID(ctx, user.ID)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return UserInfo{}, fmt.Errorf("service: UserInfo: %w", err)
+	}
+
+	return UserInfo{
+		User:        *user,
+		Balance:     balance,
+		Orders:      orders,
+		Withdrawals: withdrawals,
+		Adjustments: adjustments,
+	}, nil
+}
+
+// AdjustBalance implements Service interface.
+func (g *GopherMart) AdjustBalance(ctx context.Context, userID uuid.UUID, sum float32, reason string) (model.Adjustment, error) {
+	log := userLogger(ctx).With().
+		Str("service:", "AdjustBalance").
+		Str("userID", userID.String()).
+		Float32("sum", sum).
+		Logger()
+
+	admin := appContext.User(ctx)
+	if admin == nil {
+		log.Trace().Err(ErrNotAuthenticated).Msg("")
+		return model.Adjustment{}, ErrNotAuthenticated
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		log.Trace().Err(ErrReasonRequired).Msg("")
+		return model.Adjustment{}, ErrReasonRequired
+	}
+	if sum == 0 {
+		log.Trace().Err(ErrInvalidAmount).Msg("")
+		return model.Adjustment{}, ErrInvalidAmount
+	}
+
+	adj := model.Adjustment{
+		ID:        uuid.New(),
+		UserID:    userID,
+		AdminID:   admin.ID,
+		Sum:       sum,
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	}
+	if err := g.db.CreateAdjustment(ctx, &adj); err != nil {
+		log.Trace().Err(err).Msg("")
+		return model.Adjustment{}, fmt.Errorf("service: AdjustBalance: %w", err)
+	}
+	log.Info().Str("reason", reason).Msg("user's balance adjusted")
+
+	return adj, nil
+}
+
+// SetUserBlocked implements Service interface.
+func (g *GopherMart) SetUserBlocked(ctx context.Context, userID uuid.UUID, blocked bool) error {
+	log := userLogger(ctx).With().
+		Str("service:", "SetUserBlocked").
+		Str("userID", userID.String()).
+		Bool("blocked", blocked).
+		Logger()
+
+	if err := g.db.SetUserBlocked(ctx, userID, blocked); err != nil {
+		log.Trace().Err(err).Msg("")
+		return fmt.Errorf("service: SetUserBlocked: %w", err)
+	}
+	log.Info().Msg("user's blocked flag updated")
+
+	return nil
+}
+
+// SetUserRole implements Service interface.
+func (g *GopherMart) SetUserRole(ctx context.Context, userID uuid.UUID, role model.Role, perms []model.Permission) error {
+	log := userLogger(ctx).With().
+		Str("service:", "SetUserRole").
+		Str("userID", userID.String()).
+		Str("role", string(role)).
+		Logger()
+
+	if !role.Valid() {
+		log.Trace().Err(ErrInvalidRole).Msg("")
+		return ErrInvalidRole
+	}
+	for _, p := range perms {
+		if !p.Valid() {
+			log.Trace().Err(ErrInvalidRole).Str("permission", string(p)).Msg("")
+			return ErrInvalidRole
+		}
+	}
+	// Protect the administrators from locking themselves out.
+	if admin := appContext.User(ctx); admin != nil && admin.ID == userID {
+		log.Trace().Err(ErrOwnRole).Msg("")
+		return ErrOwnRole
+	}
+
+	if err := g.db.SetUserRole(ctx, userID, role, perms); err != nil {
+		log.Trace().Err(err).Msg("")
+		return fmt.Errorf("service: SetUserRole: %w", err)
+	}
+	log.Info().Interface("permissions", perms).Msg("user's role updated")
+
+	return nil
+}
+
+// BootstrapAdmin implements Service interface.
+func (g *GopherMart) BootstrapAdmin(ctx context.Context, login, password string) (model.User, error) {
+	log := appContext.Logger(ctx).With().Str("service:", "BootstrapAdmin").Str("login", login).Logger()
+
+	user, err := g.db.UserByLogin(ctx, login)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			log.Trace().Err(err).Msg("")
+			return model.User{}, fmt.Errorf("service: BootstrapAdmin: %w", err)
+		}
+		newUser, err := g.Create(ctx, login, password)
+		if err != nil {
+			log.Trace().Err(err).Msg("")
+			return model.User{}, fmt.Errorf("service: BootstrapAdmin: %w", err)
+		}
+		user = &newUser
+	}
+
+	if err := g.db.SetUserRole(ctx, user.ID, model.RoleAdmin, user.Permissions); err != nil {
+		log.Trace().Err(err).Msg("")
+		return model.User{}, fmt.Errorf("service: BootstrapAdmin: %w", err)
+	}
+	user.Role = model.RoleAdmin
+	log.Info().Str("id", user.ID.String()).Msg("the user is granted admin role")
+
+	return *user, nil
+}
+
+// RepollOrder implements Service interface.
+func (g *GopherMart) RepollOrder(ctx context.Context, orderID model.OrderID) error {
+	log := userLogger(ctx).With().
+		Str("service:", "RepollOrder").
+		Str("orderID", orderID.String()).
+		Logger()
+
+	order, err := g.db.OrderByID(ctx, orderID)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return fmt.Errorf("service: RepollOrder: %w", err)
+	}
+	// The accrual for a processed order is already in the accruals log and can't be calculated twice.
+	if order.Status == model.StatusProcessed {
+		log.Trace().Err(ErrOrderAlreadyProcessed).Msg("")
+		return ErrOrderAlreadyProcessed
+	}
+	if err := g.db.UpdateOrderStatus(ctx, orderID, model.StatusNew); err != nil {
+		log.Trace().Err(err).Msg("")
+		return fmt.Errorf("service: RepollOrder: %w", err)
+	}
+	log.Info().Str("previous status", string(order.Status)).Msg("the order will be polled again")
+
+	return nil
+}
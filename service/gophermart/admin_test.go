@@ -0,0 +1,376 @@
+package gophermart_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/vanamelnik/gophermart/model"
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/service/gophermart"
+	"github.com/vanamelnik/gophermart/storage"
+	mockstorage "github.com/vanamelnik/gophermart/storage/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetUserRole(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	db := mockstorage.NewMockStorage(mockCtrl)
+	ctx, s, err := initServices(db, pepper)
+	require.NoError(t, err)
+	admin := appContext.User(ctx)
+
+	samwiseID := uuid.New()
+	db.EXPECT().SetUserRole(gomock.Any(), samwiseID, model.RoleSupport, []model.Permission{model.PermUsersBlock}).
+		Return(nil).Times(1)
+
+	tt := []struct {
+		name    string
+		userID  uuid.UUID
+		role    model.Role
+		perms   []model.Permission
+		wantErr error
+	}{
+		{
+			name:    "#1 Normal case",
+			userID:  samwiseID,
+			role:    model.RoleSupport,
+			perms:   []model.Permission{model.PermUsersBlock},
+			wantErr: nil,
+		},
+		{
+			name:    "#2 Unknown role",
+			userID:  samwiseID,
+			role:    "wizard",
+			wantErr: gophermart.ErrInvalidRole,
+		},
+		{
+			name:    "#3 Unknown permission",
+			userID:  samwiseID,
+			role:    model.RoleUser,
+			perms:   []model.Permission{"ring:wear"},
+			wantErr: gophermart.ErrInvalidRole,
+		},
+		{
+			name:    "#4 Own role",
+			userID:  admin.ID,
+			role:    model.RoleUser,
+			wantErr: gophermart.ErrOwnRole,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := s.SetUserRole(ctx, tc.userID, tc.role, tc.perms)
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestBootstrapAdmin(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	db := mockstorage.NewMockStorage(mockCtrl)
+	ctx, s, err := initServices(db, pepper)
+	require.NoError(t, err)
+
+	t.Run("#1 Promote existing user", func(t *testing.T) {
+		gandalf := &model.User{ID: uuid.New(), Login: "gandalf@valinor.me", Role: model.RoleUser}
+		db.EXPECT().UserByLogin(gomock.Any(), gandalf.Login).Return(gandalf, nil).Times(1)
+		db.EXPECT().SetUserRole(gomock.Any(), gandalf.ID, model.RoleAdmin, gomock.Any()).Return(nil).Times(1)
+
+		user, err := s.BootstrapAdmin(ctx, gandalf.Login, "")
+		require.NoError(t, err)
+		assert.Equal(t, model.RoleAdmin, user.Role)
+	})
+	t.Run("#2 Create new admin", func(t *testing.T) {
+		db.EXPECT().UserByLogin(gomock.Any(), "saruman@isengard.me").Return(nil, storage.ErrNotFound).Times(1)
+		db.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		db.EXPECT().SetUserRole(gomock.Any(), gomock.Any(), model.RoleAdmin, gomock.Any()).Return(nil).Times(1)
+
+		user, err := s.BootstrapAdmin(ctx, "saruman@isengard.me", "WhiteHand0fOrthanc")
+		require.NoError(t, err)
+		assert.Equal(t, model.RoleAdmin, user.Role)
+		assert.True(t, user.Can(model.PermRolesManage))
+	})
+	t.Run("#3 New admin with invalid password", func(t *testing.T) {
+		db.EXPECT().UserByLogin(gomock.Any(), "radagast@rhosgobel.me").Return(nil, storage.ErrNotFound).Times(1)
+
+		_, err := s.BootstrapAdmin(ctx, "radagast@rhosgobel.me", "")
+		assert.Error(t, err)
+	})
+}
+
+func TestSearchUsers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	db := mockstorage.NewMockStorage(mockCtrl)
+	ctx, s, err := initServices(db, pepper)
+	require.NoError(t, err)
+
+	bilbo := model.User{ID: uuid.New(), Login: "bilbo@bagend.shire.me"}
+	dbErr := errors.New("db is down")
+
+	tt := []struct {
+		name       string
+		login      string
+		mockReturn []model.User
+		mockErr    error
+		want       []model.User
+		wantErr    error
+	}{
+		{
+			name:       "#1 Normal case",
+			login:      "bag",
+			mockReturn: []model.User{bilbo},
+			want:       []model.User{bilbo},
+		},
+		{
+			name:       "#2 Nothing found",
+			login:      "sauron",
+			mockReturn: []model.User{},
+			want:       []model.User{},
+		},
+		{
+			name:    "#3 Storage error",
+			login:   "bag",
+			mockErr: dbErr,
+			wantErr: dbErr,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			db.EXPECT().SearchUsers(gomock.Any(), tc.login, gomock.Any()).Return(tc.mockReturn, tc.mockErr).Times(1)
+			users, err := s.SearchUsers(ctx, tc.login)
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.Equal(t, tc.want, users)
+		})
+	}
+}
+
+func TestUserInfo(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	db := mockstorage.NewMockStorage(mockCtrl)
+	ctx, s, err := initServices(db, pepper)
+	require.NoError(t, err)
+
+	t.Run("#1 Normal case", func(t *testing.T) {
+		bilbo := &model.User{ID: uuid.New(), Login: "bilbo@bagend.shire.me", GPointsBalance: 70}
+		orders := []model.Order{{ID: "117", UserID: bilbo.ID, Status: model.StatusProcessed}}
+		withdrawals := []model.Withdrawal{
+			{OrderID: "125", UserID: bilbo.ID, Sum: 30, Status: model.StatusProcessed},
+			{OrderID: "133", UserID: bilbo.ID, Sum: 500, Status: model.StatusInvalid},
+		}
+		adjustments := []model.Adjustment{{ID: uuid.New(), UserID: bilbo.ID, Sum: -10, Reason: "birthday party"}}
+		db.EXPECT().UserByID(gomock.Any(), bilbo.ID).Return(bilbo, nil).Times(2)
+		db.EXPECT().WithdrawalsByUserID(gomock.Any(), bilbo.ID).Return(withdrawals, nil).Times(2)
+		db.EXPECT().UpdateBalance(gomock.Any()).Return(0, nil).Times(1)
+		db.EXPECT().UserOrders(gomock.Any(), bilbo.ID).Return(orders, nil).Times(1)
+		db.EXPECT().AdjustmentsByUserID(gomock.Any(), bilbo.ID).Return(adjustments, nil).Times(1)
+
+		info, err := s.UserInfo(ctx, bilbo.ID)
+		require.NoError(t, err)
+		assert.Equal(t, *bilbo, info.User)
+		assert.Equal(t, gophermart.UserBalance{Current: 70, Withdrawn: 30}, info.Balance)
+		assert.Equal(t, orders, info.Orders)
+		assert.Equal(t, withdrawals, info.Withdrawals)
+		assert.Equal(t, adjustments, info.Adjustments)
+	})
+	t.Run("#2 Unknown user", func(t *testing.T) {
+		id := uuid.New()
+		db.EXPECT().UserByID(gomock.Any(), id).Return(nil, storage.ErrNotFound).Times(1)
+
+		_, err := s.UserInfo(ctx, id)
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	})
+}
+
+func TestAdjustBalance(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	db := mockstorage.NewMockStorage(mockCtrl)
+	ctx, s, err := initServices(db, pepper)
+	require.NoError(t, err)
+	admin := appContext.User(ctx)
+
+	samwiseID := uuid.New()
+	tt := []struct {
+		name     string
+		ctx      context.Context
+		sum      float32
+		reason   string
+		mockCall bool
+		mockErr  error
+		wantErr  error
+	}{
+		{
+			name:     "#1 Normal case",
+			ctx:      ctx,
+			sum:      -15.5,
+			reason:   " lost the pans ",
+			mockCall: true,
+			wantErr:  nil,
+		},
+		{
+			name:    "#2 Not authenticated",
+			ctx:     appContext.WithUser(ctx, nil),
+			sum:     100,
+			reason:  "gift",
+			wantErr: gophermart.ErrNotAuthenticated,
+		},
+		{
+			name:    "#3 Empty reason",
+			ctx:     ctx,
+			sum:     100,
+			reason:  "  ",
+			wantErr: gophermart.ErrReasonRequired,
+		},
+		{
+			name:    "#4 Zero sum",
+			ctx:     ctx,
+			sum:     0,
+			reason:  "gift",
+			wantErr: gophermart.ErrInvalidAmount,
+		},
+		{
+			name:     "#5 Balance would become negative",
+			ctx:      ctx,
+			sum:      -1000,
+			reason:   "fine",
+			mockCall: true,
+			mockErr:  storage.ErrInsufficientPoints,
+			wantErr:  storage.ErrInsufficientPoints,
+		},
+		{
+			name:     "#6 Unknown user",
+			ctx:      ctx,
+			sum:      100,
+			reason:   "gift",
+			mockCall: true,
+			mockErr:  storage.ErrNotFound,
+			wantErr:  storage.ErrNotFound,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.mockCall {
+				db.EXPECT().CreateAdjustment(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, adj *model.Adjustment) error {
+						assert.Equal(t, samwiseID, adj.UserID)
+						assert.Equal(t, admin.ID, adj.AdminID)
+						assert.Equal(t, tc.sum, adj.Sum)
+
+						return tc.mockErr
+					}).Times(1)
+			}
+			adj, err := s.AdjustBalance(tc.ctx, samwiseID, tc.sum, tc.reason)
+			assert.ErrorIs(t, err, tc.wantErr)
+			if tc.wantErr == nil {
+				assert.Equal(t, strings.TrimSpace(tc.reason), adj.Reason)
+				assert.Equal(t, admin.ID, adj.AdminID)
+			}
+		})
+	}
+}
+
+func TestSetUserBlocked(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	db := mockstorage.NewMockStorage(mockCtrl)
+	ctx, s, err := initServices(db, pepper)
+	require.NoError(t, err)
+
+	tt := []struct {
+		name       string
+		blocked    bool
+		mockReturn error
+		wantErr    error
+	}{
+		{
+			name:       "#1 Block",
+			blocked:    true,
+			mockReturn: nil,
+			wantErr:    nil,
+		},
+		{
+			name:       "#2 Unblock",
+			blocked:    false,
+			mockReturn: nil,
+			wantErr:    nil,
+		},
+		{
+			name:       "#3 Unknown user",
+			blocked:    true,
+			mockReturn: storage.ErrNotFound,
+			wantErr:    storage.ErrNotFound,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			id := uuid.New()
+			db.EXPECT().SetUserBlocked(gomock.Any(), id, tc.blocked).Return(tc.mockReturn).Times(1)
+			err := s.SetUserBlocked(ctx, id, tc.blocked)
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestRepollOrder(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	db := mockstorage.NewMockStorage(mockCtrl)
+	ctx, s, err := initServices(db, pepper)
+	require.NoError(t, err)
+
+	tt := []struct {
+		name       string
+		order      *model.Order
+		orderErr   error
+		wantRepoll bool
+		wantErr    error
+	}{
+		{
+			name:       "#1 Invalid order is polled again",
+			order:      &model.Order{ID: "117", Status: model.StatusInvalid},
+			wantRepoll: true,
+			wantErr:    nil,
+		},
+		{
+			name:       "#2 Stuck order is polled again",
+			order:      &model.Order{ID: "125", Status: model.StatusProcessing},
+			wantRepoll: true,
+			wantErr:    nil,
+		},
+		{
+			name:    "#3 Processed order",
+			order:   &model.Order{ID: "133", Status: model.StatusProcessed},
+			wantErr: gophermart.ErrOrderAlreadyProcessed,
+		},
+		{
+			name:     "#4 Unknown order",
+			order:    &model.Order{ID: "141"},
+			orderErr: storage.ErrNotFound,
+			wantErr:  storage.ErrNotFound,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.orderErr != nil {
+				db.EXPECT().OrderByID(gomock.Any(), tc.order.ID).Return(nil, tc.orderErr).Times(1)
+			} else {
+				db.EXPECT().OrderByID(gomock.Any(), tc.order.ID).Return(tc.order, nil).Times(1)
+			}
+			if tc.wantRepoll {
+				db.EXPECT().UpdateOrderStatus(gomock.Any(), tc.order.ID, model.StatusNew).Return(nil).Times(1)
+			}
+			err := s.RepollOrder(ctx, tc.order.ID)
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
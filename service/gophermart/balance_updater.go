@@ -5,6 +5,7 @@ import (
 	"time"
 
 	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/pkg/metrics"
 )
 
 // balanceUpdater looks for unfinished acrrual operations and updates user balance with accrual points.
@@ -18,10 +19,13 @@ loop:
 		case <-t.C:
 			n, err := g.db.UpdateBalance(ctx)
 			if err != nil {
+				metrics.BalanceUpdaterErrors.Inc()
 				log.Error().Err(err).Msg("")
 
 				continue
 			}
+			metrics.BalanceUpdaterBatchSize.Observe(float64(n))
+			g.balanceUpdHeartbeat.Beat(time.Now())
 			if n > 0 {
 				log.Info().Int("number of accrual operations processed", n).Msg("")
 			}
@@ -29,6 +33,5 @@ loop:
 			break loop
 		}
 	}
-	g.workersWg.Done()
 	log.Info().Msg("balanceUpdater stopped")
 }
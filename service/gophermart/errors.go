@@ -1,10 +1,21 @@
 package gophermart
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vanamelnik/gophermart/pkg/pwpolicy"
+)
 
 var (
-	// ErrWrongPassword is returned by Authenticate when provided password doesn't mismatch with user's password from the storage.
+	// ErrWrongPassword is returned by ChangePassword when the provided password doesn't match the user's password from the storage.
 	ErrWrongPassword = errors.New("service: wrong password")
+	// ErrWrongCredentials is returned by Authenticate when the login doesn't exist or the password is wrong.
+	ErrWrongCredentials = errors.New("service: wrong login or password")
+	// ErrTooManyAttempts is matched by LoginThrottledError.
+	ErrTooManyAttempts = errors.New("service: too many failed login attempts")
 	// ErrNotAuthenticated is returned when authenticated user data is not found in the provided context.
 	ErrNotAuthenticated = errors.New("service: no authenticted user found in the context")
 
@@ -18,4 +29,84 @@ var (
 	ErrOrderExecutedBySameUser = errors.New("service: order already executed by the same user")
 	// ErrOrderExecutedByAnotherUser is threw when an order with the provided number already executed by another user.
 	ErrOrderExecutedByAnotherUser = errors.New("service: order already executed by another user")
+
+	// ErrInvalidWebhook is returned when the webhook subscription has an invalid url or event types.
+	ErrInvalidWebhook = errors.New("service: invalid webhook subscription")
+	// ErrDeliveryNotFailed is returned on attempt to replay a webhook delivery that hasn't failed.
+	ErrDeliveryNotFailed = errors.New("service: only failed deliveries can be replayed")
+
+	// ErrInvalidToken is returned when the session token, refresh token or access token is unknown, malformed or expired.
+	ErrInvalidToken = errors.New("service: invalid or expired token")
+	// ErrAccessTokensDisabled is returned when access tokens are requested but not configured.
+	ErrAccessTokensDisabled = errors.New("service: access tokens are disabled")
+
+	// ErrInvalidLogin is returned when the login of the new user is invalid.
+	ErrInvalidLogin = errors.New("service: invalid login")
+	// ErrInvalidPassword is matched by InvalidPasswordError.
+	ErrInvalidPassword = errors.New("service: invalid password")
+	// ErrPasswordResetDisabled is returned when password reset is requested but no notifier is configured.
+	ErrPasswordResetDisabled = errors.New("service: password reset is disabled")
+
+	// ErrTwoFactorEnabled is returned on attempt to enroll when two-factor authentication is already enabled.
+	ErrTwoFactorEnabled = errors.New("service: two-factor authentication already enabled")
+	// ErrTwoFactorNotEnabled is returned when two-factor authentication is not enrolled or not enabled.
+	ErrTwoFactorNotEnabled = errors.New("service: two-factor authentication not enabled")
+	// ErrInvalidCode is returned when the TOTP or recovery code is wrong or already used.
+	ErrInvalidCode = errors.New("service: invalid code")
+	// ErrInvalidChallenge is returned when the login challenge is unknown, expired or exceeded the attempts.
+	ErrInvalidChallenge = errors.New("service: invalid or expired login challenge")
+
+	// ErrInvalidMerchant is returned when the merchant's name is empty.
+	ErrInvalidMerchant = errors.New("service: invalid merchant")
+	// ErrInvalidScope is returned when the API key has no scopes or one of the scopes is unknown.
+	ErrInvalidScope = errors.New("service: invalid API key scope")
+	// ErrInvalidAPIKey is returned when the API key is unknown or revoked.
+	ErrInvalidAPIKey = errors.New("service: invalid API key")
+
+	// ErrUserBlocked is returned when a blocked user tries to log in.
+	ErrUserBlocked = errors.New("service: user is blocked")
+	// ErrReasonRequired is returned when a balance adjustment has no reason.
+	ErrReasonRequired = errors.New("service: reason of the adjustment is required")
+	// ErrInvalidAmount is returned when the amount of the operation is invalid.
+	ErrInvalidAmount = errors.New("service: invalid amount")
+	// ErrOrderAlreadyProcessed is returned on attempt to re-poll the order which accrual is already calculated.
+	ErrOrderAlreadyProcessed = errors.New("service: order already processed")
+	// ErrInvalidRole is returned when the role or one of the permissions is unknown.
+	ErrInvalidRole = errors.New("service: invalid role or permission")
+	// ErrOwnRole is returned when the administrators try to change their own role.
+	ErrOwnRole = errors.New("service: can't change own role")
 )
+
+// LoginThrottledError is returned by Authenticate when the login or the client's IP address made too many
+// failed attempts and must wait.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrTooManyAttempts, e.RetryAfter)
+}
+
+// Is makes errors.Is(err, ErrTooManyAttempts) true.
+func (e *LoginThrottledError) Is(target error) bool {
+	return target == ErrTooManyAttempts
+}
+
+// InvalidPasswordError is returned when the new password doesn't meet the password policy.
+type InvalidPasswordError struct {
+	Reasons []pwpolicy.Reason
+}
+
+func (e *InvalidPasswordError) Error() string {
+	msgs := make([]string, 0, len(e.Reasons))
+	for _, r := range e.Reasons {
+		msgs = append(msgs, r.Message)
+	}
+
+	return fmt.Sprintf("%s: %s", ErrInvalidPassword, strings.Join(msgs, "; "))
+}
+
+// Is makes errors.Is(err, ErrInvalidPassword) true.
+func (e *InvalidPasswordError) Is(target error) bool {
+	return target == ErrInvalidPassword
+}
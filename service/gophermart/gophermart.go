@@ -2,12 +2,20 @@ package gophermart
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 
+	"github.com/vanamelnik/gophermart/pkg/accesstoken"
 	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/pkg/health"
 	"github.com/vanamelnik/gophermart/pkg/logging"
+	"github.com/vanamelnik/gophermart/pkg/pwhash"
+	"github.com/vanamelnik/gophermart/pkg/pwpolicy"
 	"github.com/vanamelnik/gophermart/provider/accrual"
+	"github.com/vanamelnik/gophermart/provider/notifier"
+	"github.com/vanamelnik/gophermart/provider/publisher"
+	"github.com/vanamelnik/gophermart/provider/webhook"
 	"github.com/vanamelnik/gophermart/storage"
 
 	"github.com/rs/zerolog"
@@ -15,6 +23,18 @@ import (
 
 const (
 	defaultAccrualURL = "/"
+
+	defaultWebhookDispatchInterval = time.Second
+	defaultOutboxRelayInterval     = time.Second
+	defaultWebhookMaxAttempts      = 8
+	defaultWebhookRetryInterval    = 10 * time.Second
+
+	defaultSessionTTL       = 30 * 24 * time.Hour
+	defaultPasswordResetTTL = time.Hour
+	defaultTwoFactorIssuer  = "Gophermart"
+
+	// closeTimeout limits the shutdown made by Close.
+	closeTimeout = 30 * time.Second
 )
 
 // Ensure service implements interface.
@@ -27,17 +47,91 @@ type (
 		// doesn't run.
 		withWorkers bool
 		workersStop chan struct{}
-		workersWg   sync.WaitGroup
-		db          storage.Storage
-		pwPepper    string
+		// workersCtx is the context of the workers. workersCancel cancels it, so the blocking calls are aborted.
+		workersCtx      context.Context
+		workersCancel   context.CancelFunc
+		workersStopOnce sync.Once
+		workersWg       sync.WaitGroup
+		// shutdownMu serializes Shutdown calls. shutdownDone is set when the shutdown has succeeded,
+		// so the next calls don't flush the balance updates again.
+		shutdownMu   sync.Mutex
+		shutdownDone bool
+		// tasksWg waits for the tasks started in background by the requests, e.g. sending password reset tokens.
+		tasksWg sync.WaitGroup
+		db      storage.Storage
+		// log is the logger of the service taken from the context passed to New. It's used outside the requests.
+		log zerolog.Logger
+		// pwPepper is the pepper of the password hashes made without pepper id.
+		pwPepper        string
+		passwordHashCfg pwhash.Config
+		// hasher hashes and verifies users' passwords.
+		hasher *pwhash.Hasher
+		// dummyHash is compared with the password when the login doesn't exist, so the response time
+		// doesn't reveal whether the login exists.
+		dummyHash     string
+		dummyHashOnce sync.Once
 		// accrualClient calls for sending a request to accrual service.
 		accrualClient      accrual.AccrualClient
 		balanceUpdInterval time.Duration
+		// pollerHeartbeat and balanceUpdHeartbeat record the progress of accrualServicePoller and balanceUpdater.
+		pollerHeartbeat     *health.Heartbeat
+		balanceUpdHeartbeat *health.Heartbeat
+		// workerRestartDelay is the pause before the worker panicked is restarted.
+		workerRestartDelay time.Duration
+		// webhookSender delivers the events to partners' webhooks.
+		webhookSender           webhook.Sender
+		webhookDispatchInterval time.Duration
+		webhookMaxAttempts      int
+		webhookRetryInterval    time.Duration
+		// publisher publishes domain events from the outbox. If nil, the events only go to the webhooks.
+		publisher           publisher.Publisher
+		outboxRelayInterval time.Duration
+		// withdrawalsRecoverInterval is the interval between the runs of withdrawalsRecoverer.
+		withdrawalsRecoverInterval time.Duration
+		// sessionTTL is the lifetime of users' sessions.
+		sessionTTL time.Duration
+		// tokenSecret is the key of session token hashes.
+		tokenSecret string
+		// accessTokens issues and verifies JWT access tokens. If nil, access tokens are disabled.
+		accessTokens *accesstoken.Manager
+		// notifier delivers password reset tokens to users. If nil, password reset is disabled.
+		notifier         notifier.Notifier
+		passwordResetTTL time.Duration
+		// loginThrottle configures brute-force protection of the login.
+		loginThrottle LoginThrottleConfig
+		// twoFactorIssuer is the issuer shown in authenticator apps.
+		twoFactorIssuer string
+		// passwordPolicyCfg is used to create passwordPolicy that checks new passwords.
+		passwordPolicyCfg pwpolicy.Config
+		passwordPolicy    *pwpolicy.Policy
 	}
 
 	Config struct {
 		PasswordPepper string        `mapstructure:"password_pepper"`
 		UpdateInterval time.Duration `mapstructure:"update_interval"`
+		// WebhookDispatchInterval is the interval between the checks of the webhook delivery queue.
+		WebhookDispatchInterval time.Duration `mapstructure:"webhook_dispatch_interval"`
+		// WebhookMaxAttempts is the number of delivery attempts after which the delivery is marked as failed.
+		WebhookMaxAttempts int `mapstructure:"webhook_max_attempts"`
+		// WebhookRetryInterval is the delay before the first retry. Each next delay is doubled.
+		WebhookRetryInterval time.Duration `mapstructure:"webhook_retry_interval"`
+		// OutboxRelayInterval is the interval between the checks of the outbox for unpublished events.
+		OutboxRelayInterval time.Duration `mapstructure:"outbox_relay_interval"`
+		// SessionTTL is the lifetime of users' sessions.
+		SessionTTL time.Duration `mapstructure:"session_ttl"`
+		// TokenSecret is the key of session token hashes. If it's changed, all the users are signed out.
+		TokenSecret string `mapstructure:"token_secret"`
+		// PasswordResetTTL is the lifetime of password reset tokens.
+		PasswordResetTTL time.Duration `mapstructure:"password_reset_ttl"`
+		// LoginThrottle configures brute-force protection of the login. Zero values are replaced by the defaults.
+		LoginThrottle LoginThrottleConfig `mapstructure:"login_throttle"`
+		// TwoFactorIssuer is the issuer shown in authenticator apps.
+		TwoFactorIssuer string `mapstructure:"two_factor_issuer"`
+		// PasswordPolicy configures the requirements for new passwords.
+		PasswordPolicy pwpolicy.Config `mapstructure:"password_policy"`
+		// PasswordHash configures the algorithm of password hashes and the peppers. PasswordPepper is used
+		// for the hashes without pepper id.
+		PasswordHash pwhash.Config `mapstructure:"password_hash"`
 	}
 
 	ServiceOption func(*GopherMart)
@@ -48,6 +142,31 @@ func WithConfig(cfg Config) ServiceOption {
 	return func(g *GopherMart) {
 		g.pwPepper = cfg.PasswordPepper
 		g.balanceUpdInterval = cfg.UpdateInterval
+		if cfg.WebhookDispatchInterval > 0 {
+			g.webhookDispatchInterval = cfg.WebhookDispatchInterval
+		}
+		if cfg.WebhookMaxAttempts > 0 {
+			g.webhookMaxAttempts = cfg.WebhookMaxAttempts
+		}
+		if cfg.WebhookRetryInterval > 0 {
+			g.webhookRetryInterval = cfg.WebhookRetryInterval
+		}
+		if cfg.OutboxRelayInterval > 0 {
+			g.outboxRelayInterval = cfg.OutboxRelayInterval
+		}
+		g.tokenSecret = cfg.TokenSecret
+		if cfg.SessionTTL > 0 {
+			g.sessionTTL = cfg.SessionTTL
+		}
+		if cfg.PasswordResetTTL > 0 {
+			g.passwordResetTTL = cfg.PasswordResetTTL
+		}
+		g.loginThrottle = cfg.LoginThrottle.withDefaults()
+		if cfg.TwoFactorIssuer != "" {
+			g.twoFactorIssuer = cfg.TwoFactorIssuer
+		}
+		g.passwordPolicyCfg = cfg.PasswordPolicy
+		g.passwordHashCfg = cfg.PasswordHash
 	}
 }
 
@@ -58,7 +177,36 @@ func WithAccrualClient(client accrual.AccrualClient) ServiceOption {
 	}
 }
 
-// WithoutWorkers used for testing. It turns off accrualServicePoller and balanceUpdater workers.
+// WithWebhookSender sets the sender used for webhook deliveries.
+func WithWebhookSender(sender webhook.Sender) ServiceOption {
+	return func(g *GopherMart) {
+		g.webhookSender = sender
+	}
+}
+
+// WithPublisher sets the publisher of the domain events relayed from the outbox by outboxRelay worker.
+func WithPublisher(p publisher.Publisher) ServiceOption {
+	return func(g *GopherMart) {
+		g.publisher = p
+	}
+}
+
+// WithAccessTokens turns on JWT access tokens issued and verified by the manager provided.
+func WithAccessTokens(m *accesstoken.Manager) ServiceOption {
+	return func(g *GopherMart) {
+		g.accessTokens = m
+	}
+}
+
+// WithNotifier sets the notifier used for delivering password reset tokens and turns on password reset.
+func WithNotifier(n notifier.Notifier) ServiceOption {
+	return func(g *GopherMart) {
+		g.notifier = n
+	}
+}
+
+// WithoutWorkers used for testing. It turns off accrualServicePoller, balanceUpdater, webhookDispatcher,
+// sessionsCleaner and outboxRelay workers.
 func WithoutWorkers() ServiceOption {
 	return func(g *GopherMart) {
 		g.withWorkers = false
@@ -71,32 +219,92 @@ func New(ctx context.Context, db storage.Storage, opts ...ServiceOption) (*Gophe
 	g := &GopherMart{
 		workersStop:   make(chan struct{}),
 		db:            db,
+		log:           appContext.Logger(ctx),
 		accrualClient: accrual.New(defaultAccrualURL),
 		withWorkers:   true,
+
+		workerRestartDelay: defaultWorkerRestartDelay,
+
+		webhookSender:              webhook.New(0),
+		webhookDispatchInterval:    defaultWebhookDispatchInterval,
+		webhookMaxAttempts:         defaultWebhookMaxAttempts,
+		webhookRetryInterval:       defaultWebhookRetryInterval,
+		outboxRelayInterval:        defaultOutboxRelayInterval,
+		withdrawalsRecoverInterval: defaultWithdrawalsRecoverInterval,
+		sessionTTL:                 defaultSessionTTL,
+		passwordResetTTL:           defaultPasswordResetTTL,
+		loginThrottle:              DefaultLoginThrottle,
+		twoFactorIssuer:            defaultTwoFactorIssuer,
 	}
 	for _, opt := range opts {
 		opt(g)
 	}
+	policy, err := pwpolicy.New(g.passwordPolicyCfg)
+	if err != nil {
+		return nil, fmt.Errorf("service: %w", err)
+	}
+	g.passwordPolicy = policy
+	hasher, err := newHasher(g.passwordHashCfg, g.pwPepper)
+	if err != nil {
+		return nil, fmt.Errorf("service: %w", err)
+	}
+	g.hasher = hasher
 	if g.balanceUpdInterval == 0 {
 		g.withWorkers = false // do not start workers if update interval isn't set.
 	}
 
 	if g.withWorkers {
-		// Start AccrualService poller and balance updater.
-		g.workersWg.Add(2)
-		go g.accrualServicePoller(ctx)
-		go g.balanceUpdater(ctx)
+		// Start AccrualService poller, balance updater, webhook dispatcher, sessions cleaner, withdrawals recoverer
+		// and outbox relay.
+		// The workers' context is cancelled by Shutdown.
+		g.workersCtx, g.workersCancel = context.WithCancel(ctx)
+		ctx = g.workersCtx
+		g.pollerHeartbeat = health.NewHeartbeat(time.Now())
+		g.balanceUpdHeartbeat = health.NewHeartbeat(time.Now())
+		g.runWorker(ctx, "accrualServicePoller", g.accrualServicePoller)
+		g.runWorker(ctx, "balanceUpdater", g.balanceUpdater)
+		g.runWorker(ctx, "webhookDispatcher", g.webhookDispatcher)
+		g.runWorker(ctx, "sessionsCleaner", g.sessionsCleaner)
+		g.runWorker(ctx, "withdrawalsRecoverer", g.withdrawalsRecoverer)
+		g.runWorker(ctx, "outboxRelay", g.outboxRelay)
 	}
 
 	return g, nil
 }
 
-func (g *GopherMart) Close() {
-	if g.workersStop != nil {
-		close(g.workersStop)
+// newHasher creates the password hasher. The pepper is used for the hashes without pepper id unless
+// the pepper with empty id is configured.
+func newHasher(cfg pwhash.Config, pepper string) (*pwhash.Hasher, error) {
+	peppers := make(map[string]string, len(cfg.Peppers)+1)
+	peppers[""] = pepper
+	for id, p := range cfg.Peppers {
+		peppers[id] = p
+	}
+	cfg.Peppers = peppers
+
+	return pwhash.New(cfg)
+}
+
+// Heartbeats returns the heartbeats of accrualServicePoller and balanceUpdater by the worker name.
+// If the workers aren't running, empty map is returned.
+func (g *GopherMart) Heartbeats() map[string]*health.Heartbeat {
+	if g.pollerHeartbeat == nil {
+		return map[string]*health.Heartbeat{}
+	}
+
+	return map[string]*health.Heartbeat{
+		"accrualServicePoller": g.pollerHeartbeat,
+		"balanceUpdater":       g.balanceUpdHeartbeat,
 	}
+}
 
-	g.workersWg.Wait() // wait until workers stopped
+// Close stops the workers, flushes the balance updates and closes the storage.
+func (g *GopherMart) Close() {
+	ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+	defer cancel()
+	if err := g.Shutdown(ctx); err != nil {
+		g.log.Error().Err(err).Msg("")
+	}
 	if g.db != nil {
 		g.db.Close()
 		g.db = nil
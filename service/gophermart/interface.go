@@ -1,9 +1,13 @@
+//go:generate mockgen -source=interface.go -destination=./mock/mock_gophermart.go -package=mockgophermart
 package gophermart
 
 import (
 	"context"
+	"time"
 
 	"github.com/vanamelnik/gophermart/model"
+
+	"github.com/google/uuid"
 )
 
 type (
@@ -13,10 +17,73 @@ type (
 		Create(ctx context.Context, login, password string) (model.User, error)
 		// Authentcate checks whether a user with such login and password is in the storage.
 		// If successful, the model.User object is saved in the ctx.
-		Authenticate(ctx context.Context, login, password string) (model.User, error)
+		// Failed attempts are counted by the login and the client's ip: after several failures
+		// the attempts are delayed and then locked (LoginThrottledError is returned).
+		Authenticate(ctx context.Context, login, password, ip string) (model.User, error)
+
+		// CreateSession starts a new session of the user and returns the session token.
+		// Only the hash of the token is stored.
+		CreateSession(ctx context.Context, user model.User, userAgent, ip string) (string, model.Session, error)
+		// SessionUser finds the session by the session token and returns it with its user.
+		// ErrInvalidToken is returned if the session is not found or expired. Blocked users are returned as well.
+		SessionUser(ctx context.Context, sessionToken string) (*model.User, *model.Session, error)
+		// AccessTokenUser verifies the access token and returns the session it was issued for with its user.
+		// The errors are the same as SessionUser's.
+		AccessTokenUser(ctx context.Context, accessToken string) (*model.User, *model.Session, error)
+		// IssueAccessToken creates a short-lived access token for the user's session.
+		IssueAccessToken(ctx context.Context, user model.User, sessionID uuid.UUID) (string, time.Time, error)
+		// RefreshAccessToken issues a new access token for the session of the refresh (session) token.
+		// ErrUserBlocked is returned if the user is blocked.
+		RefreshAccessToken(ctx context.Context, refreshToken string) (string, time.Time, error)
+
+		// RequestPasswordReset sends a single-use password reset token to the user through the notifier.
+		// The token is sent in background: if there's no such user, nil is returned anyway.
+		RequestPasswordReset(ctx context.Context, login string) error
+		// ResetPassword sets the new password using the reset token and ends all the user's sessions.
+		ResetPassword(ctx context.Context, resetToken, newPassword string) error
+
+		// AuthenticateAPIKey finds the merchant's active API key.
+		AuthenticateAPIKey(ctx context.Context, key string) (*model.APIKey, error)
+		// SubmitMerchantOrder stores the order of the user with the login provided on behalf of the merchant
+		// authenticated. The ownership rules are the same as for ProcessOrder.
+		SubmitMerchantOrder(ctx context.Context, login string, orderID model.OrderID) error
+
+		// EnrollTwoFactor generates a new TOTP secret for the authenticated user. Two-factor authentication
+		// is enabled after ConfirmTwoFactor.
+		EnrollTwoFactor(ctx context.Context) (TwoFactorEnrollment, error)
+		// ConfirmTwoFactor enables two-factor authentication if the code is valid and returns the recovery codes.
+		// The codes are shown only once.
+		ConfirmTwoFactor(ctx context.Context, code string) ([]string, error)
+		// DisableTwoFactor disables two-factor authentication if the TOTP or recovery code is valid.
+		DisableTwoFactor(ctx context.Context, code string) error
+		// TwoFactorChallenge returns the login challenge token if the user has two-factor authentication enabled.
+		// Otherwise an empty string is returned and the user can be signed in right away.
+		TwoFactorChallenge(ctx context.Context, user model.User) (string, error)
+		// CompleteTwoFactorLogin checks the TOTP or recovery code of the login challenge and returns the user.
+		// The invalid codes are counted as failed login attempts of the user and the client's IP address.
+		CompleteTwoFactorLogin(ctx context.Context, challenge, code, ip string) (model.User, error)
 
 		// The data of authenticated user is taken from the context.
 
+		// ChangePassword sets the new password of authenticated user if the current password is correct.
+		// All the user's sessions except the current one are ended.
+		ChangePassword(ctx context.Context, currentPassword, newPassword string) error
+
+		// ExportData returns everything stored about authenticated user.
+		ExportData(ctx context.Context) (DataExport, error)
+		// DeleteAccount deletes the account of authenticated user if the password is correct. If two-factor
+		// authentication is enabled, a TOTP or recovery code is required too. The login and the credentials
+		// are deleted, the sessions are ended. The orders, accruals, withdrawals and balance adjustments are kept
+		// for accounting under a new pseudonymous user id.
+		DeleteAccount(ctx context.Context, password, code string) error
+
+		// Logout deletes the current session of authenticated user.
+		Logout(ctx context.Context) error
+		// Sessions returns active sessions of authenticated user. The current session is marked.
+		Sessions(ctx context.Context) ([]model.Session, error)
+		// RevokeSession deletes the session of authenticated user.
+		RevokeSession(ctx context.Context, id uuid.UUID) error
+
 		// GetOrders fetches all orders of authenticated user from the storage.
 		GetOrders(ctx context.Context) ([]model.Order, error)
 		// GetBalance returns information about authenticated user's bonus balance and total withdrawn amount.
@@ -29,6 +96,56 @@ type (
 		// Withdraw adds a new entry to withdrawals log and subtracts the sum from authenticated user's bonus balance.
 		Withdraw(ctx context.Context, orderID model.OrderID, sum float32) error
 
+		// CreateWebhook subscribes the url provided to the events. If the secret is empty, a random one is generated.
+		CreateWebhook(ctx context.Context, url, secret string, events []model.EventType) (model.Webhook, error)
+		// Webhooks returns all webhook subscriptions.
+		Webhooks(ctx context.Context) ([]model.Webhook, error)
+		// DeleteWebhook deletes the webhook subscription.
+		DeleteWebhook(ctx context.Context, id uuid.UUID) error
+		// WebhookDeliveries returns the delivery log of the webhook.
+		WebhookDeliveries(ctx context.Context, webhookID uuid.UUID) ([]model.WebhookDelivery, error)
+		// ReplayWebhookDelivery puts the failed delivery back into the queue.
+		ReplayWebhookDelivery(ctx context.Context, id uuid.UUID) error
+
+		// Administration. The administrator's data is taken from the context.
+
+		// SearchUsers looks for users whose login contains the string provided.
+		SearchUsers(ctx context.Context, login string) ([]model.User, error)
+		// UserInfo returns the user's data with balance, orders, withdrawals and balance adjustments.
+		UserInfo(ctx context.Context, userID uuid.UUID) (UserInfo, error)
+		// AdjustBalance credits (positive sum) or debits (negative sum) the user's balance.
+		// The reason is mandatory and is recorded in the adjustments log.
+		AdjustBalance(ctx context.Context, userID uuid.UUID, sum float32, reason string) (model.Adjustment, error)
+		// SetUserBlocked blocks or unblocks the user's account.
+		SetUserBlocked(ctx context.Context, userID uuid.UUID, blocked bool) error
+		// UnlockUser resets failed login attempts of the user and unlocks the login.
+		UnlockUser(ctx context.Context, userID uuid.UUID) error
+		// CreateMerchant adds a new merchant.
+		CreateMerchant(ctx context.Context, name string) (model.Merchant, error)
+		// Merchants returns all the merchants.
+		Merchants(ctx context.Context) ([]model.Merchant, error)
+		// CreateAPIKey creates a new API key of the merchant with the scopes provided. The key is returned only once.
+		CreateAPIKey(ctx context.Context, merchantID uuid.UUID, scopes []model.Scope) (string, model.APIKey, error)
+		// MerchantAPIKeys returns the merchant's API keys including revoked ones.
+		MerchantAPIKeys(ctx context.Context, merchantID uuid.UUID) ([]model.APIKey, error)
+		// RevokeAPIKey revokes the merchant's API key.
+		RevokeAPIKey(ctx context.Context, merchantID, keyID uuid.UUID) error
+		// SetUserRole sets the user's role and the permissions granted in addition to the role.
+		SetUserRole(ctx context.Context, userID uuid.UUID, role model.Role, perms []model.Permission) error
+		// BootstrapAdmin creates a user with admin role or promotes the existing one to admins.
+		// The password is used only when a new user is created.
+		BootstrapAdmin(ctx context.Context, login, password string) (model.User, error)
+		// RepollOrder resets the status of non-processed order to 'NEW', so it will be polled again.
+		RepollOrder(ctx context.Context, orderID model.OrderID) error
+
+		// UpgradeSessionHashes replaces legacy SHA-256 digests of session tokens with HMACs
+		// keyed by the token secret. It's called once at startup.
+		UpgradeSessionHashes(ctx context.Context) error
+		// RecoverWithdrawals rejects the withdrawals left in 'PROCESSING' status by the crashes
+		// of the previous versions. It's called at startup and then periodically by the workers, so the withdrawals
+		// orphaned by the old instances still running during the rollout are recovered too.
+		RecoverWithdrawals(ctx context.Context) error
+
 		// Close shuts down the service.
 		Close()
 	}
@@ -40,4 +157,43 @@ type (
 		// Withdrawn is total withdrawn amount.
 		Withdrawn float32 `json:"withdrawn"`
 	}
+
+	// TwoFactorEnrollment is a struct returned by EnrollTwoFactor.
+	TwoFactorEnrollment struct {
+		// Secret is the base32 encoded secret to be entered into the authenticator app manually.
+		Secret string `json:"secret"`
+		// URI is otpauth:// URI to be shown as a QR code.
+		URI string `json:"otpauth_uri"`
+	}
+
+	// UserInfo is a struct returned by UserInfo.
+	UserInfo struct {
+		User        model.User         `json:"user"`
+		Balance     UserBalance        `json:"balance"`
+		Orders      []model.Order      `json:"orders"`
+		Withdrawals []model.Withdrawal `json:"withdrawals"`
+		Adjustments []model.Adjustment `json:"adjustments"`
+	}
+
+	// DataExport is a struct returned by ExportData.
+	DataExport struct {
+		ExportedAt     time.Time             `json:"exported_at"`
+		User           model.User            `json:"user"`
+		Balance        UserBalance           `json:"balance"`
+		Orders         []model.Order         `json:"orders"`
+		Withdrawals    []model.Withdrawal    `json:"withdrawals"`
+		Adjustments    []model.Adjustment    `json:"adjustments"`
+		Sessions       []model.Session       `json:"sessions"`
+		PasswordResets []model.PasswordReset `json:"password_resets"`
+		// TwoFactor is nil if two-factor authentication was never enrolled.
+		TwoFactor *TwoFactorInfo `json:"two_factor"`
+		// FailedLogins is nil if there're no recent failed login attempts.
+		FailedLogins *model.LoginThrottle `json:"failed_logins"`
+	}
+
+	// TwoFactorInfo describes the user's two-factor authentication without the secret.
+	TwoFactorInfo struct {
+		Enabled   bool       `json:"enabled"`
+		EnabledAt *time.Time `json:"enabled_at,omitempty"`
+	}
 )
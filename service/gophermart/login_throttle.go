@@ -0,0 +1,248 @@
+package gophermart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+const (
+	loginKeyPrefix = "login:"
+	ipKeyPrefix    = "ip:"
+)
+
+type (
+	// LoginThrottleConfig configures brute-force protection of the login.
+	LoginThrottleConfig struct {
+		// FreeAttempts is the number of failed attempts allowed without delay.
+		FreeAttempts int `mapstructure:"free_attempts"`
+		// Delay is the delay after the first failure beyond the free attempts. Each next delay is doubled.
+		Delay    time.Duration `mapstructure:"delay"`
+		MaxDelay time.Duration `mapstructure:"max_delay"`
+		// LockAfter is the number of failed attempts for a login after which the login is locked.
+		LockAfter int `mapstructure:"lock_after"`
+		// IPLockAfter is the number of failed attempts from an IP address after which the address is locked.
+		IPLockAfter int `mapstructure:"ip_lock_after"`
+		// LockDuration is the time the login or IP address stays locked. Failures older than
+		// LockDuration are forgotten.
+		LockDuration time.Duration `mapstructure:"lock_duration"`
+	}
+
+	// loginPolicy is the throttling policy of one kind of keys.
+	loginPolicy struct {
+		prefix    string
+		lockAfter int
+	}
+)
+
+// DefaultLoginThrottle is used for the zero fields of LoginThrottleConfig.
+var DefaultLoginThrottle = LoginThrottleConfig{
+	FreeAttempts: 3,
+	Delay:        time.Second,
+	MaxDelay:     30 * time.Second,
+	LockAfter:    10,
+	IPLockAfter:  100,
+	LockDuration: 15 * time.Minute,
+}
+
+// withDefaults returns the config with zero values replaced by the defaults.
+func (c LoginThrottleConfig) withDefaults() LoginThrottleConfig {
+	if c.FreeAttempts <= 0 {
+		c.FreeAttempts = DefaultLoginThrottle.FreeAttempts
+	}
+	if c.Delay <= 0 {
+		c.Delay = DefaultLoginThrottle.Delay
+	}
+	if c.MaxDelay <= 0 {
+		c.MaxDelay = DefaultLoginThrottle.MaxDelay
+	}
+	if c.LockAfter <= 0 {
+		c.LockAfter = DefaultLoginThrottle.LockAfter
+	}
+	if c.IPLockAfter <= 0 {
+		c.IPLockAfter = DefaultLoginThrottle.IPLockAfter
+	}
+	if c.LockDuration <= 0 {
+		c.LockDuration = DefaultLoginThrottle.LockDuration
+	}
+
+	return c
+}
+
+// retryAfter returns the time the key must wait before the next attempt. Zero means the attempt is allowed.
+func (c LoginThrottleConfig) retryAfter(t model.LoginThrottle, now time.Time) time.Duration {
+	if t.LockedUntil != nil && now.Before(*t.LockedUntil) {
+		return t.LockedUntil.Sub(now)
+	}
+	if t.Failures < c.FreeAttempts || now.Sub(t.LastFailureAt) > c.LockDuration {
+		return 0
+	}
+	delay := c.MaxDelay
+	// Avoid overflow of the shift.
+	if n := t.Failures - c.FreeAttempts; n < 32 {
+		if d := c.Delay << n; d > 0 && d < c.MaxDelay {
+			delay = d
+		}
+	}
+	if next := t.LastFailureAt.Add(delay); now.Before(next) {
+		return next.Sub(now)
+	}
+
+	return 0
+}
+
+// loginPolicies returns the throttling policies for the login and the client's IP.
+func (g *GopherMart) loginPolicies() []loginPolicy {
+	return []loginPolicy{
+		{prefix: loginKeyPrefix, lockAfter: g.loginThrottle.LockAfter},
+		{prefix: ipKeyPrefix, lockAfter: g.loginThrottle.IPLockAfter},
+	}
+}
+
+// throttleKeys returns the keys of the login attempt by the policies.
+func throttleKeys(login, ip string) map[string]string {
+	keys := map[string]string{loginKeyPrefix: loginKey(login)}
+	if ip != "" {
+		keys[ipKeyPrefix] = ipKeyPrefix + ip
+	}
+
+	return keys
+}
+
+func loginKey(login string) string {
+	return loginKeyPrefix + strings.ToLower(login)
+}
+
+// takeLoginAttempt counts the login attempt as failed by the login and the IP address before the credentials
+// are checked and returns the updated info by the key. If the login or the IP address must wait, LoginThrottledError
+// is returned and the attempt isn't counted. The info is checked and the attempt is taken by a conditional update,
+// so the concurrent attempts can't pass the check together: the one that lost the race checks the new info.
+func (g *GopherMart) takeLoginAttempt(ctx context.Context, keys map[string]string, now time.Time) (map[string]*model.LoginThrottle, error) {
+	// Check all the keys first, so the attempt isn't counted by some of them if another one must wait.
+	seen := make(map[string]int, len(keys))
+	var retryAfter time.Duration
+	for _, key := range keys {
+		failures, d, err := g.loginRetryAfter(ctx, key, now)
+		if err != nil {
+			return nil, err
+		}
+		seen[key] = failures
+		if d > retryAfter {
+			retryAfter = d
+		}
+	}
+	if retryAfter > 0 {
+		return nil, &LoginThrottledError{RetryAfter: retryAfter}
+	}
+
+	taken := make(map[string]*model.LoginThrottle, len(keys))
+	for _, key := range keys {
+		for {
+			t, err := g.db.TakeLoginAttempt(ctx, key, seen[key], now, g.loginThrottle.LockDuration)
+			if err == nil {
+				taken[key] = t
+
+				break
+			}
+			if !errors.Is(err, storage.ErrConflict) {
+				return nil, err
+			}
+			// Another attempt has been taken concurrently: check the key once again.
+			failures, d, err := g.loginRetryAfter(ctx, key, now)
+			if err != nil {
+				return nil, err
+			}
+			if d > 0 {
+				return nil, &LoginThrottledError{RetryAfter: d}
+			}
+			seen[key] = failures
+		}
+	}
+
+	return taken, nil
+}
+
+// loginRetryAfter returns the number of failures by the key and the time the key must wait before
+// the next attempt.
+func (g *GopherMart) loginRetryAfter(ctx context.Context, key string, now time.Time) (int, time.Duration, error) {
+	t, err := g.db.LoginThrottle(ctx, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return 0, 0, nil
+		}
+
+		return 0, 0, err
+	}
+
+	return t.Failures, g.loginThrottle.retryAfter(*t, now), nil
+}
+
+// forgiveLoginAttempt takes back the attempt counted by takeLoginAttempt when the credentials are correct.
+// The errors are only logged.
+func (g *GopherMart) forgiveLoginAttempt(ctx context.Context, keys map[string]string) {
+	log := appContext.Logger(ctx)
+	for _, key := range keys {
+		if err := g.db.ForgiveLoginAttempt(ctx, key); err != nil {
+			log.Error().Err(err).Msg("could not take back the login attempt")
+		}
+	}
+}
+
+// registerLoginFailure locks the keys whose failed attempts taken by takeLoginAttempt exceeded the limits.
+// The errors are only logged: the caller returns ErrWrongCredentials anyway.
+func (g *GopherMart) registerLoginFailure(ctx context.Context, keys map[string]string,
+	taken map[string]*model.LoginThrottle, now time.Time) {
+	log := appContext.Logger(ctx)
+	for _, p := range g.loginPolicies() {
+		key, ok := keys[p.prefix]
+		if !ok {
+			continue
+		}
+		t, ok := taken[key]
+		if !ok {
+			continue
+		}
+		if t.Failures >= p.lockAfter && (t.LockedUntil == nil || !now.Before(*t.LockedUntil)) {
+			if err := g.db.LockLogin(ctx, key, now.Add(g.loginThrottle.LockDuration)); err != nil {
+				log.Error().Err(err).Msg("could not lock the login")
+
+				continue
+			}
+			log.Warn().Str("key", key).Int("failures", t.Failures).Msg("too many failed login attempts, locked")
+		}
+	}
+}
+
+// compareDummyHash spends the same time as the password check of an existing user.
+func (g *GopherMart) compareDummyHash(password string) {
+	g.dummyHashOnce.Do(func() {
+		g.dummyHash, _ = g.hasher.Hash(uuid.NewString())
+	})
+	_, _ = g.hasher.Verify(password, g.dummyHash)
+}
+
+// UnlockUser implements Service interface.
+func (g *GopherMart) UnlockUser(ctx context.Context, userID uuid.UUID) error {
+	log := userLogger(ctx).With().Str("service:", "UnlockUser").Str("userID", userID.String()).Logger()
+
+	user, err := g.db.UserByID(ctx, userID)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return fmt.Errorf("service: UnlockUser: %w", err)
+	}
+	if err := g.db.DeleteLoginThrottle(ctx, loginKey(user.Login)); err != nil {
+		log.Trace().Err(err).Msg("")
+		return fmt.Errorf("service: UnlockUser: %w", err)
+	}
+	log.Info().Msg("user's login unlocked")
+
+	return nil
+}
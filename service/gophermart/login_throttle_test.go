@@ -0,0 +1,207 @@
+package gophermart_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/pkg/logging"
+	"github.com/vanamelnik/gophermart/pkg/totp"
+	"github.com/vanamelnik/gophermart/service/gophermart"
+	"github.com/vanamelnik/gophermart/storage"
+	"github.com/vanamelnik/gophermart/storage/memory"
+	mockstorage "github.com/vanamelnik/gophermart/storage/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginThrottle(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	db := mockstorage.NewMockStorage(mockCtrl)
+	ctx, s, err := initServices(db, pepper)
+	require.NoError(t, err)
+
+	const (
+		login = "gollum@misty.mountains"
+		ip    = "192.0.2.7"
+	)
+	hash, err := bcryptHash("MyPrecious!", pepper)
+	require.NoError(t, err)
+	user := &model.User{ID: uuid.New(), Login: login, PasswordHash: hash}
+
+	t.Run("#1 Locked login", func(t *testing.T) {
+		until := time.Now().Add(10 * time.Minute)
+		db.EXPECT().LoginThrottle(gomock.Any(), "login:"+login).
+			Return(&model.LoginThrottle{Failures: 10, LastFailureAt: time.Now(), LockedUntil: &until}, nil)
+		db.EXPECT().LoginThrottle(gomock.Any(), "ip:"+ip).Return(nil, storage.ErrNotFound)
+
+		_, err := s.Authenticate(ctx, login, "MyPrecious!", ip)
+		assert.ErrorIs(t, err, gophermart.ErrTooManyAttempts)
+		var throttled *gophermart.LoginThrottledError
+		require.True(t, errors.As(err, &throttled))
+		assert.InDelta(t, 10*time.Minute, throttled.RetryAfter, float64(time.Second))
+	})
+
+	t.Run("#2 Progressive delay", func(t *testing.T) {
+		// 5 failures = 2 failures beyond 3 free attempts: the delay is 1s*2^2.
+		db.EXPECT().LoginThrottle(gomock.Any(), "login:"+login).
+			Return(&model.LoginThrottle{Failures: 5, LastFailureAt: time.Now()}, nil)
+		db.EXPECT().LoginThrottle(gomock.Any(), "ip:"+ip).Return(nil, storage.ErrNotFound)
+
+		_, err := s.Authenticate(ctx, login, "MyPrecious!", ip)
+		var throttled *gophermart.LoginThrottledError
+		require.True(t, errors.As(err, &throttled))
+		assert.InDelta(t, 4*time.Second, throttled.RetryAfter, float64(time.Second))
+	})
+
+	t.Run("#3 Delay is over", func(t *testing.T) {
+		db.EXPECT().LoginThrottle(gomock.Any(), "login:"+login).
+			Return(&model.LoginThrottle{Failures: 5, LastFailureAt: time.Now().Add(-5 * time.Second)}, nil)
+		db.EXPECT().LoginThrottle(gomock.Any(), "ip:"+ip).Return(nil, storage.ErrNotFound)
+		db.EXPECT().TakeLoginAttempt(gomock.Any(), "login:"+login, 5, gomock.Any(), gomock.Any()).
+			Return(&model.LoginThrottle{Failures: 6}, nil)
+		db.EXPECT().TakeLoginAttempt(gomock.Any(), "ip:"+ip, 0, gomock.Any(), gomock.Any()).
+			Return(&model.LoginThrottle{Failures: 1}, nil)
+		db.EXPECT().UserByLogin(gomock.Any(), login).Return(user, nil)
+		db.EXPECT().TwoFactor(gomock.Any(), user.ID).Return(nil, storage.ErrNotFound)
+		db.EXPECT().DeleteLoginThrottle(gomock.Any(), "login:"+login).Return(nil)
+		// The attempt is taken back after the password is verified.
+		db.EXPECT().ForgiveLoginAttempt(gomock.Any(), "login:"+login).Return(nil)
+		db.EXPECT().ForgiveLoginAttempt(gomock.Any(), "ip:"+ip).Return(nil)
+
+		_, err := s.Authenticate(ctx, login, "MyPrecious!", ip)
+		assert.NoError(t, err)
+	})
+
+	t.Run("#4 Lock after too many failures", func(t *testing.T) {
+		db.EXPECT().LoginThrottle(gomock.Any(), gomock.Any()).Return(nil, storage.ErrNotFound).Times(2)
+		db.EXPECT().UserByLogin(gomock.Any(), login).Return(user, nil)
+		db.EXPECT().TakeLoginAttempt(gomock.Any(), "login:"+login, 0, gomock.Any(), gomock.Any()).
+			Return(&model.LoginThrottle{Key: "login:" + login, Failures: 10}, nil)
+		db.EXPECT().TakeLoginAttempt(gomock.Any(), "ip:"+ip, 0, gomock.Any(), gomock.Any()).
+			Return(&model.LoginThrottle{Key: "ip:" + ip, Failures: 10}, nil)
+		// The ip address has a higher limit and isn't locked.
+		db.EXPECT().LockLogin(gomock.Any(), "login:"+login, gomock.Any()).Return(nil)
+
+		_, err := s.Authenticate(ctx, login, "YourPrecious?", ip)
+		assert.ErrorIs(t, err, gophermart.ErrWrongCredentials)
+	})
+
+	t.Run("#5 Unknown login", func(t *testing.T) {
+		db.EXPECT().LoginThrottle(gomock.Any(), gomock.Any()).Return(nil, storage.ErrNotFound).Times(2)
+		db.EXPECT().UserByLogin(gomock.Any(), "smeagol@misty.mountains").Return(nil, storage.ErrNotFound)
+		db.EXPECT().TakeLoginAttempt(gomock.Any(), gomock.Any(), 0, gomock.Any(), gomock.Any()).
+			Return(&model.LoginThrottle{Failures: 1}, nil).Times(2)
+
+		_, err := s.Authenticate(ctx, "smeagol@misty.mountains", "MyPrecious!", ip)
+		assert.ErrorIs(t, err, gophermart.ErrWrongCredentials)
+	})
+
+	t.Run("#6 Concurrent attempt", func(t *testing.T) {
+		// Another attempt has been taken after the check: the key is checked once again and must wait now.
+		gomock.InOrder(
+			db.EXPECT().LoginThrottle(gomock.Any(), "login:"+login).
+				Return(&model.LoginThrottle{Failures: 2, LastFailureAt: time.Now()}, nil),
+			db.EXPECT().TakeLoginAttempt(gomock.Any(), "login:"+login, 2, gomock.Any(), gomock.Any()).
+				Return(nil, storage.ErrConflict),
+			db.EXPECT().LoginThrottle(gomock.Any(), "login:"+login).
+				Return(&model.LoginThrottle{Failures: 3, LastFailureAt: time.Now()}, nil),
+		)
+		db.EXPECT().LoginThrottle(gomock.Any(), "ip:"+ip).Return(nil, storage.ErrNotFound)
+		db.EXPECT().TakeLoginAttempt(gomock.Any(), "ip:"+ip, 0, gomock.Any(), gomock.Any()).
+			Return(&model.LoginThrottle{Failures: 1}, nil).MaxTimes(1)
+
+		_, err := s.Authenticate(ctx, login, "YourPrecious?", ip)
+		var throttled *gophermart.LoginThrottledError
+		require.True(t, errors.As(err, &throttled))
+		assert.InDelta(t, time.Second, throttled.RetryAfter, float64(time.Second))
+	})
+
+	t.Run("#7 Unlock", func(t *testing.T) {
+		db.EXPECT().UserByID(gomock.Any(), user.ID).Return(user, nil)
+		db.EXPECT().DeleteLoginThrottle(gomock.Any(), "login:"+login).Return(nil)
+
+		assert.NoError(t, s.UnlockUser(ctx, user.ID))
+	})
+}
+
+func TestTwoFactorLockout(t *testing.T) {
+	ctx := appContext.WithLogger(context.Background(), logging.NewLogger(logging.WithConsoleOutput(true)))
+	s, err := gophermart.New(ctx, memory.New(),
+		gophermart.WithConfig(gophermart.Config{
+			PasswordPepper: pepper,
+			TokenSecret:    tokenSecret,
+			// No delays, so the test reaches the lock at once.
+			LoginThrottle: gophermart.LoginThrottleConfig{FreeAttempts: 100, LockAfter: 3, IPLockAfter: 100},
+		}),
+		gophermart.WithoutWorkers())
+	require.NoError(t, err)
+
+	const (
+		password = "MyPrecious!"
+		ip       = "192.0.2.7"
+	)
+	user, err := s.Create(ctx, "gollum@misty.mountains", password)
+	require.NoError(t, err)
+	userCtx := appContext.WithUser(ctx, &user)
+	enrollment, err := s.EnrollTwoFactor(userCtx)
+	require.NoError(t, err)
+	code, err := totp.Code(enrollment.Secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	_, err = s.ConfirmTwoFactor(userCtx, code)
+	require.NoError(t, err)
+
+	// The password is right, but each fresh challenge gets a wrong code.
+	for i := 0; i < 3; i++ {
+		u, err := s.Authenticate(ctx, user.Login, password, ip)
+		require.NoError(t, err, "attempt %d", i)
+		challenge, err := s.TwoFactorChallenge(ctx, u)
+		require.NoError(t, err)
+		_, err = s.CompleteTwoFactorLogin(ctx, challenge, "aaaa-bbbb-cccc-dddd", ip)
+		require.ErrorIs(t, err, gophermart.ErrInvalidCode)
+	}
+
+	_, err = s.Authenticate(ctx, user.Login, password, ip)
+	assert.ErrorIs(t, err, gophermart.ErrTooManyAttempts, "the login is locked")
+}
+
+func TestConcurrentLoginAttempts(t *testing.T) {
+	ctx := appContext.WithLogger(context.Background(), logging.NewLogger(logging.WithConsoleOutput(true)))
+	s, err := gophermart.New(ctx, memory.New(),
+		gophermart.WithConfig(gophermart.Config{
+			PasswordPepper: pepper,
+			TokenSecret:    tokenSecret,
+			LoginThrottle:  gophermart.LoginThrottleConfig{FreeAttempts: 3, Delay: time.Minute},
+		}),
+		gophermart.WithoutWorkers())
+	require.NoError(t, err)
+	user, err := s.Create(ctx, "gollum@misty.mountains", "MyPrecious!")
+	require.NoError(t, err)
+
+	// The concurrent attempts can't pass the check together: only the free attempts are checked.
+	const attempts = 20
+	errs := make(chan error, attempts)
+	for i := 0; i < attempts; i++ {
+		go func() {
+			_, err := s.Authenticate(ctx, user.Login, "YourPrecious?", "")
+			errs <- err
+		}()
+	}
+	wrong := 0
+	for i := 0; i < attempts; i++ {
+		err := <-errs
+		if errors.Is(err, gophermart.ErrWrongCredentials) {
+			wrong++
+			continue
+		}
+		assert.ErrorIs(t, err, gophermart.ErrTooManyAttempts)
+	}
+	assert.Equal(t, 3, wrong)
+}
@@ -0,0 +1,200 @@
+package gophermart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/pkg/token"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+const (
+	apiKeyPrefix     = "gmk_"
+	apiKeySize       = 32
+	apiKeyPrefixSize = 12
+	// apiKeyTouchInterval limits the updates of the key's last used time.
+	apiKeyTouchInterval = time.Minute
+)
+
+// CreateMerchant implements Service interface.
+func (g *GopherMart) CreateMerchant(ctx context.Context, name string) (model.Merchant, error) {
+	log := userLogger(ctx).With().Str("service:", "CreateMerchant").Logger()
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		log.Trace().Err(ErrInvalidMerchant).Msg("")
+		return model.Merchant{}, ErrInvalidMerchant
+	}
+	merchant := model.Merchant{
+		ID:        uuid.New(),
+		Name:      name,
+		CreatedAt: time.Now(),
+	}
+	if err := g.db.CreateMerchant(ctx, &merchant); err != nil {
+		log.Trace().Err(err).Msg("")
+		return model.Merchant{}, fmt.Errorf("service: CreateMerchant: %w", err)
+	}
+	log.Info().Str("merchant", name).Msg("merchant created")
+
+	return merchant, nil
+}
+
+// Merchants implements Service interface.
+func (g *GopherMart) Merchants(ctx context.Context) ([]model.Merchant, error) {
+	log := userLogger(ctx).With().Str("service:", "Merchants").Logger()
+
+	merchants, err := g.db.Merchants(ctx)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return nil, fmt.Errorf("service: Merchants: %w", err)
+	}
+
+	return merchants, nil
+}
+
+// CreateAPIKey implements Service interface.
+func (g *GopherMart) CreateAPIKey(ctx context.Context, merchantID uuid.UUID, scopes []model.Scope) (string, model.APIKey, error) {
+	log := userLogger(ctx).With().Str("service:", "CreateAPIKey").Str("merchantID", merchantID.String()).Logger()
+
+	if len(scopes) == 0 {
+		log.Trace().Err(ErrInvalidScope).Msg("")
+		return "", model.APIKey{}, ErrInvalidScope
+	}
+	for _, scope := range scopes {
+		if !scope.Valid() {
+			log.Trace().Err(ErrInvalidScope).Str("scope", string(scope)).Msg("")
+			return "", model.APIKey{}, ErrInvalidScope
+		}
+	}
+	if _, err := g.db.MerchantByID(ctx, merchantID); err != nil {
+		log.Trace().Err(err).Msg("")
+		return "", model.APIKey{}, fmt.Errorf("service: CreateAPIKey: %w", err)
+	}
+	secret, err := token.Generate(apiKeySize)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return "", model.APIKey{}, fmt.Errorf("service: CreateAPIKey: %w", err)
+	}
+	key := apiKeyPrefix + secret
+	apiKey := model.APIKey{
+		ID:         uuid.New(),
+		MerchantID: merchantID,
+		Prefix:     key[:apiKeyPrefixSize],
+		KeyHash:    token.Hash(g.tokenSecret, key),
+		Scopes:     scopes,
+		CreatedAt:  time.Now(),
+	}
+	if err := g.db.CreateAPIKey(ctx, &apiKey); err != nil {
+		log.Trace().Err(err).Msg("")
+		return "", model.APIKey{}, fmt.Errorf("service: CreateAPIKey: %w", err)
+	}
+	log.Info().Str("prefix", apiKey.Prefix).Msg("API key created")
+
+	return key, apiKey, nil
+}
+
+// MerchantAPIKeys implements Service interface.
+func (g *GopherMart) MerchantAPIKeys(ctx context.Context, merchantID uuid.UUID) ([]model.APIKey, error) {
+	log := userLogger(ctx).With().Str("service:", "MerchantAPIKeys").Str("merchantID", merchantID.String()).Logger()
+
+	if _, err := g.db.MerchantByID(ctx, merchantID); err != nil {
+		log.Trace().Err(err).Msg("")
+		return nil, fmt.Errorf("service: MerchantAPIKeys: %w", err)
+	}
+	keys, err := g.db.MerchantAPIKeys(ctx, merchantID)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return nil, fmt.Errorf("service: MerchantAPIKeys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey implements Service interface.
+func (g *GopherMart) RevokeAPIKey(ctx context.Context, merchantID, keyID uuid.UUID) error {
+	log := userLogger(ctx).With().Str("service:", "RevokeAPIKey").
+		Str("merchantID", merchantID.String()).
+		Str("keyID", keyID.String()).
+		Logger()
+
+	if err := g.db.RevokeAPIKey(ctx, merchantID, keyID, time.Now()); err != nil {
+		log.Trace().Err(err).Msg("")
+		return fmt.Errorf("service: RevokeAPIKey: %w", err)
+	}
+	log.Info().Msg("API key revoked")
+
+	return nil
+}
+
+// AuthenticateAPIKey implements Service interface.
+func (g *GopherMart) AuthenticateAPIKey(ctx context.Context, key string) (*model.APIKey, error) {
+	log := appContext.Logger(ctx).With().Str("service:", "AuthenticateAPIKey").Logger()
+
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		log.Trace().Err(ErrInvalidAPIKey).Msg("")
+		return nil, ErrInvalidAPIKey
+	}
+	apiKey, err := g.db.APIKeyByHash(ctx, token.Hash(g.tokenSecret, key))
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+
+		return nil, fmt.Errorf("service: AuthenticateAPIKey: %w", err)
+	}
+	if apiKey.Revoked() {
+		log.Trace().Err(ErrInvalidAPIKey).Str("prefix", apiKey.Prefix).Msg("revoked")
+		return nil, ErrInvalidAPIKey
+	}
+	now := time.Now()
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > apiKeyTouchInterval {
+		if err := g.db.TouchAPIKey(ctx, apiKey.ID, now); err != nil {
+			log.Error().Err(err).Msg("could not update the key's last used time")
+		}
+	}
+
+	return apiKey, nil
+}
+
+// SubmitMerchantOrder implements Service interface.
+func (g *GopherMart) SubmitMerchantOrder(ctx context.Context, login string, orderID model.OrderID) error {
+	log := appContext.Logger(ctx).With().Str("service:", "SubmitMerchantOrder").
+		Str("login", login).
+		Str("order ID", string(orderID)).
+		Logger()
+
+	apiKey := appContext.APIKey(ctx)
+	if apiKey == nil {
+		log.Trace().Err(ErrNotAuthenticated).Msg("")
+		return ErrNotAuthenticated
+	}
+	log = log.With().Str("merchantID", apiKey.MerchantID.String()).Logger()
+	if !orderID.Valid() {
+		log.Trace().Err(ErrInvalidOrderNumber).Msg("")
+		return ErrInvalidOrderNumber
+	}
+	user, err := g.db.UserByLogin(ctx, login)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return fmt.Errorf("service: SubmitMerchantOrder: %w", err)
+	}
+	if user.Blocked {
+		log.Trace().Err(ErrUserBlocked).Msg("")
+		return ErrUserBlocked
+	}
+
+	if err := g.storeOrder(ctx, log, "SubmitMerchantOrder", user.ID, orderID); err != nil {
+		return err
+	}
+	log.Info().Msg("the order has been submitted by the merchant")
+
+	return nil
+}
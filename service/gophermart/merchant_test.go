@@ -0,0 +1,132 @@
+package gophermart_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/pkg/token"
+	"github.com/vanamelnik/gophermart/service/gophermart"
+	"github.com/vanamelnik/gophermart/storage"
+	mockstorage "github.com/vanamelnik/gophermart/storage/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeys(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	db := mockstorage.NewMockStorage(mockCtrl)
+	ctx, s, err := initServices(db, pepper)
+	require.NoError(t, err)
+	merchantID := uuid.New()
+
+	t.Run("#1 Invalid scope", func(t *testing.T) {
+		_, _, err := s.CreateAPIKey(ctx, merchantID, nil)
+		assert.ErrorIs(t, err, gophermart.ErrInvalidScope)
+		_, _, err = s.CreateAPIKey(ctx, merchantID, []model.Scope{"orders:delete"})
+		assert.ErrorIs(t, err, gophermart.ErrInvalidScope)
+	})
+
+	var key string
+	var stored *model.APIKey
+	t.Run("#2 Create", func(t *testing.T) {
+		db.EXPECT().MerchantByID(gomock.Any(), merchantID).Return(&model.Merchant{ID: merchantID}, nil)
+		db.EXPECT().CreateAPIKey(gomock.Any(), gomock.Any()).DoAndReturn(func(_ interface{}, k *model.APIKey) error {
+			stored = k
+			return nil
+		})
+		var apiKey model.APIKey
+		key, apiKey, err = s.CreateAPIKey(ctx, merchantID, []model.Scope{model.ScopeOrdersSubmit})
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(key, "gmk_"))
+		assert.True(t, strings.HasPrefix(key, apiKey.Prefix))
+		assert.Equal(t, token.Hash(tokenSecret, key), stored.KeyHash)
+		assert.NotContains(t, stored.KeyHash, key)
+	})
+	t.Run("#3 Authenticate", func(t *testing.T) {
+		db.EXPECT().APIKeyByHash(gomock.Any(), stored.KeyHash).Return(stored, nil)
+		db.EXPECT().TouchAPIKey(gomock.Any(), stored.ID, gomock.Any()).Return(nil)
+		apiKey, err := s.AuthenticateAPIKey(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, merchantID, apiKey.MerchantID)
+	})
+	t.Run("#4 Revoked key", func(t *testing.T) {
+		revoked := *stored
+		now := time.Now()
+		revoked.RevokedAt = &now
+		db.EXPECT().APIKeyByHash(gomock.Any(), stored.KeyHash).Return(&revoked, nil)
+		_, err := s.AuthenticateAPIKey(ctx, key)
+		assert.ErrorIs(t, err, gophermart.ErrInvalidAPIKey)
+	})
+	t.Run("#5 Unknown key", func(t *testing.T) {
+		db.EXPECT().APIKeyByHash(gomock.Any(), gomock.Any()).Return(nil, storage.ErrNotFound)
+		_, err := s.AuthenticateAPIKey(ctx, "gmk_unknown")
+		assert.ErrorIs(t, err, gophermart.ErrInvalidAPIKey)
+		_, err = s.AuthenticateAPIKey(ctx, "not a key")
+		assert.ErrorIs(t, err, gophermart.ErrInvalidAPIKey)
+	})
+}
+
+func TestSubmitMerchantOrder(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	db := mockstorage.NewMockStorage(mockCtrl)
+	ctx, s, err := initServices(db, pepper)
+	require.NoError(t, err)
+	// The merchant's request has no authenticated user.
+	ctx = appContext.WithLogger(context.Background(), appContext.Logger(ctx))
+	ctx = appContext.WithAPIKey(ctx, &model.APIKey{ID: uuid.New(), MerchantID: uuid.New()})
+
+	customer := &model.User{ID: uuid.New(), Login: "sam@hobbyton.shire.me"}
+	const orderID = model.OrderID("12345678903")
+
+	t.Run("#1 Not authenticated", func(t *testing.T) {
+		err := s.SubmitMerchantOrder(appContext.WithAPIKey(ctx, nil), customer.Login, orderID)
+		assert.ErrorIs(t, err, gophermart.ErrNotAuthenticated)
+	})
+	t.Run("#2 Invalid order number", func(t *testing.T) {
+		err := s.SubmitMerchantOrder(ctx, customer.Login, "12345678900")
+		assert.ErrorIs(t, err, gophermart.ErrInvalidOrderNumber)
+	})
+	t.Run("#3 Unknown user", func(t *testing.T) {
+		db.EXPECT().UserByLogin(gomock.Any(), "nobody").Return(nil, storage.ErrNotFound)
+		err := s.SubmitMerchantOrder(ctx, "nobody", orderID)
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	})
+	t.Run("#4 New order", func(t *testing.T) {
+		db.EXPECT().UserByLogin(gomock.Any(), customer.Login).Return(customer, nil)
+		db.EXPECT().OrderByID(gomock.Any(), orderID).Return(nil, storage.ErrNotFound)
+		db.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).DoAndReturn(func(_ interface{}, o *model.Order) error {
+			assert.Equal(t, customer.ID, o.UserID)
+			assert.Equal(t, model.StatusNew, o.Status)
+			return nil
+		})
+		assert.NoError(t, s.SubmitMerchantOrder(ctx, customer.Login, orderID))
+	})
+	t.Run("#5 Order of the same user", func(t *testing.T) {
+		db.EXPECT().UserByLogin(gomock.Any(), customer.Login).Return(customer, nil)
+		db.EXPECT().OrderByID(gomock.Any(), orderID).Return(&model.Order{ID: orderID, UserID: customer.ID}, nil)
+		err := s.SubmitMerchantOrder(ctx, customer.Login, orderID)
+		assert.ErrorIs(t, err, gophermart.ErrOrderExecutedBySameUser)
+	})
+	t.Run("#6 Order of another user", func(t *testing.T) {
+		db.EXPECT().UserByLogin(gomock.Any(), customer.Login).Return(customer, nil)
+		db.EXPECT().OrderByID(gomock.Any(), orderID).Return(&model.Order{ID: orderID, UserID: uuid.New()}, nil)
+		err := s.SubmitMerchantOrder(ctx, customer.Login, orderID)
+		assert.ErrorIs(t, err, gophermart.ErrOrderExecutedByAnotherUser)
+	})
+	t.Run("#7 Blocked user", func(t *testing.T) {
+		blocked := *customer
+		blocked.Blocked = true
+		db.EXPECT().UserByLogin(gomock.Any(), customer.Login).Return(&blocked, nil)
+		err := s.SubmitMerchantOrder(ctx, customer.Login, orderID)
+		assert.ErrorIs(t, err, gophermart.ErrUserBlocked)
+	})
+}
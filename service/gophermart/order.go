@@ -8,7 +8,21 @@ import (
 
 	"github.com/vanamelnik/gophermart/model"
 	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/pkg/metrics"
 	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// orphanedWithdrawalAge is the age of 'PROCESSING' withdrawals considered orphaned, so the withdrawals
+	// still being processed by the previous version of the service during the rollout aren't rejected.
+	orphanedWithdrawalAge = time.Minute
+	// defaultWithdrawalsRecoverInterval is the interval between the recoveries of orphaned withdrawals.
+	// The withdrawals orphaned by the instances of the previous version still running after the start
+	// are recovered by the next run.
+	defaultWithdrawalsRecoverInterval = 5 * time.Minute
 )
 
 // ProcessOrder implements Service interface.
@@ -22,11 +36,24 @@ func (g *GopherMart) ProcessOrder(ctx context.Context, orderID model.OrderID) er
 	}
 	log = log.With().Str("order ID", string(orderID)).Logger()
 
+	if err := g.storeOrder(ctx, log, "ProcessOrder", user.ID, orderID); err != nil {
+		return err
+	}
+	log.Trace().Msg("the order has been successfully stored in DB")
+
+	return nil
+}
+
+// storeOrder stores the new order of the user. If the order is already stored, either
+// ErrOrderExecutedBySameUser or ErrOrderExecutedByAnotherUser is returned. The other errors are wrapped
+// with the name of the caller's method.
+func (g *GopherMart) storeOrder(ctx context.Context, log zerolog.Logger, method string, userID uuid.UUID,
+	orderID model.OrderID) error {
 	// Check if the order is already stored in DB.
 	o, err := g.db.OrderByID(ctx, orderID)
 	switch {
 	case err == nil:
-		if o.UserID == user.ID {
+		if o.UserID == userID {
 			log.Trace().Err(ErrOrderExecutedBySameUser).Msg("")
 			return ErrOrderExecutedBySameUser
 		}
@@ -38,12 +65,12 @@ func (g *GopherMart) ProcessOrder(ctx context.Context, orderID model.OrderID) er
 
 	case !errors.Is(err, storage.ErrNotFound):
 		log.Trace().Err(err).Msg("")
-		return fmt.Errorf("service: ProcessOrder: %w", err)
+		return fmt.Errorf("service: %s: %w", method, err)
 	}
 
 	order := &model.Order{
 		ID:            orderID,
-		UserID:        user.ID,
+		UserID:        userID,
 		Status:        model.StatusNew,
 		AccrualPoints: 0,
 		UploadedAt:    time.Now(),
@@ -52,9 +79,8 @@ func (g *GopherMart) ProcessOrder(ctx context.Context, orderID model.OrderID) er
 		// storage.ErrAlreadyProcessed is an internal server error because the presence
 		// of an already loaded order in the database should have been determined by OrderById method.
 		log.Trace().Err(err).Msg("")
-		return fmt.Errorf("service: ProcessOrder: %w", err)
+		return fmt.Errorf("service: %s: %w", method, err)
 	}
-	log.Trace().Msg("the order has been successfully stored in DB")
 
 	return nil
 }
@@ -69,16 +95,54 @@ func (g *GopherMart) Withdraw(ctx context.Context, orderID model.OrderID, sum fl
 		return ErrNotAuthenticated
 	}
 
-	if err := g.db.ProcessWithdraw(ctx, &model.Withdrawal{
+	withdrawal := &model.Withdrawal{
 		UserID:      user.ID,
 		OrderID:     orderID,
 		Sum:         sum,
 		Status:      model.StatusProcessing,
 		ProcessedAt: time.Now(),
-	}); err != nil {
+	}
+	if err := g.db.ProcessWithdraw(ctx, withdrawal); err != nil {
 		log.Trace().Err(err).Msg("")
 		return fmt.Errorf("service: withdraw: %w", err)
 	}
+	metrics.PointsWithdrawn.Add(float64(withdrawal.Sum))
 
 	return nil
 }
+
+// RecoverWithdrawals implements Service interface.
+func (g *GopherMart) RecoverWithdrawals(ctx context.Context) error {
+	log := appContext.Logger(ctx).With().Str("service:", "RecoverWithdrawals").Logger()
+
+	n, err := g.db.RecoverWithdrawals(ctx, time.Now().Add(-orphanedWithdrawalAge))
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return fmt.Errorf("service: RecoverWithdrawals: %w", err)
+	}
+	if n > 0 {
+		log.Warn().Int("number of withdrawals rejected", n).Msg("orphaned withdrawals recovered")
+	}
+
+	return nil
+}
+
+// withdrawalsRecoverer periodically rejects the orphaned withdrawals (see RecoverWithdrawals).
+func (g *GopherMart) withdrawalsRecoverer(ctx context.Context) {
+	log := appContext.Logger(ctx).With().Str("service:", "withdrawalsRecoverer").Logger()
+	log.Info().Msg("withdrawalsRecoverer started")
+	t := time.NewTicker(g.withdrawalsRecoverInterval)
+	defer t.Stop()
+loop:
+	for {
+		select {
+		case <-t.C:
+			if err := g.RecoverWithdrawals(ctx); err != nil {
+				log.Error().Err(err).Msg("")
+			}
+		case <-g.workersStop:
+			break loop
+		}
+	}
+	log.Info().Msg("withdrawalsRecoverer stopped")
+}
@@ -0,0 +1,74 @@
+package gophermart
+
+import (
+	"context"
+	"time"
+
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+)
+
+const (
+	// outboxBatchSize is the maximum number of events published on each tick.
+	outboxBatchSize = 500
+	// outboxClaimTTL is the time the events claimed are hidden from the relays of other instances of the service.
+	// It exceeds the time of publishing a batch, so the events of a crashed instance are published after it.
+	outboxClaimTTL = 5 * time.Minute
+)
+
+// outboxRelay publishes the domain events written to the outbox by the storage and queues their webhook
+// deliveries. Only the events of committed transactions get into the outbox, so the consumers and the partners
+// receive exactly the changes that committed. The events are delivered at least once.
+func (g *GopherMart) outboxRelay(ctx context.Context) {
+	log := appContext.Logger(ctx).With().Str("service:", "outboxRelay").Logger()
+	log.Info().Msg("outboxRelay started")
+	t := time.NewTicker(g.outboxRelayInterval)
+loop:
+	for {
+		select {
+		case <-t.C:
+			n, err := g.relayEvents(ctx)
+			if err != nil {
+				log.Error().Err(err).Msg("")
+
+				continue
+			}
+			if n > 0 {
+				log.Debug().Int("number of events published", n).Msg("")
+			}
+		case <-g.workersStop:
+			break loop
+		}
+	}
+	log.Info().Msg("outboxRelay stopped")
+}
+
+// relayEvents claims a batch of unpublished events, queues their webhook deliveries, publishes them and marks
+// them as published. The events claimed aren't published by other instances of the service.
+func (g *GopherMart) relayEvents(ctx context.Context) (int, error) {
+	now := time.Now()
+	events, err := g.db.ClaimUnpublishedEvents(ctx, now, now.Add(outboxClaimTTL), outboxBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	if err := g.queueWebhookDeliveries(ctx, events); err != nil {
+		return 0, err
+	}
+	if g.publisher != nil {
+		if err := g.publisher.Publish(ctx, events); err != nil {
+			return 0, err
+		}
+	}
+	ids := make([]int64, len(events))
+	for i, e := range events {
+		ids[i] = e.ID
+	}
+	if err := g.db.MarkEventsPublished(ctx, ids, time.Now()); err != nil {
+		return 0, err
+	}
+
+	return len(events), nil
+}
@@ -0,0 +1,132 @@
+package gophermart
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/pkg/logging"
+	"github.com/vanamelnik/gophermart/provider/publisher"
+	mockstorage "github.com/vanamelnik/gophermart/storage/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelayEvents(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	db := mockstorage.NewMockStorage(mockCtrl)
+	ctx := appContext.WithLogger(context.Background(),
+		logging.NewLogger(logging.WithConsoleOutput(true), logging.WithLevel("trace")))
+	pub := publisher.NewMemory()
+	g, err := New(ctx, db, WithPublisher(pub), WithoutWorkers())
+	require.NoError(t, err)
+
+	events := []model.Event{
+		{ID: 1, Type: model.EventOrderCreated, AggregateID: "117"},
+		{ID: 2, Type: model.EventOrderProcessed, AggregateID: "117"},
+	}
+
+	t.Run("#1 Nothing to publish", func(t *testing.T) {
+		db.EXPECT().ClaimUnpublishedEvents(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, now, claimUntil time.Time, _ int) ([]model.Event, error) {
+				assert.Equal(t, outboxClaimTTL, claimUntil.Sub(now))
+
+				return []model.Event{}, nil
+			}).Times(1)
+		n, err := g.relayEvents(ctx)
+		assert.NoError(t, err)
+		assert.Zero(t, n)
+	})
+	t.Run("#2 Storage error, the events stay in the outbox", func(t *testing.T) {
+		db.EXPECT().ClaimUnpublishedEvents(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("db is down")).Times(1)
+		_, err := g.relayEvents(ctx)
+		assert.Error(t, err)
+		assert.Empty(t, pub.Events())
+	})
+	t.Run("#3 Normal case", func(t *testing.T) {
+		db.EXPECT().ClaimUnpublishedEvents(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(events, nil).Times(1)
+		db.EXPECT().Webhooks(gomock.Any()).Return([]model.Webhook{}, nil).Times(1)
+		db.EXPECT().MarkEventsPublished(gomock.Any(), []int64{1, 2}, gomock.Any()).Return(nil).Times(1)
+		n, err := g.relayEvents(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, n)
+		assert.Equal(t, events, pub.Events())
+	})
+}
+
+func TestRelayWebhookDeliveries(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	db := mockstorage.NewMockStorage(mockCtrl)
+	ctx := appContext.WithLogger(context.Background(),
+		logging.NewLogger(logging.WithConsoleOutput(true), logging.WithLevel("trace")))
+	// Without the publisher the events only go to the webhooks.
+	g, err := New(ctx, db, WithoutWorkers())
+	require.NoError(t, err)
+
+	invalid, err := json.Marshal(model.OrderEvent{Order: "117", Status: model.StatusInvalid})
+	require.NoError(t, err)
+	processing, err := json.Marshal(model.OrderEvent{Order: "125", Status: model.StatusProcessing})
+	require.NoError(t, err)
+	events := []model.Event{
+		{ID: 1, Type: model.EventOrderCreated, AggregateID: "117", Payload: []byte(`{}`)},
+		{ID: 2, Type: model.EventOrderStatusChanged, AggregateID: "117", Payload: invalid},
+		{ID: 3, Type: model.EventOrderStatusChanged, AggregateID: "125", Payload: processing},
+		{ID: 4, Type: model.EventWithdrawalProcessed, AggregateID: "133", Payload: []byte(`{"order":"133"}`)},
+	}
+	hooks := []model.Webhook{
+		{ID: uuid.New(), EventTypes: []model.EventType{model.EventOrderInvalid}},
+		{ID: uuid.New(), EventTypes: []model.EventType{model.EventOrderProcessed, model.EventWithdrawalProcessed}},
+	}
+
+	var queued []model.WebhookDelivery
+	t.Run("#1 Storage error, the events stay in the outbox", func(t *testing.T) {
+		db.EXPECT().ClaimUnpublishedEvents(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(events, nil).Times(1)
+		db.EXPECT().Webhooks(gomock.Any()).Return(hooks, nil).Times(1)
+		db.EXPECT().CreateWebhookDelivery(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, d *model.WebhookDelivery) error {
+				queued = append(queued, *d)
+				return nil
+			}).Times(1)
+		db.EXPECT().CreateWebhookDelivery(gomock.Any(), gomock.Any()).Return(errors.New("db is down")).Times(1)
+		_, err := g.relayEvents(ctx)
+		assert.Error(t, err)
+	})
+	t.Run("#2 The deliveries are queued again with the same ids", func(t *testing.T) {
+		var retried []model.WebhookDelivery
+		db.EXPECT().ClaimUnpublishedEvents(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(events, nil).Times(1)
+		db.EXPECT().Webhooks(gomock.Any()).Return(hooks, nil).Times(1)
+		db.EXPECT().CreateWebhookDelivery(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, d *model.WebhookDelivery) error {
+				retried = append(retried, *d)
+				return nil
+			}).Times(2)
+		db.EXPECT().MarkEventsPublished(gomock.Any(), []int64{1, 2, 3, 4}, gomock.Any()).Return(nil).Times(1)
+		n, err := g.relayEvents(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 4, n)
+
+		require.Len(t, retried, 2)
+		assert.Equal(t, queued[0].ID, retried[0].ID)
+		assert.Equal(t, hooks[0].ID, retried[0].WebhookID)
+		assert.Equal(t, model.EventOrderInvalid, retried[0].EventType)
+		assert.Equal(t, model.DeliveryPending, retried[0].Status)
+		assert.Equal(t, hooks[1].ID, retried[1].WebhookID)
+		assert.Equal(t, model.EventWithdrawalProcessed, retried[1].EventType)
+		var payload struct {
+			ID   uuid.UUID       `json:"id"`
+			Data json.RawMessage `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(retried[1].Payload, &payload))
+		assert.Equal(t, retried[1].ID, payload.ID)
+		assert.JSONEq(t, `{"order":"133"}`, string(payload.Data))
+	})
+}
@@ -0,0 +1,192 @@
+package gophermart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/pkg/pwhash"
+	"github.com/vanamelnik/gophermart/pkg/pwpolicy"
+	"github.com/vanamelnik/gophermart/pkg/token"
+	"github.com/vanamelnik/gophermart/provider/notifier"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+const resetTokenSize = 32
+
+// ChangePassword implements Service interface.
+func (g *GopherMart) ChangePassword(ctx context.Context, currentPassword, newPassword string) error {
+	log := userLogger(ctx).With().Str("service:", "ChangePassword").Logger()
+
+	user := appContext.User(ctx)
+	if user == nil {
+		log.Trace().Err(ErrNotAuthenticated).Msg("")
+		return ErrNotAuthenticated
+	}
+	if _, err := g.hasher.Verify(currentPassword, user.PasswordHash); err != nil {
+		if errors.Is(err, pwhash.ErrMismatchedHashAndPassword) {
+			log.Trace().Err(err).Msg("")
+			return ErrWrongPassword
+		}
+		log.Trace().Err(err).Msg("")
+		return fmt.Errorf("service: ChangePassword: %w", err)
+	}
+	passwordHash, err := g.hashNewPassword(newPassword, user.Login)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return err
+	}
+
+	// The current session stays alive, all the others are ended.
+	keepSessionID := uuid.Nil
+	if session := appContext.Session(ctx); session != nil {
+		keepSessionID = session.ID
+	}
+	if err := g.db.ChangePassword(ctx, user.ID, passwordHash, keepSessionID); err != nil {
+		log.Trace().Err(err).Msg("")
+		return fmt.Errorf("service: ChangePassword: %w", err)
+	}
+	log.Info().Msg("password changed, other sessions are ended")
+
+	return nil
+}
+
+// RequestPasswordReset implements Service interface.
+func (g *GopherMart) RequestPasswordReset(ctx context.Context, login string) error {
+	log := appContext.Logger(ctx).With().Str("service:", "RequestPasswordReset").Str("login", login).Logger()
+
+	if g.notifier == nil {
+		log.Trace().Err(ErrPasswordResetDisabled).Msg("")
+		return ErrPasswordResetDisabled
+	}
+
+	// The user is looked up, and the token is created and sent in background, so neither the response time
+	// nor the response reveals whether the login exists. The request's context is done as soon as the response
+	// is sent, so the task gets its own context.
+	taskCtx := appContext.WithLogger(context.Background(), log)
+	g.tasksWg.Add(1)
+	go func() {
+		defer g.tasksWg.Done()
+		if err := g.sendPasswordReset(taskCtx, login); err != nil {
+			log.Error().Err(err).Msg("could not send password reset token")
+		}
+	}()
+
+	return nil
+}
+
+// sendPasswordReset creates a new password reset request of the user with the login provided and sends
+// its token to the user. If there's no such user or the user is blocked, nothing is sent.
+func (g *GopherMart) sendPasswordReset(ctx context.Context, login string) error {
+	log := appContext.Logger(ctx)
+
+	user, err := g.db.UserByLogin(ctx, login)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			log.Trace().Err(err).Msg("")
+			return nil
+		}
+
+		return fmt.Errorf("service: RequestPasswordReset: %w", err)
+	}
+	if user.Blocked {
+		log.Trace().Err(ErrUserBlocked).Msg("")
+		return nil
+	}
+
+	resetToken, err := token.Generate(resetTokenSize)
+	if err != nil {
+		return fmt.Errorf("service: RequestPasswordReset: %w", err)
+	}
+	now := time.Now()
+	reset := model.PasswordReset{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: token.Hash(g.tokenSecret, resetToken),
+		CreatedAt: now,
+		ExpiresAt: now.Add(g.passwordResetTTL),
+	}
+	if err := g.db.CreatePasswordReset(ctx, &reset); err != nil {
+		return fmt.Errorf("service: RequestPasswordReset: %w", err)
+	}
+	msg := notifier.Message{
+		To:      user.Login,
+		Subject: "Gophermart password reset",
+		Text: fmt.Sprintf("Use the token %s to reset your password. The token expires at %s.",
+			resetToken, reset.ExpiresAt.Format(time.RFC1123)),
+		SentAt: now,
+	}
+	if err := g.notifier.Notify(ctx, msg); err != nil {
+		return fmt.Errorf("service: RequestPasswordReset: %w", err)
+	}
+	log.Info().Str("resetID", reset.ID.String()).Msg("password reset token sent")
+
+	return nil
+}
+
+// ResetPassword implements Service interface.
+func (g *GopherMart) ResetPassword(ctx context.Context, resetToken, newPassword string) error {
+	log := appContext.Logger(ctx).With().Str("service:", "ResetPassword").Logger()
+
+	tokenHash := token.Hash(g.tokenSecret, resetToken)
+	// The user is needed to check that the new password doesn't contain the login.
+	user, err := g.db.PasswordResetUser(ctx, tokenHash, time.Now())
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		if errors.Is(err, storage.ErrNotFound) {
+			return ErrInvalidToken
+		}
+
+		return fmt.Errorf("service: ResetPassword: %w", err)
+	}
+	passwordHash, err := g.hashNewPassword(newPassword, user.Login)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return err
+	}
+	userID, err := g.db.ResetPassword(ctx, tokenHash, passwordHash, time.Now())
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		if errors.Is(err, storage.ErrNotFound) {
+			return ErrInvalidToken
+		}
+
+		return fmt.Errorf("service: ResetPassword: %w", err)
+	}
+	log.Info().Str("userID", userID.String()).Msg("password reset, all sessions are ended")
+
+	return nil
+}
+
+// hashNewPassword checks the new password of the user against the password policy and hashes it.
+func (g *GopherMart) hashNewPassword(password, login string) (string, error) {
+	if err := g.checkPassword(password, login); err != nil {
+		return "", err
+	}
+	passwordHash, err := g.hasher.Hash(password)
+	if err != nil {
+		return "", fmt.Errorf("service: %w", err)
+	}
+
+	return passwordHash, nil
+}
+
+// checkPassword checks the new password of the user against the password policy. If the password doesn't meet
+// the policy, *InvalidPasswordError is returned.
+func (g *GopherMart) checkPassword(password, login string) error {
+	err := g.passwordPolicy.Check(password, login)
+	if err == nil {
+		return nil
+	}
+	var policyErr *pwpolicy.Error
+	if errors.As(err, &policyErr) {
+		return &InvalidPasswordError{Reasons: policyErr.Reasons}
+	}
+
+	return fmt.Errorf("service: %w", err)
+}
@@ -0,0 +1,181 @@
+package gophermart_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/pkg/logging"
+	"github.com/vanamelnik/gophermart/pkg/pwpolicy"
+	"github.com/vanamelnik/gophermart/pkg/token"
+	"github.com/vanamelnik/gophermart/provider/notifier"
+	"github.com/vanamelnik/gophermart/service/gophermart"
+	"github.com/vanamelnik/gophermart/storage"
+	mockstorage "github.com/vanamelnik/gophermart/storage/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestChangePassword(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	db := mockstorage.NewMockStorage(mockCtrl)
+	ctx, s, err := initServices(db, pepper)
+	require.NoError(t, err)
+
+	user := appContext.User(ctx)
+	user.PasswordHash, err = bcryptHash("TheRingIsM1ne!", pepper)
+	require.NoError(t, err)
+	session := &model.Session{ID: uuid.New(), UserID: user.ID}
+	ctx = appContext.WithSession(ctx, session)
+
+	t.Run("#1 Wrong current password", func(t *testing.T) {
+		err := s.ChangePassword(ctx, "TheRingIsY0urs!", "OneRingToRuleThemAll")
+		assert.ErrorIs(t, err, gophermart.ErrWrongPassword)
+	})
+	t.Run("#2 Invalid new password", func(t *testing.T) {
+		err := s.ChangePassword(ctx, "TheRingIsM1ne!", "ring")
+		assert.ErrorIs(t, err, gophermart.ErrInvalidPassword)
+	})
+	t.Run("#3 Normal case", func(t *testing.T) {
+		db.EXPECT().ChangePassword(gomock.Any(), user.ID, gomock.Any(), session.ID).
+			DoAndReturn(func(_ context.Context, _ uuid.UUID, hash string, _ uuid.UUID) error {
+				assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(hash), []byte("OneRingToRuleThemAll"+pepper)))
+
+				return nil
+			}).Times(1)
+		assert.NoError(t, s.ChangePassword(ctx, "TheRingIsM1ne!", "OneRingToRuleThemAll"))
+	})
+}
+
+func TestPasswordReset(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	db := mockstorage.NewMockStorage(mockCtrl)
+	ctx := appContext.WithLogger(context.Background(),
+		logging.NewLogger(logging.WithConsoleOutput(true), logging.WithLevel("trace")))
+	n := notifier.NewMemory()
+	s, err := gophermart.New(ctx, db,
+		gophermart.WithConfig(gophermart.Config{PasswordPepper: pepper, TokenSecret: tokenSecret}),
+		gophermart.WithNotifier(n),
+		gophermart.WithoutWorkers())
+	require.NoError(t, err)
+
+	sam := &model.User{ID: uuid.New(), Login: "samwise@hobbyton.shire.me"}
+
+	t.Run("#1 Unknown login", func(t *testing.T) {
+		lookedUp := make(chan struct{})
+		db.EXPECT().UserByLogin(gomock.Any(), "gollum@misty.mountains").
+			DoAndReturn(func(context.Context, string) (*model.User, error) {
+				close(lookedUp)
+
+				return nil, storage.ErrNotFound
+			}).Times(1)
+		assert.NoError(t, s.RequestPasswordReset(ctx, "gollum@misty.mountains"))
+		// The login is looked up in background.
+		select {
+		case <-lookedUp:
+		case <-time.After(time.Second):
+			t.Fatal("the login hasn't been looked up")
+		}
+		assert.Empty(t, n.Messages())
+	})
+
+	var reset model.PasswordReset
+	t.Run("#2 Reset token is sent", func(t *testing.T) {
+		db.EXPECT().UserByLogin(gomock.Any(), sam.Login).Return(sam, nil).Times(1)
+		db.EXPECT().CreatePasswordReset(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, r *model.PasswordReset) error {
+				reset = *r
+
+				return nil
+			}).Times(1)
+		require.NoError(t, s.RequestPasswordReset(ctx, sam.Login))
+		// The token is sent in background.
+		require.Eventually(t, func() bool { return len(n.Messages()) == 1 }, time.Second, 10*time.Millisecond)
+		assert.Equal(t, sam.Login, n.Messages()[0].To)
+		assert.Equal(t, sam.ID, reset.UserID)
+		assert.True(t, reset.ExpiresAt.After(time.Now()))
+	})
+	resetToken := regexp.MustCompile(`token (\S+)`).FindStringSubmatch(n.Messages()[0].Text)[1]
+
+	t.Run("#3 Reset the password", func(t *testing.T) {
+		db.EXPECT().PasswordResetUser(gomock.Any(), reset.TokenHash, gomock.Any()).Return(sam, nil).Times(1)
+		db.EXPECT().ResetPassword(gomock.Any(), reset.TokenHash, gomock.Any(), gomock.Any()).
+			Return(sam.ID, nil).Times(1)
+		assert.Equal(t, token.Hash(tokenSecret, resetToken), reset.TokenHash)
+		assert.NoError(t, s.ResetPassword(ctx, resetToken, "PoTaToEs-boil-em-mash-em"))
+	})
+	t.Run("#4 Used or unknown token", func(t *testing.T) {
+		db.EXPECT().PasswordResetUser(gomock.Any(), reset.TokenHash, gomock.Any()).
+			Return(nil, storage.ErrNotFound).Times(1)
+		assert.ErrorIs(t, s.ResetPassword(ctx, resetToken, "PoTaToEs-boil-em-mash-em"), gophermart.ErrInvalidToken)
+	})
+	t.Run("#5 Reset token used concurrently", func(t *testing.T) {
+		db.EXPECT().PasswordResetUser(gomock.Any(), reset.TokenHash, gomock.Any()).Return(sam, nil).Times(1)
+		db.EXPECT().ResetPassword(gomock.Any(), reset.TokenHash, gomock.Any(), gomock.Any()).
+			Return(uuid.Nil, storage.ErrNotFound).Times(1)
+		assert.ErrorIs(t, s.ResetPassword(ctx, resetToken, "PoTaToEs-boil-em-mash-em"), gophermart.ErrInvalidToken)
+	})
+	t.Run("#6 Invalid new password", func(t *testing.T) {
+		db.EXPECT().PasswordResetUser(gomock.Any(), reset.TokenHash, gomock.Any()).Return(sam, nil).Times(2)
+		assert.ErrorIs(t, s.ResetPassword(ctx, resetToken, "short"), gophermart.ErrInvalidPassword)
+
+		err := s.ResetPassword(ctx, resetToken, "i-am-SAMWISE-gamgee")
+		var pwErr *gophermart.InvalidPasswordError
+		require.ErrorAs(t, err, &pwErr)
+		require.Len(t, pwErr.Reasons, 1)
+		assert.Equal(t, pwpolicy.ReasonContainsLogin, pwErr.Reasons[0].Code)
+	})
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	db := mockstorage.NewMockStorage(mockCtrl)
+	ctx := appContext.WithLogger(context.Background(),
+		logging.NewLogger(logging.WithConsoleOutput(true), logging.WithLevel("trace")))
+	s, err := gophermart.New(ctx, db,
+		gophermart.WithConfig(gophermart.Config{
+			PasswordPepper: pepper,
+			TokenSecret:    tokenSecret,
+			PasswordPolicy: pwpolicy.Config{
+				MinLength:        10,
+				RequireDigit:     true,
+				BannedSubstrings: []string{"hogwarts"},
+			},
+		}),
+		gophermart.WithoutWorkers())
+	require.NoError(t, err)
+
+	t.Run("#1 All the reasons are returned", func(t *testing.T) {
+		_, err := s.Create(ctx, "harry@hogwarts.uk", "HARRY-hogwarts")
+		assert.ErrorIs(t, err, gophermart.ErrInvalidPassword)
+		var pwErr *gophermart.InvalidPasswordError
+		require.ErrorAs(t, err, &pwErr)
+		codes := make([]string, 0, len(pwErr.Reasons))
+		for _, r := range pwErr.Reasons {
+			codes = append(codes, r.Code)
+		}
+		assert.Equal(t, []string{pwpolicy.ReasonMissingDigit, pwpolicy.ReasonContainsLogin, pwpolicy.ReasonBannedSubstring},
+			codes)
+	})
+	t.Run("#2 Normal case", func(t *testing.T) {
+		db.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		_, err := s.Create(ctx, "harry@hogwarts.uk", "Expelliarmus-7")
+		assert.NoError(t, err)
+	})
+	t.Run("#3 Invalid policy", func(t *testing.T) {
+		_, err := gophermart.New(ctx, db,
+			gophermart.WithConfig(gophermart.Config{PasswordPolicy: pwpolicy.Config{MinLength: 300}}),
+			gophermart.WithoutWorkers())
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,302 @@
+package gophermart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/pkg/token"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+const (
+	sessionTokenSize = 32
+	// touchInterval limits the updates of the session's last seen time to one per interval.
+	touchInterval = time.Minute
+	// sessionsCleanInterval is the interval between deletions of expired sessions.
+	sessionsCleanInterval = time.Hour
+)
+
+// CreateSession implements Service interface.
+func (g *GopherMart) CreateSession(ctx context.Context, user model.User, userAgent, ip string) (string, model.Session, error) {
+	log := appContext.Logger(ctx).With().Str("service:", "CreateSession").Str("login", user.Login).Logger()
+
+	sessionToken, err := token.Generate(sessionTokenSize)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return "", model.Session{}, fmt.Errorf("service: CreateSession: %w", err)
+	}
+	now := time.Now()
+	session := model.Session{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		TokenHash:  token.Hash(g.tokenSecret, sessionToken),
+		UserAgent:  userAgent,
+		IP:         ip,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(g.sessionTTL),
+	}
+	if err := g.db.CreateSession(ctx, &session); err != nil {
+		log.Trace().Err(err).Msg("")
+		return "", model.Session{}, fmt.Errorf("service: CreateSession: %w", err)
+	}
+	log.Info().Str("sessionID", session.ID.String()).Msg("new session created")
+
+	return sessionToken, session, nil
+}
+
+// SessionUser implements Service interface.
+func (g *GopherMart) SessionUser(ctx context.Context, sessionToken string) (*model.User, *model.Session, error) {
+	log := appContext.Logger(ctx).With().Str("service:", "SessionUser").Logger()
+
+	session, err := g.db.SessionByTokenHash(ctx, token.Hash(g.tokenSecret, sessionToken))
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, ErrInvalidToken
+		}
+
+		return nil, nil, fmt.Errorf("service: SessionUser: %w", err)
+	}
+	user, err := g.sessionUser(ctx, session)
+	if err != nil {
+		log.Trace().Err(err).Str("sessionID", session.ID.String()).Msg("")
+		return nil, nil, err
+	}
+
+	return user, session, nil
+}
+
+// AccessTokenUser implements Service interface.
+func (g *GopherMart) AccessTokenUser(ctx context.Context, accessToken string) (*model.User, *model.Session, error) {
+	log := appContext.Logger(ctx).With().Str("service:", "AccessTokenUser").Logger()
+
+	if g.accessTokens == nil {
+		log.Trace().Err(ErrAccessTokensDisabled).Msg("")
+		return nil, nil, ErrInvalidToken
+	}
+	claims, err := g.accessTokens.Parse(accessToken)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return nil, nil, ErrInvalidToken
+	}
+	sessionID, err := claims.SessionUUID()
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return nil, nil, ErrInvalidToken
+	}
+	// The session is checked, so the access tokens of revoked sessions are rejected immediately.
+	session, err := g.db.SessionByID(ctx, sessionID)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, ErrInvalidToken
+		}
+
+		return nil, nil, fmt.Errorf("service: AccessTokenUser: %w", err)
+	}
+	if userID, err := claims.UserID(); err != nil || userID != session.UserID {
+		log.Trace().Str("sessionID", session.ID.String()).Msg("the token's subject doesn't match the session's user")
+		return nil, nil, ErrInvalidToken
+	}
+	user, err := g.sessionUser(ctx, session)
+	if err != nil {
+		log.Trace().Err(err).Str("sessionID", session.ID.String()).Msg("")
+		return nil, nil, err
+	}
+
+	return user, session, nil
+}
+
+// IssueAccessToken implements Service interface.
+func (g *GopherMart) IssueAccessToken(ctx context.Context, user model.User, sessionID uuid.UUID) (string, time.Time, error) {
+	log := appContext.Logger(ctx).With().Str("service:", "IssueAccessToken").Str("login", user.Login).Logger()
+
+	if g.accessTokens == nil {
+		log.Trace().Err(ErrAccessTokensDisabled).Msg("")
+		return "", time.Time{}, ErrAccessTokensDisabled
+	}
+	accessToken, expiresAt, err := g.accessTokens.Issue(user, sessionID, time.Now())
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return "", time.Time{}, fmt.Errorf("service: IssueAccessToken: %w", err)
+	}
+
+	return accessToken, expiresAt, nil
+}
+
+// RefreshAccessToken implements Service interface.
+func (g *GopherMart) RefreshAccessToken(ctx context.Context, refreshToken string) (string, time.Time, error) {
+	log := appContext.Logger(ctx).With().Str("service:", "RefreshAccessToken").Logger()
+
+	user, session, err := g.SessionUser(ctx, refreshToken)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return "", time.Time{}, err
+	}
+	if user.Blocked {
+		log.Trace().Err(ErrUserBlocked).Msg("")
+		return "", time.Time{}, ErrUserBlocked
+	}
+
+	return g.IssueAccessToken(ctx, *user, session.ID)
+}
+
+// sessionUser checks the session and returns its user. The session's last seen time is updated.
+func (g *GopherMart) sessionUser(ctx context.Context, session *model.Session) (*model.User, error) {
+	now := time.Now()
+	if session.Expired(now) {
+		return nil, ErrInvalidToken
+	}
+	user, err := g.db.UserByID(ctx, session.UserID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrInvalidToken
+		}
+
+		return nil, fmt.Errorf("service: sessionUser: %w", err)
+	}
+	if now.Sub(session.LastSeenAt) > touchInterval {
+		// It's not critical if the last seen time isn't updated.
+		if err := g.db.TouchSession(ctx, session.ID, now); err != nil {
+			log := appContext.Logger(ctx)
+			log.Error().Err(err).Msg("could not update session's last seen time")
+		}
+		session.LastSeenAt = now
+	}
+
+	return user, nil
+}
+
+// Logout implements Service interface.
+func (g *GopherMart) Logout(ctx context.Context) error {
+	log := userLogger(ctx).With().Str("service:", "Logout").Logger()
+
+	user := appContext.User(ctx)
+	session := appContext.Session(ctx)
+	if user == nil || session == nil {
+		log.Trace().Err(ErrNotAuthenticated).Msg("")
+		return ErrNotAuthenticated
+	}
+	if err := g.db.DeleteSession(ctx, user.ID, session.ID); err != nil {
+		log.Trace().Err(err).Msg("")
+		return fmt.Errorf("service: Logout: %w", err)
+	}
+	log.Info().Str("sessionID", session.ID.String()).Msg("user logged out")
+
+	return nil
+}
+
+// Sessions implements Service interface.
+func (g *GopherMart) Sessions(ctx context.Context) ([]model.Session, error) {
+	log := userLogger(ctx).With().Str("service:", "Sessions").Logger()
+
+	user := appContext.User(ctx)
+	if user == nil {
+		log.Trace().Err(ErrNotAuthenticated).Msg("")
+		return nil, ErrNotAuthenticated
+	}
+	sessions, err := g.db.UserSessions(ctx, user.ID)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return nil, fmt.Errorf("service: Sessions: %w", err)
+	}
+
+	// Expired sessions are not shown: they are already useless and will be deleted by sessionsCleaner.
+	now := time.Now()
+	current := appContext.Session(ctx)
+	active := make([]model.Session, 0, len(sessions))
+	for _, s := range sessions {
+		if s.Expired(now) {
+			continue
+		}
+		s.Current = current != nil && current.ID == s.ID
+		active = append(active, s)
+	}
+
+	return active, nil
+}
+
+// RevokeSession implements Service interface.
+func (g *GopherMart) RevokeSession(ctx context.Context, id uuid.UUID) error {
+	log := userLogger(ctx).With().Str("service:", "RevokeSession").Str("sessionID", id.String()).Logger()
+
+	user := appContext.User(ctx)
+	if user == nil {
+		log.Trace().Err(ErrNotAuthenticated).Msg("")
+		return ErrNotAuthenticated
+	}
+	if err := g.db.DeleteSession(ctx, user.ID, id); err != nil {
+		log.Trace().Err(err).Msg("")
+		return fmt.Errorf("service: RevokeSession: %w", err)
+	}
+	log.Info().Msg("session revoked")
+
+	return nil
+}
+
+// UpgradeSessionHashes implements Service interface.
+func (g *GopherMart) UpgradeSessionHashes(ctx context.Context) error {
+	log := appContext.Logger(ctx).With().Str("service:", "UpgradeSessionHashes").Logger()
+
+	n, err := g.db.UpgradeSessionHashes(ctx, func(digest string) string {
+		return token.HashDigest(g.tokenSecret, digest)
+	})
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return fmt.Errorf("service: UpgradeSessionHashes: %w", err)
+	}
+	if n > 0 {
+		log.Info().Int("number of sessions upgraded", n).Msg("legacy session token hashes upgraded")
+	}
+
+	return nil
+}
+
+// sessionsCleaner periodically deletes expired sessions and login challenges, forgotten failed login attempts
+// and full rate limit buckets.
+func (g *GopherMart) sessionsCleaner(ctx context.Context) {
+	log := appContext.Logger(ctx).With().Str("service:", "sessionsCleaner").Logger()
+	log.Info().Msg("sessionsCleaner started")
+	t := time.NewTicker(sessionsCleanInterval)
+loop:
+	for {
+		select {
+		case <-t.C:
+			now := time.Now()
+			n, err := g.db.DeleteExpiredSessions(ctx, now)
+			if err != nil {
+				log.Error().Err(err).Msg("")
+			} else if n > 0 {
+				log.Debug().Int("number of sessions deleted", n).Msg("")
+			}
+			n, err = g.db.DeleteStaleLoginThrottles(ctx, now.Add(-g.loginThrottle.LockDuration))
+			if err != nil {
+				log.Error().Err(err).Msg("")
+			} else if n > 0 {
+				log.Debug().Int("number of login throttles deleted", n).Msg("")
+			}
+			n, err = g.db.DeleteExpiredLoginChallenges(ctx, now)
+			if err != nil {
+				log.Error().Err(err).Msg("")
+			} else if n > 0 {
+				log.Debug().Int("number of login challenges deleted", n).Msg("")
+			}
+			n, err = g.db.DeleteFullRateLimits(ctx, now)
+			if err != nil {
+				log.Error().Err(err).Msg("")
+			} else if n > 0 {
+				log.Debug().Int("number of rate limit buckets deleted", n).Msg("")
+			}
+		case <-g.workersStop:
+			break loop
+		}
+	}
+	log.Info().Msg("sessionsCleaner stopped")
+}
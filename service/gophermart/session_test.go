@@ -0,0 +1,173 @@
+package gophermart_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/pkg/accesstoken"
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/pkg/logging"
+	"github.com/vanamelnik/gophermart/pkg/token"
+	"github.com/vanamelnik/gophermart/service/gophermart"
+	"github.com/vanamelnik/gophermart/storage"
+	mockstorage "github.com/vanamelnik/gophermart/storage/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateSession(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	db := mockstorage.NewMockStorage(mockCtrl)
+	ctx, s, err := initServices(db, pepper)
+	require.NoError(t, err)
+	user := appContext.User(ctx)
+
+	var stored model.Session
+	db.EXPECT().CreateSession(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, s *model.Session) error {
+			stored = *s
+
+			return nil
+		}).Times(1)
+
+	sessionToken, session, err := s.CreateSession(ctx, *user, "Palantir/1.0", "10.0.0.1")
+	require.NoError(t, err)
+	assert.NotEmpty(t, sessionToken)
+	assert.Equal(t, user.ID, stored.UserID)
+	assert.Equal(t, token.Hash(tokenSecret, sessionToken), stored.TokenHash, "only the hash of the token must be stored")
+	assert.Equal(t, "Palantir/1.0", stored.UserAgent)
+	assert.Equal(t, "10.0.0.1", stored.IP)
+	assert.True(t, session.ExpiresAt.After(time.Now()))
+}
+
+func TestSessions(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	db := mockstorage.NewMockStorage(mockCtrl)
+	ctx, s, err := initServices(db, pepper)
+	require.NoError(t, err)
+	user := appContext.User(ctx)
+
+	now := time.Now()
+	current := model.Session{ID: uuid.New(), UserID: user.ID, ExpiresAt: now.Add(time.Hour)}
+	another := model.Session{ID: uuid.New(), UserID: user.ID, ExpiresAt: now.Add(time.Hour)}
+	expired := model.Session{ID: uuid.New(), UserID: user.ID, ExpiresAt: now.Add(-time.Hour)}
+	ctx = appContext.WithSession(ctx, &current)
+
+	t.Run("#1 List active sessions", func(t *testing.T) {
+		db.EXPECT().UserSessions(gomock.Any(), user.ID).
+			Return([]model.Session{current, another, expired}, nil).Times(1)
+
+		sessions, err := s.Sessions(ctx)
+		require.NoError(t, err)
+		require.Len(t, sessions, 2)
+		assert.True(t, sessions[0].Current)
+		assert.False(t, sessions[1].Current)
+	})
+	t.Run("#2 Revoke another session", func(t *testing.T) {
+		db.EXPECT().DeleteSession(gomock.Any(), user.ID, another.ID).Return(nil).Times(1)
+		assert.NoError(t, s.RevokeSession(ctx, another.ID))
+	})
+	t.Run("#3 Revoke a session of another user", func(t *testing.T) {
+		id := uuid.New()
+		db.EXPECT().DeleteSession(gomock.Any(), user.ID, id).Return(storage.ErrNotFound).Times(1)
+		assert.ErrorIs(t, s.RevokeSession(ctx, id), storage.ErrNotFound)
+	})
+	t.Run("#4 Logout", func(t *testing.T) {
+		db.EXPECT().DeleteSession(gomock.Any(), user.ID, current.ID).Return(nil).Times(1)
+		assert.NoError(t, s.Logout(ctx))
+	})
+}
+
+func TestUpgradeSessionHashes(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	db := mockstorage.NewMockStorage(mockCtrl)
+	ctx, s, err := initServices(db, pepper)
+	require.NoError(t, err)
+
+	const legacyToken = "legacy remember token"
+	db.EXPECT().UpgradeSessionHashes(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, upgrade func(string) string) (int, error) {
+			assert.Equal(t, token.Hash(tokenSecret, legacyToken), upgrade(token.Digest(legacyToken)),
+				"the upgraded hash must match the hash of the token")
+
+			return 1, nil
+		}).Times(1)
+	assert.NoError(t, s.UpgradeSessionHashes(ctx))
+}
+
+func TestAccessTokens(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	db := mockstorage.NewMockStorage(mockCtrl)
+	ctx := appContext.WithLogger(context.Background(),
+		logging.NewLogger(logging.WithConsoleOutput(true), logging.WithLevel("trace")))
+	tokens, err := accesstoken.New(map[string]string{"k1": "jwt secret"}, "k1", time.Minute)
+	require.NoError(t, err)
+	s, err := gophermart.New(ctx, db,
+		gophermart.WithConfig(gophermart.Config{PasswordPepper: pepper, TokenSecret: tokenSecret}),
+		gophermart.WithAccessTokens(tokens),
+		gophermart.WithoutWorkers())
+	require.NoError(t, err)
+
+	now := time.Now()
+	user := &model.User{ID: uuid.New(), Login: "bilbo@bagend.shire.me", Role: model.RoleUser}
+	session := &model.Session{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		TokenHash:  token.Hash(tokenSecret, "refresh token"),
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(time.Hour),
+	}
+	db.EXPECT().UserByID(gomock.Any(), user.ID).Return(user, nil).AnyTimes()
+
+	var accessToken string
+	t.Run("#1 Refresh access token", func(t *testing.T) {
+		db.EXPECT().SessionByTokenHash(gomock.Any(), session.TokenHash).Return(session, nil).Times(1)
+		accessToken, _, err = s.RefreshAccessToken(ctx, "refresh token")
+		require.NoError(t, err)
+		assert.NotEmpty(t, accessToken)
+	})
+	t.Run("#2 Authenticate by access token", func(t *testing.T) {
+		db.EXPECT().SessionByID(gomock.Any(), session.ID).Return(session, nil).Times(1)
+		u, sess, err := s.AccessTokenUser(ctx, accessToken)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, u.ID)
+		assert.Equal(t, session.ID, sess.ID)
+	})
+	t.Run("#3 Access token of revoked session", func(t *testing.T) {
+		db.EXPECT().SessionByID(gomock.Any(), session.ID).Return(nil, storage.ErrNotFound).Times(1)
+		_, _, err := s.AccessTokenUser(ctx, accessToken)
+		assert.ErrorIs(t, err, gophermart.ErrInvalidToken)
+	})
+	t.Run("#4 Forged access token", func(t *testing.T) {
+		_, _, err := s.AccessTokenUser(ctx, accessToken+"x")
+		assert.ErrorIs(t, err, gophermart.ErrInvalidToken)
+	})
+	t.Run("#5 Unknown refresh token", func(t *testing.T) {
+		db.EXPECT().SessionByTokenHash(gomock.Any(), gomock.Any()).Return(nil, storage.ErrNotFound).Times(1)
+		_, _, err := s.RefreshAccessToken(ctx, "unknown token")
+		assert.ErrorIs(t, err, gophermart.ErrInvalidToken)
+	})
+	t.Run("#6 Expired session", func(t *testing.T) {
+		expired := *session
+		expired.ExpiresAt = now.Add(-time.Second)
+		db.EXPECT().SessionByTokenHash(gomock.Any(), session.TokenHash).Return(&expired, nil).Times(1)
+		_, _, err := s.RefreshAccessToken(ctx, "refresh token")
+		assert.ErrorIs(t, err, gophermart.ErrInvalidToken)
+	})
+	t.Run("#7 Blocked user", func(t *testing.T) {
+		user.Blocked = true
+		defer func() { user.Blocked = false }()
+		db.EXPECT().SessionByTokenHash(gomock.Any(), session.TokenHash).Return(session, nil).Times(1)
+		_, _, err := s.RefreshAccessToken(ctx, "refresh token")
+		assert.ErrorIs(t, err, gophermart.ErrUserBlocked)
+	})
+}
@@ -0,0 +1,109 @@
+package gophermart
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/pkg/metrics"
+
+	"github.com/rs/zerolog"
+)
+
+const defaultWorkerRestartDelay = time.Second
+
+// runWorker runs the worker in a new goroutine. If the worker panics, the panic is logged with the stack trace
+// and the worker is restarted after workerRestartDelay unless the workers are stopped.
+// The worker must return after workersStop is closed.
+func (g *GopherMart) runWorker(ctx context.Context, name string, worker func(ctx context.Context)) {
+	log := appContext.Logger(ctx).With().Str("worker", name).Logger()
+	g.workersWg.Add(1)
+	go func() {
+		defer g.workersWg.Done()
+		for runRecovered(log, func() { worker(ctx) }) {
+			metrics.WorkerRestarts.WithLabelValues(name).Inc()
+			select {
+			case <-g.workersStop:
+				return
+			case <-time.After(g.workerRestartDelay):
+				log.Warn().Msg("restarting the worker after panic")
+			}
+		}
+	}()
+}
+
+// runRecovered calls f and reports whether it has panicked.
+func runRecovered(log zerolog.Logger, f func()) (panicked bool) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			log.Error().Interface("panic", rec).Str("stack", string(debug.Stack())).Msg("worker panicked")
+			panicked = true
+		}
+	}()
+	f()
+
+	return false
+}
+
+// Shutdown stops the workers and flushes the balance updates of the accruals made. The workers finish
+// the operations in progress (e.g. the order being polled) unless ctx is done; then their context is cancelled,
+// the blocking calls are aborted and the error is returned. The accruals not flushed are applied on the next start.
+// The background tasks started by the requests are awaited as well.
+// Shutdown can be called several times: after the error it's retried, after the success the calls do nothing.
+func (g *GopherMart) Shutdown(ctx context.Context) error {
+	g.shutdownMu.Lock()
+	defer g.shutdownMu.Unlock()
+	if g.shutdownDone {
+		return nil
+	}
+
+	tasksDone := make(chan struct{})
+	go func() {
+		g.tasksWg.Wait()
+		close(tasksDone)
+	}()
+	select {
+	case <-tasksDone:
+	case <-ctx.Done():
+		return fmt.Errorf("service: shutdown: background tasks haven't finished: %w", ctx.Err())
+	}
+
+	g.workersStopOnce.Do(func() {
+		if g.workersStop != nil {
+			close(g.workersStop)
+		}
+	})
+	if g.workersCancel == nil { // the workers haven't been started
+		g.shutdownDone = true
+
+		return nil
+	}
+	stopped := make(chan struct{})
+	go func() {
+		g.workersWg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		g.workersCancel()
+	case <-ctx.Done():
+		g.workersCancel()
+
+		return fmt.Errorf("service: shutdown: workers haven't stopped: %w", ctx.Err())
+	}
+
+	// Apply the accruals made by the poller before it stopped.
+	n, err := g.db.UpdateBalance(ctx)
+	if err != nil {
+		return fmt.Errorf("service: shutdown: flush balance updates: %w", err)
+	}
+	if n > 0 {
+		log := appContext.Logger(g.workersCtx)
+		log.Info().Int("number of accrual operations processed", n).Msg("balance updates flushed")
+	}
+	g.shutdownDone = true
+
+	return nil
+}
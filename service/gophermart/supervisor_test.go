@@ -0,0 +1,131 @@
+package gophermart
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/pkg/logging"
+	mockstorage "github.com/vanamelnik/gophermart/storage/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunWorker(t *testing.T) {
+	ctx := appContext.WithLogger(context.Background(),
+		logging.NewLogger(logging.WithConsoleOutput(true), logging.WithLevel("trace")))
+	g := &GopherMart{
+		workersStop:        make(chan struct{}),
+		workerRestartDelay: time.Millisecond,
+	}
+
+	var runs int32
+	started := make(chan struct{})
+	g.runWorker(ctx, "processOrder", func(ctx context.Context) {
+		if atomic.AddInt32(&runs, 1) < 3 {
+			panic("the order is cursed")
+		}
+		close(started)
+		<-g.workersStop
+	})
+
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("the worker hasn't been restarted after panic")
+	}
+	close(g.workersStop)
+	g.workersWg.Wait()
+	assert.Equal(t, int32(3), atomic.LoadInt32(&runs))
+}
+
+func TestShutdown(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	db := mockstorage.NewMockStorage(mockCtrl)
+	newService := func() *GopherMart {
+		ctx := appContext.WithLogger(context.Background(),
+			logging.NewLogger(logging.WithConsoleOutput(true), logging.WithLevel("trace")))
+		g := &GopherMart{
+			workersStop:        make(chan struct{}),
+			workerRestartDelay: time.Millisecond,
+			db:                 db,
+		}
+		g.workersCtx, g.workersCancel = context.WithCancel(ctx)
+
+		return g
+	}
+
+	t.Run("#1 The workers are stopped and the balance updates are flushed", func(t *testing.T) {
+		g := newService()
+		var stopped int32
+		g.runWorker(g.workersCtx, "balanceUpdater", func(ctx context.Context) {
+			<-g.workersStop
+			atomic.StoreInt32(&stopped, 1)
+		})
+		db.EXPECT().UpdateBalance(gomock.Any()).Return(2, nil).Times(1)
+		require.NoError(t, g.Shutdown(context.Background()))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&stopped))
+		assert.Error(t, g.workersCtx.Err(), "the workers' context is cancelled")
+
+		// The balance updates are flushed once.
+		assert.NoError(t, g.Shutdown(context.Background()))
+		db.EXPECT().Close().Return(nil).Times(1)
+		g.Close()
+	})
+	t.Run("#2 The worker stuck in a blocking call is cancelled after the timeout", func(t *testing.T) {
+		g := newService()
+		g.runWorker(g.workersCtx, "accrualServicePoller", func(ctx context.Context) {
+			<-ctx.Done() // e.g. a request to the accrual service
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		err := g.Shutdown(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		// The worker is stopped now, so the next call flushes the balance updates.
+		db.EXPECT().UpdateBalance(gomock.Any()).Return(0, nil).Times(1)
+		assert.NoError(t, g.Shutdown(context.Background()))
+	})
+}
+
+func TestWithdrawalsRecoverer(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	db := mockstorage.NewMockStorage(mockCtrl)
+	ctx := appContext.WithLogger(context.Background(),
+		logging.NewLogger(logging.WithConsoleOutput(true), logging.WithLevel("trace")))
+	g := &GopherMart{
+		workersStop:                make(chan struct{}),
+		workerRestartDelay:         time.Millisecond,
+		withdrawalsRecoverInterval: time.Millisecond,
+		db:                         db,
+	}
+
+	// The recovery goes on after the storage error.
+	recovered := make(chan struct{})
+	gomock.InOrder(
+		db.EXPECT().RecoverWithdrawals(gomock.Any(), gomock.Any()).Return(0, errors.New("connection lost")).Times(1),
+		db.EXPECT().RecoverWithdrawals(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(context.Context, time.Time) (int, error) {
+				close(recovered)
+
+				return 1, nil
+			}).Times(1),
+		db.EXPECT().RecoverWithdrawals(gomock.Any(), gomock.Any()).Return(0, nil).AnyTimes(),
+	)
+	g.runWorker(ctx, "withdrawalsRecoverer", g.withdrawalsRecoverer)
+
+	select {
+	case <-recovered:
+	case <-time.After(time.Second):
+		t.Fatal("the withdrawals haven't been recovered periodically")
+	}
+	close(g.workersStop)
+	g.workersWg.Wait()
+}
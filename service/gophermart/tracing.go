@@ -0,0 +1,452 @@
+package gophermart
+
+import (
+	"context"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/pkg/tracing"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+)
+
+var tracer = otel.Tracer("github.com/vanamelnik/gophermart/service/gophermart")
+
+// tracedService starts a span for each call of the service.
+type tracedService struct {
+	s Service
+}
+
+// WithTracing wraps the service provided, so each call is traced.
+func WithTracing(s Service) Service {
+	return tracedService{s: s}
+}
+
+// Create implements Service interface.
+func (t tracedService) Create(ctx context.Context, login, password string) (model.User, error) {
+	ctx, span := tracer.Start(ctx, "gophermart.Create")
+	v, err := t.s.Create(ctx, login, password)
+	tracing.End(span, err)
+
+	return v, err
+}
+
+// Authenticate implements Service interface.
+func (t tracedService) Authenticate(ctx context.Context, login, password, ip string) (model.User, error) {
+	ctx, span := tracer.Start(ctx, "gophermart.Authenticate")
+	v, err := t.s.Authenticate(ctx, login, password, ip)
+	tracing.End(span, err)
+
+	return v, err
+}
+
+// CreateSession implements Service interface.
+func (t tracedService) CreateSession(ctx context.Context, user model.User, userAgent, ip string) (string, model.Session, error) {
+	ctx, span := tracer.Start(ctx, "gophermart.CreateSession")
+	v1, v2, err := t.s.CreateSession(ctx, user, userAgent, ip)
+	tracing.End(span, err)
+
+	return v1, v2, err
+}
+
+// SessionUser implements Service interface.
+func (t tracedService) SessionUser(ctx context.Context, sessionToken string) (*model.User, *model.Session, error) {
+	ctx, span := tracer.Start(ctx, "gophermart.SessionUser")
+	v1, v2, err := t.s.SessionUser(ctx, sessionToken)
+	tracing.End(span, err)
+
+	return v1, v2, err
+}
+
+// AccessTokenUser implements Service interface.
+func (t tracedService) AccessTokenUser(ctx context.Context, accessToken string) (*model.User, *model.Session, error) {
+	ctx, span := tracer.Start(ctx, "gophermart.AccessTokenUser")
+	v1, v2, err := t.s.AccessTokenUser(ctx, accessToken)
+	tracing.End(span, err)
+
+	return v1, v2, err
+}
+
+// IssueAccessToken implements Service interface.
+func (t tracedService) IssueAccessToken(ctx context.Context, user model.User, sessionID uuid.UUID) (string, time.Time, error) {
+	ctx, span := tracer.Start(ctx, "gophermart.IssueAccessToken")
+	v1, v2, err := t.s.IssueAccessToken(ctx, user, sessionID)
+	tracing.End(span, err)
+
+	return v1, v2, err
+}
+
+// RefreshAccessToken implements Service interface.
+func (t tracedService) RefreshAccessToken(ctx context.Context, refreshToken string) (string, time.Time, error) {
+	ctx, span := tracer.Start(ctx, "gophermart.RefreshAccessToken")
+	v1, v2, err := t.s.RefreshAccessToken(ctx, refreshToken)
+	tracing.End(span, err)
+
+	return v1, v2, err
+}
+
+// RequestPasswordReset implements Service interface.
+func (t tracedService) RequestPasswordReset(ctx context.Context, login string) error {
+	ctx, span := tracer.Start(ctx, "gophermart.RequestPasswordReset")
+	err := t.s.RequestPasswordReset(ctx, login)
+	tracing.End(span, err)
+
+	return err
+}
+
+// ResetPassword implements Service interface.
+func (t tracedService) ResetPassword(ctx context.Context, resetToken, newPassword string) error {
+	ctx, span := tracer.Start(ctx, "gophermart.ResetPassword")
+	err := t.s.ResetPassword(ctx, resetToken, newPassword)
+	tracing.End(span, err)
+
+	return err
+}
+
+// AuthenticateAPIKey implements Service interface.
+func (t tracedService) AuthenticateAPIKey(ctx context.Context, key string) (*model.APIKey, error) {
+	ctx, span := tracer.Start(ctx, "gophermart.AuthenticateAPIKey")
+	v, err := t.s.AuthenticateAPIKey(ctx, key)
+	tracing.End(span, err)
+
+	return v, err
+}
+
+// SubmitMerchantOrder implements Service interface.
+func (t tracedService) SubmitMerchantOrder(ctx context.Context, login string, orderID model.OrderID) error {
+	ctx, span := tracer.Start(ctx, "gophermart.SubmitMerchantOrder")
+	err := t.s.SubmitMerchantOrder(ctx, login, orderID)
+	tracing.End(span, err)
+
+	return err
+}
+
+// EnrollTwoFactor implements Service interface.
+func (t tracedService) EnrollTwoFactor(ctx context.Context) (TwoFactorEnrollment, error) {
+	ctx, span := tracer.Start(ctx, "gophermart.EnrollTwoFactor")
+	v, err := t.s.EnrollTwoFactor(ctx)
+	tracing.End(span, err)
+
+	return v, err
+}
+
+// ConfirmTwoFactor implements Service interface.
+func (t tracedService) ConfirmTwoFactor(ctx context.Context, code string) ([]string, error) {
+	ctx, span := tracer.Start(ctx, "gophermart.ConfirmTwoFactor")
+	v, err := t.s.ConfirmTwoFactor(ctx, code)
+	tracing.End(span, err)
+
+	return v, err
+}
+
+// DisableTwoFactor implements Service interface.
+func (t tracedService) DisableTwoFactor(ctx context.Context, code string) error {
+	ctx, span := tracer.Start(ctx, "gophermart.DisableTwoFactor")
+	err := t.s.DisableTwoFactor(ctx, code)
+	tracing.End(span, err)
+
+	return err
+}
+
+// TwoFactorChallenge implements Service interface.
+func (t tracedService) TwoFactorChallenge(ctx context.Context, user model.User) (string, error) {
+	ctx, span := tracer.Start(ctx, "gophermart.TwoFactorChallenge")
+	v, err := t.s.TwoFactorChallenge(ctx, user)
+	tracing.End(span, err)
+
+	return v, err
+}
+
+// CompleteTwoFactorLogin implements Service interface.
+func (t tracedService) CompleteTwoFactorLogin(ctx context.Context, challenge, code, ip string) (model.User, error) {
+	ctx, span := tracer.Start(ctx, "gophermart.CompleteTwoFactorLogin")
+	v, err := t.s.CompleteTwoFactorLogin(ctx, challenge, code, ip)
+	tracing.End(span, err)
+
+	return v, err
+}
+
+// ChangePassword implements Service interface.
+func (t tracedService) ChangePassword(ctx context.Context, currentPassword, newPassword string) error {
+	ctx, span := tracer.Start(ctx, "gophermart.ChangePassword")
+	err := t.s.ChangePassword(ctx, currentPassword, newPassword)
+	tracing.End(span, err)
+
+	return err
+}
+
+// ExportData implements Service interface.
+func (t tracedService) ExportData(ctx context.Context) (DataExport, error) {
+	ctx, span := tracer.Start(ctx, "gophermart.ExportData")
+	v, err := t.s.ExportData(ctx)
+	tracing.End(span, err)
+
+	return v, err
+}
+
+// DeleteAccount implements Service interface.
+func (t tracedService) DeleteAccount(ctx context.Context, password, code string) error {
+	ctx, span := tracer.Start(ctx, "gophermart.DeleteAccount")
+	err := t.s.DeleteAccount(ctx, password, code)
+	tracing.End(span, err)
+
+	return err
+}
+
+// Logout implements Service interface.
+func (t tracedService) Logout(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "gophermart.Logout")
+	err := t.s.Logout(ctx)
+	tracing.End(span, err)
+
+	return err
+}
+
+// Sessions implements Service interface.
+func (t tracedService) Sessions(ctx context.Context) ([]model.Session, error) {
+	ctx, span := tracer.Start(ctx, "gophermart.Sessions")
+	v, err := t.s.Sessions(ctx)
+	tracing.End(span, err)
+
+	return v, err
+}
+
+// RevokeSession implements Service interface.
+func (t tracedService) RevokeSession(ctx context.Context, id uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "gophermart.RevokeSession")
+	err := t.s.RevokeSession(ctx, id)
+	tracing.End(span, err)
+
+	return err
+}
+
+// GetOrders implements Service interface.
+func (t tracedService) GetOrders(ctx context.Context) ([]model.Order, error) {
+	ctx, span := tracer.Start(ctx, "gophermart.GetOrders")
+	v, err := t.s.GetOrders(ctx)
+	tracing.End(span, err)
+
+	return v, err
+}
+
+// GetBalance implements Service interface.
+func (t tracedService) GetBalance(ctx context.Context) (UserBalance, error) {
+	ctx, span := tracer.Start(ctx, "gophermart.GetBalance")
+	v, err := t.s.GetBalance(ctx)
+	tracing.End(span, err)
+
+	return v, err
+}
+
+// GetWithdrawals implements Service interface.
+func (t tracedService) GetWithdrawals(ctx context.Context) ([]model.Withdrawal, error) {
+	ctx, span := tracer.Start(ctx, "gophermart.GetWithdrawals")
+	v, err := t.s.GetWithdrawals(ctx)
+	tracing.End(span, err)
+
+	return v, err
+}
+
+// ProcessOrder implements Service interface.
+func (t tracedService) ProcessOrder(ctx context.Context, orderID model.OrderID) error {
+	ctx, span := tracer.Start(ctx, "gophermart.ProcessOrder")
+	err := t.s.ProcessOrder(ctx, orderID)
+	tracing.End(span, err)
+
+	return err
+}
+
+// Withdraw implements Service interface.
+func (t tracedService) Withdraw(ctx context.Context, orderID model.OrderID, sum float32) error {
+	ctx, span := tracer.Start(ctx, "gophermart.Withdraw")
+	err := t.s.Withdraw(ctx, orderID, sum)
+	tracing.End(span, err)
+
+	return err
+}
+
+// CreateWebhook implements Service interface.
+func (t tracedService) CreateWebhook(ctx context.Context, url, secret string, events []model.EventType) (model.Webhook, error) {
+	ctx, span := tracer.Start(ctx, "gophermart.CreateWebhook")
+	v, err := t.s.CreateWebhook(ctx, url, secret, events)
+	tracing.End(span, err)
+
+	return v, err
+}
+
+// Webhooks implements Service interface.
+func (t tracedService) Webhooks(ctx context.Context) ([]model.Webhook, error) {
+	ctx, span := tracer.Start(ctx, "gophermart.Webhooks")
+	v, err := t.s.Webhooks(ctx)
+	tracing.End(span, err)
+
+	return v, err
+}
+
+// DeleteWebhook implements Service interface.
+func (t tracedService) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "gophermart.DeleteWebhook")
+	err := t.s.DeleteWebhook(ctx, id)
+	tracing.End(span, err)
+
+	return err
+}
+
+// WebhookDeliveries implements Service interface.
+func (t tracedService) WebhookDeliveries(ctx context.Context, webhookID uuid.UUID) ([]model.WebhookDelivery, error) {
+	ctx, span := tracer.Start(ctx, "gophermart.WebhookDeliveries")
+	v, err := t.s.WebhookDeliveries(ctx, webhookID)
+	tracing.End(span, err)
+
+	return v, err
+}
+
+// ReplayWebhookDelivery implements Service interface.
+func (t tracedService) ReplayWebhookDelivery(ctx context.Context, id uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "gophermart.ReplayWebhookDelivery")
+	err := t.s.ReplayWebhookDelivery(ctx, id)
+	tracing.End(span, err)
+
+	return err
+}
+
+// SearchUsers implements Service interface.
+func (t tracedService) SearchUsers(ctx context.Context, login string) ([]model.User, error) {
+	ctx, span := tracer.Start(ctx, "gophermart.SearchUsers")
+	v, err := t.s.SearchUsers(ctx, login)
+	tracing.End(span, err)
+
+	return v, err
+}
+
+// UserInfo implements Service interface.
+func (t tracedService) UserInfo(ctx context.Context, userID uuid.UUID) (UserInfo, error) {
+	ctx, span := tracer.Start(ctx, "gophermart.UserInfo")
+	v, err := t.s.UserInfo(ctx, userID)
+	tracing.End(span, err)
+
+	return v, err
+}
+
+// AdjustBalance implements Service interface.
+func (t tracedService) AdjustBalance(ctx context.Context, userID uuid.UUID, sum float32, reason string) (model.Adjustment, error) {
+	ctx, span := tracer.Start(ctx, "gophermart.AdjustBalance")
+	v, err := t.s.AdjustBalance(ctx, userID, sum, reason)
+	tracing.End(span, err)
+
+	return v, err
+}
+
+// SetUserBlocked implements Service interface.
+func (t tracedService) SetUserBlocked(ctx context.Context, userID uuid.UUID, blocked bool) error {
+	ctx, span := tracer.Start(ctx, "gophermart.SetUserBlocked")
+	err := t.s.SetUserBlocked(ctx, userID, blocked)
+	tracing.End(span, err)
+
+	return err
+}
+
+// UnlockUser implements Service interface.
+func (t tracedService) UnlockUser(ctx context.Context, userID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "gophermart.UnlockUser")
+	err := t.s.UnlockUser(ctx, userID)
+	tracing.End(span, err)
+
+	return err
+}
+
+// CreateMerchant implements Service interface.
+func (t tracedService) CreateMerchant(ctx context.Context, name string) (model.Merchant, error) {
+	ctx, span := tracer.Start(ctx, "gophermart.CreateMerchant")
+	v, err := t.s.CreateMerchant(ctx, name)
+	tracing.End(span, err)
+
+	return v, err
+}
+
+// Merchants implements Service interface.
+func (t tracedService) Merchants(ctx context.Context) ([]model.Merchant, error) {
+	ctx, span := tracer.Start(ctx, "gophermart.Merchants")
+	v, err := t.s.Merchants(ctx)
+	tracing.End(span, err)
+
+	return v, err
+}
+
+// CreateAPIKey implements Service interface.
+func (t tracedService) CreateAPIKey(ctx context.Context, merchantID uuid.UUID, scopes []model.Scope) (string, model.APIKey, error) {
+	ctx, span := tracer.Start(ctx, "gophermart.CreateAPIKey")
+	v1, v2, err := t.s.CreateAPIKey(ctx, merchantID, scopes)
+	tracing.End(span, err)
+
+	return v1, v2, err
+}
+
+// MerchantAPIKeys implements Service interface.
+func (t tracedService) MerchantAPIKeys(ctx context.Context, merchantID uuid.UUID) ([]model.APIKey, error) {
+	ctx, span := tracer.Start(ctx, "gophermart.MerchantAPIKeys")
+	v, err := t.s.MerchantAPIKeys(ctx, merchantID)
+	tracing.End(span, err)
+
+	return v, err
+}
+
+// RevokeAPIKey implements Service interface.
+func (t tracedService) RevokeAPIKey(ctx context.Context, merchantID, keyID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "gophermart.RevokeAPIKey")
+	err := t.s.RevokeAPIKey(ctx, merchantID, keyID)
+	tracing.End(span, err)
+
+	return err
+}
+
+// SetUserRole implements Service interface.
+func (t tracedService) SetUserRole(ctx context.Context, userID uuid.UUID, role model.Role, perms []model.Permission) error {
+	ctx, span := tracer.Start(ctx, "gophermart.SetUserRole")
+	err := t.s.SetUserRole(ctx, userID, role, perms)
+	tracing.End(span, err)
+
+	return err
+}
+
+// BootstrapAdmin implements Service interface.
+func (t tracedService) BootstrapAdmin(ctx context.Context, login, password string) (model.User, error) {
+	ctx, span := tracer.Start(ctx, "gophermart.BootstrapAdmin")
+	v, err := t.s.BootstrapAdmin(ctx, login, password)
+	tracing.End(span, err)
+
+	return v, err
+}
+
+// RepollOrder implements Service interface.
+func (t tracedService) RepollOrder(ctx context.Context, orderID model.OrderID) error {
+	ctx, span := tracer.Start(ctx, "gophermart.RepollOrder")
+	err := t.s.RepollOrder(ctx, orderID)
+	tracing.End(span, err)
+
+	return err
+}
+
+// UpgradeSessionHashes implements Service interface.
+func (t tracedService) UpgradeSessionHashes(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "gophermart.UpgradeSessionHashes")
+	err := t.s.UpgradeSessionHashes(ctx)
+	tracing.End(span, err)
+
+	return err
+}
+
+// RecoverWithdrawals implements Service interface.
+func (t tracedService) RecoverWithdrawals(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "gophermart.RecoverWithdrawals")
+	err := t.s.RecoverWithdrawals(ctx)
+	tracing.End(span, err)
+
+	return err
+}
+
+// Close implements Service interface.
+func (t tracedService) Close() {
+	t.s.Close()
+}
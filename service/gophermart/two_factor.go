@@ -0,0 +1,317 @@
+package gophermart
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/pkg/token"
+	"github.com/vanamelnik/gophermart/pkg/totp"
+	"github.com/vanamelnik/gophermart/storage"
+)
+
+const (
+	loginChallengeTTL         = 5 * time.Minute
+	loginChallengeMaxAttempts = 5
+	challengeTokenSize        = 32
+
+	recoveryCodesNumber = 10
+	recoveryCodeSize    = 10 // random bytes, 16 base32 characters
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// EnrollTwoFactor implements Service interface.
+func (g *GopherMart) EnrollTwoFactor(ctx context.Context) (TwoFactorEnrollment, error) {
+	log := userLogger(ctx).With().Str("service:", "EnrollTwoFactor").Logger()
+
+	user := appContext.User(ctx)
+	if user == nil {
+		log.Trace().Err(ErrNotAuthenticated).Msg("")
+		return TwoFactorEnrollment{}, ErrNotAuthenticated
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return TwoFactorEnrollment{}, fmt.Errorf("service: EnrollTwoFactor: %w", err)
+	}
+	if err := g.db.SetPendingTwoFactor(ctx, user.ID, secret); err != nil {
+		log.Trace().Err(err).Msg("")
+		if errors.Is(err, storage.ErrTwoFactorEnabled) {
+			return TwoFactorEnrollment{}, ErrTwoFactorEnabled
+		}
+
+		return TwoFactorEnrollment{}, fmt.Errorf("service: EnrollTwoFactor: %w", err)
+	}
+	log.Info().Msg("two-factor authentication enrollment started")
+
+	return TwoFactorEnrollment{
+		Secret: secret,
+		URI:    totp.URI(g.twoFactorIssuer, user.Login, secret),
+	}, nil
+}
+
+// ConfirmTwoFactor implements Service interface.
+func (g *GopherMart) ConfirmTwoFactor(ctx context.Context, code string) ([]string, error) {
+	log := userLogger(ctx).With().Str("service:", "ConfirmTwoFactor").Logger()
+
+	user := appContext.User(ctx)
+	if user == nil {
+		log.Trace().Err(ErrNotAuthenticated).Msg("")
+		return nil, ErrNotAuthenticated
+	}
+	tf, err := g.db.TwoFactor(ctx, user.ID)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrTwoFactorNotEnabled
+		}
+
+		return nil, fmt.Errorf("service: ConfirmTwoFactor: %w", err)
+	}
+	if tf.Enabled() {
+		log.Trace().Err(ErrTwoFactorEnabled).Msg("")
+		return nil, ErrTwoFactorEnabled
+	}
+	now := time.Now()
+	step, ok := totp.Validate(tf.Secret, strings.TrimSpace(code), now)
+	if !ok {
+		log.Trace().Err(ErrInvalidCode).Msg("")
+		return nil, ErrInvalidCode
+	}
+
+	codes := make([]string, 0, recoveryCodesNumber)
+	hashes := make([]string, 0, recoveryCodesNumber)
+	for i := 0; i < recoveryCodesNumber; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			log.Trace().Err(err).Msg("")
+			return nil, fmt.Errorf("service: ConfirmTwoFactor: %w", err)
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, token.Hash(g.tokenSecret, normalizeRecoveryCode(code)))
+	}
+	if err := g.db.EnableTwoFactor(ctx, user.ID, step, now, hashes); err != nil {
+		log.Trace().Err(err).Msg("")
+		if errors.Is(err, storage.ErrNotFound) {
+			// Confirmed concurrently.
+			return nil, ErrTwoFactorEnabled
+		}
+
+		return nil, fmt.Errorf("service: ConfirmTwoFactor: %w", err)
+	}
+	log.Info().Msg("two-factor authentication enabled")
+
+	return codes, nil
+}
+
+// DisableTwoFactor implements Service interface.
+func (g *GopherMart) DisableTwoFactor(ctx context.Context, code string) error {
+	log := userLogger(ctx).With().Str("service:", "DisableTwoFactor").Logger()
+
+	user := appContext.User(ctx)
+	if user == nil {
+		log.Trace().Err(ErrNotAuthenticated).Msg("")
+		return ErrNotAuthenticated
+	}
+	tf, err := g.enabledTwoFactor(ctx, *user)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return err
+	}
+	if tf == nil {
+		log.Trace().Err(ErrTwoFactorNotEnabled).Msg("")
+		return ErrTwoFactorNotEnabled
+	}
+	if err := g.checkSecondFactor(ctx, *tf, code); err != nil {
+		log.Trace().Err(err).Msg("")
+		return err
+	}
+	if err := g.db.DisableTwoFactor(ctx, user.ID); err != nil {
+		log.Trace().Err(err).Msg("")
+		return fmt.Errorf("service: DisableTwoFactor: %w", err)
+	}
+	log.Info().Msg("two-factor authentication disabled")
+
+	return nil
+}
+
+// TwoFactorChallenge implements Service interface.
+func (g *GopherMart) TwoFactorChallenge(ctx context.Context, user model.User) (string, error) {
+	log := appContext.Logger(ctx).With().Str("service:", "TwoFactorChallenge").Str("login", user.Login).Logger()
+
+	tf, err := g.enabledTwoFactor(ctx, user)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return "", err
+	}
+	if tf == nil {
+		return "", nil
+	}
+	challenge, err := token.Generate(challengeTokenSize)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return "", fmt.Errorf("service: TwoFactorChallenge: %w", err)
+	}
+	now := time.Now()
+	if err := g.db.CreateLoginChallenge(ctx, &model.LoginChallenge{
+		TokenHash: token.Hash(g.tokenSecret, challenge),
+		UserID:    user.ID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(loginChallengeTTL),
+	}); err != nil {
+		log.Trace().Err(err).Msg("")
+		return "", fmt.Errorf("service: TwoFactorChallenge: %w", err)
+	}
+	log.Debug().Msg("two-factor authentication required")
+
+	return challenge, nil
+}
+
+// CompleteTwoFactorLogin implements Service interface.
+func (g *GopherMart) CompleteTwoFactorLogin(ctx context.Context, challenge, code, ip string) (model.User, error) {
+	log := appContext.Logger(ctx).With().Str("service:", "CompleteTwoFactorLogin").Str("ip", ip).Logger()
+
+	now := time.Now()
+	hash := token.Hash(g.tokenSecret, challenge)
+	// The attempt is counted before the code is checked, so the concurrent requests can't exceed the limit.
+	c, err := g.db.TakeChallengeAttempt(ctx, hash, loginChallengeMaxAttempts, now)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		if errors.Is(err, storage.ErrNotFound) {
+			// The challenge is unknown, expired or has no attempts left.
+			if err := g.db.DeleteLoginChallenge(ctx, hash); err != nil {
+				log.Error().Err(err).Msg("could not delete the login challenge")
+			}
+
+			return model.User{}, ErrInvalidChallenge
+		}
+
+		return model.User{}, fmt.Errorf("service: CompleteTwoFactorLogin: %w", err)
+	}
+	user, err := g.db.UserByID(ctx, c.UserID)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return model.User{}, fmt.Errorf("service: CompleteTwoFactorLogin: %w", err)
+	}
+	if user.Blocked {
+		log.Trace().Err(ErrUserBlocked).Msg("")
+		return model.User{}, ErrUserBlocked
+	}
+	// The invalid codes are counted as failed login attempts, so the login is locked after too many of them
+	// even if the attacker knows the password and requests new challenges.
+	keys := throttleKeys(user.Login, ip)
+	taken, err := g.takeLoginAttempt(ctx, keys, now)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		if errors.Is(err, ErrTooManyAttempts) {
+			return model.User{}, err
+		}
+
+		return model.User{}, fmt.Errorf("service: CompleteTwoFactorLogin: %w", err)
+	}
+	failed := false
+	defer func() {
+		if !failed {
+			g.forgiveLoginAttempt(ctx, keys)
+		}
+	}()
+	tf, err := g.enabledTwoFactor(ctx, *user)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return model.User{}, err
+	}
+	if tf == nil {
+		// Disabled after the challenge was issued.
+		log.Trace().Err(ErrInvalidChallenge).Msg("")
+		return model.User{}, ErrInvalidChallenge
+	}
+	if err := g.checkSecondFactor(ctx, *tf, code); err != nil {
+		log.Trace().Err(err).Msg("")
+		if errors.Is(err, ErrInvalidCode) {
+			failed = true
+			g.registerLoginFailure(ctx, keys, taken, now)
+		}
+
+		return model.User{}, err
+	}
+	if err := g.db.DeleteLoginChallenge(ctx, hash); err != nil {
+		log.Error().Err(err).Msg("could not delete the login challenge")
+	}
+	if err := g.db.DeleteLoginThrottle(ctx, loginKey(user.Login)); err != nil {
+		log.Error().Err(err).Msg("could not reset failed login attempts")
+	}
+	log.Info().Str("login", user.Login).Msg("two-factor authentication passed")
+
+	return *user, nil
+}
+
+// enabledTwoFactor returns the user's two-factor authentication or nil if it isn't enabled.
+func (g *GopherMart) enabledTwoFactor(ctx context.Context, user model.User) (*model.TwoFactor, error) {
+	tf, err := g.db.TwoFactor(ctx, user.ID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("service: two-factor: %w", err)
+	}
+	if !tf.Enabled() {
+		return nil, nil
+	}
+
+	return tf, nil
+}
+
+// checkSecondFactor checks either the TOTP code or the recovery code and marks it as used.
+func (g *GopherMart) checkSecondFactor(ctx context.Context, tf model.TwoFactor, code string) error {
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(tf.Secret, code, time.Now())
+		if !ok || step <= tf.LastUsedStep {
+			return ErrInvalidCode
+		}
+		if err := g.db.UseTOTPStep(ctx, tf.UserID, step); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				// Used concurrently.
+				return ErrInvalidCode
+			}
+
+			return fmt.Errorf("service: two-factor: %w", err)
+		}
+
+		return nil
+	}
+	err := g.db.UseRecoveryCode(ctx, tf.UserID, token.Hash(g.tokenSecret, normalizeRecoveryCode(code)), time.Now())
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return ErrInvalidCode
+		}
+
+		return fmt.Errorf("service: two-factor: %w", err)
+	}
+
+	return nil
+}
+
+// generateRecoveryCode returns a random code formatted as xxxx-xxxx-xxxx-xxxx.
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, recoveryCodeSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	s := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))
+
+	return s[0:4] + "-" + s[4:8] + "-" + s[8:12] + "-" + s[12:16], nil
+}
+
+// normalizeRecoveryCode removes the separators, so the code can be entered in any case and format.
+func normalizeRecoveryCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(code))
+}
@@ -0,0 +1,209 @@
+package gophermart_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/pkg/token"
+	"github.com/vanamelnik/gophermart/pkg/totp"
+	"github.com/vanamelnik/gophermart/service/gophermart"
+	"github.com/vanamelnik/gophermart/storage"
+	mockstorage "github.com/vanamelnik/gophermart/storage/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTwoFactorEnrollment(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	db := mockstorage.NewMockStorage(mockCtrl)
+	ctx, s, err := initServices(db, pepper)
+	require.NoError(t, err)
+	user := appContext.User(ctx)
+
+	var secret string
+	t.Run("#1 Enroll", func(t *testing.T) {
+		db.EXPECT().SetPendingTwoFactor(gomock.Any(), user.ID, gomock.Any()).
+			DoAndReturn(func(_, _ interface{}, s string) error {
+				secret = s
+				return nil
+			})
+		enrollment, err := s.EnrollTwoFactor(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, secret, enrollment.Secret)
+		assert.True(t, strings.HasPrefix(enrollment.URI, "otpauth://totp/Gophermart:frodo@hobbyton.shire.me?"))
+	})
+	t.Run("#2 Already enabled", func(t *testing.T) {
+		db.EXPECT().SetPendingTwoFactor(gomock.Any(), user.ID, gomock.Any()).Return(storage.ErrTwoFactorEnabled)
+		_, err := s.EnrollTwoFactor(ctx)
+		assert.ErrorIs(t, err, gophermart.ErrTwoFactorEnabled)
+	})
+	t.Run("#3 Invalid confirmation code", func(t *testing.T) {
+		db.EXPECT().TwoFactor(gomock.Any(), user.ID).Return(&model.TwoFactor{UserID: user.ID, Secret: secret}, nil)
+		_, err := s.ConfirmTwoFactor(ctx, "000000x")
+		assert.ErrorIs(t, err, gophermart.ErrInvalidCode)
+	})
+	t.Run("#4 Confirm", func(t *testing.T) {
+		now := time.Now()
+		code, err := totp.Code(secret, totp.Step(now))
+		require.NoError(t, err)
+		db.EXPECT().TwoFactor(gomock.Any(), user.ID).Return(&model.TwoFactor{UserID: user.ID, Secret: secret}, nil)
+		var hashes []string
+		db.EXPECT().EnableTwoFactor(gomock.Any(), user.ID, totp.Step(now), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_, _, _, _ interface{}, h []string) error {
+				hashes = h
+				return nil
+			})
+
+		codes, err := s.ConfirmTwoFactor(ctx, code)
+		require.NoError(t, err)
+		require.Len(t, codes, 10)
+		assert.Regexp(t, `^[a-z2-7]{4}-[a-z2-7]{4}-[a-z2-7]{4}-[a-z2-7]{4}$`, codes[0])
+		assert.Equal(t, token.Hash(tokenSecret, strings.ReplaceAll(codes[0], "-", "")), hashes[0])
+	})
+	t.Run("#5 Not enrolled", func(t *testing.T) {
+		db.EXPECT().TwoFactor(gomock.Any(), user.ID).Return(nil, storage.ErrNotFound)
+		_, err := s.ConfirmTwoFactor(ctx, "123456")
+		assert.ErrorIs(t, err, gophermart.ErrTwoFactorNotEnabled)
+	})
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	db := mockstorage.NewMockStorage(mockCtrl)
+	ctx, s, err := initServices(db, pepper)
+	require.NoError(t, err)
+	user := *appContext.User(ctx)
+
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	enabledAt := time.Now()
+	tf := &model.TwoFactor{UserID: user.ID, Secret: secret, EnabledAt: &enabledAt}
+	const ip = "192.0.2.7"
+
+	t.Run("#1 No challenge without two-factor authentication", func(t *testing.T) {
+		db.EXPECT().TwoFactor(gomock.Any(), user.ID).Return(nil, storage.ErrNotFound)
+		challenge, err := s.TwoFactorChallenge(ctx, user)
+		require.NoError(t, err)
+		assert.Empty(t, challenge)
+
+		db.EXPECT().TwoFactor(gomock.Any(), user.ID).Return(&model.TwoFactor{UserID: user.ID, Secret: secret}, nil)
+		challenge, err = s.TwoFactorChallenge(ctx, user)
+		require.NoError(t, err)
+		assert.Empty(t, challenge, "pending two-factor authentication is not required")
+	})
+
+	var c *model.LoginChallenge
+	var challenge string
+	t.Run("#2 Challenge", func(t *testing.T) {
+		db.EXPECT().TwoFactor(gomock.Any(), user.ID).Return(tf, nil)
+		db.EXPECT().CreateLoginChallenge(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ interface{}, lc *model.LoginChallenge) error {
+				c = lc
+				return nil
+			})
+		challenge, err = s.TwoFactorChallenge(ctx, user)
+		require.NoError(t, err)
+		assert.NotEmpty(t, challenge)
+		assert.Equal(t, token.Hash(tokenSecret, challenge), c.TokenHash)
+		assert.Equal(t, user.ID, c.UserID)
+	})
+	// The login attempts of the user and the IP address are throttled: the attempt is counted as failed
+	// before the code is checked.
+	expectThrottleCheck := func() {
+		db.EXPECT().LoginThrottle(gomock.Any(), "login:"+user.Login).Return(nil, storage.ErrNotFound)
+		db.EXPECT().LoginThrottle(gomock.Any(), "ip:"+ip).Return(nil, storage.ErrNotFound)
+		db.EXPECT().TakeLoginAttempt(gomock.Any(), "login:"+user.Login, 0, gomock.Any(), gomock.Any()).
+			Return(&model.LoginThrottle{Failures: 1}, nil)
+		db.EXPECT().TakeLoginAttempt(gomock.Any(), "ip:"+ip, 0, gomock.Any(), gomock.Any()).
+			Return(&model.LoginThrottle{Failures: 1}, nil)
+	}
+	expectLoginSuccess := func() {
+		db.EXPECT().DeleteLoginChallenge(gomock.Any(), c.TokenHash).Return(nil)
+		db.EXPECT().DeleteLoginThrottle(gomock.Any(), "login:"+user.Login).Return(nil)
+		db.EXPECT().ForgiveLoginAttempt(gomock.Any(), "login:"+user.Login).Return(nil)
+		db.EXPECT().ForgiveLoginAttempt(gomock.Any(), "ip:"+ip).Return(nil)
+	}
+	t.Run("#3 Invalid code", func(t *testing.T) {
+		db.EXPECT().TakeChallengeAttempt(gomock.Any(), c.TokenHash, 5, gomock.Any()).Return(c, nil)
+		db.EXPECT().UserByID(gomock.Any(), user.ID).Return(&user, nil)
+		expectThrottleCheck()
+		db.EXPECT().TwoFactor(gomock.Any(), user.ID).Return(tf, nil)
+		db.EXPECT().UseRecoveryCode(gomock.Any(), user.ID, gomock.Any(), gomock.Any()).Return(storage.ErrNotFound)
+
+		_, err := s.CompleteTwoFactorLogin(ctx, challenge, "aaaa-bbbb-cccc-dddd", ip)
+		assert.ErrorIs(t, err, gophermart.ErrInvalidCode)
+	})
+	t.Run("#4 Valid TOTP code", func(t *testing.T) {
+		now := time.Now()
+		code, err := totp.Code(secret, totp.Step(now))
+		require.NoError(t, err)
+		db.EXPECT().TakeChallengeAttempt(gomock.Any(), c.TokenHash, 5, gomock.Any()).Return(c, nil)
+		db.EXPECT().UserByID(gomock.Any(), user.ID).Return(&user, nil)
+		expectThrottleCheck()
+		db.EXPECT().TwoFactor(gomock.Any(), user.ID).Return(tf, nil)
+		db.EXPECT().UseTOTPStep(gomock.Any(), user.ID, totp.Step(now)).Return(nil)
+		expectLoginSuccess()
+
+		u, err := s.CompleteTwoFactorLogin(ctx, challenge, code, ip)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, u.ID)
+	})
+	t.Run("#5 Replayed TOTP code", func(t *testing.T) {
+		now := time.Now()
+		code, err := totp.Code(secret, totp.Step(now))
+		require.NoError(t, err)
+		used := *tf
+		used.LastUsedStep = totp.Step(now)
+		db.EXPECT().TakeChallengeAttempt(gomock.Any(), c.TokenHash, 5, gomock.Any()).Return(c, nil)
+		db.EXPECT().UserByID(gomock.Any(), user.ID).Return(&user, nil)
+		expectThrottleCheck()
+		db.EXPECT().TwoFactor(gomock.Any(), user.ID).Return(&used, nil)
+
+		_, err = s.CompleteTwoFactorLogin(ctx, challenge, code, ip)
+		assert.ErrorIs(t, err, gophermart.ErrInvalidCode)
+	})
+	t.Run("#6 Recovery code", func(t *testing.T) {
+		db.EXPECT().TakeChallengeAttempt(gomock.Any(), c.TokenHash, 5, gomock.Any()).Return(c, nil)
+		db.EXPECT().UserByID(gomock.Any(), user.ID).Return(&user, nil)
+		expectThrottleCheck()
+		db.EXPECT().TwoFactor(gomock.Any(), user.ID).Return(tf, nil)
+		db.EXPECT().UseRecoveryCode(gomock.Any(), user.ID, token.Hash(tokenSecret, "aaaabbbbccccdddd"), gomock.Any()).
+			Return(nil)
+		expectLoginSuccess()
+
+		_, err := s.CompleteTwoFactorLogin(ctx, challenge, "AAAA-BBBB-CCCC-DDDD", ip)
+		assert.NoError(t, err)
+	})
+	t.Run("#7 Too many attempts", func(t *testing.T) {
+		db.EXPECT().TakeChallengeAttempt(gomock.Any(), c.TokenHash, 5, gomock.Any()).Return(nil, storage.ErrNotFound)
+		db.EXPECT().DeleteLoginChallenge(gomock.Any(), c.TokenHash).Return(nil)
+
+		_, err := s.CompleteTwoFactorLogin(ctx, challenge, "123456", ip)
+		assert.ErrorIs(t, err, gophermart.ErrInvalidChallenge)
+	})
+	t.Run("#8 Locked login", func(t *testing.T) {
+		until := time.Now().Add(10 * time.Minute)
+		db.EXPECT().TakeChallengeAttempt(gomock.Any(), c.TokenHash, 5, gomock.Any()).Return(c, nil)
+		db.EXPECT().UserByID(gomock.Any(), user.ID).Return(&user, nil)
+		db.EXPECT().LoginThrottle(gomock.Any(), "login:"+user.Login).
+			Return(&model.LoginThrottle{Failures: 10, LastFailureAt: time.Now(), LockedUntil: &until}, nil)
+		db.EXPECT().LoginThrottle(gomock.Any(), "ip:"+ip).Return(nil, storage.ErrNotFound)
+
+		_, err := s.CompleteTwoFactorLogin(ctx, challenge, "123456", ip)
+		assert.ErrorIs(t, err, gophermart.ErrTooManyAttempts)
+	})
+	t.Run("#9 Unknown challenge", func(t *testing.T) {
+		db.EXPECT().TakeChallengeAttempt(gomock.Any(), gomock.Any(), 5, gomock.Any()).Return(nil, storage.ErrNotFound)
+		db.EXPECT().DeleteLoginChallenge(gomock.Any(), gomock.Any()).Return(nil)
+
+		_, err := s.CompleteTwoFactorLogin(ctx, "unknown", "123456", ip)
+		assert.ErrorIs(t, err, gophermart.ErrInvalidChallenge)
+	})
+}
@@ -7,9 +7,10 @@ import (
 	"time"
 
 	"github.com/vanamelnik/gophermart/model"
-	"github.com/vanamelnik/gophermart/pkg/bcrypt"
 	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
 	"github.com/vanamelnik/gophermart/pkg/currency"
+	"github.com/vanamelnik/gophermart/pkg/metrics"
+	"github.com/vanamelnik/gophermart/pkg/pwhash"
 	"github.com/vanamelnik/gophermart/storage"
 
 	"github.com/google/uuid"
@@ -30,14 +31,19 @@ func (g *GopherMart) Create(ctx context.Context, login, password string) (model.
 		Password:       password,
 		CreatedAt:      time.Now(),
 		GPointsBalance: 0,
+		Role:           model.RoleUser,
 	}
 
 	if err := user.Validate(); err != nil {
+		log.Trace().Err(err).Msg("")
+		return model.User{}, fmt.Errorf("%w: %v", ErrInvalidLogin, err)
+	}
+	if err := g.checkPassword(password, login); err != nil {
 		log.Trace().Err(err).Msg("")
 		return model.User{}, err
 	}
 
-	user.PasswordHash, err = bcrypt.BcryptPassword(password, g.pwPepper)
+	user.PasswordHash, err = g.hasher.Hash(password)
 	if err != nil {
 		log.Trace().Err(err).Msg("")
 		return model.User{}, fmt.Errorf("service: create: %w", err)
@@ -52,29 +58,91 @@ func (g *GopherMart) Create(ctx context.Context, login, password string) (model.
 		Str("login", user.Login).
 		Str("id", user.ID.String()).
 		Msg("successfully created a new user")
+	metrics.Registrations.Inc()
 
 	return user, nil
 }
 
 // Authenticate implements Service interface.
-func (g *GopherMart) Authenticate(ctx context.Context, login, password string) (model.User, error) {
-	log := appContext.Logger(ctx).With().Str("service:", "authenticate:").Logger()
+func (g *GopherMart) Authenticate(ctx context.Context, login, password, ip string) (model.User, error) {
+	log := appContext.Logger(ctx).With().Str("service:", "authenticate:").Str("ip", ip).Logger()
+
+	now := time.Now()
+	keys := throttleKeys(login, ip)
+	// The attempt is counted as failed before the credentials are checked. It's taken back unless they are wrong.
+	taken, err := g.takeLoginAttempt(ctx, keys, now)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		if errors.Is(err, ErrTooManyAttempts) {
+			return model.User{}, err
+		}
+
+		return model.User{}, fmt.Errorf("service: authenticate: %w", err)
+	}
+	failed := false
+	defer func() {
+		if !failed {
+			g.forgiveLoginAttempt(ctx, keys)
+		}
+	}()
 
 	// we don't need to validate login & password - in the DB all is OK.
 	user, err := g.db.UserByLogin(ctx, login)
 	if err != nil {
 		log.Trace().Err(err).Msg("")
+		if errors.Is(err, storage.ErrNotFound) {
+			// Spend the same time as for the existing login and don't tell the client that the login is unknown.
+			g.compareDummyHash(password)
+			failed = true
+			g.registerLoginFailure(ctx, keys, taken, now)
+
+			return model.User{}, ErrWrongCredentials
+		}
+
 		return model.User{}, fmt.Errorf("service: authenticate: %w", err)
 	}
+	if user.Deleted() {
+		// The tombstone of a deleted account has no credentials.
+		log.Trace().Msg("the account is deleted")
+		g.compareDummyHash(password)
+		failed = true
+		g.registerLoginFailure(ctx, keys, taken, now)
+
+		return model.User{}, ErrWrongCredentials
+	}
 
-	if err = bcrypt.CompareHashAndPassword(password+g.pwPepper, user.PasswordHash); err != nil {
-		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
-			log.Trace().Err(err).Msg("")
-			return model.User{}, ErrWrongPassword
+	needsRehash, err := g.hasher.Verify(password, user.PasswordHash)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		if errors.Is(err, pwhash.ErrMismatchedHashAndPassword) {
+			failed = true
+			g.registerLoginFailure(ctx, keys, taken, now)
+
+			return model.User{}, ErrWrongCredentials
 		}
+
+		return model.User{}, fmt.Errorf("service: authenticate: %w", err)
+	}
+
+	if user.Blocked {
+		log.Trace().Err(ErrUserBlocked).Msg("")
+		return model.User{}, ErrUserBlocked
+	}
+
+	// With two-factor authentication the failed attempts are reset after the second factor is checked.
+	tf, err := g.enabledTwoFactor(ctx, *user)
+	if err != nil {
 		log.Trace().Err(err).Msg("")
 		return model.User{}, fmt.Errorf("service: authenticate: %w", err)
 	}
+	if tf == nil {
+		if err := g.db.DeleteLoginThrottle(ctx, loginKey(login)); err != nil {
+			log.Error().Err(err).Msg("could not reset failed login attempts")
+		}
+	}
+	if needsRehash {
+		g.upgradePasswordHash(ctx, user, password)
+	}
 
 	log.Info().
 		Str("login", user.Login).
@@ -84,6 +152,27 @@ func (g *GopherMart) Authenticate(ctx context.Context, login, password string) (
 	return *user, nil
 }
 
+// upgradePasswordHash replaces the user's password hash made with an outdated algorithm, parameters or pepper.
+// The errors are only logged: the user is already authenticated.
+func (g *GopherMart) upgradePasswordHash(ctx context.Context, user *model.User, password string) {
+	log := appContext.Logger(ctx).With().Str("service:", "upgradePasswordHash").Str("userID", user.ID.String()).Logger()
+
+	newHash, err := g.hasher.Hash(password)
+	if err != nil {
+		log.Error().Err(err).Msg("could not hash the password")
+
+		return
+	}
+	// The hash isn't replaced if the password was changed concurrently.
+	if err := g.db.UpdatePasswordHash(ctx, user.ID, user.PasswordHash, newHash); err != nil {
+		log.Error().Err(err).Msg("could not upgrade the password hash")
+
+		return
+	}
+	user.PasswordHash = newHash
+	log.Info().Msg("password hash upgraded")
+}
+
 // GetOrders implements Service interface.
 func (g *GopherMart) GetOrders(ctx context.Context) ([]model.Order, error) {
 	log := userLogger(ctx).With().Str("service:", "getOrders").Logger()
@@ -132,12 +221,27 @@ func (g *GopherMart) GetBalance(ctx context.Context) (UserBalance, error) {
 		return UserBalance{}, ErrNotAuthenticated
 	}
 
+	balance, err := g.userBalance(ctx, user.ID)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return UserBalance{}, fmt.Errorf("service: GetBalance: %w", err)
+	}
+
+	log.Info().
+		Float32("current", balance.Current).
+		Float32("withdrawn", balance.Withdrawn).
+		Msg("information about user's balance successfully received")
+
+	return balance, nil
+}
+
+// userBalance returns information about the user's bonus balance and total withdrawn amount.
+func (g *GopherMart) userBalance(ctx context.Context, userID uuid.UUID) (UserBalance, error) {
 	balance := UserBalance{}
 
-	withdrawals, err := g.db.WithdrawalsByUserID(ctx, user.ID)
+	withdrawals, err := g.db.WithdrawalsByUserID(ctx, userID)
 	if err != nil && !errors.Is(err, storage.ErrNotFound) { // It's OK if the user has not performed any withdraw operations.
-		log.Trace().Err(err).Msg("")
-		return UserBalance{}, fmt.Errorf("service: GetBalance: %w", err)
+		return UserBalance{}, err
 	}
 
 	// Collect information about total withdrawn bonus amount.
@@ -150,22 +254,15 @@ func (g *GopherMart) GetBalance(ctx context.Context) (UserBalance, error) {
 
 	// Update balance information
 	if _, err := g.db.UpdateBalance(ctx); err != nil {
-		log.Trace().Err(err).Msg("")
 		return UserBalance{}, err
 	}
 
 	// Update current user balance information
-	user, err = g.db.UserByLogin(ctx, user.Login)
+	user, err := g.db.UserByID(ctx, userID)
 	if err != nil {
-		log.Trace().Err(err).Msg("")
 		return UserBalance{}, err
 	}
 	balance.Current = user.GPointsBalance
 
-	log.Info().
-		Float32("current", balance.Current).
-		Float32("withdrawn", balance.Withdrawn).
-		Msg("information about user's balance successfully received")
-
 	return balance, nil
 }
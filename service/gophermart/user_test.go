@@ -2,13 +2,14 @@ package gophermart_test
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/vanamelnik/gophermart/model"
-	"github.com/vanamelnik/gophermart/pkg/bcrypt"
 	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
 	"github.com/vanamelnik/gophermart/pkg/logging"
+	"github.com/vanamelnik/gophermart/pkg/pwhash"
 	"github.com/vanamelnik/gophermart/service/gophermart"
 	"github.com/vanamelnik/gophermart/storage"
 	mockstorage "github.com/vanamelnik/gophermart/storage/mock"
@@ -17,9 +18,13 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
-const pepper = "custom pepper"
+const (
+	pepper      = "custom pepper"
+	tokenSecret = "custom token secret"
+)
 
 func TestCreate(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
@@ -98,11 +103,17 @@ func TestAuthenticate(t *testing.T) {
 	require.NoError(t, err)
 
 	// generate hashes for mock responses
-	billgatesRightPwdHash, err := bcrypt.BcryptPassword("L1nuxF0rever", pepper)
+	billgatesRightPwdHash, err := bcryptHash("L1nuxF0rever", pepper)
 	require.NoError(t, err)
-	hedgehogPwdHash, err := bcrypt.BcryptPassword("Ho0o0o0orse!", pepper)
+	hedgehogPwdHash, err := bcryptHash("Ho0o0o0orse!", pepper)
 	require.NoError(t, err)
 
+	db.EXPECT().LoginThrottle(gomock.Any(), gomock.Any()).Return(nil, storage.ErrNotFound).AnyTimes()
+	db.EXPECT().TakeLoginAttempt(gomock.Any(), gomock.Any(), 0, gomock.Any(), gomock.Any()).
+		Return(&model.LoginThrottle{Failures: 1}, nil).Times(6) // login and ip keys for each test
+	db.EXPECT().ForgiveLoginAttempt(gomock.Any(), gomock.Any()).Return(nil).Times(2) // test #3
+	db.EXPECT().TwoFactor(gomock.Any(), gomock.Any()).Return(nil, storage.ErrNotFound).Times(1)
+	db.EXPECT().DeleteLoginThrottle(gomock.Any(), "login:hedgehog@mist.ru").Return(nil).Times(1)
 	db.EXPECT().UserByLogin(gomock.Any(), gomock.Any()).Return(nil, storage.ErrNotFound).Times(1)
 	db.EXPECT().UserByLogin(gomock.Any(), "billgates@microsoft.com").Return(&model.User{
 		ID:             [16]byte{},
@@ -153,7 +164,7 @@ func TestAuthenticate(t *testing.T) {
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			user, err := s.Authenticate(ctx, tc.login, tc.password)
+			user, err := s.Authenticate(ctx, tc.login, tc.password, "192.0.2.1")
 			if tc.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -168,6 +179,58 @@ func TestAuthenticate(t *testing.T) {
 	}
 }
 
+func TestAuthenticateRehash(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	db := mockstorage.NewMockStorage(mockCtrl)
+	ctx := appContext.WithLogger(context.Background(),
+		logging.NewLogger(logging.WithConsoleOutput(true), logging.WithLevel("trace")))
+	s, err := gophermart.New(ctx, db,
+		gophermart.WithConfig(gophermart.Config{
+			PasswordPepper: pepper,
+			TokenSecret:    tokenSecret,
+			PasswordHash: pwhash.Config{
+				Algorithm:     pwhash.AlgorithmArgon2id,
+				Argon2id:      pwhash.Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 1},
+				Peppers:       map[string]string{"2022-06": "new pepper"},
+				CurrentPepper: "2022-06",
+			},
+		}),
+		gophermart.WithoutWorkers())
+	require.NoError(t, err)
+
+	// The hash made before the algorithm and the pepper were changed.
+	legacyHash, err := bcryptHash("Ho0o0o0orse!", pepper)
+	require.NoError(t, err)
+	hedgehog := &model.User{ID: uuid.New(), Login: "hedgehog@mist.ru", PasswordHash: legacyHash}
+
+	db.EXPECT().LoginThrottle(gomock.Any(), gomock.Any()).Return(nil, storage.ErrNotFound).AnyTimes()
+	db.EXPECT().TakeLoginAttempt(gomock.Any(), gomock.Any(), 0, gomock.Any(), gomock.Any()).
+		Return(&model.LoginThrottle{Failures: 1}, nil).AnyTimes()
+	db.EXPECT().ForgiveLoginAttempt(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	db.EXPECT().TwoFactor(gomock.Any(), gomock.Any()).Return(nil, storage.ErrNotFound).AnyTimes()
+	db.EXPECT().DeleteLoginThrottle(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	db.EXPECT().UserByLogin(gomock.Any(), hedgehog.Login).Return(hedgehog, nil).Times(1)
+	var newHash string
+	db.EXPECT().UpdatePasswordHash(gomock.Any(), hedgehog.ID, legacyHash, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ uuid.UUID, _, hash string) error {
+			newHash = hash
+
+			return nil
+		}).Times(1)
+
+	user, err := s.Authenticate(ctx, hedgehog.Login, "Ho0o0o0orse!", "192.0.2.1")
+	require.NoError(t, err)
+	assert.Equal(t, newHash, user.PasswordHash)
+	assert.True(t, strings.HasPrefix(newHash, "$p=2022-06$argon2id$"), newHash)
+
+	// The upgraded hash isn't upgraded again.
+	hedgehog.PasswordHash = newHash
+	db.EXPECT().UserByLogin(gomock.Any(), hedgehog.Login).Return(hedgehog, nil).Times(1)
+	_, err = s.Authenticate(ctx, hedgehog.Login, "Ho0o0o0orse!", "192.0.2.1")
+	require.NoError(t, err)
+}
+
 func TestGetOrders(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -224,7 +287,7 @@ func initServices(mockdb storage.Storage, pepper string) (context.Context, *goph
 		GPointsBalance: 0,
 	})
 	s, err := gophermart.New(ctx, mockdb,
-		gophermart.WithConfig(gophermart.Config{PasswordPepper: pepper}),
+		gophermart.WithConfig(gophermart.Config{PasswordPepper: pepper, TokenSecret: tokenSecret}),
 		gophermart.WithoutWorkers())
 	if err != nil {
 		return nil, nil, err
@@ -232,3 +295,10 @@ func initServices(mockdb storage.Storage, pepper string) (context.Context, *goph
 
 	return ctx, s, nil
 }
+
+// bcryptHash hashes the peppered password with bcrypt like the former implementation of the password hashing.
+func bcryptHash(password, pepper string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password+pepper), bcrypt.DefaultCost)
+
+	return string(hash), err
+}
@@ -0,0 +1,222 @@
+package gophermart
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+
+	"github.com/google/uuid"
+)
+
+const webhookSecretSize = 32
+
+// webhookPayload is the envelope of every event sent to the partners.
+type webhookPayload struct {
+	ID        uuid.UUID       `json:"id"`
+	Event     model.EventType `json:"event"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      interface{}     `json:"data"`
+}
+
+// CreateWebhook implements Service interface.
+func (g *GopherMart) CreateWebhook(ctx context.Context, webhookURL, secret string, events []model.EventType) (model.Webhook, error) {
+	log := appContext.Logger(ctx).With().Str("service:", "CreateWebhook").Logger()
+
+	if err := validateWebhook(webhookURL, events); err != nil {
+		log.Trace().Err(err).Msg("")
+		return model.Webhook{}, err
+	}
+	if secret == "" {
+		var err error
+		if secret, err = generateSecret(webhookSecretSize); err != nil {
+			log.Trace().Err(err).Msg("")
+			return model.Webhook{}, fmt.Errorf("service: CreateWebhook: %w", err)
+		}
+	}
+	webhook := model.Webhook{
+		ID:         uuid.New(),
+		URL:        webhookURL,
+		Secret:     secret,
+		EventTypes: events,
+		CreatedAt:  time.Now(),
+	}
+	if err := g.db.CreateWebhook(ctx, &webhook); err != nil {
+		log.Trace().Err(err).Msg("")
+		return model.Webhook{}, fmt.Errorf("service: CreateWebhook: %w", err)
+	}
+	log.Info().
+		Str("id", webhook.ID.String()).
+		Str("url", webhook.URL).
+		Msg("webhook subscription created")
+
+	return webhook, nil
+}
+
+// Webhooks implements Service interface.
+func (g *GopherMart) Webhooks(ctx context.Context) ([]model.Webhook, error) {
+	log := appContext.Logger(ctx).With().Str("service:", "Webhooks").Logger()
+
+	webhooks, err := g.db.Webhooks(ctx)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return nil, fmt.Errorf("service: Webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+// DeleteWebhook implements Service interface.
+func (g *GopherMart) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	log := appContext.Logger(ctx).With().Str("service:", "DeleteWebhook").Logger()
+
+	if err := g.db.DeleteWebhook(ctx, id); err != nil {
+		log.Trace().Err(err).Msg("")
+		return fmt.Errorf("service: DeleteWebhook: %w", err)
+	}
+
+	return nil
+}
+
+// WebhookDeliveries implements Service interface.
+func (g *GopherMart) WebhookDeliveries(ctx context.Context, webhookID uuid.UUID) ([]model.WebhookDelivery, error) {
+	log := appContext.Logger(ctx).With().Str("service:", "WebhookDeliveries").Logger()
+
+	deliveries, err := g.db.WebhookDeliveries(ctx, webhookID)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return nil, fmt.Errorf("service: WebhookDeliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// ReplayWebhookDelivery implements Service interface.
+func (g *GopherMart) ReplayWebhookDelivery(ctx context.Context, id uuid.UUID) error {
+	log := appContext.Logger(ctx).With().
+		Str("service:", "ReplayWebhookDelivery").
+		Str("deliveryID", id.String()).
+		Logger()
+
+	d, err := g.db.WebhookDeliveryByID(ctx, id)
+	if err != nil {
+		log.Trace().Err(err).Msg("")
+		return fmt.Errorf("service: ReplayWebhookDelivery: %w", err)
+	}
+	if d.Status != model.DeliveryFailed {
+		log.Trace().Err(ErrDeliveryNotFailed).Str("status", string(d.Status)).Msg("")
+		return ErrDeliveryNotFailed
+	}
+
+	// The delivery is put back into the queue with a fresh attempts counter.
+	d.Status = model.DeliveryPending
+	d.Attempts = 0
+	d.NextAttemptAt = time.Now()
+	if err := g.db.UpdateWebhookDelivery(ctx, d); err != nil {
+		log.Trace().Err(err).Msg("")
+		return fmt.Errorf("service: ReplayWebhookDelivery: %w", err)
+	}
+	log.Info().Msg("webhook delivery is queued for replay")
+
+	return nil
+}
+
+// queueWebhookDeliveries adds a delivery of each event to the queue for every webhook subscribed to it.
+// The id of the delivery is derived from the event and the webhook, so the events queued again after
+// a failure aren't delivered twice.
+func (g *GopherMart) queueWebhookDeliveries(ctx context.Context, events []model.Event) error {
+	var webhooks []model.Webhook
+	for _, e := range events {
+		eventType, ok := webhookEvent(e)
+		if !ok {
+			continue
+		}
+		if webhooks == nil {
+			var err error
+			if webhooks, err = g.db.Webhooks(ctx); err != nil {
+				return err
+			}
+		}
+		now := time.Now()
+		for _, w := range webhooks {
+			if !w.Subscribed(eventType) {
+				continue
+			}
+			id := uuid.NewSHA1(w.ID, []byte(strconv.FormatInt(e.ID, 10)))
+			payload, err := json.Marshal(webhookPayload{
+				ID:        id,
+				Event:     eventType,
+				CreatedAt: e.CreatedAt,
+				Data:      e.Payload,
+			})
+			if err != nil {
+				return err
+			}
+			if err := g.db.CreateWebhookDelivery(ctx, &model.WebhookDelivery{
+				ID:            id,
+				WebhookID:     w.ID,
+				EventType:     eventType,
+				Payload:       payload,
+				Status:        model.DeliveryPending,
+				NextAttemptAt: now,
+				CreatedAt:     now,
+			}); err != nil {
+				return fmt.Errorf("webhook %s: %w", w.ID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// webhookEvent returns the type of the webhook event the domain event is delivered as.
+// False is returned if the partners aren't notified of the event.
+func webhookEvent(e model.Event) (model.EventType, bool) {
+	switch e.Type {
+	case model.EventOrderProcessed, model.EventWithdrawalProcessed:
+		return e.Type, true
+	case model.EventOrderStatusChanged:
+		var order model.OrderEvent
+		if err := json.Unmarshal(e.Payload, &order); err == nil && order.Status == model.StatusInvalid {
+			return model.EventOrderInvalid, true
+		}
+	}
+
+	return "", false
+}
+
+// validateWebhook checks the url and the list of events of a new subscription.
+func validateWebhook(webhookURL string, events []model.EventType) error {
+	u, err := url.Parse(webhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: invalid url", ErrInvalidWebhook)
+	}
+	if len(events) == 0 {
+		return fmt.Errorf("%w: no event types", ErrInvalidWebhook)
+	}
+	for _, e := range events {
+		if !e.Valid() {
+			return fmt.Errorf("%w: unknown event type %q", ErrInvalidWebhook, e)
+		}
+	}
+
+	return nil
+}
+
+// generateSecret creates a random hex encoded secret.
+func generateSecret(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.New("could not generate a secret")
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,117 @@
+package gophermart
+
+import (
+	"context"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+)
+
+const (
+	// webhookBatchSize is the maximum number of deliveries sent on each tick.
+	webhookBatchSize = 100
+	// maxWebhookRetryInterval caps the exponential backoff.
+	maxWebhookRetryInterval = time.Hour
+	// webhookClaimTTL is the time the deliveries claimed are hidden from other instances of the service.
+	// It exceeds the time of sending a batch, so the deliveries of a crashed instance are retried after it.
+	webhookClaimTTL = 30 * time.Minute
+)
+
+// webhookDispatcher sends the pending deliveries from the queue to the partners' webhooks.
+// Failed deliveries are retried with exponential backoff until the attempts are exhausted.
+func (g *GopherMart) webhookDispatcher(ctx context.Context) {
+	log := appContext.Logger(ctx).With().Str("service:", "webhookDispatcher").Logger()
+	log.Info().Msg("webhookDispatcher started")
+	t := time.NewTicker(g.webhookDispatchInterval)
+loop:
+	for {
+		select {
+		case <-t.C:
+			g.dispatchWebhooks(ctx)
+		case <-g.workersStop:
+			break loop
+		}
+	}
+	log.Info().Msg("webhookDispatcher stopped")
+}
+
+// dispatchWebhooks claims a batch of due deliveries and sends them. The deliveries claimed aren't sent
+// by other instances of the service.
+func (g *GopherMart) dispatchWebhooks(ctx context.Context) {
+	log := appContext.Logger(ctx).With().Str("service:", "webhookDispatcher").Logger()
+
+	now := time.Now()
+	deliveries, err := g.db.ClaimWebhookDeliveries(ctx, now, now.Add(webhookClaimTTL), webhookBatchSize)
+	if err != nil {
+		log.Error().Err(err).Msg("could not claim due deliveries")
+
+		return
+	}
+	if len(deliveries) == 0 {
+		return
+	}
+
+	webhooks, err := g.db.Webhooks(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("could not get webhooks")
+
+		return
+	}
+	byID := make(map[string]model.Webhook, len(webhooks))
+	for _, w := range webhooks {
+		byID[w.ID.String()] = w
+	}
+
+	for _, d := range deliveries {
+		w, ok := byID[d.WebhookID.String()]
+		if !ok { // the webhook has been deleted after the deliveries were fetched.
+			continue
+		}
+		g.deliverWebhook(ctx, w, d)
+	}
+}
+
+// deliverWebhook performs a delivery attempt and stores its result in the delivery log.
+func (g *GopherMart) deliverWebhook(ctx context.Context, w model.Webhook, d model.WebhookDelivery) {
+	log := appContext.Logger(ctx).With().
+		Str("service:", "webhookDispatcher").
+		Str("deliveryID", d.ID.String()).
+		Str("event", string(d.EventType)).
+		Logger()
+
+	code, err := g.webhookSender.Send(ctx, w.URL, w.Secret, d)
+	d.Attempts++
+	d.LastStatusCode = code
+	switch {
+	case err == nil:
+		d.Status = model.DeliveryDelivered
+		d.LastError = ""
+		log.Info().Int("attempt", d.Attempts).Msg("webhook delivered")
+	case d.Attempts >= g.webhookMaxAttempts:
+		d.Status = model.DeliveryFailed
+		d.LastError = err.Error()
+		log.Error().Err(err).Int("attempt", d.Attempts).Msg("webhook delivery failed, no attempts left")
+	default:
+		d.LastError = err.Error()
+		d.NextAttemptAt = time.Now().Add(g.webhookBackoff(d.Attempts))
+		log.Warn().Err(err).Int("attempt", d.Attempts).Time("next attempt", d.NextAttemptAt).Msg("webhook delivery failed")
+	}
+
+	if err := g.db.UpdateWebhookDelivery(ctx, &d); err != nil {
+		log.Error().Err(err).Msg("could not update the delivery")
+	}
+}
+
+// webhookBackoff returns the delay before the next attempt: retry interval doubled for each failed attempt.
+func (g *GopherMart) webhookBackoff(attempts int) time.Duration {
+	delay := g.webhookRetryInterval
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxWebhookRetryInterval {
+			return maxWebhookRetryInterval
+		}
+	}
+
+	return delay
+}
@@ -0,0 +1,131 @@
+package gophermart
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	appContext "github.com/vanamelnik/gophermart/pkg/ctx"
+	"github.com/vanamelnik/gophermart/pkg/logging"
+	"github.com/vanamelnik/gophermart/provider/webhook"
+	mockstorage "github.com/vanamelnik/gophermart/storage/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDispatchWebhooks(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	db := mockstorage.NewMockStorage(mockCtrl)
+	ctx := appContext.WithLogger(context.Background(),
+		logging.NewLogger(logging.WithConsoleOutput(true), logging.WithLevel("trace")))
+
+	// The receiver fails every request until healthy is set.
+	var healthy, received int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&received, 1)
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	g, err := New(ctx, db,
+		WithConfig(Config{WebhookMaxAttempts: 2, WebhookRetryInterval: time.Minute}),
+		WithWebhookSender(webhook.New(time.Second)),
+		WithoutWorkers())
+	require.NoError(t, err)
+
+	hook := model.Webhook{
+		ID:         uuid.New(),
+		URL:        receiver.URL,
+		Secret:     "secret",
+		EventTypes: []model.EventType{model.EventOrderProcessed},
+	}
+	delivery := model.WebhookDelivery{
+		ID:        uuid.New(),
+		WebhookID: hook.ID,
+		EventType: model.EventOrderProcessed,
+		Payload:   []byte(`{}`),
+		Status:    model.DeliveryPending,
+	}
+	db.EXPECT().Webhooks(gomock.Any()).Return([]model.Webhook{hook}, nil).AnyTimes()
+
+	t.Run("#1 First attempt fails, the delivery is rescheduled", func(t *testing.T) {
+		db.EXPECT().ClaimWebhookDeliveries(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, now, claimUntil time.Time, _ int) ([]model.WebhookDelivery, error) {
+				assert.Equal(t, webhookClaimTTL, claimUntil.Sub(now))
+
+				return []model.WebhookDelivery{delivery}, nil
+			}).Times(1)
+		db.EXPECT().UpdateWebhookDelivery(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, d *model.WebhookDelivery) error {
+				assert.Equal(t, model.DeliveryPending, d.Status)
+				assert.Equal(t, 1, d.Attempts)
+				assert.Equal(t, http.StatusInternalServerError, d.LastStatusCode)
+				assert.NotEmpty(t, d.LastError)
+				assert.True(t, d.NextAttemptAt.After(time.Now().Add(59*time.Second)))
+				delivery = *d
+
+				return nil
+			}).Times(1)
+		g.dispatchWebhooks(ctx)
+	})
+	t.Run("#2 Attempts exhausted, the delivery is failed", func(t *testing.T) {
+		db.EXPECT().ClaimWebhookDeliveries(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return([]model.WebhookDelivery{delivery}, nil).Times(1)
+		db.EXPECT().UpdateWebhookDelivery(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, d *model.WebhookDelivery) error {
+				assert.Equal(t, model.DeliveryFailed, d.Status)
+				assert.Equal(t, 2, d.Attempts)
+				delivery = *d
+
+				return nil
+			}).Times(1)
+		g.dispatchWebhooks(ctx)
+	})
+	t.Run("#3 Replayed delivery is delivered", func(t *testing.T) {
+		atomic.StoreInt32(&healthy, 1)
+		db.EXPECT().WebhookDeliveryByID(gomock.Any(), delivery.ID).Return(&delivery, nil).Times(1)
+		db.EXPECT().UpdateWebhookDelivery(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, d *model.WebhookDelivery) error {
+				assert.Equal(t, model.DeliveryPending, d.Status)
+				assert.Zero(t, d.Attempts)
+				delivery = *d
+
+				return nil
+			}).Times(1)
+		require.NoError(t, g.ReplayWebhookDelivery(ctx, delivery.ID))
+
+		db.EXPECT().ClaimWebhookDeliveries(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return([]model.WebhookDelivery{delivery}, nil).Times(1)
+		db.EXPECT().UpdateWebhookDelivery(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, d *model.WebhookDelivery) error {
+				assert.Equal(t, model.DeliveryDelivered, d.Status)
+				assert.Equal(t, http.StatusOK, d.LastStatusCode)
+				assert.Empty(t, d.LastError)
+
+				return nil
+			}).Times(1)
+		g.dispatchWebhooks(ctx)
+	})
+	assert.EqualValues(t, 3, atomic.LoadInt32(&received))
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	g := &GopherMart{webhookRetryInterval: 10 * time.Second}
+	assert.Equal(t, 10*time.Second, g.webhookBackoff(1))
+	assert.Equal(t, 20*time.Second, g.webhookBackoff(2))
+	assert.Equal(t, 80*time.Second, g.webhookBackoff(4))
+	assert.Equal(t, maxWebhookRetryInterval, g.webhookBackoff(20))
+}
@@ -1,7 +1,10 @@
 package gophermart_test
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/vanamelnik/gophermart/storage"
 	mockstorage "github.com/vanamelnik/gophermart/storage/mock"
@@ -41,3 +44,22 @@ func TestWithdraw(t *testing.T) {
 		})
 	}
 }
+
+func TestRecoverWithdrawals(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	db := mockstorage.NewMockStorage(mockCtrl)
+	ctx, s, err := initServices(db, pepper)
+	require.NoError(t, err)
+
+	db.EXPECT().RecoverWithdrawals(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, before time.Time) (int, error) {
+			assert.True(t, before.Before(time.Now()), "the withdrawals being processed now aren't rejected")
+
+			return 1, nil
+		}).Times(1)
+	assert.NoError(t, s.RecoverWithdrawals(ctx))
+
+	db.EXPECT().RecoverWithdrawals(gomock.Any(), gomock.Any()).Return(0, errors.New("connection lost")).Times(1)
+	assert.Error(t, s.RecoverWithdrawals(ctx))
+}
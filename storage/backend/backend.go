@@ -0,0 +1,37 @@
+// Package backend opens the storage selected by the database DSN, so all the commands use the same backends.
+package backend
+
+import (
+	"github.com/vanamelnik/gophermart/storage"
+	"github.com/vanamelnik/gophermart/storage/memory"
+	"github.com/vanamelnik/gophermart/storage/psql"
+	"github.com/vanamelnik/gophermart/storage/sqlite"
+
+	"github.com/rs/zerolog"
+)
+
+// PsqlMigrationsURL is the source of Postgres migrations. The path is relative to the working directory.
+// SQLite migrations are embedded into the binary.
+const PsqlMigrationsURL = "file://storage/psql/migration"
+
+// Open connects to the storage selected by the DSN: "memory://" selects the in-memory storage,
+// "sqlite://" selects SQLite database file, otherwise Postgres database is used. The migrations are applied
+// to the SQL databases.
+func Open(dsn string, log zerolog.Logger) (storage.Storage, error) {
+	switch {
+	case memory.IsDSN(dsn):
+		log.Warn().Msg("backend: in-memory storage is used, the data will be lost on exit")
+
+		return memory.New(), nil
+	case sqlite.IsDSN(dsn):
+		return sqlite.New(
+			sqlite.WithDSN(dsn),
+			sqlite.WithAutoMigrate(log),
+		)
+	}
+
+	return psql.New(
+		psql.WithDSN(dsn),
+		psql.WithAutoMigrate(log, PsqlMigrationsURL),
+	)
+}
@@ -4,6 +4,7 @@ package storage
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/vanamelnik/gophermart/model"
 
@@ -15,10 +16,131 @@ type Storage interface {
 	CreateUser(ctx context.Context, user model.User) error
 	// UserByLogin looks for a user with provided login.
 	UserByLogin(ctx context.Context, login string) (*model.User, error)
-	// UserByRemember lloks for a user with provided remember token.
-	UserByRemember(ctx context.Context, remember string) (*model.User, error)
-	// UpdateUser updates user information (login, password hash and remember token).
+	// UserByID looks for a user with provided id.
+	UserByID(ctx context.Context, id uuid.UUID) (*model.User, error)
+	// SearchUsers returns up to limit users whose login contains the string provided (case insensitive),
+	// ordered by login. If there aren't any, empty slice is returned.
+	SearchUsers(ctx context.Context, login string, limit int) ([]model.User, error)
+	// UpdateUser updates user information (login and password hash). If there's no such user, ErrNotFound
+	// is returned; if the login is occupied by another user, ErrLoginAlreadyExists is returned.
 	UpdateUser(ctx context.Context, user model.User) error
+	// SetUserBlocked blocks or unblocks the user.
+	SetUserBlocked(ctx context.Context, id uuid.UUID, blocked bool) error
+	// DeleteUser replaces the user with an anonymous tombstone (see model.DeletedUserLogin) with the pseudonymous id
+	// provided: the orders, accruals, withdrawals and adjustments are moved to the tombstone, the user's id
+	// in the outbox events and the webhook deliveries is replaced with the pseudonymous one, the user with
+	// the sessions, password resets and two-factor authentication is deleted. If there's no such user,
+	// ErrNotFound is returned.
+	DeleteUser(ctx context.Context, id, pseudonymID uuid.UUID, now time.Time) error
+	// SetUserRole sets the role of the user and the permissions granted in addition to the role.
+	SetUserRole(ctx context.Context, id uuid.UUID, role model.Role, perms []model.Permission) error
+
+	// ChangePassword sets the user's password hash and deletes all the user's sessions except keepSessionID.
+	ChangePassword(ctx context.Context, userID uuid.UUID, passwordHash string, keepSessionID uuid.UUID) error
+	// UpdatePasswordHash replaces the user's password hash with the new one if it's still equal to the old one.
+	// Otherwise or if there's no such user ErrNotFound is returned.
+	UpdatePasswordHash(ctx context.Context, userID uuid.UUID, oldHash, newHash string) error
+	// CreatePasswordReset adds a new password reset request.
+	CreatePasswordReset(ctx context.Context, reset *model.PasswordReset) error
+	// UserPasswordResets returns all the password resets of the user. If there aren't any, empty slice is returned.
+	UserPasswordResets(ctx context.Context, userID uuid.UUID) ([]model.PasswordReset, error)
+	// PasswordResetUser returns the user of the unused and not expired password reset with the token hash provided.
+	// If there's no such valid reset, ErrNotFound is returned.
+	PasswordResetUser(ctx context.Context, tokenHash string, now time.Time) (*model.User, error)
+	// ResetPassword atomically marks the unused and not expired password reset with the token hash provided
+	// as used, sets the user's password hash, deletes all the user's sessions and invalidates other reset requests.
+	// The user's id is returned. If there's no such valid reset, ErrNotFound is returned.
+	ResetPassword(ctx context.Context, tokenHash, passwordHash string, now time.Time) (uuid.UUID, error)
+
+	// LoginThrottle returns failed login attempts info by the key.
+	LoginThrottle(ctx context.Context, key string) (*model.LoginThrottle, error)
+	// TakeLoginAttempt atomically counts the login attempt by the key as failed, provided the key still has
+	// the number of failures seen (zero if there's no info), and returns the updated info. Otherwise ErrConflict
+	// is returned: the key has changed since it was checked. If the last failure was more than window ago,
+	// the counter starts over.
+	TakeLoginAttempt(ctx context.Context, key string, seenFailures int, now time.Time, window time.Duration) (*model.LoginThrottle, error)
+	// ForgiveLoginAttempt takes back the attempt counted by TakeLoginAttempt if it hasn't failed.
+	ForgiveLoginAttempt(ctx context.Context, key string) error
+	// LockLogin locks the key until the time provided.
+	LockLogin(ctx context.Context, key string, until time.Time) error
+	// DeleteLoginThrottle resets failed attempts and the lock of the key.
+	DeleteLoginThrottle(ctx context.Context, key string) error
+	// DeleteStaleLoginThrottles deletes the info about keys neither failed nor locked since the time provided
+	// and returns their number.
+	DeleteStaleLoginThrottles(ctx context.Context, before time.Time) (int, error)
+
+	// TwoFactor returns the user's two-factor authentication, either pending or enabled.
+	TwoFactor(ctx context.Context, userID uuid.UUID) (*model.TwoFactor, error)
+	// SetPendingTwoFactor sets a new secret of the pending two-factor authentication.
+	// If two-factor authentication is already enabled, ErrTwoFactorEnabled is returned.
+	SetPendingTwoFactor(ctx context.Context, userID uuid.UUID, secret string) error
+	// EnableTwoFactor confirms the pending two-factor authentication, marks the step of the confirmation code
+	// as used and replaces the user's recovery codes. If there's no pending two-factor authentication,
+	// ErrNotFound is returned.
+	EnableTwoFactor(ctx context.Context, userID uuid.UUID, step int64, now time.Time, recoveryCodeHashes []string) error
+	// DisableTwoFactor deletes the user's two-factor authentication, recovery codes and login challenges.
+	DisableTwoFactor(ctx context.Context, userID uuid.UUID) error
+	// UseTOTPStep atomically marks the time step as used. If the step or a later one is already used,
+	// ErrNotFound is returned.
+	UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) error
+	// UseRecoveryCode atomically marks the unused recovery code as used. If there's no such code, ErrNotFound is returned.
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string, now time.Time) error
+	// CreateLoginChallenge adds a new login challenge.
+	CreateLoginChallenge(ctx context.Context, c *model.LoginChallenge) error
+	// LoginChallenge looks for a login challenge with the token hash provided.
+	LoginChallenge(ctx context.Context, tokenHash string) (*model.LoginChallenge, error)
+	// TakeChallengeAttempt atomically counts an attempt to enter the code and returns the challenge. If there's
+	// no such challenge, it's expired at the time provided or maxAttempts are already made, ErrNotFound is returned.
+	TakeChallengeAttempt(ctx context.Context, tokenHash string, maxAttempts int, now time.Time) (*model.LoginChallenge, error)
+	// DeleteLoginChallenge deletes the login challenge.
+	DeleteLoginChallenge(ctx context.Context, tokenHash string) error
+	// DeleteExpiredLoginChallenges deletes the challenges expired at the time provided and returns their number.
+	DeleteExpiredLoginChallenges(ctx context.Context, now time.Time) (int, error)
+
+	// CreateMerchant adds a new merchant. If the name is occupied, ErrMerchantAlreadyExists is returned.
+	CreateMerchant(ctx context.Context, merchant *model.Merchant) error
+	// Merchants returns all the merchants.
+	Merchants(ctx context.Context) ([]model.Merchant, error)
+	// MerchantByID looks for the merchant with the id provided.
+	MerchantByID(ctx context.Context, id uuid.UUID) (*model.Merchant, error)
+	// CreateAPIKey adds a new merchant's API key.
+	CreateAPIKey(ctx context.Context, key *model.APIKey) error
+	// MerchantAPIKeys returns all the API keys of the merchant including revoked ones.
+	MerchantAPIKeys(ctx context.Context, merchantID uuid.UUID) ([]model.APIKey, error)
+	// APIKeyByHash looks for an API key with the hash provided.
+	APIKeyByHash(ctx context.Context, keyHash string) (*model.APIKey, error)
+	// TouchAPIKey updates the time when the key was last used.
+	TouchAPIKey(ctx context.Context, id uuid.UUID, lastUsedAt time.Time) error
+	// RevokeAPIKey revokes the merchant's API key. If there's no such active key, ErrNotFound is returned.
+	RevokeAPIKey(ctx context.Context, merchantID, id uuid.UUID, now time.Time) error
+
+	// TakeRateLimitToken atomically refills the token bucket of the key and takes a token from it.
+	TakeRateLimitToken(ctx context.Context, key string, limit model.RateLimit, now time.Time) (model.RateLimitResult, error)
+	// DeleteFullRateLimits deletes the buckets that are full at the time provided and returns their number.
+	// A missing bucket is considered full.
+	DeleteFullRateLimits(ctx context.Context, now time.Time) (int, error)
+
+	// CreateSession adds a new session of the user.
+	CreateSession(ctx context.Context, session *model.Session) error
+	// SessionByTokenHash looks for a session with the token hash provided.
+	SessionByTokenHash(ctx context.Context, tokenHash string) (*model.Session, error)
+	// SessionByID looks for a session with the id provided.
+	SessionByID(ctx context.Context, id uuid.UUID) (*model.Session, error)
+	// TouchSession updates the time when the session was last seen.
+	TouchSession(ctx context.Context, id uuid.UUID, lastSeenAt time.Time) error
+	// UserSessions returns all sessions of the user, the most recently seen first.
+	UserSessions(ctx context.Context, userID uuid.UUID) ([]model.Session, error)
+	// DeleteSession deletes the user's session. If the user has no session with such id, ErrNotFound is returned.
+	DeleteSession(ctx context.Context, userID, id uuid.UUID) error
+	// UpgradeSessionHashes replaces the legacy SHA-256 digests of session tokens with the hashes
+	// returned by upgrade function and returns the number of sessions upgraded.
+	UpgradeSessionHashes(ctx context.Context, upgrade func(digest string) string) (int, error)
+	// DeleteExpiredSessions deletes the sessions expired at the moment provided and returns their number.
+	DeleteExpiredSessions(ctx context.Context, now time.Time) (int, error)
+
+	// The following state changing methods write the domain events into the outbox within the same
+	// transaction: CreateOrder, UpdateOrderStatus, CreateAccrual, UpdateBalance, ProcessWithdraw,
+	// RecoverWithdrawals and CreateAdjustment.
 
 	// CreateOrder creates a new entry in the orders table.
 	CreateOrder(ctx context.Context, order *model.Order) error
@@ -38,13 +160,50 @@ type Storage interface {
 	// Flags 'processed' are set to true.
 	UpdateBalance(ctx context.Context) (int, error)
 
-	// ProcessWithdraw creates a new entry in the withdrawals_log table and updates user's balance.
+	// ProcessWithdraw creates a new entry in the withdrawals_log table and updates user's balance in one transaction.
 	// This function must update and check users's balance and return the error if the balance is less than the amount provided.
-	// OrderId must be unique.
+	// The rejected withdrawals are kept in the log with 'INVALID' status. OrderId must be unique.
 	ProcessWithdraw(ctx context.Context, withdraw *model.Withdrawal) error
+	// RecoverWithdrawals rejects the withdrawals left in 'PROCESSING' status since before the time provided
+	// and returns their number. The points of such withdrawals have never been taken from users' balances.
+	RecoverWithdrawals(ctx context.Context, before time.Time) (int, error)
 	// WithdrawalsByUserID fetches all withdrawals made by the provided user. If there aren't any, empty slice is returned.
 	WithdrawalsByUserID(ctx context.Context, id uuid.UUID) ([]model.Withdrawal, error)
 
+	// CreateAdjustment adds a new entry to the adjustments_log table and adds the sum (positive or negative)
+	// to the user's balance. ErrInsufficientPoints is returned if the balance would become negative.
+	CreateAdjustment(ctx context.Context, adjustment *model.Adjustment) error
+	// AdjustmentsByUserID fetches all balance adjustments of the user. If there aren't any, empty slice is returned.
+	AdjustmentsByUserID(ctx context.Context, id uuid.UUID) ([]model.Adjustment, error)
+
+	// CreateWebhook adds a new webhook subscription.
+	CreateWebhook(ctx context.Context, webhook *model.Webhook) error
+	// Webhooks returns all webhook subscriptions. If there aren't any, empty slice is returned.
+	Webhooks(ctx context.Context) ([]model.Webhook, error)
+	// DeleteWebhook deletes the webhook subscription with all its deliveries.
+	DeleteWebhook(ctx context.Context, id uuid.UUID) error
+	// CreateWebhookDelivery adds a new entry to the delivery queue. If the delivery with the same id
+	// is already queued, nothing is done, so the delivery can be queued again after a failure.
+	CreateWebhookDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+	// WebhookDeliveryByID searches for the delivery with the provided id.
+	WebhookDeliveryByID(ctx context.Context, id uuid.UUID) (*model.WebhookDelivery, error)
+	// WebhookDeliveries returns all deliveries of the webhook ordered by creation time.
+	// If there aren't any, empty slice is returned.
+	WebhookDeliveries(ctx context.Context, webhookID uuid.UUID) ([]model.WebhookDelivery, error)
+	// ClaimWebhookDeliveries atomically claims up to limit 'PENDING' deliveries whose next attempt time is not after now
+	// by moving their next attempt time to claimUntil, so other instances of the service skip them until then.
+	// The claimed deliveries are returned ordered by creation time. If there aren't any, empty slice is returned.
+	ClaimWebhookDeliveries(ctx context.Context, now, claimUntil time.Time, limit int) ([]model.WebhookDelivery, error)
+	// UpdateWebhookDelivery updates the status, attempts counter, last attempt result and the next attempt time.
+	UpdateWebhookDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+
+	// ClaimUnpublishedEvents atomically claims up to limit domain events from the outbox that haven't been published
+	// and aren't claimed at the moment now, so other instances of the service skip them until claimUntil.
+	// The events are returned ordered by event ID. If there aren't any, empty slice is returned.
+	ClaimUnpublishedEvents(ctx context.Context, now, claimUntil time.Time, limit int) ([]model.Event, error)
+	// MarkEventsPublished marks the events with the IDs provided as published.
+	MarkEventsPublished(ctx context.Context, ids []int64, publishedAt time.Time) error
+
 	// Close shuts the database down.
 	Close() error
 }
@@ -62,4 +221,11 @@ var (
 
 	// ErrInvalidInput is threw when accrual or withdrawal amount less than zero.
 	ErrInvalidInput = errors.New("storage: amount less than zero")
+
+	// ErrMerchantAlreadyExists is returned when the merchant's name is already occupied.
+	ErrMerchantAlreadyExists = errors.New("storage: merchant already exists")
+	// ErrTwoFactorEnabled is returned when the pending secret can't be set because two-factor authentication is enabled.
+	ErrTwoFactorEnabled = errors.New("storage: two-factor authentication already enabled")
+	// ErrConflict is returned when the data has been changed concurrently since it was read.
+	ErrConflict = errors.New("storage: changed concurrently")
 )
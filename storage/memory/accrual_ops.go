@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+)
+
+// CreateAccrual implements Storage interface.
+func (m *Memory) CreateAccrual(_ context.Context, orderID model.OrderID, amount float32) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	o, ok := m.orders[orderID]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	for _, a := range m.accruals {
+		if a.orderID == orderID {
+			return storage.ErrAlreadyProcessed
+		}
+	}
+	// If the order has not been processed yet, but has been marked as processed, that's an internal server error.
+	if o.Status == model.StatusProcessed {
+		return storage.ErrInvalidStatus
+	}
+	event, err := newEvent(model.EventOrderProcessed, orderID.String(), model.OrderEvent{
+		Order:          orderID,
+		UserID:         o.UserID,
+		Status:         model.StatusProcessed,
+		PreviousStatus: o.Status,
+		Accrual:        amount,
+	})
+	if err != nil {
+		return err
+	}
+	m.accruals = append(m.accruals, accrual{orderID: orderID, userID: o.UserID, sum: amount})
+	o.Status = model.StatusProcessed
+	o.AccrualPoints = amount
+	m.orders[orderID] = o
+	m.insertEvents(event)
+
+	return nil
+}
+
+// UpdateBalance implements Storage interface.
+func (m *Memory) UpdateBalance(_ context.Context) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Create the events first, so either all the accruals are processed or none.
+	pending := make([]int, 0)
+	events := make([]model.Event, 0)
+	for i, a := range m.accruals {
+		if _, ok := m.users[a.userID]; a.processed || !ok {
+			continue
+		}
+		event, err := newEvent(model.EventBalanceCredited, a.userID.String(), model.BalanceEvent{
+			Order:  a.orderID,
+			UserID: a.userID,
+			Sum:    a.sum,
+		})
+		if err != nil {
+			return 0, err
+		}
+		pending = append(pending, i)
+		events = append(events, event)
+	}
+	for _, i := range pending {
+		a := &m.accruals[i]
+		u := m.users[a.userID]
+		u.GPointsBalance += a.sum
+		m.users[a.userID] = u
+		a.processed = true
+	}
+	m.insertEvents(events...)
+
+	return len(pending), nil
+}
@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+// CreateAdjustment implements Storage interface.
+func (m *Memory) CreateAdjustment(_ context.Context, adj *model.Adjustment) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[adj.UserID]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	if u.GPointsBalance+adj.Sum < 0 {
+		return storage.ErrInsufficientPoints
+	}
+	if _, ok := m.users[adj.AdminID]; !ok {
+		return storage.ErrNotFound
+	}
+	for _, a := range m.adjustments {
+		if a.ID == adj.ID {
+			return errors.New("memory: adjustment already exists")
+		}
+	}
+	event, err := newEvent(model.EventBalanceAdjusted, adj.UserID.String(), adj)
+	if err != nil {
+		return err
+	}
+	u.GPointsBalance += adj.Sum
+	m.users[adj.UserID] = u
+	m.adjustments = append(m.adjustments, *adj)
+	m.insertEvents(event)
+
+	return nil
+}
+
+// AdjustmentsByUserID implements Storage interface.
+func (m *Memory) AdjustmentsByUserID(_ context.Context, id uuid.UUID) ([]model.Adjustment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	adjustments := make([]model.Adjustment, 0)
+	for _, a := range m.adjustments {
+		if a.UserID == id {
+			adjustments = append(adjustments, a)
+		}
+	}
+	sort.SliceStable(adjustments, func(i, j int) bool {
+		return adjustments[i].CreatedAt.Before(adjustments[j].CreatedAt)
+	})
+
+	return adjustments, nil
+}
@@ -0,0 +1,136 @@
+// Package memory is a thread-safe in-memory implementation of storage.Storage. It's used for development
+// and fast tests; the data is lost when the process exits. Each method holds a single lock, so the state changes
+// are atomic like the transactions of Postgres storage.
+package memory
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+// Scheme is the scheme of the DSN that selects the in-memory storage, e.g. "memory://".
+const Scheme = "memory"
+
+// Ensure service implements interface.
+var _ storage.Storage = (*Memory)(nil)
+
+type (
+	Memory struct {
+		mu sync.Mutex
+
+		users          map[uuid.UUID]model.User
+		passwordResets []model.PasswordReset
+		throttles      map[string]model.LoginThrottle
+		twoFactors     map[uuid.UUID]model.TwoFactor
+		recoveryCodes  []recoveryCode
+		challenges     map[string]model.LoginChallenge
+		merchants      map[uuid.UUID]model.Merchant
+		apiKeys        map[uuid.UUID]model.APIKey
+		rateLimits     map[string]rateLimit
+		sessions       map[uuid.UUID]model.Session
+
+		orders      map[model.OrderID]model.Order
+		accruals    []accrual
+		withdrawals []model.Withdrawal
+		adjustments []model.Adjustment
+
+		webhooks   map[uuid.UUID]model.Webhook
+		deliveries map[uuid.UUID]model.WebhookDelivery
+		outbox     []outboxEvent
+	}
+
+	// accrual is an entry of the accruals log.
+	accrual struct {
+		orderID   model.OrderID
+		userID    uuid.UUID
+		sum       float32
+		processed bool
+	}
+
+	recoveryCode struct {
+		userID   uuid.UUID
+		codeHash string
+		usedAt   *time.Time
+	}
+
+	rateLimit struct {
+		model.RateLimitBucket
+		// fullAt is the time the bucket is full again, so it can be deleted.
+		fullAt time.Time
+	}
+
+	outboxEvent struct {
+		model.Event
+		publishedAt *time.Time
+		// claimedUntil is the time until which the event is claimed by a relay.
+		claimedUntil time.Time
+	}
+)
+
+// IsDSN reports whether the DSN selects the in-memory storage.
+func IsDSN(dsn string) bool {
+	return strings.HasPrefix(dsn, Scheme+"://")
+}
+
+// New creates a new empty storage.
+func New() *Memory {
+	return &Memory{
+		users:      make(map[uuid.UUID]model.User),
+		throttles:  make(map[string]model.LoginThrottle),
+		twoFactors: make(map[uuid.UUID]model.TwoFactor),
+		challenges: make(map[string]model.LoginChallenge),
+		merchants:  make(map[uuid.UUID]model.Merchant),
+		apiKeys:    make(map[uuid.UUID]model.APIKey),
+		rateLimits: make(map[string]rateLimit),
+		sessions:   make(map[uuid.UUID]model.Session),
+		orders:     make(map[model.OrderID]model.Order),
+		webhooks:   make(map[uuid.UUID]model.Webhook),
+		deliveries: make(map[uuid.UUID]model.WebhookDelivery),
+	}
+}
+
+// Close implements Storage interface. The data is kept, so the storage can be used after Close.
+func (m *Memory) Close() error {
+	return nil
+}
+
+// newEvent creates a domain event. It's created before the state is changed, so the change and the event
+// are stored together or not at all.
+func newEvent(eventType model.EventType, aggregateID string, payload interface{}) (model.Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return model.Event{}, err
+	}
+
+	return model.Event{
+		Type:        eventType,
+		AggregateID: aggregateID,
+		Payload:     data,
+		CreatedAt:   time.Now(),
+	}, nil
+}
+
+// insertEvents writes the events into the outbox assigning the sequence numbers. The caller must hold the lock.
+func (m *Memory) insertEvents(events ...model.Event) {
+	for _, e := range events {
+		e.ID = int64(len(m.outbox) + 1)
+		m.outbox = append(m.outbox, outboxEvent{Event: e})
+	}
+}
+
+// timePtr returns a pointer to the copy of the time provided, so the stored values aren't shared with the callers.
+func timePtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+
+	return &c
+}
@@ -0,0 +1,161 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+// CreateMerchant implements Storage interface.
+func (m *Memory) CreateMerchant(_ context.Context, merchant *model.Merchant) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.merchants[merchant.ID]; ok {
+		return storage.ErrMerchantAlreadyExists
+	}
+	for _, other := range m.merchants {
+		if other.Name == merchant.Name {
+			return storage.ErrMerchantAlreadyExists
+		}
+	}
+	m.merchants[merchant.ID] = *merchant
+
+	return nil
+}
+
+// Merchants implements Storage interface.
+func (m *Memory) Merchants(_ context.Context) ([]model.Merchant, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	merchants := make([]model.Merchant, 0, len(m.merchants))
+	for _, merchant := range m.merchants {
+		merchants = append(merchants, merchant)
+	}
+	sort.Slice(merchants, func(i, j int) bool {
+		if !merchants[i].CreatedAt.Equal(merchants[j].CreatedAt) {
+			return merchants[i].CreatedAt.Before(merchants[j].CreatedAt)
+		}
+
+		return merchants[i].Name < merchants[j].Name
+	})
+
+	return merchants, nil
+}
+
+// MerchantByID implements Storage interface.
+func (m *Memory) MerchantByID(_ context.Context, id uuid.UUID) (*model.Merchant, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	merchant, ok := m.merchants[id]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	return &merchant, nil
+}
+
+// CreateAPIKey implements Storage interface.
+func (m *Memory) CreateAPIKey(_ context.Context, key *model.APIKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.merchants[key.MerchantID]; !ok {
+		return storage.ErrNotFound
+	}
+	for _, other := range m.apiKeys {
+		if other.ID == key.ID || other.KeyHash == key.KeyHash {
+			return errors.New("memory: API key already exists")
+		}
+	}
+	k := *key
+	k.Scopes = copyScopes(key.Scopes)
+	k.LastUsedAt, k.RevokedAt = nil, nil
+	m.apiKeys[key.ID] = k
+
+	return nil
+}
+
+// MerchantAPIKeys implements Storage interface.
+func (m *Memory) MerchantAPIKeys(_ context.Context, merchantID uuid.UUID) ([]model.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]model.APIKey, 0)
+	for _, k := range m.apiKeys {
+		if k.MerchantID == merchantID {
+			keys = append(keys, *cloneAPIKey(k))
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+		}
+
+		return keys[i].ID.String() < keys[j].ID.String()
+	})
+
+	return keys, nil
+}
+
+// APIKeyByHash implements Storage interface.
+func (m *Memory) APIKeyByHash(_ context.Context, keyHash string) (*model.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, k := range m.apiKeys {
+		if k.KeyHash == keyHash {
+			return cloneAPIKey(k), nil
+		}
+	}
+
+	return nil, storage.ErrNotFound
+}
+
+// TouchAPIKey implements Storage interface.
+func (m *Memory) TouchAPIKey(_ context.Context, id uuid.UUID, lastUsedAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if k, ok := m.apiKeys[id]; ok {
+		k.LastUsedAt = &lastUsedAt
+		m.apiKeys[id] = k
+	}
+
+	return nil
+}
+
+// RevokeAPIKey implements Storage interface.
+func (m *Memory) RevokeAPIKey(_ context.Context, merchantID, id uuid.UUID, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k, ok := m.apiKeys[id]
+	if !ok || k.MerchantID != merchantID || k.Revoked() {
+		return storage.ErrNotFound
+	}
+	k.RevokedAt = &now
+	m.apiKeys[id] = k
+
+	return nil
+}
+
+func cloneAPIKey(k model.APIKey) *model.APIKey {
+	k.Scopes = copyScopes(k.Scopes)
+	k.LastUsedAt = timePtr(k.LastUsedAt)
+	k.RevokedAt = timePtr(k.RevokedAt)
+
+	return &k
+}
+
+func copyScopes(scopes []model.Scope) []model.Scope {
+	return append(make([]model.Scope, 0, len(scopes)), scopes...)
+}
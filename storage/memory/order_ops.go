@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+// CreateOrder implements Storage interface.
+func (m *Memory) CreateOrder(_ context.Context, order *model.Order) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.orders[order.ID]; ok {
+		return storage.ErrAlreadyProcessed
+	}
+	if _, ok := m.users[order.UserID]; !ok {
+		return storage.ErrNotFound
+	}
+	event, err := newEvent(model.EventOrderCreated, order.ID.String(), model.OrderEvent{
+		Order:  order.ID,
+		UserID: order.UserID,
+		Status: order.Status,
+	})
+	if err != nil {
+		return err
+	}
+	m.orders[order.ID] = *order
+	m.insertEvents(event)
+
+	return nil
+}
+
+// OrderByID implements Storage interface.
+func (m *Memory) OrderByID(_ context.Context, orderID model.OrderID) (*model.Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	o, ok := m.orders[orderID]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	return &o, nil
+}
+
+// OrdersByStatus implements Storage interface.
+func (m *Memory) OrdersByStatus(_ context.Context, status model.Status) ([]model.Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.filterOrders(func(o model.Order) bool { return o.Status == status }), nil
+}
+
+// UpdateOrderStatus implements Storage interface.
+func (m *Memory) UpdateOrderStatus(_ context.Context, orderID model.OrderID, status model.Status) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	o, ok := m.orders[orderID]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	event, err := newEvent(model.EventOrderStatusChanged, orderID.String(), model.OrderEvent{
+		Order:          orderID,
+		UserID:         o.UserID,
+		Status:         status,
+		PreviousStatus: o.Status,
+	})
+	if err != nil {
+		return err
+	}
+	o.Status = status
+	m.orders[orderID] = o
+	m.insertEvents(event)
+
+	return nil
+}
+
+// UserOrders implements Storage interface.
+func (m *Memory) UserOrders(_ context.Context, userID uuid.UUID) ([]model.Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.filterOrders(func(o model.Order) bool { return o.UserID == userID }), nil
+}
+
+// filterOrders returns the orders matching the filter ordered by upload time. The caller must hold the lock.
+func (m *Memory) filterOrders(match func(o model.Order) bool) []model.Order {
+	orders := make([]model.Order, 0)
+	for _, o := range m.orders {
+		if match(o) {
+			orders = append(orders, o)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		if !orders[i].UploadedAt.Equal(orders[j].UploadedAt) {
+			return orders[i].UploadedAt.Before(orders[j].UploadedAt)
+		}
+
+		return orders[i].ID < orders[j].ID
+	})
+
+	return orders
+}
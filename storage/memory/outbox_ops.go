@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+)
+
+// ClaimUnpublishedEvents implements Storage interface.
+func (m *Memory) ClaimUnpublishedEvents(_ context.Context, now, claimUntil time.Time, limit int) ([]model.Event, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	events := make([]model.Event, 0)
+	for i, e := range m.outbox {
+		if len(events) == limit {
+			break
+		}
+		if e.publishedAt == nil && !e.claimedUntil.After(now) {
+			m.outbox[i].claimedUntil = claimUntil
+			e.Payload = append([]byte(nil), e.Payload...)
+			events = append(events, e.Event)
+		}
+	}
+
+	return events, nil
+}
+
+// MarkEventsPublished implements Storage interface.
+func (m *Memory) MarkEventsPublished(_ context.Context, ids []int64, publishedAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, id := range ids {
+		// The events are numbered from 1 in the order of insertion.
+		if id >= 1 && id <= int64(len(m.outbox)) {
+			t := publishedAt
+			m.outbox[id-1].publishedAt = &t
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+// ChangePassword implements Storage interface.
+func (m *Memory) ChangePassword(_ context.Context, userID uuid.UUID, passwordHash string, keepSessionID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.setPasswordHash(userID, passwordHash); err != nil {
+		return err
+	}
+	for id, s := range m.sessions {
+		if s.UserID == userID && id != keepSessionID {
+			delete(m.sessions, id)
+		}
+	}
+
+	return nil
+}
+
+// UpdatePasswordHash implements Storage interface.
+func (m *Memory) UpdatePasswordHash(_ context.Context, userID uuid.UUID, oldHash, newHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+	if !ok || u.PasswordHash != oldHash {
+		return storage.ErrNotFound
+	}
+	u.PasswordHash = newHash
+	m.users[userID] = u
+
+	return nil
+}
+
+// CreatePasswordReset implements Storage interface.
+func (m *Memory) CreatePasswordReset(_ context.Context, reset *model.PasswordReset) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[reset.UserID]; !ok {
+		return storage.ErrNotFound
+	}
+	for _, r := range m.passwordResets {
+		if r.ID == reset.ID || r.TokenHash == reset.TokenHash {
+			return errors.New("memory: password reset already exists")
+		}
+	}
+	r := *reset
+	r.UsedAt = nil
+	m.passwordResets = append(m.passwordResets, r)
+
+	return nil
+}
+
+// UserPasswordResets implements Storage interface.
+func (m *Memory) UserPasswordResets(_ context.Context, userID uuid.UUID) ([]model.PasswordReset, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	resets := make([]model.PasswordReset, 0)
+	for _, r := range m.passwordResets {
+		if r.UserID == userID {
+			r.UsedAt = timePtr(r.UsedAt)
+			resets = append(resets, r)
+		}
+	}
+	sort.SliceStable(resets, func(i, j int) bool { return resets[i].CreatedAt.Before(resets[j].CreatedAt) })
+
+	return resets, nil
+}
+
+// PasswordResetUser implements Storage interface.
+func (m *Memory) PasswordResetUser(_ context.Context, tokenHash string, now time.Time) (*model.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, ok := m.validPasswordReset(tokenHash, now)
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	u, ok := m.users[m.passwordResets[i].UserID]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	return cloneUser(u), nil
+}
+
+// ResetPassword implements Storage interface.
+func (m *Memory) ResetPassword(_ context.Context, tokenHash, passwordHash string, now time.Time) (uuid.UUID, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, ok := m.validPasswordReset(tokenHash, now)
+	if !ok {
+		return uuid.Nil, storage.ErrNotFound
+	}
+	userID := m.passwordResets[i].UserID
+	if err := m.setPasswordHash(userID, passwordHash); err != nil {
+		return uuid.Nil, err
+	}
+	// All the sessions are ended and the reset tokens of the user including this one are invalidated.
+	for id, s := range m.sessions {
+		if s.UserID == userID {
+			delete(m.sessions, id)
+		}
+	}
+	for i := range m.passwordResets {
+		if m.passwordResets[i].UserID == userID && m.passwordResets[i].UsedAt == nil {
+			usedAt := now
+			m.passwordResets[i].UsedAt = &usedAt
+		}
+	}
+
+	return userID, nil
+}
+
+// validPasswordReset returns the index of the unused and not expired password reset with the token hash provided.
+// The caller must hold the lock.
+func (m *Memory) validPasswordReset(tokenHash string, now time.Time) (int, bool) {
+	for i, r := range m.passwordResets {
+		if r.TokenHash == tokenHash && r.UsedAt == nil && r.ExpiresAt.After(now) {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+// setPasswordHash sets the user's password hash. The caller must hold the lock.
+func (m *Memory) setPasswordHash(userID uuid.UUID, passwordHash string) error {
+	u, ok := m.users[userID]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	u.PasswordHash = passwordHash
+	m.users[userID] = u
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+)
+
+// TakeRateLimitToken implements Storage interface.
+func (m *Memory) TakeRateLimitToken(_ context.Context, key string, limit model.RateLimit, now time.Time) (model.RateLimitResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// A missing bucket is a zero one, i.e. full.
+	b, res := limit.Take(m.rateLimits[key].RateLimitBucket, now)
+	m.rateLimits[key] = rateLimit{RateLimitBucket: b, fullAt: now.Add(res.Reset)}
+
+	return res, nil
+}
+
+// DeleteFullRateLimits implements Storage interface.
+func (m *Memory) DeleteFullRateLimits(_ context.Context, now time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for key, b := range m.rateLimits {
+		if !b.fullAt.After(now) {
+			delete(m.rateLimits, key)
+			n++
+		}
+	}
+
+	return n, nil
+}
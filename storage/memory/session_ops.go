@@ -0,0 +1,131 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+// CreateSession implements Storage interface.
+func (m *Memory) CreateSession(_ context.Context, session *model.Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[session.UserID]; !ok {
+		return storage.ErrNotFound
+	}
+	for _, s := range m.sessions {
+		if s.ID == session.ID || s.TokenHash == session.TokenHash {
+			return errors.New("memory: session already exists")
+		}
+	}
+	s := *session
+	s.Current = false
+	m.sessions[session.ID] = s
+
+	return nil
+}
+
+// SessionByTokenHash implements Storage interface.
+func (m *Memory) SessionByTokenHash(_ context.Context, tokenHash string) (*model.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, s := range m.sessions {
+		if s.TokenHash == tokenHash {
+			return &s, nil
+		}
+	}
+
+	return nil, storage.ErrNotFound
+}
+
+// SessionByID implements Storage interface.
+func (m *Memory) SessionByID(_ context.Context, id uuid.UUID) (*model.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[id]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	return &s, nil
+}
+
+// TouchSession implements Storage interface.
+func (m *Memory) TouchSession(_ context.Context, id uuid.UUID, lastSeenAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if s, ok := m.sessions[id]; ok {
+		s.LastSeenAt = lastSeenAt
+		m.sessions[id] = s
+	}
+
+	return nil
+}
+
+// UserSessions implements Storage interface.
+func (m *Memory) UserSessions(_ context.Context, userID uuid.UUID) ([]model.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sessions := make([]model.Session, 0)
+	for _, s := range m.sessions {
+		if s.UserID == userID {
+			sessions = append(sessions, s)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].LastSeenAt.Equal(sessions[j].LastSeenAt) {
+			return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+		}
+
+		return sessions[i].ID.String() < sessions[j].ID.String()
+	})
+
+	return sessions, nil
+}
+
+// DeleteSession implements Storage interface.
+func (m *Memory) DeleteSession(_ context.Context, userID, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[id]
+	if !ok || s.UserID != userID {
+		return storage.ErrNotFound
+	}
+	delete(m.sessions, id)
+
+	return nil
+}
+
+// UpgradeSessionHashes implements Storage interface. The sessions are created with the hashes provided
+// by the service only, so there are no legacy digests to upgrade.
+func (m *Memory) UpgradeSessionHashes(_ context.Context, _ func(digest string) string) (int, error) {
+	return 0, nil
+}
+
+// DeleteExpiredSessions implements Storage interface.
+func (m *Memory) DeleteExpiredSessions(_ context.Context, now time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for id, s := range m.sessions {
+		if s.Expired(now) {
+			delete(m.sessions, id)
+			n++
+		}
+	}
+
+	return n, nil
+}
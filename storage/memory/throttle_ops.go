@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+)
+
+// LoginThrottle implements Storage interface.
+func (m *Memory) LoginThrottle(_ context.Context, key string) (*model.LoginThrottle, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.throttles[key]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	t.LockedUntil = timePtr(t.LockedUntil)
+
+	return &t, nil
+}
+
+// TakeLoginAttempt implements Storage interface.
+func (m *Memory) TakeLoginAttempt(_ context.Context, key string, seenFailures int, now time.Time,
+	window time.Duration) (*model.LoginThrottle, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.throttles[key]
+	if ok && t.Failures != seenFailures {
+		return nil, storage.ErrConflict
+	}
+	if !ok || t.LastFailureAt.Before(now.Add(-window)) {
+		t.Key = key
+		t.Failures = 0
+	}
+	t.Failures++
+	t.LastFailureAt = now
+	m.throttles[key] = t
+	t.LockedUntil = timePtr(t.LockedUntil)
+
+	return &t, nil
+}
+
+// ForgiveLoginAttempt implements Storage interface.
+func (m *Memory) ForgiveLoginAttempt(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if t, ok := m.throttles[key]; ok && t.Failures > 0 {
+		t.Failures--
+		m.throttles[key] = t
+	}
+
+	return nil
+}
+
+// LockLogin implements Storage interface.
+func (m *Memory) LockLogin(_ context.Context, key string, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.throttles[key]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	t.LockedUntil = &until
+	m.throttles[key] = t
+
+	return nil
+}
+
+// DeleteLoginThrottle implements Storage interface.
+func (m *Memory) DeleteLoginThrottle(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.throttles, key)
+
+	return nil
+}
+
+// DeleteStaleLoginThrottles implements Storage interface.
+func (m *Memory) DeleteStaleLoginThrottles(_ context.Context, before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for key, t := range m.throttles {
+		if t.LastFailureAt.Before(before) && (t.LockedUntil == nil || t.LockedUntil.Before(before)) {
+			delete(m.throttles, key)
+			n++
+		}
+	}
+
+	return n, nil
+}
@@ -0,0 +1,206 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+// TwoFactor implements Storage interface.
+func (m *Memory) TwoFactor(_ context.Context, userID uuid.UUID) (*model.TwoFactor, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.twoFactors[userID]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	t.EnabledAt = timePtr(t.EnabledAt)
+
+	return &t, nil
+}
+
+// SetPendingTwoFactor implements Storage interface.
+func (m *Memory) SetPendingTwoFactor(_ context.Context, userID uuid.UUID, secret string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if t, ok := m.twoFactors[userID]; ok && t.Enabled() {
+		return storage.ErrTwoFactorEnabled
+	}
+	if _, ok := m.users[userID]; !ok {
+		return storage.ErrNotFound
+	}
+	m.twoFactors[userID] = model.TwoFactor{UserID: userID, Secret: secret}
+
+	return nil
+}
+
+// EnableTwoFactor implements Storage interface.
+func (m *Memory) EnableTwoFactor(_ context.Context, userID uuid.UUID, step int64, now time.Time, recoveryCodeHashes []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.twoFactors[userID]
+	if !ok || t.Enabled() {
+		return storage.ErrNotFound
+	}
+	seen := make(map[string]bool, len(recoveryCodeHashes))
+	for _, hash := range recoveryCodeHashes {
+		if seen[hash] {
+			return errors.New("memory: duplicate recovery code")
+		}
+		seen[hash] = true
+	}
+	t.EnabledAt = &now
+	t.LastUsedStep = step
+	m.twoFactors[userID] = t
+	m.deleteRecoveryCodes(userID)
+	for _, hash := range recoveryCodeHashes {
+		m.recoveryCodes = append(m.recoveryCodes, recoveryCode{userID: userID, codeHash: hash})
+	}
+
+	return nil
+}
+
+// DisableTwoFactor implements Storage interface.
+func (m *Memory) DisableTwoFactor(_ context.Context, userID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.deleteTwoFactor(userID)
+
+	return nil
+}
+
+// UseTOTPStep implements Storage interface.
+func (m *Memory) UseTOTPStep(_ context.Context, userID uuid.UUID, step int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.twoFactors[userID]
+	if !ok || t.LastUsedStep >= step {
+		return storage.ErrNotFound
+	}
+	t.LastUsedStep = step
+	m.twoFactors[userID] = t
+
+	return nil
+}
+
+// UseRecoveryCode implements Storage interface.
+func (m *Memory) UseRecoveryCode(_ context.Context, userID uuid.UUID, codeHash string, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, c := range m.recoveryCodes {
+		if c.userID == userID && c.codeHash == codeHash && c.usedAt == nil {
+			m.recoveryCodes[i].usedAt = &now
+
+			return nil
+		}
+	}
+
+	return storage.ErrNotFound
+}
+
+// CreateLoginChallenge implements Storage interface.
+func (m *Memory) CreateLoginChallenge(_ context.Context, c *model.LoginChallenge) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.challenges[c.TokenHash]; ok {
+		return errors.New("memory: login challenge already exists")
+	}
+	if _, ok := m.users[c.UserID]; !ok {
+		return storage.ErrNotFound
+	}
+	challenge := *c
+	challenge.Attempts = 0
+	m.challenges[c.TokenHash] = challenge
+
+	return nil
+}
+
+// LoginChallenge implements Storage interface.
+func (m *Memory) LoginChallenge(_ context.Context, tokenHash string) (*model.LoginChallenge, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.challenges[tokenHash]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	return &c, nil
+}
+
+// TakeChallengeAttempt implements Storage interface.
+func (m *Memory) TakeChallengeAttempt(_ context.Context, tokenHash string, maxAttempts int,
+	now time.Time) (*model.LoginChallenge, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.challenges[tokenHash]
+	if !ok || c.Attempts >= maxAttempts || c.Expired(now) {
+		return nil, storage.ErrNotFound
+	}
+	c.Attempts++
+	m.challenges[tokenHash] = c
+
+	return &c, nil
+}
+
+// DeleteLoginChallenge implements Storage interface.
+func (m *Memory) DeleteLoginChallenge(_ context.Context, tokenHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.challenges, tokenHash)
+
+	return nil
+}
+
+// DeleteExpiredLoginChallenges implements Storage interface.
+func (m *Memory) DeleteExpiredLoginChallenges(_ context.Context, now time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for tokenHash, c := range m.challenges {
+		if c.Expired(now) {
+			delete(m.challenges, tokenHash)
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+// deleteTwoFactor deletes the user's two-factor authentication, recovery codes and login challenges.
+// The caller must hold the lock.
+func (m *Memory) deleteTwoFactor(userID uuid.UUID) {
+	delete(m.twoFactors, userID)
+	m.deleteRecoveryCodes(userID)
+	for tokenHash, c := range m.challenges {
+		if c.UserID == userID {
+			delete(m.challenges, tokenHash)
+		}
+	}
+}
+
+// deleteRecoveryCodes deletes the user's recovery codes. The caller must hold the lock.
+func (m *Memory) deleteRecoveryCodes(userID uuid.UUID) {
+	codes := m.recoveryCodes[:0]
+	for _, c := range m.recoveryCodes {
+		if c.userID != userID {
+			codes = append(codes, c)
+		}
+	}
+	m.recoveryCodes = codes
+}
@@ -0,0 +1,268 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+// CreateUser implements Storage interface.
+func (m *Memory) CreateUser(_ context.Context, user model.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[user.ID]; ok {
+		return storage.ErrLoginAlreadyExists
+	}
+	if _, ok := m.userByLogin(user.Login); ok {
+		return storage.ErrLoginAlreadyExists
+	}
+	if user.Role == "" {
+		user.Role = model.RoleUser
+	}
+	m.users[user.ID] = model.User{
+		ID:             user.ID,
+		Login:          user.Login,
+		PasswordHash:   user.PasswordHash,
+		CreatedAt:      user.CreatedAt,
+		GPointsBalance: user.GPointsBalance,
+		Role:           user.Role,
+		Permissions:    copyPermissions(user.Permissions),
+	}
+
+	return nil
+}
+
+// UserByLogin implements Storage interface.
+func (m *Memory) UserByLogin(_ context.Context, login string) (*model.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.userByLogin(login)
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	return cloneUser(u), nil
+}
+
+// UserByID implements Storage interface.
+func (m *Memory) UserByID(_ context.Context, id uuid.UUID) (*model.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[id]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	return cloneUser(u), nil
+}
+
+// SearchUsers implements Storage interface.
+func (m *Memory) SearchUsers(_ context.Context, login string, limit int) ([]model.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	users := make([]model.User, 0)
+	for _, u := range m.users {
+		if strings.Contains(strings.ToLower(u.Login), strings.ToLower(login)) {
+			users = append(users, *cloneUser(u))
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Login < users[j].Login })
+	if len(users) > limit {
+		users = users[:limit]
+	}
+
+	return users, nil
+}
+
+// UpdateUser implements Storage interface.
+func (m *Memory) UpdateUser(_ context.Context, user model.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[user.ID]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	if other, ok := m.userByLogin(user.Login); ok && other.ID != user.ID {
+		return storage.ErrLoginAlreadyExists
+	}
+	u.Login = user.Login
+	u.PasswordHash = user.PasswordHash
+	m.users[user.ID] = u
+
+	return nil
+}
+
+// SetUserBlocked implements Storage interface.
+func (m *Memory) SetUserBlocked(_ context.Context, id uuid.UUID, blocked bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[id]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	u.Blocked = blocked
+	m.users[id] = u
+
+	return nil
+}
+
+// SetUserRole implements Storage interface.
+func (m *Memory) SetUserRole(_ context.Context, id uuid.UUID, role model.Role, perms []model.Permission) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[id]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	u.Role = role
+	u.Permissions = copyPermissions(perms)
+	m.users[id] = u
+
+	return nil
+}
+
+// DeleteUser implements Storage interface.
+func (m *Memory) DeleteUser(_ context.Context, id, pseudonymID uuid.UUID, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[id]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	// The tombstone inherits the balance and the registration time, the login and the credentials are dropped.
+	deletedAt := now
+	m.users[pseudonymID] = model.User{
+		ID:             pseudonymID,
+		Login:          model.DeletedUserLogin(pseudonymID),
+		CreatedAt:      u.CreatedAt,
+		GPointsBalance: u.GPointsBalance,
+		Role:           model.RoleUser,
+		Permissions:    []model.Permission{},
+		Blocked:        true,
+		DeletedAt:      &deletedAt,
+	}
+	for orderID, o := range m.orders {
+		if o.UserID == id {
+			o.UserID = pseudonymID
+			m.orders[orderID] = o
+		}
+	}
+	for i := range m.accruals {
+		if m.accruals[i].userID == id {
+			m.accruals[i].userID = pseudonymID
+		}
+	}
+	for i := range m.withdrawals {
+		if m.withdrawals[i].UserID == id {
+			m.withdrawals[i].UserID = pseudonymID
+		}
+	}
+	for i := range m.adjustments {
+		if m.adjustments[i].UserID == id {
+			m.adjustments[i].UserID = pseudonymID
+		}
+		if m.adjustments[i].AdminID == id {
+			m.adjustments[i].AdminID = pseudonymID
+		}
+	}
+	// The events and the webhook deliveries not yet sent must not link the user's id to the pseudonymous one.
+	for i := range m.outbox {
+		e := &m.outbox[i]
+		if e.AggregateID == id.String() {
+			e.AggregateID = pseudonymID.String()
+		}
+		e.Payload = replaceID(e.Payload, id, pseudonymID, "user_id")
+		e.Payload = replaceID(e.Payload, id, pseudonymID, "admin_id")
+	}
+	for deliveryID, d := range m.deliveries {
+		d.Payload = replaceID(d.Payload, id, pseudonymID, "data", "user_id")
+		m.deliveries[deliveryID] = d
+	}
+	delete(m.users, id)
+	m.deleteUserData(id)
+
+	return nil
+}
+
+// replaceID replaces the id at the path in the JSON object with the pseudonymous id. If there's no such id,
+// the payload is returned as is.
+func replaceID(payload json.RawMessage, id, pseudonymID uuid.UUID, path ...string) json.RawMessage {
+	var obj map[string]interface{}
+	if err := json.Unmarshal(payload, &obj); err != nil {
+		return payload
+	}
+	parent := obj
+	for _, key := range path[:len(path)-1] {
+		child, ok := parent[key].(map[string]interface{})
+		if !ok {
+			return payload
+		}
+		parent = child
+	}
+	key := path[len(path)-1]
+	if parent[key] != id.String() {
+		return payload
+	}
+	parent[key] = pseudonymID.String()
+	replaced, err := json.Marshal(obj)
+	if err != nil {
+		return payload
+	}
+
+	return replaced
+}
+
+// deleteUserData deletes the sessions, password resets and two-factor authentication of the user
+// like the cascade deletion in Postgres. The caller must hold the lock.
+func (m *Memory) deleteUserData(userID uuid.UUID) {
+	for id, s := range m.sessions {
+		if s.UserID == userID {
+			delete(m.sessions, id)
+		}
+	}
+	resets := m.passwordResets[:0]
+	for _, r := range m.passwordResets {
+		if r.UserID != userID {
+			resets = append(resets, r)
+		}
+	}
+	m.passwordResets = resets
+	m.deleteTwoFactor(userID)
+}
+
+// userByLogin looks for the user with the login provided. The caller must hold the lock.
+func (m *Memory) userByLogin(login string) (model.User, bool) {
+	for _, u := range m.users {
+		if u.Login == login {
+			return u, true
+		}
+	}
+
+	return model.User{}, false
+}
+
+func cloneUser(u model.User) *model.User {
+	u.Permissions = copyPermissions(u.Permissions)
+	u.DeletedAt = timePtr(u.DeletedAt)
+
+	return &u
+}
+
+func copyPermissions(perms []model.Permission) []model.Permission {
+	return append(make([]model.Permission, 0, len(perms)), perms...)
+}
@@ -0,0 +1,186 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+// CreateWebhook implements Storage interface.
+func (m *Memory) CreateWebhook(_ context.Context, webhook *model.Webhook) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.webhooks[webhook.ID]; ok {
+		return errors.New("memory: webhook already exists")
+	}
+	w := *webhook
+	w.EventTypes = append(make([]model.EventType, 0, len(webhook.EventTypes)), webhook.EventTypes...)
+	m.webhooks[webhook.ID] = w
+
+	return nil
+}
+
+// Webhooks implements Storage interface.
+func (m *Memory) Webhooks(_ context.Context) ([]model.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	webhooks := make([]model.Webhook, 0, len(m.webhooks))
+	for _, w := range m.webhooks {
+		w.EventTypes = append(make([]model.EventType, 0, len(w.EventTypes)), w.EventTypes...)
+		webhooks = append(webhooks, w)
+	}
+	sort.Slice(webhooks, func(i, j int) bool {
+		if !webhooks[i].CreatedAt.Equal(webhooks[j].CreatedAt) {
+			return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt)
+		}
+
+		return webhooks[i].ID.String() < webhooks[j].ID.String()
+	})
+
+	return webhooks, nil
+}
+
+// DeleteWebhook implements Storage interface.
+func (m *Memory) DeleteWebhook(_ context.Context, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.webhooks[id]; !ok {
+		return storage.ErrNotFound
+	}
+	delete(m.webhooks, id)
+	for deliveryID, d := range m.deliveries {
+		if d.WebhookID == id {
+			delete(m.deliveries, deliveryID)
+		}
+	}
+
+	return nil
+}
+
+// CreateWebhookDelivery implements Storage interface.
+func (m *Memory) CreateWebhookDelivery(_ context.Context, delivery *model.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.webhooks[delivery.WebhookID]; !ok {
+		return storage.ErrNotFound
+	}
+	if _, ok := m.deliveries[delivery.ID]; ok {
+		return nil
+	}
+	m.deliveries[delivery.ID] = cloneDelivery(*delivery)
+
+	return nil
+}
+
+// WebhookDeliveryByID implements Storage interface.
+func (m *Memory) WebhookDeliveryByID(_ context.Context, id uuid.UUID) (*model.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d, ok := m.deliveries[id]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	d = cloneDelivery(d)
+
+	return &d, nil
+}
+
+// WebhookDeliveries implements Storage interface.
+func (m *Memory) WebhookDeliveries(_ context.Context, webhookID uuid.UUID) ([]model.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	deliveries := m.filterDeliveries(func(d model.WebhookDelivery) bool { return d.WebhookID == webhookID })
+	sort.Slice(deliveries, func(i, j int) bool {
+		if !deliveries[i].CreatedAt.Equal(deliveries[j].CreatedAt) {
+			return deliveries[i].CreatedAt.Before(deliveries[j].CreatedAt)
+		}
+
+		return deliveries[i].ID.String() < deliveries[j].ID.String()
+	})
+
+	return deliveries, nil
+}
+
+// ClaimWebhookDeliveries implements Storage interface.
+func (m *Memory) ClaimWebhookDeliveries(_ context.Context, now, claimUntil time.Time, limit int) ([]model.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	deliveries := m.filterDeliveries(func(d model.WebhookDelivery) bool {
+		return d.Status == model.DeliveryPending && !d.NextAttemptAt.After(now)
+	})
+	sort.Slice(deliveries, func(i, j int) bool {
+		if !deliveries[i].NextAttemptAt.Equal(deliveries[j].NextAttemptAt) {
+			return deliveries[i].NextAttemptAt.Before(deliveries[j].NextAttemptAt)
+		}
+
+		return deliveries[i].ID.String() < deliveries[j].ID.String()
+	})
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	for i := range deliveries {
+		d := m.deliveries[deliveries[i].ID]
+		d.NextAttemptAt = claimUntil
+		m.deliveries[d.ID] = d
+		deliveries[i].NextAttemptAt = claimUntil
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		if !deliveries[i].CreatedAt.Equal(deliveries[j].CreatedAt) {
+			return deliveries[i].CreatedAt.Before(deliveries[j].CreatedAt)
+		}
+
+		return deliveries[i].ID.String() < deliveries[j].ID.String()
+	})
+
+	return deliveries, nil
+}
+
+// UpdateWebhookDelivery implements Storage interface.
+func (m *Memory) UpdateWebhookDelivery(_ context.Context, delivery *model.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d, ok := m.deliveries[delivery.ID]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	d.Status = delivery.Status
+	d.Attempts = delivery.Attempts
+	d.LastStatusCode = delivery.LastStatusCode
+	d.LastError = delivery.LastError
+	d.NextAttemptAt = delivery.NextAttemptAt
+	m.deliveries[delivery.ID] = d
+
+	return nil
+}
+
+// filterDeliveries returns the copies of the deliveries matching the filter. The caller must hold the lock.
+func (m *Memory) filterDeliveries(match func(d model.WebhookDelivery) bool) []model.WebhookDelivery {
+	deliveries := make([]model.WebhookDelivery, 0)
+	for _, d := range m.deliveries {
+		if match(d) {
+			deliveries = append(deliveries, cloneDelivery(d))
+		}
+	}
+
+	return deliveries
+}
+
+func cloneDelivery(d model.WebhookDelivery) model.WebhookDelivery {
+	d.Payload = append([]byte(nil), d.Payload...)
+
+	return d
+}
@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+// ProcessWithdraw implements Storage interface. Like Postgres storage it sets the status of the withdrawal
+// provided to 'PROCESSED' ('INVALID' if the sum is negative or the balance is insufficient) and keeps
+// the rejected withdrawals in the log.
+func (m *Memory) ProcessWithdraw(_ context.Context, withdraw *model.Withdrawal) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, w := range m.withdrawals {
+		if w.OrderID == withdraw.OrderID {
+			return storage.ErrAlreadyProcessed
+		}
+	}
+	u, ok := m.users[withdraw.UserID]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	withdraw.Status = model.StatusProcessed
+	if withdraw.Sum < 0 {
+		withdraw.Status = model.StatusInvalid
+		m.withdrawals = append(m.withdrawals, *withdraw)
+
+		return storage.ErrInvalidInput
+	}
+
+	eventType := model.EventWithdrawalProcessed
+	if u.GPointsBalance < withdraw.Sum {
+		withdraw.Status = model.StatusInvalid
+		eventType = model.EventWithdrawalRejected
+	}
+	w := *withdraw
+	event, err := newEvent(eventType, w.OrderID.String(), model.WithdrawalEvent{
+		Order:       w.OrderID,
+		UserID:      w.UserID,
+		Sum:         w.Sum,
+		Status:      w.Status,
+		ProcessedAt: w.ProcessedAt,
+	})
+	if err != nil {
+		return err
+	}
+	m.withdrawals = append(m.withdrawals, w)
+	m.insertEvents(event)
+	if w.Status == model.StatusInvalid {
+		return storage.ErrInsufficientPoints
+	}
+	u.GPointsBalance -= w.Sum
+	m.users[w.UserID] = u
+
+	return nil
+}
+
+// RecoverWithdrawals implements Storage interface. The withdrawals are processed atomically, so there are
+// no orphaned withdrawals to recover.
+func (m *Memory) RecoverWithdrawals(_ context.Context, _ time.Time) (int, error) {
+	return 0, nil
+}
+
+// WithdrawalsByUserID implements Storage interface.
+func (m *Memory) WithdrawalsByUserID(_ context.Context, id uuid.UUID) ([]model.Withdrawal, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	withdrawals := make([]model.Withdrawal, 0)
+	for _, w := range m.withdrawals {
+		if w.UserID == id {
+			withdrawals = append(withdrawals, w)
+		}
+	}
+	sort.SliceStable(withdrawals, func(i, j int) bool {
+		return withdrawals[i].ProcessedAt.Before(withdrawals[j].ProcessedAt)
+	})
+
+	return withdrawals, nil
+}
@@ -0,0 +1,42 @@
+// Package migration reads the migrations of the SQL storages, so the schema version can be checked
+// regardless of the backend.
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-migrate/migrate/v4/source"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+)
+
+// Latest returns the version of the last migration at the source provided (e.g. "file://migration").
+func Latest(sourceURL string) (uint, error) {
+	src, err := source.Open(sourceURL)
+	if err != nil {
+		return 0, fmt.Errorf("migration: Latest: %w", err)
+	}
+	defer src.Close()
+
+	return LatestOf(src)
+}
+
+// LatestOf returns the version of the last migration of the source driver provided, e.g. the migrations
+// embedded into the binary. The source isn't closed.
+func LatestOf(src source.Driver) (uint, error) {
+	version, err := src.First()
+	if err != nil {
+		return 0, fmt.Errorf("migration: Latest: %w", err)
+	}
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("migration: Latest: %w", err)
+		}
+		version = next
+	}
+}
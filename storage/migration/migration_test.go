@@ -0,0 +1,22 @@
+package migration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatest(t *testing.T) {
+	for sourceURL, min := range map[string]uint{
+		"file://../psql/migration":   14,
+		"file://../sqlite/migration": 2,
+	} {
+		version, err := Latest(sourceURL)
+		require.NoError(t, err, sourceURL)
+		assert.GreaterOrEqual(t, version, min, sourceURL)
+	}
+
+	_, err := Latest("file://nowhere")
+	assert.Error(t, err)
+}
@@ -7,6 +7,7 @@ package mockstorage
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	uuid "github.com/google/uuid"
@@ -36,6 +37,80 @@ func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
 	return m.recorder
 }
 
+// APIKeyByHash mocks base method.
+func (m *MockStorage) APIKeyByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "APIKeyByHash", ctx, keyHash)
+	ret0, _ := ret[0].(*model.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// APIKeyByHash indicates an expected call of APIKeyByHash.
+func (mr *MockStorageMockRecorder) APIKeyByHash(ctx, keyHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "APIKeyByHash", reflect.TypeOf((*MockStorage)(nil).APIKeyByHash), ctx, keyHash)
+}
+
+// AdjustmentsByUserID mocks base method.
+func (m *MockStorage) AdjustmentsByUserID(ctx context.Context, id uuid.UUID) ([]model.Adjustment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdjustmentsByUserID", ctx, id)
+	ret0, _ := ret[0].([]model.Adjustment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AdjustmentsByUserID indicates an expected call of AdjustmentsByUserID.
+func (mr *MockStorageMockRecorder) AdjustmentsByUserID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdjustmentsByUserID", reflect.TypeOf((*MockStorage)(nil).AdjustmentsByUserID), ctx, id)
+}
+
+// ChangePassword mocks base method.
+func (m *MockStorage) ChangePassword(ctx context.Context, userID uuid.UUID, passwordHash string, keepSessionID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, userID, passwordHash, keepSessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockStorageMockRecorder) ChangePassword(ctx, userID, passwordHash, keepSessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockStorage)(nil).ChangePassword), ctx, userID, passwordHash, keepSessionID)
+}
+
+// ClaimUnpublishedEvents mocks base method.
+func (m *MockStorage) ClaimUnpublishedEvents(ctx context.Context, now, claimUntil time.Time, limit int) ([]model.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimUnpublishedEvents", ctx, now, claimUntil, limit)
+	ret0, _ := ret[0].([]model.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimUnpublishedEvents indicates an expected call of ClaimUnpublishedEvents.
+func (mr *MockStorageMockRecorder) ClaimUnpublishedEvents(ctx, now, claimUntil, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimUnpublishedEvents", reflect.TypeOf((*MockStorage)(nil).ClaimUnpublishedEvents), ctx, now, claimUntil, limit)
+}
+
+// ClaimWebhookDeliveries mocks base method.
+func (m *MockStorage) ClaimWebhookDeliveries(ctx context.Context, now, claimUntil time.Time, limit int) ([]model.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimWebhookDeliveries", ctx, now, claimUntil, limit)
+	ret0, _ := ret[0].([]model.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimWebhookDeliveries indicates an expected call of ClaimWebhookDeliveries.
+func (mr *MockStorageMockRecorder) ClaimWebhookDeliveries(ctx, now, claimUntil, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimWebhookDeliveries", reflect.TypeOf((*MockStorage)(nil).ClaimWebhookDeliveries), ctx, now, claimUntil, limit)
+}
+
 // Close mocks base method.
 func (m *MockStorage) Close() error {
 	m.ctrl.T.Helper()
@@ -50,6 +125,20 @@ func (mr *MockStorageMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockStorage)(nil).Close))
 }
 
+// CreateAPIKey mocks base method.
+func (m *MockStorage) CreateAPIKey(ctx context.Context, key *model.APIKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKey", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey.
+func (mr *MockStorageMockRecorder) CreateAPIKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockStorage)(nil).CreateAPIKey), ctx, key)
+}
+
 // CreateAccrual mocks base method.
 func (m *MockStorage) CreateAccrual(ctx context.Context, orderID model.OrderID, amount float32) error {
 	m.ctrl.T.Helper()
@@ -64,6 +153,48 @@ func (mr *MockStorageMockRecorder) CreateAccrual(ctx, orderID, amount interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccrual", reflect.TypeOf((*MockStorage)(nil).CreateAccrual), ctx, orderID, amount)
 }
 
+// CreateAdjustment mocks base method.
+func (m *MockStorage) CreateAdjustment(ctx context.Context, adjustment *model.Adjustment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAdjustment", ctx, adjustment)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAdjustment indicates an expected call of CreateAdjustment.
+func (mr *MockStorageMockRecorder) CreateAdjustment(ctx, adjustment interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAdjustment", reflect.TypeOf((*MockStorage)(nil).CreateAdjustment), ctx, adjustment)
+}
+
+// CreateLoginChallenge mocks base method.
+func (m *MockStorage) CreateLoginChallenge(ctx context.Context, c *model.LoginChallenge) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateLoginChallenge", ctx, c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateLoginChallenge indicates an expected call of CreateLoginChallenge.
+func (mr *MockStorageMockRecorder) CreateLoginChallenge(ctx, c interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLoginChallenge", reflect.TypeOf((*MockStorage)(nil).CreateLoginChallenge), ctx, c)
+}
+
+// CreateMerchant mocks base method.
+func (m *MockStorage) CreateMerchant(ctx context.Context, merchant *model.Merchant) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMerchant", ctx, merchant)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateMerchant indicates an expected call of CreateMerchant.
+func (mr *MockStorageMockRecorder) CreateMerchant(ctx, merchant interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMerchant", reflect.TypeOf((*MockStorage)(nil).CreateMerchant), ctx, merchant)
+}
+
 // CreateOrder mocks base method.
 func (m *MockStorage) CreateOrder(ctx context.Context, order *model.Order) error {
 	m.ctrl.T.Helper()
@@ -78,6 +209,34 @@ func (mr *MockStorageMockRecorder) CreateOrder(ctx, order interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrder", reflect.TypeOf((*MockStorage)(nil).CreateOrder), ctx, order)
 }
 
+// CreatePasswordReset mocks base method.
+func (m *MockStorage) CreatePasswordReset(ctx context.Context, reset *model.PasswordReset) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePasswordReset", ctx, reset)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePasswordReset indicates an expected call of CreatePasswordReset.
+func (mr *MockStorageMockRecorder) CreatePasswordReset(ctx, reset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePasswordReset", reflect.TypeOf((*MockStorage)(nil).CreatePasswordReset), ctx, reset)
+}
+
+// CreateSession mocks base method.
+func (m *MockStorage) CreateSession(ctx context.Context, session *model.Session) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", ctx, session)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockStorageMockRecorder) CreateSession(ctx, session interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockStorage)(nil).CreateSession), ctx, session)
+}
+
 // CreateUser mocks base method.
 func (m *MockStorage) CreateUser(ctx context.Context, user model.User) error {
 	m.ctrl.T.Helper()
@@ -92,6 +251,309 @@ func (mr *MockStorageMockRecorder) CreateUser(ctx, user interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStorage)(nil).CreateUser), ctx, user)
 }
 
+// CreateWebhook mocks base method.
+func (m *MockStorage) CreateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhook", ctx, webhook)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook.
+func (mr *MockStorageMockRecorder) CreateWebhook(ctx, webhook interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhook", reflect.TypeOf((*MockStorage)(nil).CreateWebhook), ctx, webhook)
+}
+
+// CreateWebhookDelivery mocks base method.
+func (m *MockStorage) CreateWebhookDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookDelivery", ctx, delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWebhookDelivery indicates an expected call of CreateWebhookDelivery.
+func (mr *MockStorageMockRecorder) CreateWebhookDelivery(ctx, delivery interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookDelivery", reflect.TypeOf((*MockStorage)(nil).CreateWebhookDelivery), ctx, delivery)
+}
+
+// DeleteExpiredLoginChallenges mocks base method.
+func (m *MockStorage) DeleteExpiredLoginChallenges(ctx context.Context, now time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredLoginChallenges", ctx, now)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredLoginChallenges indicates an expected call of DeleteExpiredLoginChallenges.
+func (mr *MockStorageMockRecorder) DeleteExpiredLoginChallenges(ctx, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredLoginChallenges", reflect.TypeOf((*MockStorage)(nil).DeleteExpiredLoginChallenges), ctx, now)
+}
+
+// DeleteExpiredSessions mocks base method.
+func (m *MockStorage) DeleteExpiredSessions(ctx context.Context, now time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredSessions", ctx, now)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredSessions indicates an expected call of DeleteExpiredSessions.
+func (mr *MockStorageMockRecorder) DeleteExpiredSessions(ctx, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredSessions", reflect.TypeOf((*MockStorage)(nil).DeleteExpiredSessions), ctx, now)
+}
+
+// DeleteFullRateLimits mocks base method.
+func (m *MockStorage) DeleteFullRateLimits(ctx context.Context, now time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFullRateLimits", ctx, now)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteFullRateLimits indicates an expected call of DeleteFullRateLimits.
+func (mr *MockStorageMockRecorder) DeleteFullRateLimits(ctx, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFullRateLimits", reflect.TypeOf((*MockStorage)(nil).DeleteFullRateLimits), ctx, now)
+}
+
+// DeleteLoginChallenge mocks base method.
+func (m *MockStorage) DeleteLoginChallenge(ctx context.Context, tokenHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteLoginChallenge", ctx, tokenHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteLoginChallenge indicates an expected call of DeleteLoginChallenge.
+func (mr *MockStorageMockRecorder) DeleteLoginChallenge(ctx, tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLoginChallenge", reflect.TypeOf((*MockStorage)(nil).DeleteLoginChallenge), ctx, tokenHash)
+}
+
+// DeleteLoginThrottle mocks base method.
+func (m *MockStorage) DeleteLoginThrottle(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteLoginThrottle", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteLoginThrottle indicates an expected call of DeleteLoginThrottle.
+func (mr *MockStorageMockRecorder) DeleteLoginThrottle(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLoginThrottle", reflect.TypeOf((*MockStorage)(nil).DeleteLoginThrottle), ctx, key)
+}
+
+// DeleteSession mocks base method.
+func (m *MockStorage) DeleteSession(ctx context.Context, userID, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSession", ctx, userID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSession indicates an expected call of DeleteSession.
+func (mr *MockStorageMockRecorder) DeleteSession(ctx, userID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSession", reflect.TypeOf((*MockStorage)(nil).DeleteSession), ctx, userID, id)
+}
+
+// DeleteStaleLoginThrottles mocks base method.
+func (m *MockStorage) DeleteStaleLoginThrottles(ctx context.Context, before time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteStaleLoginThrottles", ctx, before)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteStaleLoginThrottles indicates an expected call of DeleteStaleLoginThrottles.
+func (mr *MockStorageMockRecorder) DeleteStaleLoginThrottles(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteStaleLoginThrottles", reflect.TypeOf((*MockStorage)(nil).DeleteStaleLoginThrottles), ctx, before)
+}
+
+// DeleteUser mocks base method.
+func (m *MockStorage) DeleteUser(ctx context.Context, id, pseudonymID uuid.UUID, now time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, id, pseudonymID, now)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockStorageMockRecorder) DeleteUser(ctx, id, pseudonymID, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockStorage)(nil).DeleteUser), ctx, id, pseudonymID, now)
+}
+
+// DeleteWebhook mocks base method.
+func (m *MockStorage) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhook", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockStorageMockRecorder) DeleteWebhook(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockStorage)(nil).DeleteWebhook), ctx, id)
+}
+
+// DisableTwoFactor mocks base method.
+func (m *MockStorage) DisableTwoFactor(ctx context.Context, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTwoFactor", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableTwoFactor indicates an expected call of DisableTwoFactor.
+func (mr *MockStorageMockRecorder) DisableTwoFactor(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTwoFactor", reflect.TypeOf((*MockStorage)(nil).DisableTwoFactor), ctx, userID)
+}
+
+// EnableTwoFactor mocks base method.
+func (m *MockStorage) EnableTwoFactor(ctx context.Context, userID uuid.UUID, step int64, now time.Time, recoveryCodeHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableTwoFactor", ctx, userID, step, now, recoveryCodeHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableTwoFactor indicates an expected call of EnableTwoFactor.
+func (mr *MockStorageMockRecorder) EnableTwoFactor(ctx, userID, step, now, recoveryCodeHashes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableTwoFactor", reflect.TypeOf((*MockStorage)(nil).EnableTwoFactor), ctx, userID, step, now, recoveryCodeHashes)
+}
+
+// ForgiveLoginAttempt mocks base method.
+func (m *MockStorage) ForgiveLoginAttempt(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForgiveLoginAttempt", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForgiveLoginAttempt indicates an expected call of ForgiveLoginAttempt.
+func (mr *MockStorageMockRecorder) ForgiveLoginAttempt(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForgiveLoginAttempt", reflect.TypeOf((*MockStorage)(nil).ForgiveLoginAttempt), ctx, key)
+}
+
+// LockLogin mocks base method.
+func (m *MockStorage) LockLogin(ctx context.Context, key string, until time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockLogin", ctx, key, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockLogin indicates an expected call of LockLogin.
+func (mr *MockStorageMockRecorder) LockLogin(ctx, key, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockLogin", reflect.TypeOf((*MockStorage)(nil).LockLogin), ctx, key, until)
+}
+
+// LoginChallenge mocks base method.
+func (m *MockStorage) LoginChallenge(ctx context.Context, tokenHash string) (*model.LoginChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoginChallenge", ctx, tokenHash)
+	ret0, _ := ret[0].(*model.LoginChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoginChallenge indicates an expected call of LoginChallenge.
+func (mr *MockStorageMockRecorder) LoginChallenge(ctx, tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginChallenge", reflect.TypeOf((*MockStorage)(nil).LoginChallenge), ctx, tokenHash)
+}
+
+// LoginThrottle mocks base method.
+func (m *MockStorage) LoginThrottle(ctx context.Context, key string) (*model.LoginThrottle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoginThrottle", ctx, key)
+	ret0, _ := ret[0].(*model.LoginThrottle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoginThrottle indicates an expected call of LoginThrottle.
+func (mr *MockStorageMockRecorder) LoginThrottle(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginThrottle", reflect.TypeOf((*MockStorage)(nil).LoginThrottle), ctx, key)
+}
+
+// MarkEventsPublished mocks base method.
+func (m *MockStorage) MarkEventsPublished(ctx context.Context, ids []int64, publishedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkEventsPublished", ctx, ids, publishedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkEventsPublished indicates an expected call of MarkEventsPublished.
+func (mr *MockStorageMockRecorder) MarkEventsPublished(ctx, ids, publishedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkEventsPublished", reflect.TypeOf((*MockStorage)(nil).MarkEventsPublished), ctx, ids, publishedAt)
+}
+
+// MerchantAPIKeys mocks base method.
+func (m *MockStorage) MerchantAPIKeys(ctx context.Context, merchantID uuid.UUID) ([]model.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MerchantAPIKeys", ctx, merchantID)
+	ret0, _ := ret[0].([]model.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MerchantAPIKeys indicates an expected call of MerchantAPIKeys.
+func (mr *MockStorageMockRecorder) MerchantAPIKeys(ctx, merchantID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MerchantAPIKeys", reflect.TypeOf((*MockStorage)(nil).MerchantAPIKeys), ctx, merchantID)
+}
+
+// MerchantByID mocks base method.
+func (m *MockStorage) MerchantByID(ctx context.Context, id uuid.UUID) (*model.Merchant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MerchantByID", ctx, id)
+	ret0, _ := ret[0].(*model.Merchant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MerchantByID indicates an expected call of MerchantByID.
+func (mr *MockStorageMockRecorder) MerchantByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MerchantByID", reflect.TypeOf((*MockStorage)(nil).MerchantByID), ctx, id)
+}
+
+// Merchants mocks base method.
+func (m *MockStorage) Merchants(ctx context.Context) ([]model.Merchant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Merchants", ctx)
+	ret0, _ := ret[0].([]model.Merchant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Merchants indicates an expected call of Merchants.
+func (mr *MockStorageMockRecorder) Merchants(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Merchants", reflect.TypeOf((*MockStorage)(nil).Merchants), ctx)
+}
+
 // OrderByID mocks base method.
 func (m *MockStorage) OrderByID(ctx context.Context, orderID model.OrderID) (*model.Order, error) {
 	m.ctrl.T.Helper()
@@ -122,6 +584,21 @@ func (mr *MockStorageMockRecorder) OrdersByStatus(ctx, status interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OrdersByStatus", reflect.TypeOf((*MockStorage)(nil).OrdersByStatus), ctx, status)
 }
 
+// PasswordResetUser mocks base method.
+func (m *MockStorage) PasswordResetUser(ctx context.Context, tokenHash string, now time.Time) (*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PasswordResetUser", ctx, tokenHash, now)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PasswordResetUser indicates an expected call of PasswordResetUser.
+func (mr *MockStorageMockRecorder) PasswordResetUser(ctx, tokenHash, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PasswordResetUser", reflect.TypeOf((*MockStorage)(nil).PasswordResetUser), ctx, tokenHash, now)
+}
+
 // ProcessWithdraw mocks base method.
 func (m *MockStorage) ProcessWithdraw(ctx context.Context, withdraw *model.Withdrawal) error {
 	m.ctrl.T.Helper()
@@ -136,6 +613,225 @@ func (mr *MockStorageMockRecorder) ProcessWithdraw(ctx, withdraw interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessWithdraw", reflect.TypeOf((*MockStorage)(nil).ProcessWithdraw), ctx, withdraw)
 }
 
+// RecoverWithdrawals mocks base method.
+func (m *MockStorage) RecoverWithdrawals(ctx context.Context, before time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecoverWithdrawals", ctx, before)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecoverWithdrawals indicates an expected call of RecoverWithdrawals.
+func (mr *MockStorageMockRecorder) RecoverWithdrawals(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecoverWithdrawals", reflect.TypeOf((*MockStorage)(nil).RecoverWithdrawals), ctx, before)
+}
+
+// ResetPassword mocks base method.
+func (m *MockStorage) ResetPassword(ctx context.Context, tokenHash, passwordHash string, now time.Time) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", ctx, tokenHash, passwordHash, now)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockStorageMockRecorder) ResetPassword(ctx, tokenHash, passwordHash, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockStorage)(nil).ResetPassword), ctx, tokenHash, passwordHash, now)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockStorage) RevokeAPIKey(ctx context.Context, merchantID, id uuid.UUID, now time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", ctx, merchantID, id, now)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockStorageMockRecorder) RevokeAPIKey(ctx, merchantID, id, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockStorage)(nil).RevokeAPIKey), ctx, merchantID, id, now)
+}
+
+// SearchUsers mocks base method.
+func (m *MockStorage) SearchUsers(ctx context.Context, login string, limit int) ([]model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchUsers", ctx, login, limit)
+	ret0, _ := ret[0].([]model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchUsers indicates an expected call of SearchUsers.
+func (mr *MockStorageMockRecorder) SearchUsers(ctx, login, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchUsers", reflect.TypeOf((*MockStorage)(nil).SearchUsers), ctx, login, limit)
+}
+
+// SessionByID mocks base method.
+func (m *MockStorage) SessionByID(ctx context.Context, id uuid.UUID) (*model.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SessionByID", ctx, id)
+	ret0, _ := ret[0].(*model.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SessionByID indicates an expected call of SessionByID.
+func (mr *MockStorageMockRecorder) SessionByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SessionByID", reflect.TypeOf((*MockStorage)(nil).SessionByID), ctx, id)
+}
+
+// SessionByTokenHash mocks base method.
+func (m *MockStorage) SessionByTokenHash(ctx context.Context, tokenHash string) (*model.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SessionByTokenHash", ctx, tokenHash)
+	ret0, _ := ret[0].(*model.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SessionByTokenHash indicates an expected call of SessionByTokenHash.
+func (mr *MockStorageMockRecorder) SessionByTokenHash(ctx, tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SessionByTokenHash", reflect.TypeOf((*MockStorage)(nil).SessionByTokenHash), ctx, tokenHash)
+}
+
+// SetPendingTwoFactor mocks base method.
+func (m *MockStorage) SetPendingTwoFactor(ctx context.Context, userID uuid.UUID, secret string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPendingTwoFactor", ctx, userID, secret)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPendingTwoFactor indicates an expected call of SetPendingTwoFactor.
+func (mr *MockStorageMockRecorder) SetPendingTwoFactor(ctx, userID, secret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPendingTwoFactor", reflect.TypeOf((*MockStorage)(nil).SetPendingTwoFactor), ctx, userID, secret)
+}
+
+// SetUserBlocked mocks base method.
+func (m *MockStorage) SetUserBlocked(ctx context.Context, id uuid.UUID, blocked bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserBlocked", ctx, id, blocked)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserBlocked indicates an expected call of SetUserBlocked.
+func (mr *MockStorageMockRecorder) SetUserBlocked(ctx, id, blocked interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserBlocked", reflect.TypeOf((*MockStorage)(nil).SetUserBlocked), ctx, id, blocked)
+}
+
+// SetUserRole mocks base method.
+func (m *MockStorage) SetUserRole(ctx context.Context, id uuid.UUID, role model.Role, perms []model.Permission) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserRole", ctx, id, role, perms)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserRole indicates an expected call of SetUserRole.
+func (mr *MockStorageMockRecorder) SetUserRole(ctx, id, role, perms interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserRole", reflect.TypeOf((*MockStorage)(nil).SetUserRole), ctx, id, role, perms)
+}
+
+// TakeChallengeAttempt mocks base method.
+func (m *MockStorage) TakeChallengeAttempt(ctx context.Context, tokenHash string, maxAttempts int, now time.Time) (*model.LoginChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeChallengeAttempt", ctx, tokenHash, maxAttempts, now)
+	ret0, _ := ret[0].(*model.LoginChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TakeChallengeAttempt indicates an expected call of TakeChallengeAttempt.
+func (mr *MockStorageMockRecorder) TakeChallengeAttempt(ctx, tokenHash, maxAttempts, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeChallengeAttempt", reflect.TypeOf((*MockStorage)(nil).TakeChallengeAttempt), ctx, tokenHash, maxAttempts, now)
+}
+
+// TakeLoginAttempt mocks base method.
+func (m *MockStorage) TakeLoginAttempt(ctx context.Context, key string, seenFailures int, now time.Time, window time.Duration) (*model.LoginThrottle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeLoginAttempt", ctx, key, seenFailures, now, window)
+	ret0, _ := ret[0].(*model.LoginThrottle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TakeLoginAttempt indicates an expected call of TakeLoginAttempt.
+func (mr *MockStorageMockRecorder) TakeLoginAttempt(ctx, key, seenFailures, now, window interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeLoginAttempt", reflect.TypeOf((*MockStorage)(nil).TakeLoginAttempt), ctx, key, seenFailures, now, window)
+}
+
+// TakeRateLimitToken mocks base method.
+func (m *MockStorage) TakeRateLimitToken(ctx context.Context, key string, limit model.RateLimit, now time.Time) (model.RateLimitResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeRateLimitToken", ctx, key, limit, now)
+	ret0, _ := ret[0].(model.RateLimitResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TakeRateLimitToken indicates an expected call of TakeRateLimitToken.
+func (mr *MockStorageMockRecorder) TakeRateLimitToken(ctx, key, limit, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeRateLimitToken", reflect.TypeOf((*MockStorage)(nil).TakeRateLimitToken), ctx, key, limit, now)
+}
+
+// TouchAPIKey mocks base method.
+func (m *MockStorage) TouchAPIKey(ctx context.Context, id uuid.UUID, lastUsedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchAPIKey", ctx, id, lastUsedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchAPIKey indicates an expected call of TouchAPIKey.
+func (mr *MockStorageMockRecorder) TouchAPIKey(ctx, id, lastUsedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchAPIKey", reflect.TypeOf((*MockStorage)(nil).TouchAPIKey), ctx, id, lastUsedAt)
+}
+
+// TouchSession mocks base method.
+func (m *MockStorage) TouchSession(ctx context.Context, id uuid.UUID, lastSeenAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchSession", ctx, id, lastSeenAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchSession indicates an expected call of TouchSession.
+func (mr *MockStorageMockRecorder) TouchSession(ctx, id, lastSeenAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchSession", reflect.TypeOf((*MockStorage)(nil).TouchSession), ctx, id, lastSeenAt)
+}
+
+// TwoFactor mocks base method.
+func (m *MockStorage) TwoFactor(ctx context.Context, userID uuid.UUID) (*model.TwoFactor, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TwoFactor", ctx, userID)
+	ret0, _ := ret[0].(*model.TwoFactor)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TwoFactor indicates an expected call of TwoFactor.
+func (mr *MockStorageMockRecorder) TwoFactor(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TwoFactor", reflect.TypeOf((*MockStorage)(nil).TwoFactor), ctx, userID)
+}
+
 // UpdateBalance mocks base method.
 func (m *MockStorage) UpdateBalance(ctx context.Context) (int, error) {
 	m.ctrl.T.Helper()
@@ -165,6 +861,20 @@ func (mr *MockStorageMockRecorder) UpdateOrderStatus(ctx, orderID, status interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrderStatus", reflect.TypeOf((*MockStorage)(nil).UpdateOrderStatus), ctx, orderID, status)
 }
 
+// UpdatePasswordHash mocks base method.
+func (m *MockStorage) UpdatePasswordHash(ctx context.Context, userID uuid.UUID, oldHash, newHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePasswordHash", ctx, userID, oldHash, newHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePasswordHash indicates an expected call of UpdatePasswordHash.
+func (mr *MockStorageMockRecorder) UpdatePasswordHash(ctx, userID, oldHash, newHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePasswordHash", reflect.TypeOf((*MockStorage)(nil).UpdatePasswordHash), ctx, userID, oldHash, newHash)
+}
+
 // UpdateUser mocks base method.
 func (m *MockStorage) UpdateUser(ctx context.Context, user model.User) error {
 	m.ctrl.T.Helper()
@@ -179,34 +889,91 @@ func (mr *MockStorageMockRecorder) UpdateUser(ctx, user interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockStorage)(nil).UpdateUser), ctx, user)
 }
 
-// UserByLogin mocks base method.
-func (m *MockStorage) UserByLogin(ctx context.Context, login string) (*model.User, error) {
+// UpdateWebhookDelivery mocks base method.
+func (m *MockStorage) UpdateWebhookDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UserByLogin", ctx, login)
+	ret := m.ctrl.Call(m, "UpdateWebhookDelivery", ctx, delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWebhookDelivery indicates an expected call of UpdateWebhookDelivery.
+func (mr *MockStorageMockRecorder) UpdateWebhookDelivery(ctx, delivery interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhookDelivery", reflect.TypeOf((*MockStorage)(nil).UpdateWebhookDelivery), ctx, delivery)
+}
+
+// UpgradeSessionHashes mocks base method.
+func (m *MockStorage) UpgradeSessionHashes(ctx context.Context, upgrade func(string) string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpgradeSessionHashes", ctx, upgrade)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpgradeSessionHashes indicates an expected call of UpgradeSessionHashes.
+func (mr *MockStorageMockRecorder) UpgradeSessionHashes(ctx, upgrade interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpgradeSessionHashes", reflect.TypeOf((*MockStorage)(nil).UpgradeSessionHashes), ctx, upgrade)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockStorage) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string, now time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", ctx, userID, codeHash, now)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockStorageMockRecorder) UseRecoveryCode(ctx, userID, codeHash, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockStorage)(nil).UseRecoveryCode), ctx, userID, codeHash, now)
+}
+
+// UseTOTPStep mocks base method.
+func (m *MockStorage) UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseTOTPStep", ctx, userID, step)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseTOTPStep indicates an expected call of UseTOTPStep.
+func (mr *MockStorageMockRecorder) UseTOTPStep(ctx, userID, step interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseTOTPStep", reflect.TypeOf((*MockStorage)(nil).UseTOTPStep), ctx, userID, step)
+}
+
+// UserByID mocks base method.
+func (m *MockStorage) UserByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserByID", ctx, id)
 	ret0, _ := ret[0].(*model.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// UserByLogin indicates an expected call of UserByLogin.
-func (mr *MockStorageMockRecorder) UserByLogin(ctx, login interface{}) *gomock.Call {
+// UserByID indicates an expected call of UserByID.
+func (mr *MockStorageMockRecorder) UserByID(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserByLogin", reflect.TypeOf((*MockStorage)(nil).UserByLogin), ctx, login)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserByID", reflect.TypeOf((*MockStorage)(nil).UserByID), ctx, id)
 }
 
-// UserByRemember mocks base method.
-func (m *MockStorage) UserByRemember(ctx context.Context, remember string) (*model.User, error) {
+// UserByLogin mocks base method.
+func (m *MockStorage) UserByLogin(ctx context.Context, login string) (*model.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UserByRemember", ctx, remember)
+	ret := m.ctrl.Call(m, "UserByLogin", ctx, login)
 	ret0, _ := ret[0].(*model.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// UserByRemember indicates an expected call of UserByRemember.
-func (mr *MockStorageMockRecorder) UserByRemember(ctx, remember interface{}) *gomock.Call {
+// UserByLogin indicates an expected call of UserByLogin.
+func (mr *MockStorageMockRecorder) UserByLogin(ctx, login interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserByRemember", reflect.TypeOf((*MockStorage)(nil).UserByRemember), ctx, remember)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserByLogin", reflect.TypeOf((*MockStorage)(nil).UserByLogin), ctx, login)
 }
 
 // UserOrders mocks base method.
@@ -224,6 +991,81 @@ func (mr *MockStorageMockRecorder) UserOrders(ctx, userID interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserOrders", reflect.TypeOf((*MockStorage)(nil).UserOrders), ctx, userID)
 }
 
+// UserPasswordResets mocks base method.
+func (m *MockStorage) UserPasswordResets(ctx context.Context, userID uuid.UUID) ([]model.PasswordReset, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserPasswordResets", ctx, userID)
+	ret0, _ := ret[0].([]model.PasswordReset)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UserPasswordResets indicates an expected call of UserPasswordResets.
+func (mr *MockStorageMockRecorder) UserPasswordResets(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserPasswordResets", reflect.TypeOf((*MockStorage)(nil).UserPasswordResets), ctx, userID)
+}
+
+// UserSessions mocks base method.
+func (m *MockStorage) UserSessions(ctx context.Context, userID uuid.UUID) ([]model.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserSessions", ctx, userID)
+	ret0, _ := ret[0].([]model.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UserSessions indicates an expected call of UserSessions.
+func (mr *MockStorageMockRecorder) UserSessions(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserSessions", reflect.TypeOf((*MockStorage)(nil).UserSessions), ctx, userID)
+}
+
+// WebhookDeliveries mocks base method.
+func (m *MockStorage) WebhookDeliveries(ctx context.Context, webhookID uuid.UUID) ([]model.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WebhookDeliveries", ctx, webhookID)
+	ret0, _ := ret[0].([]model.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WebhookDeliveries indicates an expected call of WebhookDeliveries.
+func (mr *MockStorageMockRecorder) WebhookDeliveries(ctx, webhookID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WebhookDeliveries", reflect.TypeOf((*MockStorage)(nil).WebhookDeliveries), ctx, webhookID)
+}
+
+// WebhookDeliveryByID mocks base method.
+func (m *MockStorage) WebhookDeliveryByID(ctx context.Context, id uuid.UUID) (*model.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WebhookDeliveryByID", ctx, id)
+	ret0, _ := ret[0].(*model.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WebhookDeliveryByID indicates an expected call of WebhookDeliveryByID.
+func (mr *MockStorageMockRecorder) WebhookDeliveryByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WebhookDeliveryByID", reflect.TypeOf((*MockStorage)(nil).WebhookDeliveryByID), ctx, id)
+}
+
+// Webhooks mocks base method.
+func (m *MockStorage) Webhooks(ctx context.Context) ([]model.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Webhooks", ctx)
+	ret0, _ := ret[0].([]model.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Webhooks indicates an expected call of Webhooks.
+func (mr *MockStorageMockRecorder) Webhooks(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Webhooks", reflect.TypeOf((*MockStorage)(nil).Webhooks), ctx)
+}
+
 // WithdrawalsByUserID mocks base method.
 func (m *MockStorage) WithdrawalsByUserID(ctx context.Context, id uuid.UUID) ([]model.Withdrawal, error) {
 	m.ctrl.T.Helper()
@@ -54,6 +54,15 @@ func (p Psql) CreateAccrual(ctx context.Context, orderID model.OrderID, amount f
 		amount, orderID); err != nil {
 		return err
 	}
+	if err := insertEvent(ctx, tx, model.EventOrderProcessed, orderID.String(), model.OrderEvent{
+		Order:          orderID,
+		UserID:         userID,
+		Status:         model.StatusProcessed,
+		PreviousStatus: status,
+		Accrual:        amount,
+	}); err != nil {
+		return err
+	}
 
 	return tx.Commit()
 }
@@ -132,6 +141,15 @@ func (p Psql) UpdateBalance(ctx context.Context) (int, error) {
 		if _, err := stmtStatus.ExecContext(ctx, a.orderID); err != nil {
 			log.Printf("process: accruals: %v", err)
 
+			return 0, err
+		}
+		if err := insertEvent(ctx, tx, model.EventBalanceCredited, a.userID.String(), model.BalanceEvent{
+			Order:  model.OrderID(a.orderID),
+			UserID: a.userID,
+			Sum:    a.sum,
+		}); err != nil {
+			log.Printf("process: outbox: %v", err)
+
 			return 0, err
 		}
 	}
@@ -0,0 +1,73 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+// CreateAdjustment implements Storage interface.
+func (p Psql) CreateAdjustment(ctx context.Context, adj *model.Adjustment) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	//nolint:errcheck
+	defer tx.Rollback()
+
+	row := tx.QueryRowContext(ctx, `SELECT gpoints_balance FROM users WHERE id=$1 FOR UPDATE;`, adj.UserID)
+	var balance float32
+	if err := row.Scan(&balance); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.ErrNotFound
+		}
+
+		return err
+	}
+	if balance+adj.Sum < 0 {
+		return storage.ErrInsufficientPoints
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET gpoints_balance = gpoints_balance + $1 WHERE id=$2;`,
+		adj.Sum, adj.UserID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO adjustments_log (id, user_id, admin_id, sum, reason, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6);`, adj.ID, adj.UserID, adj.AdminID, adj.Sum, adj.Reason, adj.CreatedAt); err != nil {
+		return err
+	}
+	if err := insertEvent(ctx, tx, model.EventBalanceAdjusted, adj.UserID.String(), adj); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AdjustmentsByUserID implements Storage interface.
+func (p Psql) AdjustmentsByUserID(ctx context.Context, id uuid.UUID) ([]model.Adjustment, error) {
+	rows, err := p.db.QueryContext(ctx, `SELECT id, user_id, admin_id, sum, reason, created_at
+	FROM adjustments_log WHERE user_id=$1 ORDER BY created_at ASC;`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	adjustments := make([]model.Adjustment, 0)
+	for rows.Next() {
+		var a model.Adjustment
+		if err := rows.Scan(&a.ID, &a.UserID, &a.AdminID, &a.Sum, &a.Reason, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		adjustments = append(adjustments, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return adjustments, nil
+}
@@ -0,0 +1,78 @@
+package psql
+
+import (
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+func (ts *TestSuite) TestAdjustments() {
+	erin := &model.User{
+		ID:             uuid.New(),
+		Login:          "erinbrockovich@pge.com",
+		PasswordHash:   "AsDfGhJkL",
+		CreatedAt:      time.Now(),
+		GPointsBalance: 100,
+	}
+	ts.Require().NoError(ts.storage.CreateUser(ts.ctx, *erin))
+
+	tt := []struct {
+		name    string
+		userID  uuid.UUID
+		sum     float32
+		wantErr error
+	}{
+		{
+			name:    "#1 Credit Erin",
+			userID:  erin.ID,
+			sum:     50.5,
+			wantErr: nil,
+		},
+		{
+			name:    "#2 Debit Erin",
+			userID:  erin.ID,
+			sum:     -100,
+			wantErr: nil,
+		},
+		{
+			name:    "#3 Debit more than Erin has",
+			userID:  erin.ID,
+			sum:     -100,
+			wantErr: storage.ErrInsufficientPoints,
+		},
+		{
+			name:    "#4 Non-existing user",
+			userID:  uuid.New(),
+			sum:     100,
+			wantErr: storage.ErrNotFound,
+		},
+	}
+	for _, tc := range tt {
+		ts.Run(tc.name, func() {
+			err := ts.storage.CreateAdjustment(ts.ctx, &model.Adjustment{
+				ID:        uuid.New(),
+				UserID:    tc.userID,
+				AdminID:   ts.alice.user.ID,
+				Sum:       tc.sum,
+				Reason:    tc.name,
+				CreatedAt: time.Now(),
+			})
+			ts.Assert().ErrorIs(err, tc.wantErr)
+		})
+	}
+	ts.Run("#5 Check Erin's adjustments log and balance", func() {
+		adjustments, err := ts.storage.AdjustmentsByUserID(ts.ctx, erin.ID)
+		ts.Require().NoError(err)
+		ts.Require().Len(adjustments, 2)
+		ts.Assert().EqualValues(50.5, adjustments[0].Sum)
+		ts.Assert().EqualValues(-100, adjustments[1].Sum)
+		ts.Assert().Equal(ts.alice.user.ID, adjustments[0].AdminID)
+
+		erin, err := ts.storage.UserByID(ts.ctx, erin.ID)
+		ts.Require().NoError(err)
+		ts.Assert().EqualValues(50.5, erin.GPointsBalance)
+	})
+}
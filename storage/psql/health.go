@@ -0,0 +1,34 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/vanamelnik/gophermart/storage"
+)
+
+// Ping checks the connection to the database.
+func (p Psql) Ping(ctx context.Context) error {
+	if err := p.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("psql: Ping: %w", err)
+	}
+
+	return nil
+}
+
+// MigrationVersion returns the version of the database schema applied by golang-migrate and whether the last
+// migration has failed (dirty). If no migrations have been applied, ErrNotFound is returned.
+func (p Psql) MigrationVersion(ctx context.Context) (version uint, dirty bool, err error) {
+	err = p.db.QueryRowContext(ctx, `SELECT "version", "dirty" FROM "schema_migrations" LIMIT 1;`).Scan(&version, &dirty)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, storage.ErrNotFound
+		}
+
+		return 0, false, fmt.Errorf("psql: MigrationVersion: %w", err)
+	}
+
+	return version, dirty, nil
+}
@@ -0,0 +1,15 @@
+package psql
+
+import "github.com/vanamelnik/gophermart/storage/migration"
+
+func (ts *TestSuite) TestMigrationVersion() {
+	p := ts.storage.(*Psql)
+	ts.NoError(p.Ping(ts.ctx))
+
+	latest, err := migration.Latest(migrationsPath)
+	ts.Require().NoError(err)
+	version, dirty, err := p.MigrationVersion(ts.ctx)
+	ts.Require().NoError(err)
+	ts.False(dirty)
+	ts.Equal(latest, version)
+}
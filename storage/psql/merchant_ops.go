@@ -0,0 +1,187 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx"
+)
+
+const (
+	scopesSeparator = ","
+	apiKeyColumns   = `id, merchant_id, prefix, key_hash, scopes, created_at, last_used_at, revoked_at`
+)
+
+// CreateMerchant implements Storage interface.
+func (p Psql) CreateMerchant(ctx context.Context, merchant *model.Merchant) error {
+	_, err := p.db.ExecContext(ctx, `INSERT INTO merchants (id, name, created_at) VALUES ($1, $2, $3);`,
+		merchant.ID, merchant.Name, merchant.CreatedAt)
+	if err != nil {
+		var pgErr pgx.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return storage.ErrMerchantAlreadyExists
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// Merchants implements Storage interface.
+func (p Psql) Merchants(ctx context.Context) ([]model.Merchant, error) {
+	rows, err := p.db.QueryContext(ctx, `SELECT id, name, created_at FROM merchants ORDER BY created_at ASC;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	merchants := make([]model.Merchant, 0)
+	for rows.Next() {
+		var m model.Merchant
+		if err := rows.Scan(&m.ID, &m.Name, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		merchants = append(merchants, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return merchants, nil
+}
+
+// MerchantByID implements Storage interface.
+func (p Psql) MerchantByID(ctx context.Context, id uuid.UUID) (*model.Merchant, error) {
+	m := &model.Merchant{}
+	err := p.db.QueryRowContext(ctx, `SELECT id, name, created_at FROM merchants WHERE id=$1;`, id).
+		Scan(&m.ID, &m.Name, &m.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// CreateAPIKey implements Storage interface.
+func (p Psql) CreateAPIKey(ctx context.Context, key *model.APIKey) error {
+	_, err := p.db.ExecContext(ctx, `INSERT INTO api_keys (id, merchant_id, prefix, key_hash, scopes, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6);`,
+		key.ID, key.MerchantID, key.Prefix, key.KeyHash, joinScopes(key.Scopes), key.CreatedAt)
+
+	return err
+}
+
+// MerchantAPIKeys implements Storage interface.
+func (p Psql) MerchantAPIKeys(ctx context.Context, merchantID uuid.UUID) ([]model.APIKey, error) {
+	rows, err := p.db.QueryContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys
+	WHERE merchant_id=$1 ORDER BY created_at ASC;`, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]model.APIKey, 0)
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// APIKeyByHash implements Storage interface.
+func (p Psql) APIKeyByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
+	row := p.db.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash=$1;`, keyHash)
+
+	return scanAPIKey(row)
+}
+
+// TouchAPIKey implements Storage interface.
+func (p Psql) TouchAPIKey(ctx context.Context, id uuid.UUID, lastUsedAt time.Time) error {
+	_, err := p.db.ExecContext(ctx, `UPDATE api_keys SET last_used_at=$1 WHERE id=$2;`, lastUsedAt, id)
+
+	return err
+}
+
+// RevokeAPIKey implements Storage interface.
+func (p Psql) RevokeAPIKey(ctx context.Context, merchantID, id uuid.UUID, now time.Time) error {
+	res, err := p.db.ExecContext(ctx, `UPDATE api_keys SET revoked_at=$1
+	WHERE id=$2 AND merchant_id=$3 AND revoked_at IS NULL;`, now, id, merchantID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// scanAPIKey scans a row selected with apiKeyColumns.
+func scanAPIKey(row interface{ Scan(...interface{}) error }) (*model.APIKey, error) {
+	k := &model.APIKey{}
+	var (
+		scopes              string
+		lastUsedAt, revoked sql.NullTime
+	)
+	err := row.Scan(&k.ID, &k.MerchantID, &k.Prefix, &k.KeyHash, &scopes, &k.CreatedAt, &lastUsedAt, &revoked)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+
+		return nil, err
+	}
+	k.Scopes = splitScopes(scopes)
+	if lastUsedAt.Valid {
+		k.LastUsedAt = &lastUsedAt.Time
+	}
+	if revoked.Valid {
+		k.RevokedAt = &revoked.Time
+	}
+
+	return k, nil
+}
+
+func joinScopes(scopes []model.Scope) string {
+	s := make([]string, len(scopes))
+	for i, scope := range scopes {
+		s[i] = string(scope)
+	}
+
+	return strings.Join(s, scopesSeparator)
+}
+
+func splitScopes(s string) []model.Scope {
+	scopes := make([]model.Scope, 0)
+	for _, scope := range strings.Split(s, scopesSeparator) {
+		if scope != "" {
+			scopes = append(scopes, model.Scope(scope))
+		}
+	}
+
+	return scopes
+}
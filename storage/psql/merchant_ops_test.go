@@ -0,0 +1,60 @@
+package psql
+
+import (
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+func (ts *TestSuite) TestMerchants() {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	merchant := &model.Merchant{ID: uuid.New(), Name: "Gopher Store", CreatedAt: now}
+	key := &model.APIKey{
+		ID:         uuid.New(),
+		MerchantID: merchant.ID,
+		Prefix:     "gmk_abcdefgh",
+		KeyHash:    "key hash",
+		Scopes:     []model.Scope{model.ScopeOrdersSubmit},
+		CreatedAt:  now,
+	}
+
+	ts.Run("#1 Create merchant", func() {
+		ts.Require().NoError(ts.storage.CreateMerchant(ts.ctx, merchant))
+		ts.ErrorIs(ts.storage.CreateMerchant(ts.ctx, &model.Merchant{ID: uuid.New(), Name: merchant.Name, CreatedAt: now}),
+			storage.ErrMerchantAlreadyExists)
+		merchants, err := ts.storage.Merchants(ts.ctx)
+		ts.Require().NoError(err)
+		ts.Len(merchants, 1)
+		m, err := ts.storage.MerchantByID(ts.ctx, merchant.ID)
+		ts.Require().NoError(err)
+		ts.Equal(merchant.Name, m.Name)
+	})
+	ts.Run("#2 Create API key", func() {
+		ts.Require().NoError(ts.storage.CreateAPIKey(ts.ctx, key))
+		k, err := ts.storage.APIKeyByHash(ts.ctx, key.KeyHash)
+		ts.Require().NoError(err)
+		ts.Equal(key.ID, k.ID)
+		ts.Equal(key.Scopes, k.Scopes)
+		ts.Nil(k.LastUsedAt)
+		ts.False(k.Revoked())
+	})
+	ts.Run("#3 Touch API key", func() {
+		ts.Require().NoError(ts.storage.TouchAPIKey(ts.ctx, key.ID, now))
+		keys, err := ts.storage.MerchantAPIKeys(ts.ctx, merchant.ID)
+		ts.Require().NoError(err)
+		ts.Require().Len(keys, 1)
+		ts.Require().NotNil(keys[0].LastUsedAt)
+		ts.True(now.Equal(*keys[0].LastUsedAt))
+	})
+	ts.Run("#4 Revoke API key", func() {
+		ts.ErrorIs(ts.storage.RevokeAPIKey(ts.ctx, uuid.New(), key.ID, now), storage.ErrNotFound)
+		ts.Require().NoError(ts.storage.RevokeAPIKey(ts.ctx, merchant.ID, key.ID, now))
+		ts.ErrorIs(ts.storage.RevokeAPIKey(ts.ctx, merchant.ID, key.ID, now), storage.ErrNotFound)
+		k, err := ts.storage.APIKeyByHash(ts.ctx, key.KeyHash)
+		ts.Require().NoError(err)
+		ts.True(k.Revoked())
+	})
+}
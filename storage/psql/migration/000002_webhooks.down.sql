@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS webhook_deliveries CASCADE;
+DROP TABLE IF EXISTS webhooks CASCADE;
+DROP TYPE IF EXISTS delivery_status;
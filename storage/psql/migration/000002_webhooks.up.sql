@@ -0,0 +1,30 @@
+CREATE TYPE "delivery_status" AS ENUM (
+  'PENDING',
+  'DELIVERED',
+  'FAILED'
+);
+
+CREATE TABLE "webhooks" (
+  "id" uuid UNIQUE PRIMARY KEY,
+  "url" text NOT NULL,
+  "secret" text NOT NULL,
+  "event_types" text NOT NULL,
+  "created_at" timestamp
+);
+
+CREATE TABLE "webhook_deliveries" (
+  "id" uuid UNIQUE PRIMARY KEY,
+  "webhook_id" uuid NOT NULL,
+  "event_type" text NOT NULL,
+  "payload" text NOT NULL,
+  "status" delivery_status NOT NULL,
+  "attempts" integer NOT NULL DEFAULT 0,
+  "last_status_code" integer NOT NULL DEFAULT 0,
+  "last_error" text NOT NULL DEFAULT '',
+  "next_attempt_at" timestamp NOT NULL,
+  "created_at" timestamp
+);
+
+CREATE INDEX ON "webhook_deliveries" ("status", "next_attempt_at");
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("webhook_id") REFERENCES "webhooks" ("id") ON DELETE CASCADE;
@@ -0,0 +1,10 @@
+CREATE TABLE "outbox" (
+  "id" bigserial PRIMARY KEY,
+  "event_type" text NOT NULL,
+  "aggregate_id" text NOT NULL,
+  "payload" text NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "published_at" timestamp
+);
+
+CREATE INDEX ON "outbox" ("id") WHERE "published_at" IS NULL;
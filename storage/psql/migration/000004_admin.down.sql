@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS adjustments_log CASCADE;
+ALTER TABLE "users" DROP COLUMN IF EXISTS "blocked";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "role";
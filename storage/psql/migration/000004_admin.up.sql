@@ -0,0 +1,15 @@
+ALTER TABLE "users" ADD COLUMN "role" text NOT NULL DEFAULT 'user';
+ALTER TABLE "users" ADD COLUMN "blocked" boolean NOT NULL DEFAULT false;
+
+CREATE TABLE "adjustments_log" (
+  "id" uuid UNIQUE PRIMARY KEY,
+  "user_id" uuid NOT NULL,
+  "admin_id" uuid NOT NULL,
+  "sum" decimal NOT NULL,
+  "reason" text NOT NULL,
+  "created_at" timestamp
+);
+
+ALTER TABLE "adjustments_log" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "adjustments_log" ADD FOREIGN KEY ("admin_id") REFERENCES "users" ("id");
@@ -0,0 +1,2 @@
+UPDATE "users" SET "role" = 'user' WHERE "role" = 'support';
+ALTER TABLE "users" DROP COLUMN IF EXISTS "permissions";
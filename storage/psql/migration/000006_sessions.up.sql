@@ -0,0 +1,20 @@
+CREATE TABLE "sessions" (
+  "id" uuid UNIQUE PRIMARY KEY,
+  "user_id" uuid NOT NULL,
+  "token" text UNIQUE NOT NULL,
+  "user_agent" text NOT NULL DEFAULT '',
+  "ip" text NOT NULL DEFAULT '',
+  "created_at" timestamp NOT NULL,
+  "last_seen_at" timestamp NOT NULL,
+  "expires_at" timestamp NOT NULL
+);
+
+ALTER TABLE "sessions" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "sessions" ("user_id");
+
+-- Keep users signed in: existing remember tokens become sessions.
+INSERT INTO "sessions" ("id", "user_id", "token", "created_at", "last_seen_at", "expires_at")
+SELECT md5(random()::text || "id"::text)::uuid, "id", "remember_token",
+  now(), now(), now() + interval '30 days'
+FROM "users" WHERE "remember_token" <> '';
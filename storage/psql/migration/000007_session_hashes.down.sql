@@ -0,0 +1,6 @@
+-- The hashes can't be converted back to the tokens: all sessions are ended.
+DELETE FROM "sessions";
+ALTER TABLE "sessions" DROP COLUMN IF EXISTS "hash_version";
+ALTER TABLE "sessions" RENAME COLUMN "token_hash" TO "token";
+
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "remember_token" VARCHAR(50) NOT NULL DEFAULT '';
@@ -0,0 +1,8 @@
+-- Only hashes of the session tokens are stored. Existing tokens are replaced with their SHA-256
+-- (version 1). They are upgraded to HMAC (version 2) by the service at startup, because the key is
+-- known to the service only.
+UPDATE "sessions" SET "token" = encode(sha256("token"::bytea), 'hex');
+ALTER TABLE "sessions" RENAME COLUMN "token" TO "token_hash";
+ALTER TABLE "sessions" ADD COLUMN "hash_version" smallint NOT NULL DEFAULT 1;
+
+ALTER TABLE "users" DROP COLUMN "remember_token";
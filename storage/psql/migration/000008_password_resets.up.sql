@@ -0,0 +1,10 @@
+CREATE TABLE "password_resets" (
+  "id" uuid UNIQUE PRIMARY KEY,
+  "user_id" uuid NOT NULL,
+  "token_hash" text UNIQUE NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "expires_at" timestamp NOT NULL,
+  "used_at" timestamp
+);
+
+ALTER TABLE "password_resets" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
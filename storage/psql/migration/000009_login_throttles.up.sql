@@ -0,0 +1,6 @@
+CREATE TABLE "login_throttles" (
+  "key" text PRIMARY KEY,
+  "failures" int NOT NULL,
+  "last_failure_at" timestamp NOT NULL,
+  "locked_until" timestamp
+);
@@ -0,0 +1,8 @@
+CREATE TABLE "rate_limits" (
+  "key" text PRIMARY KEY,
+  "tokens" double precision NOT NULL,
+  "updated_at" timestamp NOT NULL,
+  "full_at" timestamp NOT NULL
+);
+
+CREATE INDEX ON "rate_limits" ("full_at");
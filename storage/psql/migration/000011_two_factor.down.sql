@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS login_challenges CASCADE;
+DROP TABLE IF EXISTS recovery_codes CASCADE;
+DROP TABLE IF EXISTS two_factor CASCADE;
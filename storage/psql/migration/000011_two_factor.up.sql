@@ -0,0 +1,25 @@
+CREATE TABLE "two_factor" (
+  "user_id" uuid PRIMARY KEY,
+  "secret" text NOT NULL,
+  "enabled_at" timestamp,
+  "last_used_step" bigint NOT NULL DEFAULT 0
+);
+
+CREATE TABLE "recovery_codes" (
+  "user_id" uuid NOT NULL,
+  "code_hash" text NOT NULL,
+  "used_at" timestamp,
+  PRIMARY KEY ("user_id", "code_hash")
+);
+
+CREATE TABLE "login_challenges" (
+  "token_hash" text PRIMARY KEY,
+  "user_id" uuid NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "expires_at" timestamp NOT NULL,
+  "attempts" int NOT NULL DEFAULT 0
+);
+
+ALTER TABLE "two_factor" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "recovery_codes" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "login_challenges" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
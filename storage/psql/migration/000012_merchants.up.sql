@@ -0,0 +1,18 @@
+CREATE TABLE "merchants" (
+  "id" uuid UNIQUE PRIMARY KEY,
+  "name" text UNIQUE NOT NULL,
+  "created_at" timestamp NOT NULL
+);
+
+CREATE TABLE "api_keys" (
+  "id" uuid UNIQUE PRIMARY KEY,
+  "merchant_id" uuid NOT NULL,
+  "prefix" text NOT NULL,
+  "key_hash" text UNIQUE NOT NULL,
+  "scopes" text NOT NULL DEFAULT '',
+  "created_at" timestamp NOT NULL,
+  "last_used_at" timestamp,
+  "revoked_at" timestamp
+);
+
+ALTER TABLE "api_keys" ADD FOREIGN KEY ("merchant_id") REFERENCES "merchants" ("id") ON DELETE CASCADE;
@@ -0,0 +1,2 @@
+-- Deleted accounts are replaced by anonymous tombstones keeping the financial logs.
+ALTER TABLE "users" ADD COLUMN "deleted_at" timestamp;
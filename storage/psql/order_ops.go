@@ -15,9 +15,16 @@ import (
 
 // CreateOrder implements Storage interface.
 func (p Psql) CreateOrder(ctx context.Context, order *model.Order) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	//nolint:errcheck
+	defer tx.Rollback()
+
 	const query = `INSERT INTO orders (id, user_id, status, accrual_points, uploaded_at)
 	VALUES ($1, $2, $3, $4, $5);`
-	_, err := p.db.ExecContext(ctx, query,
+	_, err = tx.ExecContext(ctx, query,
 		order.ID, order.UserID, order.Status, order.AccrualPoints, order.UploadedAt)
 	if err != nil {
 		var pgErr pgx.PgError
@@ -27,8 +34,15 @@ func (p Psql) CreateOrder(ctx context.Context, order *model.Order) error {
 
 		return err
 	}
+	if err := insertEvent(ctx, tx, model.EventOrderCreated, order.ID.String(), model.OrderEvent{
+		Order:  order.ID,
+		UserID: order.UserID,
+		Status: order.Status,
+	}); err != nil {
+		return err
+	}
 
-	return nil
+	return tx.Commit()
 }
 
 // OrderByID implements Storage interface.
@@ -77,11 +91,20 @@ func (p Psql) OrdersByStatus(ctx context.Context, status model.Status) ([]model.
 
 // UpdateOrderStatus implements Storage interface.
 func (p Psql) UpdateOrderStatus(ctx context.Context, orderID model.OrderID, status model.Status) error {
-	// check if the order exists in db
-	row := p.db.QueryRowContext(ctx, `SELECT status	FROM orders WHERE id=$1;`, orderID)
-	s := ""
-	err := row.Scan(&s)
+	tx, err := p.db.BeginTx(ctx, nil)
 	if err != nil {
+		return err
+	}
+	//nolint:errcheck
+	defer tx.Rollback()
+
+	// check if the order exists in db
+	row := tx.QueryRowContext(ctx, `SELECT user_id, status FROM orders WHERE id=$1 FOR UPDATE;`, orderID)
+	var (
+		userID         uuid.UUID
+		previousStatus model.Status
+	)
+	if err := row.Scan(&userID, &previousStatus); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return storage.ErrNotFound
 		}
@@ -89,11 +112,19 @@ func (p Psql) UpdateOrderStatus(ctx context.Context, orderID model.OrderID, stat
 		return err
 	}
 	// update the status
-	if _, err := p.db.ExecContext(ctx, `UPDATE orders SET status=$1 WHERE id=$2;`, status, orderID); err != nil {
+	if _, err := tx.ExecContext(ctx, `UPDATE orders SET status=$1 WHERE id=$2;`, status, orderID); err != nil {
+		return err
+	}
+	if err := insertEvent(ctx, tx, model.EventOrderStatusChanged, orderID.String(), model.OrderEvent{
+		Order:          orderID,
+		UserID:         userID,
+		Status:         status,
+		PreviousStatus: previousStatus,
+	}); err != nil {
 		return err
 	}
 
-	return nil
+	return tx.Commit()
 }
 
 // UserOrders implements Storage interface.
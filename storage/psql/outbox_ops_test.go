@@ -0,0 +1,78 @@
+package psql
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+)
+
+func (ts *TestSuite) TestOutbox() {
+	// Publish everything written by the other tests.
+	for {
+		events, err := ts.storage.ClaimUnpublishedEvents(ts.ctx, time.Now(), time.Now(), 100)
+		ts.Require().NoError(err)
+		if len(events) == 0 {
+			break
+		}
+		ids := make([]int64, len(events))
+		for i, e := range events {
+			ids[i] = e.ID
+		}
+		ts.Require().NoError(ts.storage.MarkEventsPublished(ts.ctx, ids, time.Now()))
+	}
+
+	ts.Run("#1 CreateOrder and UpdateOrderStatus write events", func() {
+		ts.Require().NoError(ts.storage.CreateOrder(ts.ctx, &model.Order{
+			ID:         "5512",
+			UserID:     ts.alice.user.ID,
+			Status:     model.StatusNew,
+			UploadedAt: time.Now(),
+		}))
+		ts.Require().NoError(ts.storage.UpdateOrderStatus(ts.ctx, "5512", model.StatusProcessing))
+
+		events, err := ts.storage.ClaimUnpublishedEvents(ts.ctx, time.Now(), time.Now(), 100)
+		ts.Require().NoError(err)
+		ts.Require().Len(events, 2)
+		ts.Assert().Equal(model.EventOrderCreated, events[0].Type)
+		ts.Assert().Equal(model.EventOrderStatusChanged, events[1].Type)
+		ts.Assert().Less(events[0].ID, events[1].ID)
+
+		var payload model.OrderEvent
+		ts.Require().NoError(json.Unmarshal(events[1].Payload, &payload))
+		ts.Assert().Equal(model.OrderID("5512"), payload.Order)
+		ts.Assert().Equal(ts.alice.user.ID, payload.UserID)
+		ts.Assert().Equal(model.StatusProcessing, payload.Status)
+		ts.Assert().Equal(model.StatusNew, payload.PreviousStatus)
+
+		ts.Require().NoError(ts.storage.MarkEventsPublished(ts.ctx, []int64{events[0].ID, events[1].ID}, time.Now()))
+	})
+	ts.Run("#2 Failed operation doesn't write events", func() {
+		ts.Require().Error(ts.storage.CreateOrder(ts.ctx, &model.Order{
+			ID:         "5512",
+			UserID:     ts.alice.user.ID,
+			Status:     model.StatusNew,
+			UploadedAt: time.Now(),
+		}))
+		events, err := ts.storage.ClaimUnpublishedEvents(ts.ctx, time.Now(), time.Now(), 100)
+		ts.Require().NoError(err)
+		ts.Assert().Empty(events)
+	})
+	ts.Run("#3 Claimed events are skipped until the claim expires", func() {
+		ts.Require().NoError(ts.storage.UpdateOrderStatus(ts.ctx, "5512", model.StatusInvalid))
+		now := time.Now()
+		events, err := ts.storage.ClaimUnpublishedEvents(ts.ctx, now, now.Add(time.Minute), 100)
+		ts.Require().NoError(err)
+		ts.Require().Len(events, 1)
+
+		claimed, err := ts.storage.ClaimUnpublishedEvents(ts.ctx, now, now.Add(time.Minute), 100)
+		ts.Require().NoError(err)
+		ts.Assert().Empty(claimed)
+
+		claimed, err = ts.storage.ClaimUnpublishedEvents(ts.ctx, now.Add(time.Minute), now.Add(2*time.Minute), 100)
+		ts.Require().NoError(err)
+		ts.Require().Len(claimed, 1)
+		ts.Assert().Equal(events[0].ID, claimed[0].ID)
+		ts.Require().NoError(ts.storage.MarkEventsPublished(ts.ctx, []int64{claimed[0].ID}, time.Now()))
+	})
+}
@@ -0,0 +1,154 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+// ChangePassword implements Storage interface.
+func (p Psql) ChangePassword(ctx context.Context, userID uuid.UUID, passwordHash string, keepSessionID uuid.UUID) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	//nolint:errcheck
+	defer tx.Rollback()
+
+	if err := setPasswordHash(ctx, tx, userID, passwordHash); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM sessions WHERE user_id=$1 AND id<>$2;`,
+		userID, keepSessionID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdatePasswordHash implements Storage interface.
+func (p Psql) UpdatePasswordHash(ctx context.Context, userID uuid.UUID, oldHash, newHash string) error {
+	res, err := p.db.ExecContext(ctx, `UPDATE users SET password_hash=$1 WHERE id=$2 AND password_hash=$3;`,
+		newHash, userID, oldHash)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// CreatePasswordReset implements Storage interface.
+func (p Psql) CreatePasswordReset(ctx context.Context, reset *model.PasswordReset) error {
+	_, err := p.db.ExecContext(ctx, `INSERT INTO password_resets (id, user_id, token_hash, created_at, expires_at)
+	VALUES ($1, $2, $3, $4, $5);`,
+		reset.ID, reset.UserID, reset.TokenHash, reset.CreatedAt, reset.ExpiresAt)
+
+	return err
+}
+
+// UserPasswordResets implements Storage interface.
+func (p Psql) UserPasswordResets(ctx context.Context, userID uuid.UUID) ([]model.PasswordReset, error) {
+	rows, err := p.db.QueryContext(ctx, `SELECT id, user_id, token_hash, created_at, expires_at, used_at
+	FROM password_resets WHERE user_id=$1 ORDER BY created_at ASC;`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	resets := make([]model.PasswordReset, 0)
+	for rows.Next() {
+		var (
+			r      model.PasswordReset
+			usedAt sql.NullTime
+		)
+		if err := rows.Scan(&r.ID, &r.UserID, &r.TokenHash, &r.CreatedAt, &r.ExpiresAt, &usedAt); err != nil {
+			return nil, err
+		}
+		if usedAt.Valid {
+			r.UsedAt = &usedAt.Time
+		}
+		resets = append(resets, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return resets, nil
+}
+
+// PasswordResetUser implements Storage interface.
+func (p Psql) PasswordResetUser(ctx context.Context, tokenHash string, now time.Time) (*model.User, error) {
+	row := p.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users
+	WHERE id=(SELECT user_id FROM password_resets WHERE token_hash=$1 AND used_at IS NULL AND expires_at>$2);`,
+		tokenHash, now)
+
+	return scanUser(row)
+}
+
+// ResetPassword implements Storage interface.
+func (p Psql) ResetPassword(ctx context.Context, tokenHash, passwordHash string, now time.Time) (uuid.UUID, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	//nolint:errcheck
+	defer tx.Rollback()
+
+	// The reset is marked as used atomically, so concurrent requests with the same token can't both succeed.
+	var userID uuid.UUID
+	err = tx.QueryRowContext(ctx, `UPDATE password_resets SET used_at=$1
+	WHERE token_hash=$2 AND used_at IS NULL AND expires_at>$1
+	RETURNING user_id;`, now, tokenHash).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, storage.ErrNotFound
+		}
+
+		return uuid.Nil, err
+	}
+	if err := setPasswordHash(ctx, tx, userID, passwordHash); err != nil {
+		return uuid.Nil, err
+	}
+	// All the sessions are ended and other reset tokens are invalidated.
+	if _, err := tx.ExecContext(ctx, `DELETE FROM sessions WHERE user_id=$1;`, userID); err != nil {
+		return uuid.Nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE password_resets SET used_at=$1
+	WHERE user_id=$2 AND used_at IS NULL;`, now, userID); err != nil {
+		return uuid.Nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return uuid.Nil, err
+	}
+
+	return userID, nil
+}
+
+func setPasswordHash(ctx context.Context, tx *sql.Tx, userID uuid.UUID, passwordHash string) error {
+	res, err := tx.ExecContext(ctx, `UPDATE users SET password_hash=$1 WHERE id=$2;`, passwordHash, userID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package psql
+
+import (
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+func (ts *TestSuite) TestUpdatePasswordHash() {
+	gandalf := model.User{
+		ID:           uuid.New(),
+		Login:        "gandalf@the.grey",
+		PasswordHash: "bcrypt hash",
+		CreatedAt:    time.Now(),
+	}
+	ts.Require().NoError(ts.storage.CreateUser(ts.ctx, gandalf))
+
+	ts.Run("#1 Upgrade the hash", func() {
+		ts.Require().NoError(ts.storage.UpdatePasswordHash(ts.ctx, gandalf.ID, "bcrypt hash", "argon2id hash"))
+		u, err := ts.storage.UserByID(ts.ctx, gandalf.ID)
+		ts.Require().NoError(err)
+		ts.Assert().Equal("argon2id hash", u.PasswordHash)
+	})
+	ts.Run("#2 The hash was changed concurrently", func() {
+		err := ts.storage.UpdatePasswordHash(ts.ctx, gandalf.ID, "bcrypt hash", "another hash")
+		ts.Assert().ErrorIs(err, storage.ErrNotFound)
+		u, err := ts.storage.UserByID(ts.ctx, gandalf.ID)
+		ts.Require().NoError(err)
+		ts.Assert().Equal("argon2id hash", u.PasswordHash)
+	})
+}
+
+func (ts *TestSuite) TestPasswordReset() {
+	harry := &model.User{
+		ID:           uuid.New(),
+		Login:        "harryhoudini@escape.com",
+		PasswordHash: "old hash",
+		CreatedAt:    time.Now(),
+	}
+	ts.Require().NoError(ts.storage.CreateUser(ts.ctx, *harry))
+	now := time.Now()
+	for i, hash := range []string{"laptop", "phone"} {
+		ts.Require().NoError(ts.storage.CreateSession(ts.ctx, &model.Session{
+			ID:         uuid.New(),
+			UserID:     harry.ID,
+			TokenHash:  "harry's " + hash,
+			CreatedAt:  now,
+			LastSeenAt: now.Add(time.Duration(i) * time.Second),
+			ExpiresAt:  now.Add(time.Hour),
+		}))
+	}
+	sessions, err := ts.storage.UserSessions(ts.ctx, harry.ID)
+	ts.Require().NoError(err)
+	ts.Require().Len(sessions, 2)
+
+	ts.Run("#1 Change password keeps the current session only", func() {
+		ts.Require().NoError(ts.storage.ChangePassword(ts.ctx, harry.ID, "new hash", sessions[0].ID))
+		u, err := ts.storage.UserByID(ts.ctx, harry.ID)
+		ts.Require().NoError(err)
+		ts.Assert().Equal("new hash", u.PasswordHash)
+		left, err := ts.storage.UserSessions(ts.ctx, harry.ID)
+		ts.Require().NoError(err)
+		ts.Require().Len(left, 1)
+		ts.Assert().Equal(sessions[0].ID, left[0].ID)
+	})
+	for _, r := range []*model.PasswordReset{
+		{ID: uuid.New(), UserID: harry.ID, TokenHash: "expired", CreatedAt: now, ExpiresAt: now.Add(-time.Second)},
+		{ID: uuid.New(), UserID: harry.ID, TokenHash: "valid", CreatedAt: now, ExpiresAt: now.Add(time.Hour)},
+		{ID: uuid.New(), UserID: harry.ID, TokenHash: "another valid", CreatedAt: now, ExpiresAt: now.Add(time.Hour)},
+	} {
+		ts.Require().NoError(ts.storage.CreatePasswordReset(ts.ctx, r))
+	}
+	ts.Run("#2 Expired token", func() {
+		_, err := ts.storage.PasswordResetUser(ts.ctx, "expired", now)
+		ts.Assert().ErrorIs(err, storage.ErrNotFound)
+		_, err = ts.storage.ResetPassword(ts.ctx, "expired", "reset hash", now)
+		ts.Assert().ErrorIs(err, storage.ErrNotFound)
+	})
+	ts.Run("#3 Reset password", func() {
+		u, err := ts.storage.PasswordResetUser(ts.ctx, "valid", now)
+		ts.Require().NoError(err)
+		ts.Assert().Equal(harry.Login, u.Login)
+		userID, err := ts.storage.ResetPassword(ts.ctx, "valid", "reset hash", now)
+		ts.Require().NoError(err)
+		ts.Assert().Equal(harry.ID, userID)
+		u, err = ts.storage.UserByID(ts.ctx, harry.ID)
+		ts.Require().NoError(err)
+		ts.Assert().Equal("reset hash", u.PasswordHash)
+		left, err := ts.storage.UserSessions(ts.ctx, harry.ID)
+		ts.Require().NoError(err)
+		ts.Assert().Empty(left)
+	})
+	ts.Run("#4 Tokens are single-use", func() {
+		_, err := ts.storage.ResetPassword(ts.ctx, "valid", "another hash", now)
+		ts.Assert().ErrorIs(err, storage.ErrNotFound)
+		_, err = ts.storage.PasswordResetUser(ts.ctx, "another valid", now)
+		ts.Assert().ErrorIs(err, storage.ErrNotFound, "other tokens must be invalidated")
+		_, err = ts.storage.ResetPassword(ts.ctx, "another valid", "another hash", now)
+		ts.Assert().ErrorIs(err, storage.ErrNotFound, "other tokens must be invalidated")
+	})
+}
@@ -80,6 +80,11 @@ func New(opts ...Option) (*Psql, error) {
 	return p, nil
 }
 
+// Stats returns the stats of the database connection pool.
+func (p Psql) Stats() sql.DBStats {
+	return p.db.Stats()
+}
+
 func (p Psql) Close() error {
 	if p.db == nil {
 		return nil
@@ -0,0 +1,53 @@
+package psql
+
+import (
+	"context"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+)
+
+// TakeRateLimitToken implements Storage interface.
+func (p Psql) TakeRateLimitToken(ctx context.Context, key string, limit model.RateLimit, now time.Time) (model.RateLimitResult, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return model.RateLimitResult{}, err
+	}
+	//nolint:errcheck
+	defer tx.Rollback()
+
+	// Create a full bucket if the key has none, so that the row can be locked.
+	if _, err := tx.ExecContext(ctx, `INSERT INTO rate_limits (key, tokens, updated_at, full_at)
+	VALUES ($1, $2, $3, $3) ON CONFLICT (key) DO NOTHING;`, key, float64(limit.Size()), now); err != nil {
+		return model.RateLimitResult{}, err
+	}
+	var b model.RateLimitBucket
+	if err := tx.QueryRowContext(ctx, `SELECT tokens, updated_at FROM rate_limits WHERE key=$1 FOR UPDATE;`, key).
+		Scan(&b.Tokens, &b.UpdatedAt); err != nil {
+		return model.RateLimitResult{}, err
+	}
+	b, res := limit.Take(b, now)
+	if _, err := tx.ExecContext(ctx, `UPDATE rate_limits SET tokens=$1, updated_at=$2, full_at=$3 WHERE key=$4;`,
+		b.Tokens, b.UpdatedAt, now.Add(res.Reset), key); err != nil {
+		return model.RateLimitResult{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return model.RateLimitResult{}, err
+	}
+
+	return res, nil
+}
+
+// DeleteFullRateLimits implements Storage interface.
+func (p Psql) DeleteFullRateLimits(ctx context.Context, now time.Time) (int, error) {
+	res, err := p.db.ExecContext(ctx, `DELETE FROM rate_limits WHERE full_at <= $1;`, now)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
+}
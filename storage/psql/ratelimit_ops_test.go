@@ -0,0 +1,39 @@
+package psql
+
+import (
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+)
+
+func (ts *TestSuite) TestRateLimits() {
+	const key = "post /api/user/orders|ip:10.0.0.1"
+	limit := model.RateLimit{Requests: 2, Per: time.Minute}
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	ts.Run("#1 Take the tokens", func() {
+		for i := 1; i >= 0; i-- {
+			res, err := ts.storage.TakeRateLimitToken(ts.ctx, key, limit, now)
+			ts.Require().NoError(err)
+			ts.True(res.Allowed)
+			ts.Equal(i, res.Remaining)
+		}
+		res, err := ts.storage.TakeRateLimitToken(ts.ctx, key, limit, now)
+		ts.Require().NoError(err)
+		ts.False(res.Allowed)
+		ts.Equal(30*time.Second, res.RetryAfter)
+	})
+	ts.Run("#2 The bucket is refilled", func() {
+		res, err := ts.storage.TakeRateLimitToken(ts.ctx, key, limit, now.Add(30*time.Second))
+		ts.Require().NoError(err)
+		ts.True(res.Allowed)
+	})
+	ts.Run("#3 Delete full buckets", func() {
+		n, err := ts.storage.DeleteFullRateLimits(ts.ctx, now.Add(time.Minute))
+		ts.Require().NoError(err)
+		ts.Equal(0, n)
+		n, err = ts.storage.DeleteFullRateLimits(ts.ctx, now.Add(2*time.Minute))
+		ts.Require().NoError(err)
+		ts.Equal(1, n)
+	})
+}
@@ -0,0 +1,169 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+const sessionColumns = `id, user_id, token_hash, user_agent, ip, created_at, last_seen_at, expires_at`
+
+// Versions of the session token hashes.
+const (
+	hashVersionDigest = 1 // SHA-256 of the token
+	hashVersionHMAC   = 2 // HMAC of the token's SHA-256 keyed by the server secret
+)
+
+// CreateSession implements Storage interface.
+func (p Psql) CreateSession(ctx context.Context, session *model.Session) error {
+	_, err := p.db.ExecContext(ctx, `INSERT INTO sessions (`+sessionColumns+`, hash_version)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);`,
+		session.ID, session.UserID, session.TokenHash, session.UserAgent, session.IP,
+		session.CreatedAt, session.LastSeenAt, session.ExpiresAt, hashVersionHMAC)
+
+	return err
+}
+
+// SessionByTokenHash implements Storage interface.
+func (p Psql) SessionByTokenHash(ctx context.Context, tokenHash string) (*model.Session, error) {
+	row := p.db.QueryRowContext(ctx, `SELECT `+sessionColumns+`
+	FROM sessions WHERE token_hash=$1 AND hash_version=$2;`, tokenHash, hashVersionHMAC)
+
+	return scanSession(row)
+}
+
+// SessionByID implements Storage interface.
+func (p Psql) SessionByID(ctx context.Context, id uuid.UUID) (*model.Session, error) {
+	row := p.db.QueryRowContext(ctx, `SELECT `+sessionColumns+`
+	FROM sessions WHERE id=$1;`, id)
+
+	return scanSession(row)
+}
+
+// TouchSession implements Storage interface.
+func (p Psql) TouchSession(ctx context.Context, id uuid.UUID, lastSeenAt time.Time) error {
+	_, err := p.db.ExecContext(ctx, `UPDATE sessions SET last_seen_at=$1 WHERE id=$2;`, lastSeenAt, id)
+
+	return err
+}
+
+// UserSessions implements Storage interface.
+func (p Psql) UserSessions(ctx context.Context, userID uuid.UUID) ([]model.Session, error) {
+	rows, err := p.db.QueryContext(ctx, `SELECT `+sessionColumns+`
+	FROM sessions WHERE user_id=$1 ORDER BY last_seen_at DESC;`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := make([]model.Session, 0)
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// DeleteSession implements Storage interface.
+func (p Psql) DeleteSession(ctx context.Context, userID, id uuid.UUID) error {
+	res, err := p.db.ExecContext(ctx, `DELETE FROM sessions WHERE id=$1 AND user_id=$2;`, id, userID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteExpiredSessions implements Storage interface.
+func (p Psql) DeleteExpiredSessions(ctx context.Context, now time.Time) (int, error) {
+	res, err := p.db.ExecContext(ctx, `DELETE FROM sessions WHERE expires_at <= $1;`, now)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
+}
+
+// UpgradeSessionHashes implements Storage interface.
+func (p Psql) UpgradeSessionHashes(ctx context.Context, upgrade func(digest string) string) (int, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	//nolint:errcheck
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, token_hash FROM sessions WHERE hash_version=$1 FOR UPDATE;`,
+		hashVersionDigest)
+	if err != nil {
+		return 0, err
+	}
+	digests := make(map[uuid.UUID]string)
+	for rows.Next() {
+		var (
+			id     uuid.UUID
+			digest string
+		)
+		if err := rows.Scan(&id, &digest); err != nil {
+			rows.Close()
+
+			return 0, err
+		}
+		digests[id] = digest
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for id, digest := range digests {
+		if _, err := tx.ExecContext(ctx, `UPDATE sessions SET token_hash=$1, hash_version=$2 WHERE id=$3;`,
+			upgrade(digest), hashVersionHMAC, id); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return len(digests), nil
+}
+
+// scanSession scans a row selected with sessionColumns.
+func scanSession(row interface{ Scan(...interface{}) error }) (*model.Session, error) {
+	s := &model.Session{}
+	err := row.Scan(&s.ID, &s.UserID, &s.TokenHash, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	return s, nil
+}
@@ -0,0 +1,108 @@
+package psql
+
+import (
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+func (ts *TestSuite) TestSessions() {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	laptop := &model.Session{
+		ID:         uuid.New(),
+		UserID:     ts.alice.user.ID,
+		TokenHash:  "laptop token hash",
+		UserAgent:  "Firefox",
+		IP:         "10.0.0.1",
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(time.Hour),
+	}
+	phone := &model.Session{
+		ID:         uuid.New(),
+		UserID:     ts.alice.user.ID,
+		TokenHash:  "phone token hash",
+		UserAgent:  "Safari",
+		IP:         "10.0.0.2",
+		CreatedAt:  now,
+		LastSeenAt: now.Add(time.Second),
+		ExpiresAt:  now.Add(-time.Second),
+	}
+
+	ts.Run("#1 Create sessions", func() {
+		ts.Require().NoError(ts.storage.CreateSession(ts.ctx, laptop))
+		ts.Require().NoError(ts.storage.CreateSession(ts.ctx, phone))
+	})
+	ts.Run("#2 Find the session by token hash", func() {
+		s, err := ts.storage.SessionByTokenHash(ts.ctx, laptop.TokenHash)
+		ts.Require().NoError(err)
+		ts.Assert().Equal(laptop.ID, s.ID)
+		ts.Assert().Equal(laptop.UserAgent, s.UserAgent)
+		ts.Assert().Equal(laptop.IP, s.IP)
+
+		s, err = ts.storage.SessionByID(ts.ctx, laptop.ID)
+		ts.Require().NoError(err)
+		ts.Assert().Equal(laptop.TokenHash, s.TokenHash)
+
+		_, err = ts.storage.SessionByTokenHash(ts.ctx, "unknown")
+		ts.Assert().ErrorIs(err, storage.ErrNotFound)
+	})
+	ts.Run("#3 Touch the session", func() {
+		ts.Require().NoError(ts.storage.TouchSession(ts.ctx, laptop.ID, now.Add(time.Minute)))
+		sessions, err := ts.storage.UserSessions(ts.ctx, ts.alice.user.ID)
+		ts.Require().NoError(err)
+		ts.Require().Len(sessions, 2)
+		ts.Assert().Equal(laptop.ID, sessions[0].ID, "the most recently seen session must be the first")
+	})
+	ts.Run("#4 Another user can't delete the session", func() {
+		ts.Assert().ErrorIs(ts.storage.DeleteSession(ts.ctx, ts.bob.user.ID, laptop.ID), storage.ErrNotFound)
+	})
+	ts.Run("#5 Delete expired sessions", func() {
+		n, err := ts.storage.DeleteExpiredSessions(ts.ctx, now)
+		ts.Require().NoError(err)
+		ts.Assert().Equal(1, n)
+		_, err = ts.storage.SessionByTokenHash(ts.ctx, phone.TokenHash)
+		ts.Assert().ErrorIs(err, storage.ErrNotFound)
+	})
+	ts.Run("#6 Delete the session", func() {
+		ts.Require().NoError(ts.storage.DeleteSession(ts.ctx, ts.alice.user.ID, laptop.ID))
+		sessions, err := ts.storage.UserSessions(ts.ctx, ts.alice.user.ID)
+		ts.Require().NoError(err)
+		ts.Assert().Empty(sessions)
+	})
+}
+
+func (ts *TestSuite) TestUpgradeSessionHashes() {
+	now := time.Now()
+	legacy := &model.Session{
+		ID:         uuid.New(),
+		UserID:     ts.bob.user.ID,
+		TokenHash:  "legacy digest",
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(time.Hour),
+	}
+	ts.Require().NoError(ts.storage.CreateSession(ts.ctx, legacy))
+	// Pretend the session was created before the hashes were keyed.
+	_, err := ts.storage.(*Psql).db.ExecContext(ts.ctx, `UPDATE sessions SET hash_version=$1 WHERE id=$2;`,
+		hashVersionDigest, legacy.ID)
+	ts.Require().NoError(err)
+
+	_, err = ts.storage.SessionByTokenHash(ts.ctx, legacy.TokenHash)
+	ts.Assert().ErrorIs(err, storage.ErrNotFound, "legacy digests must not be accepted")
+
+	n, err := ts.storage.UpgradeSessionHashes(ts.ctx, func(digest string) string { return "hmac of " + digest })
+	ts.Require().NoError(err)
+	ts.Assert().Equal(1, n)
+
+	s, err := ts.storage.SessionByTokenHash(ts.ctx, "hmac of legacy digest")
+	ts.Require().NoError(err)
+	ts.Assert().Equal(legacy.ID, s.ID)
+
+	n, err = ts.storage.UpgradeSessionHashes(ts.ctx, func(digest string) string { return "hmac of " + digest })
+	ts.Require().NoError(err)
+	ts.Assert().Zero(n)
+}
@@ -2,20 +2,24 @@ package psql
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/vanamelnik/gophermart/model"
-	"github.com/vanamelnik/gophermart/pkg/bcrypt"
 	"github.com/vanamelnik/gophermart/pkg/logging"
 	"github.com/vanamelnik/gophermart/storage"
+	"github.com/vanamelnik/gophermart/storage/storagetest"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 
 	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"golang.org/x/crypto/bcrypt"
 )
 
 const (
@@ -82,7 +86,7 @@ func (ts *TestSuite) SetupSuite() {
 func (ts *TestSuite) loadFixtures() {
 	for _, f := range []fixture{ts.alice, ts.bob} {
 		// Setup and load users
-		hash, err := bcrypt.BcryptPassword(f.user.Login, "")
+		hash, err := bcryptHash(f.user.Login, "")
 		ts.Require().NoError(err)
 		f.user.ID = uuid.New()
 		f.user.PasswordHash = hash
@@ -105,6 +109,36 @@ func TestPsql(t *testing.T) {
 	suite.Run(t, new(TestSuite))
 }
 
+// TestConformance runs the conformance tests against a schema of their own, so they neither see the suite's
+// fixtures nor leave their data behind.
+func TestConformance(t *testing.T) {
+	s := newSchemaStorage(t)
+	storagetest.Run(t, func(*testing.T) storage.Storage { return s })
+}
+
+// newSchemaStorage creates a new schema, applies the migrations to it and returns the storage using it.
+// The schema is dropped on cleanup.
+func newSchemaStorage(t *testing.T) *Psql {
+	t.Helper()
+	admin, err := New(WithDSN(defaultDSN))
+	require.NoError(t, err)
+	schema := "test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	_, err = admin.db.Exec(`CREATE SCHEMA ` + schema + `;`)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := admin.db.Exec(`DROP SCHEMA ` + schema + ` CASCADE;`)
+		assert.NoError(t, err)
+		assert.NoError(t, admin.Close())
+	})
+
+	s, err := New(WithDSN(defaultDSN+"&search_path="+schema),
+		WithAutoMigrate(logging.NewLogger(logging.WithConsoleOutput(true)), migrationsPath))
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, s.Close()) })
+
+	return s
+}
+
 func (ts *TestSuite) TearDownSuite() {
 	ts.Require().NoError(ts.storage.Close())
 
@@ -115,3 +149,10 @@ func (ts *TestSuite) TearDownSuite() {
 
 	ts.Require().NoError(m.Down())
 }
+
+// bcryptHash hashes the peppered password with bcrypt like the former implementation of the password hashing.
+func bcryptHash(password, pepper string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password+pepper), bcrypt.DefaultCost)
+
+	return string(hash), err
+}
@@ -0,0 +1,104 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+)
+
+const throttleColumns = `key, failures, last_failure_at, locked_until`
+
+// LoginThrottle implements Storage interface.
+func (p Psql) LoginThrottle(ctx context.Context, key string) (*model.LoginThrottle, error) {
+	row := p.db.QueryRowContext(ctx, `SELECT `+throttleColumns+` FROM login_throttles WHERE key=$1;`, key)
+
+	return scanThrottle(row)
+}
+
+// TakeLoginAttempt implements Storage interface. The failures are counted by the conditional upsert,
+// so the concurrent attempts checked against the same info can't be taken together.
+func (p Psql) TakeLoginAttempt(ctx context.Context, key string, seenFailures int, now time.Time,
+	window time.Duration) (*model.LoginThrottle, error) {
+	row := p.db.QueryRowContext(ctx, `INSERT INTO login_throttles (key, failures, last_failure_at)
+	VALUES ($1, 1, $2)
+	ON CONFLICT (key) DO UPDATE SET
+		failures = CASE WHEN login_throttles.last_failure_at < $3 THEN 1 ELSE login_throttles.failures + 1 END,
+		last_failure_at = $2
+	WHERE login_throttles.failures = $4
+	RETURNING `+throttleColumns+`;`, key, now, now.Add(-window), seenFailures)
+	t, err := scanThrottle(row)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, storage.ErrConflict
+	}
+
+	return t, err
+}
+
+// ForgiveLoginAttempt implements Storage interface.
+func (p Psql) ForgiveLoginAttempt(ctx context.Context, key string) error {
+	_, err := p.db.ExecContext(ctx, `UPDATE login_throttles SET failures = failures - 1
+	WHERE key=$1 AND failures > 0;`, key)
+
+	return err
+}
+
+// LockLogin implements Storage interface.
+func (p Psql) LockLogin(ctx context.Context, key string, until time.Time) error {
+	res, err := p.db.ExecContext(ctx, `UPDATE login_throttles SET locked_until=$1 WHERE key=$2;`, until, key)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteLoginThrottle implements Storage interface.
+func (p Psql) DeleteLoginThrottle(ctx context.Context, key string) error {
+	_, err := p.db.ExecContext(ctx, `DELETE FROM login_throttles WHERE key=$1;`, key)
+
+	return err
+}
+
+// DeleteStaleLoginThrottles implements Storage interface.
+func (p Psql) DeleteStaleLoginThrottles(ctx context.Context, before time.Time) (int, error) {
+	res, err := p.db.ExecContext(ctx, `DELETE FROM login_throttles
+	WHERE last_failure_at < $1 AND (locked_until IS NULL OR locked_until < $1);`, before)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
+}
+
+// scanThrottle scans a row selected with throttleColumns.
+func scanThrottle(row interface{ Scan(...interface{}) error }) (*model.LoginThrottle, error) {
+	t := &model.LoginThrottle{}
+	var lockedUntil sql.NullTime
+	if err := row.Scan(&t.Key, &t.Failures, &t.LastFailureAt, &lockedUntil); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+
+		return nil, err
+	}
+	if lockedUntil.Valid {
+		t.LockedUntil = &lockedUntil.Time
+	}
+
+	return t, nil
+}
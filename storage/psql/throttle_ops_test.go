@@ -0,0 +1,54 @@
+package psql
+
+import (
+	"time"
+
+	"github.com/vanamelnik/gophermart/storage"
+)
+
+func (ts *TestSuite) TestLoginThrottles() {
+	const key = "login:gollum@misty.mountains"
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	ts.Run("#1 No failures", func() {
+		_, err := ts.storage.LoginThrottle(ts.ctx, key)
+		ts.ErrorIs(err, storage.ErrNotFound)
+		ts.ErrorIs(ts.storage.LockLogin(ts.ctx, key, now), storage.ErrNotFound)
+	})
+	ts.Run("#2 Take attempts", func() {
+		for i := 1; i <= 3; i++ {
+			t, err := ts.storage.TakeLoginAttempt(ts.ctx, key, i-1, now, time.Minute)
+			ts.Require().NoError(err)
+			ts.Equal(i, t.Failures)
+			ts.Nil(t.LockedUntil)
+		}
+	})
+	ts.Run("#3 Lock", func() {
+		ts.Require().NoError(ts.storage.LockLogin(ts.ctx, key, now.Add(time.Minute)))
+		t, err := ts.storage.LoginThrottle(ts.ctx, key)
+		ts.Require().NoError(err)
+		ts.Equal(3, t.Failures)
+		ts.Require().NotNil(t.LockedUntil)
+		ts.True(now.Add(time.Minute).Equal(*t.LockedUntil))
+	})
+	ts.Run("#4 Old failures are forgotten", func() {
+		t, err := ts.storage.TakeLoginAttempt(ts.ctx, key, 3, now.Add(2*time.Minute), time.Minute)
+		ts.Require().NoError(err)
+		ts.Equal(1, t.Failures)
+	})
+	ts.Run("#5 Delete stale", func() {
+		n, err := ts.storage.DeleteStaleLoginThrottles(ts.ctx, now.Add(time.Minute))
+		ts.Require().NoError(err)
+		ts.Equal(0, n)
+		n, err = ts.storage.DeleteStaleLoginThrottles(ts.ctx, now.Add(3*time.Minute))
+		ts.Require().NoError(err)
+		ts.Equal(1, n)
+	})
+	ts.Run("#6 Delete", func() {
+		_, err := ts.storage.TakeLoginAttempt(ts.ctx, key, 0, now, time.Minute)
+		ts.Require().NoError(err)
+		ts.Require().NoError(ts.storage.DeleteLoginThrottle(ts.ctx, key))
+		_, err = ts.storage.LoginThrottle(ts.ctx, key)
+		ts.ErrorIs(err, storage.ErrNotFound)
+	})
+}
@@ -0,0 +1,80 @@
+package psql
+
+import (
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+func (ts *TestSuite) TestTwoFactor() {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	user := model.User{
+		ID:           uuid.New(),
+		Login:        "gandalf@valinor.me",
+		PasswordHash: "hash",
+		CreatedAt:    now,
+	}
+	ts.Require().NoError(ts.storage.CreateUser(ts.ctx, user))
+
+	ts.Run("#1 Enroll", func() {
+		_, err := ts.storage.TwoFactor(ts.ctx, user.ID)
+		ts.ErrorIs(err, storage.ErrNotFound)
+		ts.Require().NoError(ts.storage.SetPendingTwoFactor(ts.ctx, user.ID, "FIRSTSECRET"))
+		// The pending secret can be replaced.
+		ts.Require().NoError(ts.storage.SetPendingTwoFactor(ts.ctx, user.ID, "SECONDSECRET"))
+		tf, err := ts.storage.TwoFactor(ts.ctx, user.ID)
+		ts.Require().NoError(err)
+		ts.Equal("SECONDSECRET", tf.Secret)
+		ts.False(tf.Enabled())
+	})
+	ts.Run("#2 Enable", func() {
+		ts.Require().NoError(ts.storage.EnableTwoFactor(ts.ctx, user.ID, 100, now, []string{"code1", "code2"}))
+		ts.ErrorIs(ts.storage.EnableTwoFactor(ts.ctx, user.ID, 101, now, nil), storage.ErrNotFound)
+		ts.ErrorIs(ts.storage.SetPendingTwoFactor(ts.ctx, user.ID, "THIRDSECRET"), storage.ErrTwoFactorEnabled)
+		tf, err := ts.storage.TwoFactor(ts.ctx, user.ID)
+		ts.Require().NoError(err)
+		ts.True(tf.Enabled())
+		ts.Equal(int64(100), tf.LastUsedStep)
+	})
+	ts.Run("#3 TOTP steps are used once", func() {
+		ts.ErrorIs(ts.storage.UseTOTPStep(ts.ctx, user.ID, 100), storage.ErrNotFound)
+		ts.NoError(ts.storage.UseTOTPStep(ts.ctx, user.ID, 101))
+		ts.ErrorIs(ts.storage.UseTOTPStep(ts.ctx, user.ID, 101), storage.ErrNotFound)
+	})
+	ts.Run("#4 Recovery codes are used once", func() {
+		ts.NoError(ts.storage.UseRecoveryCode(ts.ctx, user.ID, "code1", now))
+		ts.ErrorIs(ts.storage.UseRecoveryCode(ts.ctx, user.ID, "code1", now), storage.ErrNotFound)
+		ts.ErrorIs(ts.storage.UseRecoveryCode(ts.ctx, user.ID, "unknown", now), storage.ErrNotFound)
+	})
+	ts.Run("#5 Login challenges", func() {
+		c := &model.LoginChallenge{
+			TokenHash: "challenge hash",
+			UserID:    user.ID,
+			CreatedAt: now,
+			ExpiresAt: now.Add(time.Minute),
+		}
+		ts.Require().NoError(ts.storage.CreateLoginChallenge(ts.ctx, c))
+		got, err := ts.storage.TakeChallengeAttempt(ts.ctx, c.TokenHash, 1, now)
+		ts.Require().NoError(err)
+		ts.Equal(user.ID, got.UserID)
+		ts.Equal(1, got.Attempts)
+		_, err = ts.storage.TakeChallengeAttempt(ts.ctx, c.TokenHash, 1, now)
+		ts.ErrorIs(err, storage.ErrNotFound)
+
+		n, err := ts.storage.DeleteExpiredLoginChallenges(ts.ctx, now)
+		ts.Require().NoError(err)
+		ts.Equal(0, n)
+		n, err = ts.storage.DeleteExpiredLoginChallenges(ts.ctx, now.Add(time.Minute))
+		ts.Require().NoError(err)
+		ts.Equal(1, n)
+	})
+	ts.Run("#6 Disable", func() {
+		ts.Require().NoError(ts.storage.DisableTwoFactor(ts.ctx, user.ID))
+		_, err := ts.storage.TwoFactor(ts.ctx, user.ID)
+		ts.ErrorIs(err, storage.ErrNotFound)
+		ts.ErrorIs(ts.storage.UseRecoveryCode(ts.ctx, user.ID, "code2", now), storage.ErrNotFound)
+	})
+}
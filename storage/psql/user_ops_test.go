@@ -4,7 +4,6 @@ import (
 	"time"
 
 	"github.com/vanamelnik/gophermart/model"
-	"github.com/vanamelnik/gophermart/pkg/bcrypt"
 	"github.com/vanamelnik/gophermart/storage"
 
 	"github.com/google/uuid"
@@ -29,7 +28,7 @@ func (ts *TestSuite) TestCreateUsers() {
 	}
 	for _, tc := range tt {
 		ts.Run(tc.name, func() {
-			hash, err := bcrypt.BcryptPassword(tc.login, "") // all our fake people use their login as a password!..
+			hash, err := bcryptHash(tc.login, "") // all our fake people use their login as a password!..
 			ts.Require().NoError(err)
 			u := &model.User{
 				ID:             uuid.New(),
@@ -73,3 +72,141 @@ func (ts *TestSuite) TestUserByLogin() {
 		})
 	}
 }
+
+func (ts *TestSuite) TestSearchUsers() {
+	tt := []struct {
+		name      string
+		login     string
+		wantLogin []string
+	}{
+		{
+			name:      "#1 Search Bob",
+			login:     "BOBMARLEY",
+			wantLogin: []string{"bobmarley@rambler.ru"},
+		},
+		{
+			name:      "#2 Wildcards are not allowed",
+			login:     "%",
+			wantLogin: []string{},
+		},
+		{
+			name:      "#3 Nobody found",
+			login:     "paulmccartney",
+			wantLogin: []string{},
+		},
+	}
+	for _, tc := range tt {
+		ts.Run(tc.name, func() {
+			users, err := ts.storage.SearchUsers(ts.ctx, tc.login, 10)
+			ts.Require().NoError(err)
+			logins := make([]string, 0)
+			for _, u := range users {
+				logins = append(logins, u.Login)
+			}
+			ts.Assert().Equal(tc.wantLogin, logins)
+		})
+	}
+}
+
+func (ts *TestSuite) TestSetUserBlocked() {
+	frank := &model.User{
+		ID:           uuid.New(),
+		Login:        "frankzappa@mothers.com",
+		PasswordHash: "ZxCvBnM",
+		CreatedAt:    time.Now(),
+	}
+	ts.Require().NoError(ts.storage.CreateUser(ts.ctx, *frank))
+
+	ts.Require().NoError(ts.storage.SetUserBlocked(ts.ctx, frank.ID, true))
+	u, err := ts.storage.UserByID(ts.ctx, frank.ID)
+	ts.Require().NoError(err)
+	ts.Assert().True(u.Blocked)
+	ts.Assert().Equal(model.RoleUser, u.Role)
+
+	ts.Require().NoError(ts.storage.SetUserBlocked(ts.ctx, frank.ID, false))
+	u, err = ts.storage.UserByLogin(ts.ctx, frank.Login)
+	ts.Require().NoError(err)
+	ts.Assert().False(u.Blocked)
+
+	ts.Assert().ErrorIs(ts.storage.SetUserBlocked(ts.ctx, uuid.New(), true), storage.ErrNotFound)
+}
+
+func (ts *TestSuite) TestSetUserRole() {
+	george := &model.User{
+		ID:           uuid.New(),
+		Login:        "georgeharrison@apple.com",
+		PasswordHash: "QwErTy",
+		CreatedAt:    time.Now(),
+	}
+	ts.Require().NoError(ts.storage.CreateUser(ts.ctx, *george))
+
+	perms := []model.Permission{model.PermUsersBlock, model.PermWebhooksManage}
+	ts.Require().NoError(ts.storage.SetUserRole(ts.ctx, george.ID, model.RoleSupport, perms))
+	u, err := ts.storage.UserByID(ts.ctx, george.ID)
+	ts.Require().NoError(err)
+	ts.Assert().Equal(model.RoleSupport, u.Role)
+	ts.Assert().Equal(perms, u.Permissions)
+
+	ts.Assert().ErrorIs(ts.storage.SetUserRole(ts.ctx, uuid.New(), model.RoleAdmin, nil), storage.ErrNotFound)
+}
+
+func (ts *TestSuite) TestDeleteUser() {
+	ringo := &model.User{
+		ID:             uuid.New(),
+		Login:          "ringostarr@apple.com",
+		PasswordHash:   "YeLlOwSuBmArInE",
+		CreatedAt:      time.Now().Truncate(time.Second),
+		GPointsBalance: 500,
+	}
+	ts.Require().NoError(ts.storage.CreateUser(ts.ctx, *ringo))
+	now := time.Now()
+	ts.Require().NoError(ts.storage.CreateOrder(ts.ctx, &model.Order{
+		ID: "9990001", UserID: ringo.ID, Status: model.StatusNew, UploadedAt: now,
+	}))
+	ts.Require().NoError(ts.storage.CreateAccrual(ts.ctx, "9990001", 100))
+	ts.Require().NoError(ts.storage.ProcessWithdraw(ts.ctx, &model.Withdrawal{
+		UserID: ringo.ID, OrderID: "9990002", Sum: 50, ProcessedAt: now,
+	}))
+	ts.Require().NoError(ts.storage.CreateSession(ts.ctx, &model.Session{
+		ID: uuid.New(), UserID: ringo.ID, TokenHash: "ringo's drums",
+		CreatedAt: now, LastSeenAt: now, ExpiresAt: now.Add(time.Hour),
+	}))
+
+	pseudonymID := uuid.New()
+	ts.Run("#1 Delete the user", func() {
+		ts.Require().NoError(ts.storage.DeleteUser(ts.ctx, ringo.ID, pseudonymID, now))
+
+		_, err := ts.storage.UserByID(ts.ctx, ringo.ID)
+		ts.Assert().ErrorIs(err, storage.ErrNotFound)
+		_, err = ts.storage.UserByLogin(ts.ctx, ringo.Login)
+		ts.Assert().ErrorIs(err, storage.ErrNotFound)
+		_, err = ts.storage.SessionByTokenHash(ts.ctx, "ringo's drums")
+		ts.Assert().ErrorIs(err, storage.ErrNotFound)
+	})
+	ts.Run("#2 The financial logs are kept by the tombstone", func() {
+		tombstone, err := ts.storage.UserByID(ts.ctx, pseudonymID)
+		ts.Require().NoError(err)
+		ts.Assert().True(tombstone.Deleted())
+		ts.Assert().True(tombstone.Blocked)
+		ts.Assert().Equal(model.DeletedUserLogin(pseudonymID), tombstone.Login)
+		ts.Assert().Empty(tombstone.PasswordHash)
+		ts.Assert().Equal(float32(450), tombstone.GPointsBalance)
+		ts.Assert().Equal(ringo.CreatedAt.UTC(), tombstone.CreatedAt.UTC())
+
+		orders, err := ts.storage.UserOrders(ts.ctx, pseudonymID)
+		ts.Require().NoError(err)
+		ts.Require().Len(orders, 1)
+		ts.Assert().Equal(model.OrderID("9990001"), orders[0].ID)
+		withdrawals, err := ts.storage.WithdrawalsByUserID(ts.ctx, pseudonymID)
+		ts.Require().NoError(err)
+		ts.Assert().Len(withdrawals, 1)
+	})
+	ts.Run("#3 The login is free", func() {
+		ringo.ID = uuid.New()
+		ts.Assert().NoError(ts.storage.CreateUser(ts.ctx, *ringo))
+	})
+	ts.Run("#4 Non-existing user", func() {
+		err := ts.storage.DeleteUser(ts.ctx, uuid.New(), uuid.New(), now)
+		ts.Assert().ErrorIs(err, storage.ErrNotFound)
+	})
+}
@@ -0,0 +1,97 @@
+package psql
+
+import (
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+func (ts *TestSuite) TestWebhooks() {
+	hook := &model.Webhook{
+		ID:         uuid.New(),
+		URL:        "http://partner.example/hook",
+		Secret:     "s3cr3t",
+		EventTypes: []model.EventType{model.EventOrderProcessed, model.EventWithdrawalProcessed},
+		CreatedAt:  time.Now(),
+	}
+	ts.Require().NoError(ts.storage.CreateWebhook(ts.ctx, hook))
+
+	now := time.Now()
+	due := &model.WebhookDelivery{
+		ID:            uuid.New(),
+		WebhookID:     hook.ID,
+		EventType:     model.EventOrderProcessed,
+		Payload:       []byte(`{"order":"117"}`),
+		Status:        model.DeliveryPending,
+		NextAttemptAt: now.Add(-time.Second),
+		CreatedAt:     now,
+	}
+	later := &model.WebhookDelivery{
+		ID:            uuid.New(),
+		WebhookID:     hook.ID,
+		EventType:     model.EventWithdrawalProcessed,
+		Payload:       []byte(`{"order":"1016"}`),
+		Status:        model.DeliveryPending,
+		NextAttemptAt: now.Add(time.Hour),
+		CreatedAt:     now.Add(time.Millisecond),
+	}
+
+	ts.Run("#1 Fetch webhooks", func() {
+		webhooks, err := ts.storage.Webhooks(ts.ctx)
+		ts.Require().NoError(err)
+		ts.Require().Len(webhooks, 1)
+		ts.Assert().Equal(hook.URL, webhooks[0].URL)
+		ts.Assert().Equal(hook.Secret, webhooks[0].Secret)
+		ts.Assert().Equal(hook.EventTypes, webhooks[0].EventTypes)
+	})
+	ts.Run("#2 Enqueue deliveries", func() {
+		ts.Require().NoError(ts.storage.CreateWebhookDelivery(ts.ctx, due))
+		ts.Require().NoError(ts.storage.CreateWebhookDelivery(ts.ctx, later))
+	})
+	ts.Run("#3 Only due deliveries are claimed", func() {
+		deliveries, err := ts.storage.ClaimWebhookDeliveries(ts.ctx, now, now.Add(time.Minute), 10)
+		ts.Require().NoError(err)
+		ts.Require().Len(deliveries, 1)
+		ts.Assert().Equal(due.ID, deliveries[0].ID)
+		ts.Assert().JSONEq(string(due.Payload), string(deliveries[0].Payload))
+
+		// The claimed delivery is skipped until the claim expires.
+		deliveries, err = ts.storage.ClaimWebhookDeliveries(ts.ctx, now, now.Add(time.Minute), 10)
+		ts.Require().NoError(err)
+		ts.Assert().Empty(deliveries)
+	})
+	ts.Run("#4 Failed delivery leaves the queue", func() {
+		due.Status = model.DeliveryFailed
+		due.Attempts = 8
+		due.LastStatusCode = 503
+		due.LastError = "unavailable"
+		ts.Require().NoError(ts.storage.UpdateWebhookDelivery(ts.ctx, due))
+
+		deliveries, err := ts.storage.ClaimWebhookDeliveries(ts.ctx, now.Add(2*time.Minute), now.Add(3*time.Minute), 10)
+		ts.Require().NoError(err)
+		ts.Assert().Empty(deliveries)
+
+		d, err := ts.storage.WebhookDeliveryByID(ts.ctx, due.ID)
+		ts.Require().NoError(err)
+		ts.Assert().Equal(model.DeliveryFailed, d.Status)
+		ts.Assert().Equal(8, d.Attempts)
+		ts.Assert().Equal(503, d.LastStatusCode)
+		ts.Assert().Equal("unavailable", d.LastError)
+	})
+	ts.Run("#5 Delivery log", func() {
+		deliveries, err := ts.storage.WebhookDeliveries(ts.ctx, hook.ID)
+		ts.Require().NoError(err)
+		ts.Require().Len(deliveries, 2)
+		ts.Assert().Equal(due.ID, deliveries[0].ID)
+		ts.Assert().Equal(later.ID, deliveries[1].ID)
+	})
+	ts.Run("#6 Delete webhook with its deliveries", func() {
+		ts.Require().NoError(ts.storage.DeleteWebhook(ts.ctx, hook.ID))
+		ts.Assert().ErrorIs(ts.storage.DeleteWebhook(ts.ctx, hook.ID), storage.ErrNotFound)
+		_, err := ts.storage.WebhookDeliveryByID(ts.ctx, due.ID)
+		ts.Assert().ErrorIs(err, storage.ErrNotFound)
+	})
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"log"
+	"time"
 
 	"github.com/vanamelnik/gophermart/model"
 	"github.com/vanamelnik/gophermart/storage"
@@ -14,15 +14,40 @@ import (
 	"github.com/jackc/pgx"
 )
 
-// ProcessWithdraw implements Storage interface.
+// ProcessWithdraw implements Storage interface. The withdrawal is logged and the points are taken
+// from user's balance in the same transaction, so the withdrawals are never left in 'PROCESSING' status.
 func (p Psql) ProcessWithdraw(ctx context.Context, withdraw *model.Withdrawal) error {
-	// Try to create a new entry in the withdrawals_log table. If the order has already been processed, return an error.
-	withdraw.Status = model.StatusProcessing
-	if withdraw.Sum < 0 {
-		withdraw.Status = model.StatusInvalid
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
 	}
+	//nolint:errcheck
+	defer tx.Rollback()
+
+	// Lock user's balance until the withdrawal is logged.
+	row := tx.QueryRowContext(ctx, `SELECT gpoints_balance FROM users WHERE id=$1 FOR UPDATE;`, withdraw.UserID)
+	var balance float32
+	if err := row.Scan(&balance); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.ErrNotFound
+		}
 
-	if _, err := p.db.ExecContext(ctx, `INSERT INTO withdrawals_log (order_id, user_id, sum, status, processed_at)
+		return err
+	}
+
+	// The invalid withdrawals are kept in the log, but never reach the outbox.
+	var result error
+	withdraw.Status = model.StatusProcessed
+	eventType := model.EventWithdrawalProcessed
+	switch {
+	case withdraw.Sum < 0:
+		withdraw.Status, eventType, result = model.StatusInvalid, "", storage.ErrInvalidInput
+	case balance < withdraw.Sum:
+		withdraw.Status, eventType, result = model.StatusInvalid, model.EventWithdrawalRejected, storage.ErrInsufficientPoints
+	}
+
+	// Try to create a new entry in the withdrawals_log table. If the order has already been processed, return an error.
+	if _, err := tx.ExecContext(ctx, `INSERT INTO withdrawals_log (order_id, user_id, sum, status, processed_at)
 	VALUES ($1, $2, $3, $4, $5);`, withdraw.OrderID, withdraw.UserID, withdraw.Sum, withdraw.Status, withdraw.ProcessedAt); err != nil {
 		var pgErr pgx.PgError
 		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
@@ -31,44 +56,73 @@ func (p Psql) ProcessWithdraw(ctx context.Context, withdraw *model.Withdrawal) e
 
 		return err
 	}
-	if withdraw.Status == model.StatusInvalid {
-		return storage.ErrInvalidInput
+	if withdraw.Status == model.StatusProcessed {
+		if _, err := tx.ExecContext(ctx, `UPDATE users SET gpoints_balance = gpoints_balance - $1 WHERE id=$2;`,
+			withdraw.Sum, withdraw.UserID); err != nil {
+			return err
+		}
+	}
+	if eventType != "" {
+		if err := insertEvent(ctx, tx, eventType, withdraw.OrderID.String(), model.WithdrawalEvent{
+			Order:       withdraw.OrderID,
+			UserID:      withdraw.UserID,
+			Sum:         withdraw.Sum,
+			Status:      withdraw.Status,
+			ProcessedAt: withdraw.ProcessedAt,
+		}); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
 	}
 
+	return result
+}
+
+// RecoverWithdrawals implements Storage interface.
+func (p Psql) RecoverWithdrawals(ctx context.Context, before time.Time) (int, error) {
 	tx, err := p.db.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	defer func() {
-		// if the transaction has been rolled back, set status to 'INVALID'
-		err := tx.Rollback()
-		if !errors.Is(err, sql.ErrTxDone) { // if transaction was rejected, set status to INVALID
-			log.Printf("transaction has been rolled back. Updating withdrawals_log set status=INVALID orderID=%v", withdraw.OrderID)
-			//nolint:errcheck
-			p.db.ExecContext(ctx, `UPDATE withdrawals_log SET status='INVALID' WHERE order_id=$1;`, withdraw.OrderID)
-		}
-	}()
+	//nolint:errcheck
+	defer tx.Rollback()
 
-	// Try to change user's balance. If there are no enough points, return an error.
-	row := tx.QueryRowContext(ctx, `SELECT gpoints_balance FROM users WHERE id=$1 FOR UPDATE;`, withdraw.UserID)
-	var balance float32
-	if err := row.Scan(&balance); err != nil {
-		return err
+	// The points of the orphaned withdrawals have never been taken, so the withdrawals are rejected.
+	rows, err := tx.QueryContext(ctx, `UPDATE withdrawals_log SET status='INVALID'
+	WHERE status='PROCESSING' AND processed_at < $1
+	RETURNING order_id, user_id, sum, processed_at;`, before)
+	if err != nil {
+		return 0, err
 	}
-	if balance < withdraw.Sum {
-		return storage.ErrInsufficientPoints
+	defer rows.Close()
+	withdrawals := make([]model.Withdrawal, 0)
+	for rows.Next() {
+		w := model.Withdrawal{Status: model.StatusInvalid}
+		if err := rows.Scan(&w.OrderID, &w.UserID, &w.Sum, &w.ProcessedAt); err != nil {
+			return 0, err
+		}
+		withdrawals = append(withdrawals, w)
 	}
-	if _, err := tx.ExecContext(ctx, `UPDATE users SET gpoints_balance = gpoints_balance - $1 WHERE id=$2;`,
-		withdraw.Sum, withdraw.UserID); err != nil {
-		return err
+	if err := rows.Err(); err != nil {
+		return 0, err
 	}
+	rows.Close()
 
-	// All is OK, set status to 'processed'.
-	if _, err := tx.ExecContext(ctx, `UPDATE withdrawals_log SET status='PROCESSED' WHERE order_id=$1;`, withdraw.OrderID); err != nil {
-		return err
+	for _, w := range withdrawals {
+		if err := insertEvent(ctx, tx, model.EventWithdrawalRejected, w.OrderID.String(), model.WithdrawalEvent{
+			Order:       w.OrderID,
+			UserID:      w.UserID,
+			Sum:         w.Sum,
+			Status:      w.Status,
+			ProcessedAt: w.ProcessedAt,
+		}); err != nil {
+			return 0, err
+		}
 	}
 
-	return tx.Commit()
+	return len(withdrawals), tx.Commit()
 }
 
 // WithdrawalsByUserId implements Storage interface.
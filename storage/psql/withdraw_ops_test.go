@@ -103,3 +103,49 @@ func (ts *TestSuite) TestWithdraw() {
 		ts.Assert().Equal(model.StatusInvalid, aliceW[0].Status)
 	})
 }
+
+func (ts *TestSuite) TestRecoverWithdrawals() {
+	frank := &model.User{
+		ID:             uuid.New(),
+		Login:          "frankzappa@mail.su",
+		PasswordHash:   "qWeRtYuIoP",
+		CreatedAt:      time.Now(),
+		GPointsBalance: 100,
+	}
+	ts.Require().NoError(ts.storage.CreateUser(ts.ctx, *frank))
+
+	// The withdrawals orphaned by the previous versions are left in 'PROCESSING' status.
+	now := time.Now()
+	for orderID, processedAt := range map[model.OrderID]time.Time{"6064": now.Add(-time.Hour), "7070": now} {
+		_, err := ts.storage.(*Psql).db.ExecContext(ts.ctx, `INSERT INTO withdrawals_log (order_id, user_id, sum, status, processed_at)
+		VALUES ($1, $2, 50, 'PROCESSING', $3);`, orderID, frank.ID, processedAt)
+		ts.Require().NoError(err)
+	}
+
+	ts.Run("#1 Only the old withdrawals are rejected", func() {
+		n, err := ts.storage.RecoverWithdrawals(ts.ctx, now.Add(-time.Minute))
+		ts.Require().NoError(err)
+		ts.Assert().Equal(1, n)
+		withdrawals, err := ts.storage.WithdrawalsByUserID(ts.ctx, frank.ID)
+		ts.Require().NoError(err)
+		ts.Require().Len(withdrawals, 2)
+		ts.Assert().Equal(model.StatusInvalid, withdrawals[0].Status)
+		ts.Assert().Equal(model.StatusProcessing, withdrawals[1].Status)
+		u, err := ts.storage.UserByID(ts.ctx, frank.ID)
+		ts.Require().NoError(err)
+		ts.Assert().EqualValues(100, u.GPointsBalance, "the points haven't been taken")
+	})
+	ts.Run("#2 The rejection is written to the outbox", func() {
+		events, err := ts.storage.ClaimUnpublishedEvents(ts.ctx, time.Now(), time.Now(), 100)
+		ts.Require().NoError(err)
+		ts.Require().NotEmpty(events)
+		last := events[len(events)-1]
+		ts.Assert().Equal(model.EventWithdrawalRejected, last.Type)
+		ts.Assert().Equal("6064", last.AggregateID)
+	})
+	ts.Run("#3 Recovery is idempotent", func() {
+		n, err := ts.storage.RecoverWithdrawals(ts.ctx, now.Add(-time.Minute))
+		ts.Require().NoError(err)
+		ts.Assert().Zero(n)
+	})
+}
@@ -0,0 +1,135 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+// CreateAccrual implements Storage interface.
+func (s Sqlite) CreateAccrual(ctx context.Context, orderID model.OrderID, amount float32) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	//nolint:errcheck
+	defer tx.Rollback()
+
+	// Get user ID from orders table
+	row := tx.QueryRowContext(ctx, `SELECT user_id, status FROM orders WHERE id=$1;`,
+		orderID)
+	var userID uuid.UUID
+	var status model.Status
+	if err := row.Scan(&userID, &status); err != nil {
+		return storage.ErrNotFound
+	}
+
+	// Insert accrual information into accruals_log table. If the data has already been inserted
+	// an unique violation error will be thrown.
+	if _, err = tx.ExecContext(ctx, `INSERT INTO accruals_log (order_id, user_id, sum) VALUES ($1, $2, $3);`,
+		orderID, userID, amount); err != nil {
+		if isUniqueViolation(err) {
+			return storage.ErrAlreadyProcessed
+		}
+
+		return err
+	}
+
+	// If the order has not been processed yet, but has been marked as processed, that's an internal server error.
+	if status == model.StatusProcessed {
+		return storage.ErrInvalidStatus
+	}
+
+	// Update status and information about accrual points in order entry.
+	if _, err = tx.ExecContext(ctx, `UPDATE orders SET status='PROCESSED', accrual_points=$1 WHERE id=$2;`,
+		amount, orderID); err != nil {
+		return err
+	}
+	if err := insertEvent(ctx, tx, model.EventOrderProcessed, orderID.String(), model.OrderEvent{
+		Order:          orderID,
+		UserID:         userID,
+		Status:         model.StatusProcessed,
+		PreviousStatus: status,
+		Accrual:        amount,
+	}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateBalance implements Storage interface. Each accrual is claimed by the conditional update of its
+// 'processed' flag, so it's credited once even if the balances are updated concurrently.
+func (s Sqlite) UpdateBalance(ctx context.Context) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	//nolint:errcheck
+	defer tx.Rollback()
+
+	// Collect information about unprocessed accruals.
+	rows, err := tx.QueryContext(ctx, `SELECT accruals_log.order_id, accruals_log.user_id, accruals_log.sum
+	FROM accruals_log JOIN users ON users.id = accruals_log.user_id
+	WHERE NOT accruals_log.processed;`)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	type accrual struct {
+		orderID string
+		userID  uuid.UUID
+		sum     float32
+	}
+	accruals := make([]accrual, 0)
+	for rows.Next() {
+		var a accrual
+		if err := rows.Scan(&a.orderID, &a.userID, &a.sum); err != nil {
+			return 0, err
+		}
+		accruals = append(accruals, a)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	rows.Close()
+
+	processed := 0
+	for _, a := range accruals {
+		// Set flag 'processed'. If the entry has already been processed, it's skipped.
+		res, err := tx.ExecContext(ctx, `UPDATE accruals_log SET processed = true
+		WHERE order_id = $1 AND NOT processed;`, a.orderID)
+		if err != nil {
+			return 0, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		if n == 0 {
+			continue
+		}
+		// Add points to the account.
+		if _, err := tx.ExecContext(ctx, `UPDATE users SET gpoints_balance = gpoints_balance + $1 WHERE id = $2;`,
+			a.sum, a.userID); err != nil {
+			return 0, err
+		}
+		if err := insertEvent(ctx, tx, model.EventBalanceCredited, a.userID.String(), model.BalanceEvent{
+			Order:  model.OrderID(a.orderID),
+			UserID: a.userID,
+			Sum:    a.sum,
+		}); err != nil {
+			return 0, err
+		}
+		processed++
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return processed, nil
+}
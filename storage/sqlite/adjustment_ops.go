@@ -0,0 +1,76 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+// CreateAdjustment implements Storage interface.
+func (s Sqlite) CreateAdjustment(ctx context.Context, adj *model.Adjustment) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	//nolint:errcheck
+	defer tx.Rollback()
+
+	// The balance is checked by the update itself, so it can't go negative without the row lock.
+	res, err := tx.ExecContext(ctx, `UPDATE users SET gpoints_balance = gpoints_balance + $1
+	WHERE id=$2 AND gpoints_balance + $1 >= 0;`, adj.Sum, adj.UserID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id=$1);`, adj.UserID).
+			Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return storage.ErrNotFound
+		}
+
+		return storage.ErrInsufficientPoints
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO adjustments_log (id, user_id, admin_id, sum, reason, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6);`, adj.ID, adj.UserID, adj.AdminID, adj.Sum, adj.Reason, adj.CreatedAt); err != nil {
+		return err
+	}
+	if err := insertEvent(ctx, tx, model.EventBalanceAdjusted, adj.UserID.String(), adj); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AdjustmentsByUserID implements Storage interface.
+func (s Sqlite) AdjustmentsByUserID(ctx context.Context, id uuid.UUID) ([]model.Adjustment, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, user_id, admin_id, sum, reason, created_at
+	FROM adjustments_log WHERE user_id=$1 ORDER BY created_at ASC;`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	adjustments := make([]model.Adjustment, 0)
+	for rows.Next() {
+		var a model.Adjustment
+		if err := rows.Scan(&a.ID, &a.UserID, &a.AdminID, &a.Sum, &a.Reason, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		adjustments = append(adjustments, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return adjustments, nil
+}
@@ -0,0 +1,17 @@
+DROP TABLE IF EXISTS "api_keys";
+DROP TABLE IF EXISTS "merchants";
+DROP TABLE IF EXISTS "login_challenges";
+DROP TABLE IF EXISTS "recovery_codes";
+DROP TABLE IF EXISTS "two_factor";
+DROP TABLE IF EXISTS "rate_limits";
+DROP TABLE IF EXISTS "login_throttles";
+DROP TABLE IF EXISTS "password_resets";
+DROP TABLE IF EXISTS "sessions";
+DROP TABLE IF EXISTS "outbox";
+DROP TABLE IF EXISTS "webhook_deliveries";
+DROP TABLE IF EXISTS "webhooks";
+DROP TABLE IF EXISTS "adjustments_log";
+DROP TABLE IF EXISTS "withdrawals_log";
+DROP TABLE IF EXISTS "accruals_log";
+DROP TABLE IF EXISTS "orders";
+DROP TABLE IF EXISTS "users";
@@ -0,0 +1,157 @@
+-- SQLite has no enum types, the statuses are checked by constraints. The timestamps are stored
+-- as UTC text of fixed width, so they are compared as strings.
+
+CREATE TABLE "users" (
+  "id" text PRIMARY KEY,
+  "login" text UNIQUE NOT NULL,
+  "password_hash" text NOT NULL,
+  "gpoints_balance" real NOT NULL DEFAULT 0,
+  "role" text NOT NULL DEFAULT 'user',
+  "permissions" text NOT NULL DEFAULT '',
+  "blocked" boolean NOT NULL DEFAULT false,
+  "created_at" timestamp,
+  "deleted_at" timestamp
+);
+
+CREATE TABLE "orders" (
+  "id" text PRIMARY KEY NOT NULL,
+  "user_id" text REFERENCES "users" ("id"),
+  "status" text NOT NULL CHECK ("status" IN ('NEW', 'REGISTERED', 'INVALID', 'PROCESSING', 'PROCESSED')),
+  "accrual_points" real,
+  "uploaded_at" timestamp
+);
+
+CREATE TABLE "accruals_log" (
+  "order_id" text UNIQUE NOT NULL,
+  "user_id" text REFERENCES "users" ("id"),
+  "processed" boolean NOT NULL DEFAULT false,
+  "sum" real
+);
+
+CREATE TABLE "withdrawals_log" (
+  "order_id" text UNIQUE NOT NULL,
+  "user_id" text REFERENCES "users" ("id"),
+  "sum" real,
+  "status" text CHECK ("status" IN ('NEW', 'REGISTERED', 'INVALID', 'PROCESSING', 'PROCESSED')),
+  "processed_at" timestamp
+);
+
+CREATE TABLE "adjustments_log" (
+  "id" text PRIMARY KEY,
+  "user_id" text NOT NULL REFERENCES "users" ("id"),
+  "admin_id" text NOT NULL REFERENCES "users" ("id"),
+  "sum" real NOT NULL,
+  "reason" text NOT NULL,
+  "created_at" timestamp
+);
+
+CREATE TABLE "webhooks" (
+  "id" text PRIMARY KEY,
+  "url" text NOT NULL,
+  "secret" text NOT NULL,
+  "event_types" text NOT NULL,
+  "created_at" timestamp
+);
+
+CREATE TABLE "webhook_deliveries" (
+  "id" text PRIMARY KEY,
+  "webhook_id" text NOT NULL REFERENCES "webhooks" ("id") ON DELETE CASCADE,
+  "event_type" text NOT NULL,
+  "payload" text NOT NULL,
+  "status" text NOT NULL CHECK ("status" IN ('PENDING', 'DELIVERED', 'FAILED')),
+  "attempts" integer NOT NULL DEFAULT 0,
+  "last_status_code" integer NOT NULL DEFAULT 0,
+  "last_error" text NOT NULL DEFAULT '',
+  "next_attempt_at" timestamp NOT NULL,
+  "created_at" timestamp
+);
+
+CREATE INDEX "webhook_deliveries_status_idx" ON "webhook_deliveries" ("status", "next_attempt_at");
+
+CREATE TABLE "outbox" (
+  "id" integer PRIMARY KEY AUTOINCREMENT,
+  "event_type" text NOT NULL,
+  "aggregate_id" text NOT NULL,
+  "payload" text NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "published_at" timestamp
+);
+
+CREATE INDEX "outbox_unpublished_idx" ON "outbox" ("id") WHERE "published_at" IS NULL;
+
+CREATE TABLE "sessions" (
+  "id" text PRIMARY KEY,
+  "user_id" text NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+  "token_hash" text UNIQUE NOT NULL,
+  "user_agent" text NOT NULL DEFAULT '',
+  "ip" text NOT NULL DEFAULT '',
+  "created_at" timestamp NOT NULL,
+  "last_seen_at" timestamp NOT NULL,
+  "expires_at" timestamp NOT NULL
+);
+
+CREATE INDEX "sessions_user_id_idx" ON "sessions" ("user_id");
+
+CREATE TABLE "password_resets" (
+  "id" text PRIMARY KEY,
+  "user_id" text NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+  "token_hash" text UNIQUE NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "expires_at" timestamp NOT NULL,
+  "used_at" timestamp
+);
+
+CREATE TABLE "login_throttles" (
+  "key" text PRIMARY KEY,
+  "failures" integer NOT NULL,
+  "last_failure_at" timestamp NOT NULL,
+  "locked_until" timestamp
+);
+
+CREATE TABLE "rate_limits" (
+  "key" text PRIMARY KEY,
+  "tokens" real NOT NULL,
+  "updated_at" timestamp NOT NULL,
+  "full_at" timestamp NOT NULL
+);
+
+CREATE INDEX "rate_limits_full_at_idx" ON "rate_limits" ("full_at");
+
+CREATE TABLE "two_factor" (
+  "user_id" text PRIMARY KEY REFERENCES "users" ("id") ON DELETE CASCADE,
+  "secret" text NOT NULL,
+  "enabled_at" timestamp,
+  "last_used_step" integer NOT NULL DEFAULT 0
+);
+
+CREATE TABLE "recovery_codes" (
+  "user_id" text NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+  "code_hash" text NOT NULL,
+  "used_at" timestamp,
+  PRIMARY KEY ("user_id", "code_hash")
+);
+
+CREATE TABLE "login_challenges" (
+  "token_hash" text PRIMARY KEY,
+  "user_id" text NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+  "created_at" timestamp NOT NULL,
+  "expires_at" timestamp NOT NULL,
+  "attempts" integer NOT NULL DEFAULT 0
+);
+
+CREATE TABLE "merchants" (
+  "id" text PRIMARY KEY,
+  "name" text UNIQUE NOT NULL,
+  "created_at" timestamp NOT NULL
+);
+
+CREATE TABLE "api_keys" (
+  "id" text PRIMARY KEY,
+  "merchant_id" text NOT NULL REFERENCES "merchants" ("id") ON DELETE CASCADE,
+  "prefix" text NOT NULL,
+  "key_hash" text UNIQUE NOT NULL,
+  "scopes" text NOT NULL DEFAULT '',
+  "created_at" timestamp NOT NULL,
+  "last_used_at" timestamp,
+  "revoked_at" timestamp
+);
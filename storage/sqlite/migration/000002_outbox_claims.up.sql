@@ -0,0 +1,2 @@
+-- The events claimed by a relay are skipped by the relays of other instances until the claim expires.
+ALTER TABLE "outbox" ADD COLUMN "claimed_until" timestamp;
@@ -0,0 +1,153 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+// CreateOrder implements Storage interface.
+func (s Sqlite) CreateOrder(ctx context.Context, order *model.Order) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	//nolint:errcheck
+	defer tx.Rollback()
+
+	const query = `INSERT INTO orders (id, user_id, status, accrual_points, uploaded_at)
+	VALUES ($1, $2, $3, $4, $5);`
+	_, err = tx.ExecContext(ctx, query,
+		order.ID, order.UserID, order.Status, order.AccrualPoints, order.UploadedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return storage.ErrAlreadyProcessed
+		}
+
+		return err
+	}
+	if err := insertEvent(ctx, tx, model.EventOrderCreated, order.ID.String(), model.OrderEvent{
+		Order:  order.ID,
+		UserID: order.UserID,
+		Status: order.Status,
+	}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// OrderByID implements Storage interface.
+func (s Sqlite) OrderByID(ctx context.Context, orderID model.OrderID) (*model.Order, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT id, user_id, status, accrual_points, uploaded_at
+	FROM orders WHERE id=$1;`, orderID)
+	var o model.Order
+	if err := row.Scan(&o.ID,
+		&o.UserID,
+		&o.Status,
+		&o.AccrualPoints,
+		&o.UploadedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	return &o, nil
+}
+
+// OrderByStatus implements Storage interface.
+func (s Sqlite) OrdersByStatus(ctx context.Context, status model.Status) ([]model.Order, error) {
+	orders := make([]model.Order, 0)
+	rows, err := s.db.QueryContext(ctx, `SELECT id, user_id, status, accrual_points, uploaded_at
+		FROM orders WHERE status = $1;`, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var o model.Order
+		if err := rows.Scan(&o.ID, &o.UserID, &o.Status, &o.AccrualPoints, &o.UploadedAt); err != nil {
+			return nil, err
+		}
+		orders = append(orders, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// UpdateOrderStatus implements Storage interface.
+func (s Sqlite) UpdateOrderStatus(ctx context.Context, orderID model.OrderID, status model.Status) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	//nolint:errcheck
+	defer tx.Rollback()
+
+	// check if the order exists in db
+	row := tx.QueryRowContext(ctx, `SELECT user_id, status FROM orders WHERE id=$1;`, orderID)
+	var (
+		userID         uuid.UUID
+		previousStatus model.Status
+	)
+	if err := row.Scan(&userID, &previousStatus); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.ErrNotFound
+		}
+
+		return err
+	}
+	// update the status
+	if _, err := tx.ExecContext(ctx, `UPDATE orders SET status=$1 WHERE id=$2;`, status, orderID); err != nil {
+		return err
+	}
+	if err := insertEvent(ctx, tx, model.EventOrderStatusChanged, orderID.String(), model.OrderEvent{
+		Order:          orderID,
+		UserID:         userID,
+		Status:         status,
+		PreviousStatus: previousStatus,
+	}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UserOrders implements Storage interface.
+func (s Sqlite) UserOrders(ctx context.Context, userID uuid.UUID) ([]model.Order, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, user_id, status, accrual_points, uploaded_at
+	FROM orders WHERE user_id=$1 ORDER BY uploaded_at ASC;`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := make([]model.Order, 0)
+	for rows.Next() {
+		var o model.Order
+		if err := rows.Scan(&o.ID,
+			&o.UserID,
+			&o.Status,
+			&o.AccrualPoints,
+			&o.UploadedAt); err != nil {
+			return nil, err
+		}
+		orders = append(orders, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
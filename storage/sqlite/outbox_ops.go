@@ -0,0 +1,100 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+)
+
+// ClaimUnpublishedEvents implements Storage interface.
+func (s Sqlite) ClaimUnpublishedEvents(ctx context.Context, now, claimUntil time.Time, limit int) ([]model.Event, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	//nolint:errcheck
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, event_type, aggregate_id, payload, created_at
+	FROM outbox WHERE published_at IS NULL AND (claimed_until IS NULL OR claimed_until <= $1)
+	ORDER BY id ASC LIMIT $2;`, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	events, err := scanEvents(rows)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range events {
+		if _, err := tx.ExecContext(ctx, `UPDATE outbox SET claimed_until=$1 WHERE id=$2;`, claimUntil, e.ID); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// MarkEventsPublished implements Storage interface.
+func (s Sqlite) MarkEventsPublished(ctx context.Context, ids []int64, publishedAt time.Time) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	//nolint:errcheck
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `UPDATE outbox SET published_at = $1 WHERE id = $2;`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, id := range ids {
+		if _, err := stmt.ExecContext(ctx, publishedAt, id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// scanEvents scans the events selected from the outbox.
+func scanEvents(rows *sql.Rows) ([]model.Event, error) {
+	defer rows.Close()
+
+	events := make([]model.Event, 0)
+	for rows.Next() {
+		var (
+			e       model.Event
+			payload []byte
+		)
+		if err := rows.Scan(&e.ID, &e.Type, &e.AggregateID, &payload, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.Payload = payload
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// insertEvent writes a domain event into the outbox within the transaction provided.
+func insertEvent(ctx context.Context, tx *sql.Tx, eventType model.EventType, aggregateID string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO outbox (event_type, aggregate_id, payload, created_at)
+	VALUES ($1, $2, $3, $4);`, eventType, aggregateID, string(data), time.Now())
+
+	return err
+}
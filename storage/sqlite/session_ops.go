@@ -0,0 +1,124 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+const sessionColumns = `id, user_id, token_hash, user_agent, ip, created_at, last_seen_at, expires_at`
+
+// CreateSession implements Storage interface.
+func (s Sqlite) CreateSession(ctx context.Context, session *model.Session) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO sessions (`+sessionColumns+`)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8);`,
+		session.ID, session.UserID, session.TokenHash, session.UserAgent, session.IP,
+		session.CreatedAt, session.LastSeenAt, session.ExpiresAt)
+
+	return err
+}
+
+// SessionByTokenHash implements Storage interface.
+func (s Sqlite) SessionByTokenHash(ctx context.Context, tokenHash string) (*model.Session, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+sessionColumns+`
+	FROM sessions WHERE token_hash=$1;`, tokenHash)
+
+	return scanSession(row)
+}
+
+// SessionByID implements Storage interface.
+func (s Sqlite) SessionByID(ctx context.Context, id uuid.UUID) (*model.Session, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+sessionColumns+`
+	FROM sessions WHERE id=$1;`, id)
+
+	return scanSession(row)
+}
+
+// TouchSession implements Storage interface.
+func (s Sqlite) TouchSession(ctx context.Context, id uuid.UUID, lastSeenAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE sessions SET last_seen_at=$1 WHERE id=$2;`, lastSeenAt, id)
+
+	return err
+}
+
+// UserSessions implements Storage interface.
+func (s Sqlite) UserSessions(ctx context.Context, userID uuid.UUID) ([]model.Session, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+sessionColumns+`
+	FROM sessions WHERE user_id=$1 ORDER BY last_seen_at DESC;`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := make([]model.Session, 0)
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// DeleteSession implements Storage interface.
+func (s Sqlite) DeleteSession(ctx context.Context, userID, id uuid.UUID) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM sessions WHERE id=$1 AND user_id=$2;`, id, userID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteExpiredSessions implements Storage interface.
+func (s Sqlite) DeleteExpiredSessions(ctx context.Context, now time.Time) (int, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM sessions WHERE expires_at <= $1;`, now)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
+}
+
+// UpgradeSessionHashes implements Storage interface. SQLite storage has no legacy sessions: the token hashes
+// have always been HMAC.
+func (s Sqlite) UpgradeSessionHashes(ctx context.Context, upgrade func(digest string) string) (int, error) {
+	return 0, nil
+}
+
+// scanSession scans a row selected with sessionColumns.
+func scanSession(row interface{ Scan(...interface{}) error }) (*model.Session, error) {
+	s := &model.Session{}
+	err := row.Scan(&s.ID, &s.UserID, &s.TokenHash, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	return s, nil
+}
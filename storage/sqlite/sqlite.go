@@ -0,0 +1,206 @@
+// Package sqlite is an implementation of storage.Storage on the embedded SQLite database. It lets Gophermart run
+// as a single binary without a database server.
+//
+// SQLite has no row locks, so the storage uses a single connection: the transactions are serialized, and
+// the balances are changed by conditional updates instead of SELECT ... FOR UPDATE.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"embed"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/rs/zerolog"
+	"github.com/vanamelnik/gophermart/storage"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	// Register sqlite driver.
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite"
+)
+
+// Scheme is the scheme of the DSN that selects SQLite storage, e.g. "sqlite://gophermart.db".
+const Scheme = "sqlite"
+
+// timeFormat is the format of the timestamps stored. The times are stored in UTC with fixed width,
+// so they can be compared as strings.
+const timeFormat = "2006-01-02T15:04:05.000000000Z"
+
+const defaultDSN = Scheme + "://gophermart.db"
+
+// migrations are embedded, so the single binary can create and upgrade the database.
+//
+//go:embed migration/*.sql
+var migrations embed.FS
+
+// pragmas are executed on each new connection.
+var pragmas = []string{
+	"PRAGMA foreign_keys = ON;",
+	"PRAGMA busy_timeout = 5000;",
+	"PRAGMA journal_mode = WAL;",
+}
+
+// Ensure service implements interface.
+var _ storage.Storage = (*Sqlite)(nil)
+
+type (
+	Sqlite struct {
+		dsn string
+		db  *sql.DB
+	}
+
+	Option func(*Sqlite) error
+)
+
+// IsDSN reports whether the DSN selects SQLite storage.
+func IsDSN(dsn string) bool {
+	return strings.HasPrefix(dsn, Scheme+"://")
+}
+
+// WithDSN overrides default config. The DSN is "sqlite://" followed by the path to the database file.
+func WithDSN(dsn string) Option {
+	return func(s *Sqlite) error {
+		if !IsDSN(dsn) {
+			return fmt.Errorf("sqlite: WithDSN: the DSN must start with %s://", Scheme)
+		}
+		s.dsn = dsn
+
+		return nil
+	}
+}
+
+// Migrations returns the source of the migrations embedded into the package.
+func Migrations() (source.Driver, error) {
+	return iofs.New(migrations, "migration")
+}
+
+// WithAutoMigrate applies the embedded migrations against db. Should run after setting up correct DSN string
+// in the config.
+func WithAutoMigrate(log zerolog.Logger) Option {
+	return func(s *Sqlite) error {
+		src, err := Migrations()
+		if err != nil {
+			return fmt.Errorf("sqlite: WithAutoMigrate: %w", err)
+		}
+		m, err := migrate.NewWithSourceInstance("iofs", src, s.dsn)
+		if err != nil {
+			return fmt.Errorf("sqlite: WithAutoMigrate: %w", err)
+		}
+		defer m.Close()
+
+		if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			return fmt.Errorf("sqlite: WithAutoMigrate: %w", err)
+		}
+
+		log.Info().Str("service", "sqlite").Msg("auto migrate applied")
+		return nil
+	}
+}
+
+// New opens SQLite database. The database file is created if it doesn't exist.
+func New(opts ...Option) (*Sqlite, error) {
+	s := &Sqlite{dsn: defaultDSN}
+	for i, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, fmt.Errorf("storage: applying option [%d]: %w", i, err)
+		}
+	}
+
+	s.db = sql.OpenDB(connector{path: strings.TrimPrefix(s.dsn, Scheme+"://")})
+	// SQLite allows a single writer. The transactions are serialized on the only connection,
+	// so a transaction reads the rows no one else can change until it's committed.
+	s.db.SetMaxOpenConns(1)
+
+	if err := s.db.Ping(); err != nil {
+		return nil, fmt.Errorf("storage: ping for DSN (%s) failed: %w", s.dsn, err)
+	}
+
+	return s, nil
+}
+
+// Stats returns the stats of the database connection pool.
+func (s Sqlite) Stats() sql.DBStats {
+	return s.db.Stats()
+}
+
+func (s Sqlite) Close() error {
+	if s.db == nil {
+		return nil
+	}
+
+	return s.db.Close()
+}
+
+// connector opens the connections to the database file and sets them up with the pragmas.
+type connector struct {
+	path string
+}
+
+// Connect implements driver.Connector interface.
+func (c connector) Connect(context.Context) (driver.Conn, error) {
+	conn, err := c.Driver().Open(c.path)
+	if err != nil {
+		return nil, err
+	}
+	execer, ok := conn.(driver.Execer) //nolint:staticcheck
+	if !ok {
+		conn.Close()
+
+		return nil, errors.New("sqlite: the driver doesn't support Exec")
+	}
+	for _, pragma := range pragmas {
+		if _, err := execer.Exec(pragma, nil); err != nil {
+			conn.Close()
+
+			return nil, fmt.Errorf("sqlite: %s: %w", pragma, err)
+		}
+	}
+
+	return timeConn{conn}, nil
+}
+
+// Driver implements driver.Connector interface.
+func (c connector) Driver() driver.Driver {
+	return &sqlite.Driver{}
+}
+
+// timeConn converts time.Time arguments to timeFormat. The driver stores them as time.String(),
+// which can't be compared.
+type timeConn struct {
+	driver.Conn
+}
+
+// CheckNamedValue implements driver.NamedValueChecker interface.
+func (timeConn) CheckNamedValue(nv *driver.NamedValue) error {
+	switch t := nv.Value.(type) {
+	case time.Time:
+		nv.Value = t.UTC().Format(timeFormat)
+	case *time.Time:
+		nv.Value = nil
+		if t != nil {
+			nv.Value = t.UTC().Format(timeFormat)
+		}
+	default:
+		return driver.ErrSkip
+	}
+
+	return nil
+}
+
+// isUniqueViolation reports whether the error is a violation of the unique constraint or the primary key.
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+
+	return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+}
@@ -0,0 +1,227 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+// TwoFactor implements Storage interface.
+func (s Sqlite) TwoFactor(ctx context.Context, userID uuid.UUID) (*model.TwoFactor, error) {
+	t := &model.TwoFactor{}
+	var enabledAt sql.NullTime
+	err := s.db.QueryRowContext(ctx, `SELECT user_id, secret, enabled_at, last_used_step FROM two_factor
+	WHERE user_id=$1;`, userID).Scan(&t.UserID, &t.Secret, &enabledAt, &t.LastUsedStep)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+
+		return nil, err
+	}
+	if enabledAt.Valid {
+		t.EnabledAt = &enabledAt.Time
+	}
+
+	return t, nil
+}
+
+// SetPendingTwoFactor implements Storage interface.
+func (s Sqlite) SetPendingTwoFactor(ctx context.Context, userID uuid.UUID, secret string) error {
+	res, err := s.db.ExecContext(ctx, `INSERT INTO two_factor (user_id, secret) VALUES ($1, $2)
+	ON CONFLICT (user_id) DO UPDATE SET secret=$2, last_used_step=0 WHERE two_factor.enabled_at IS NULL;`,
+		userID, secret)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrTwoFactorEnabled
+	}
+
+	return nil
+}
+
+// EnableTwoFactor implements Storage interface.
+func (s Sqlite) EnableTwoFactor(ctx context.Context, userID uuid.UUID, step int64, now time.Time, recoveryCodeHashes []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	//nolint:errcheck
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `UPDATE two_factor SET enabled_at=$1, last_used_step=$2
+	WHERE user_id=$3 AND enabled_at IS NULL;`, now, step, userID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrNotFound
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id=$1;`, userID); err != nil {
+		return err
+	}
+	for _, hash := range recoveryCodeHashes {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2);`,
+			userID, hash); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DisableTwoFactor implements Storage interface.
+func (s Sqlite) DisableTwoFactor(ctx context.Context, userID uuid.UUID) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	//nolint:errcheck
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		`DELETE FROM two_factor WHERE user_id=$1;`,
+		`DELETE FROM recovery_codes WHERE user_id=$1;`,
+		`DELETE FROM login_challenges WHERE user_id=$1;`,
+	} {
+		if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UseTOTPStep implements Storage interface.
+func (s Sqlite) UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE two_factor SET last_used_step=$1
+	WHERE user_id=$2 AND last_used_step<$1;`, step, userID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// UseRecoveryCode implements Storage interface.
+func (s Sqlite) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string, now time.Time) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE recovery_codes SET used_at=$1
+	WHERE user_id=$2 AND code_hash=$3 AND used_at IS NULL;`, now, userID, codeHash)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// CreateLoginChallenge implements Storage interface.
+func (s Sqlite) CreateLoginChallenge(ctx context.Context, c *model.LoginChallenge) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO login_challenges (token_hash, user_id, created_at, expires_at)
+	VALUES ($1, $2, $3, $4);`, c.TokenHash, c.UserID, c.CreatedAt, c.ExpiresAt)
+
+	return err
+}
+
+// LoginChallenge implements Storage interface.
+func (s Sqlite) LoginChallenge(ctx context.Context, tokenHash string) (*model.LoginChallenge, error) {
+	c := &model.LoginChallenge{}
+	err := s.db.QueryRowContext(ctx, `SELECT token_hash, user_id, created_at, expires_at, attempts
+	FROM login_challenges WHERE token_hash=$1;`, tokenHash).
+		Scan(&c.TokenHash, &c.UserID, &c.CreatedAt, &c.ExpiresAt, &c.Attempts)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// TakeChallengeAttempt implements Storage interface.
+func (s Sqlite) TakeChallengeAttempt(ctx context.Context, tokenHash string, maxAttempts int,
+	now time.Time) (*model.LoginChallenge, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	//nolint:errcheck
+	defer tx.Rollback()
+
+	// The attempt is counted by a single conditional update, so the concurrent attempts can't exceed the limit.
+	// RETURNING loses the column types, so the timestamps are selected in the same transaction.
+	res, err := tx.ExecContext(ctx, `UPDATE login_challenges SET attempts=attempts+1
+	WHERE token_hash=$1 AND attempts<$2 AND expires_at>$3;`, tokenHash, maxAttempts, now)
+	if err != nil {
+		return nil, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, storage.ErrNotFound
+	}
+	c := &model.LoginChallenge{}
+	if err := tx.QueryRowContext(ctx, `SELECT token_hash, user_id, created_at, expires_at, attempts
+	FROM login_challenges WHERE token_hash=$1;`, tokenHash).
+		Scan(&c.TokenHash, &c.UserID, &c.CreatedAt, &c.ExpiresAt, &c.Attempts); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// DeleteLoginChallenge implements Storage interface.
+func (s Sqlite) DeleteLoginChallenge(ctx context.Context, tokenHash string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM login_challenges WHERE token_hash=$1;`, tokenHash)
+
+	return err
+}
+
+// DeleteExpiredLoginChallenges implements Storage interface.
+func (s Sqlite) DeleteExpiredLoginChallenges(ctx context.Context, now time.Time) (int, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM login_challenges WHERE expires_at<=$1;`, now)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
+}
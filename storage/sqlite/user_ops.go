@@ -0,0 +1,237 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+const permissionsSeparator = ","
+
+const userColumns = `id, login, password_hash, gpoints_balance, role, permissions, blocked, created_at, deleted_at`
+
+// CreateUser implements Storage interface.
+func (s Sqlite) CreateUser(ctx context.Context, user model.User) error {
+	if user.Role == "" {
+		user.Role = model.RoleUser
+	}
+	const query = `INSERT INTO users (id, login, password_hash, gpoints_balance, role, permissions, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7);`
+	_, err := s.db.ExecContext(ctx, query,
+		user.ID, user.Login, user.PasswordHash, user.GPointsBalance, user.Role, joinPermissions(user.Permissions), user.CreatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return storage.ErrLoginAlreadyExists
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// UserByLogin implements Storage interface.
+func (s Sqlite) UserByLogin(ctx context.Context, login string) (*model.User, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+userColumns+`
+	FROM users WHERE login=$1;`, login)
+
+	return scanUser(row)
+}
+
+// UserByID implements Storage interface.
+func (s Sqlite) UserByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+userColumns+`
+	FROM users WHERE id=$1;`, id)
+
+	return scanUser(row)
+}
+
+// SearchUsers implements Storage interface.
+func (s Sqlite) SearchUsers(ctx context.Context, login string, limit int) ([]model.User, error) {
+	// Escape LIKE wildcards: the login is searched as a plain substring. LIKE of SQLite is case-insensitive.
+	pattern := "%" + strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(login) + "%"
+	rows, err := s.db.QueryContext(ctx, `SELECT `+userColumns+`
+	FROM users WHERE login LIKE $1 ESCAPE '\' ORDER BY login ASC LIMIT $2;`, pattern, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]model.User, 0)
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// UpdateUser implements Storage interface.
+func (s Sqlite) UpdateUser(ctx context.Context, user model.User) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE users SET
+	login=$1, password_hash=$2
+	WHERE id=$3;`, user.Login, user.PasswordHash, user.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return storage.ErrLoginAlreadyExists
+		}
+
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// SetUserBlocked implements Storage interface.
+func (s Sqlite) SetUserBlocked(ctx context.Context, id uuid.UUID, blocked bool) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE users SET blocked=$1 WHERE id=$2;`, blocked, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// SetUserRole implements Storage interface.
+func (s Sqlite) SetUserRole(ctx context.Context, id uuid.UUID, role model.Role, perms []model.Permission) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE users SET role=$1, permissions=$2 WHERE id=$3;`,
+		role, joinPermissions(perms), id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteUser implements Storage interface.
+func (s Sqlite) DeleteUser(ctx context.Context, id, pseudonymID uuid.UUID, now time.Time) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	//nolint:errcheck
+	defer tx.Rollback()
+
+	// The tombstone inherits the balance and the registration time, the login and the credentials are dropped.
+	res, err := tx.ExecContext(ctx, `INSERT INTO users
+	(id, login, password_hash, gpoints_balance, role, permissions, blocked, created_at, deleted_at)
+	SELECT $1, $2, '', gpoints_balance, $3, '', true, created_at, $4 FROM users WHERE id=$5;`,
+		pseudonymID, model.DeletedUserLogin(pseudonymID), model.RoleUser, now, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrNotFound
+	}
+	for _, query := range []string{
+		`UPDATE orders SET user_id=$1 WHERE user_id=$2;`,
+		`UPDATE accruals_log SET user_id=$1 WHERE user_id=$2;`,
+		`UPDATE withdrawals_log SET user_id=$1 WHERE user_id=$2;`,
+		`UPDATE adjustments_log SET user_id=$1 WHERE user_id=$2;`,
+		`UPDATE adjustments_log SET admin_id=$1 WHERE admin_id=$2;`,
+	} {
+		if _, err := tx.ExecContext(ctx, query, pseudonymID, id); err != nil {
+			return err
+		}
+	}
+	// The events and the webhook deliveries not yet sent must not link the user's id to the pseudonymous one.
+	for _, query := range []string{
+		`UPDATE outbox SET aggregate_id=$1 WHERE aggregate_id=$2;`,
+		`UPDATE outbox SET payload=json_set(payload, '$.user_id', $1) WHERE json_extract(payload, '$.user_id')=$2;`,
+		`UPDATE outbox SET payload=json_set(payload, '$.admin_id', $1) WHERE json_extract(payload, '$.admin_id')=$2;`,
+		`UPDATE webhook_deliveries SET payload=json_set(payload, '$.data.user_id', $1)
+		WHERE json_extract(payload, '$.data.user_id')=$2;`,
+	} {
+		if _, err := tx.ExecContext(ctx, query, pseudonymID.String(), id.String()); err != nil {
+			return err
+		}
+	}
+	// Sessions, password resets and two-factor authentication are deleted by cascade.
+	if _, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id=$1;`, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// scanUser scans a row selected with userColumns.
+func scanUser(row interface{ Scan(...interface{}) error }) (*model.User, error) {
+	u := &model.User{}
+	var (
+		perms     string
+		deletedAt sql.NullTime
+	)
+	err := row.Scan(&u.ID, &u.Login, &u.PasswordHash, &u.GPointsBalance,
+		&u.Role, &perms, &u.Blocked, &u.CreatedAt, &deletedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+
+		return nil, err
+	}
+	u.Permissions = splitPermissions(perms)
+	if deletedAt.Valid {
+		u.DeletedAt = &deletedAt.Time
+	}
+
+	return u, nil
+}
+
+func joinPermissions(perms []model.Permission) string {
+	s := make([]string, len(perms))
+	for i, p := range perms {
+		s[i] = string(p)
+	}
+
+	return strings.Join(s, permissionsSeparator)
+}
+
+func splitPermissions(s string) []model.Permission {
+	perms := make([]model.Permission, 0)
+	for _, p := range strings.Split(s, permissionsSeparator) {
+		if p != "" {
+			perms = append(perms, model.Permission(p))
+		}
+	}
+
+	return perms
+}
@@ -0,0 +1,219 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+const eventTypesSeparator = ","
+
+// CreateWebhook implements Storage interface.
+func (s Sqlite) CreateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO webhooks (id, url, secret, event_types, created_at)
+	VALUES ($1, $2, $3, $4, $5);`,
+		webhook.ID, webhook.URL, webhook.Secret, joinEventTypes(webhook.EventTypes), webhook.CreatedAt)
+
+	return err
+}
+
+// Webhooks implements Storage interface.
+func (s Sqlite) Webhooks(ctx context.Context) ([]model.Webhook, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, url, secret, event_types, created_at
+	FROM webhooks ORDER BY created_at ASC;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := make([]model.Webhook, 0)
+	for rows.Next() {
+		var (
+			w      model.Webhook
+			events string
+		)
+		if err := rows.Scan(&w.ID, &w.URL, &w.Secret, &events, &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		w.EventTypes = splitEventTypes(events)
+		webhooks = append(webhooks, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+// DeleteWebhook implements Storage interface.
+func (s Sqlite) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id=$1;`, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// CreateWebhookDelivery implements Storage interface.
+func (s Sqlite) CreateWebhookDelivery(ctx context.Context, d *model.WebhookDelivery) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO webhook_deliveries
+	(id, webhook_id, event_type, payload, status, attempts, last_status_code, last_error, next_attempt_at, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	ON CONFLICT (id) DO NOTHING;`,
+		d.ID, d.WebhookID, d.EventType, string(d.Payload), d.Status,
+		d.Attempts, d.LastStatusCode, d.LastError, d.NextAttemptAt, d.CreatedAt)
+
+	return err
+}
+
+// WebhookDeliveryByID implements Storage interface.
+func (s Sqlite) WebhookDeliveryByID(ctx context.Context, id uuid.UUID) (*model.WebhookDelivery, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+deliveryColumns+`
+	FROM webhook_deliveries WHERE id=$1;`, id)
+	d, err := scanDelivery(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// WebhookDeliveries implements Storage interface.
+func (s Sqlite) WebhookDeliveries(ctx context.Context, webhookID uuid.UUID) ([]model.WebhookDelivery, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+deliveryColumns+`
+	FROM webhook_deliveries WHERE webhook_id=$1 ORDER BY created_at ASC;`, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanDeliveries(rows)
+}
+
+// ClaimWebhookDeliveries implements Storage interface.
+func (s Sqlite) ClaimWebhookDeliveries(ctx context.Context, now, claimUntil time.Time, limit int) ([]model.WebhookDelivery, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	//nolint:errcheck
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `SELECT `+deliveryColumns+`
+	FROM webhook_deliveries WHERE status='PENDING' AND next_attempt_at <= $1
+	ORDER BY next_attempt_at ASC LIMIT $2;`, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	deliveries, err := scanDeliveries(rows)
+	if err != nil {
+		return nil, err
+	}
+	for i := range deliveries {
+		if _, err := tx.ExecContext(ctx, `UPDATE webhook_deliveries SET next_attempt_at=$1 WHERE id=$2;`,
+			claimUntil, deliveries[i].ID); err != nil {
+			return nil, err
+		}
+		deliveries[i].NextAttemptAt = claimUntil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].CreatedAt.Before(deliveries[j].CreatedAt) })
+
+	return deliveries, nil
+}
+
+// UpdateWebhookDelivery implements Storage interface.
+func (s Sqlite) UpdateWebhookDelivery(ctx context.Context, d *model.WebhookDelivery) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE webhook_deliveries SET
+	status=$1, attempts=$2, last_status_code=$3, last_error=$4, next_attempt_at=$5
+	WHERE id=$6;`, d.Status, d.Attempts, d.LastStatusCode, d.LastError, d.NextAttemptAt, d.ID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+const deliveryColumns = `id, webhook_id, event_type, payload, status,
+	attempts, last_status_code, last_error, next_attempt_at, created_at`
+
+// scanDelivery scans a row selected with deliveryColumns.
+func scanDelivery(row interface{ Scan(...interface{}) error }) (*model.WebhookDelivery, error) {
+	var (
+		d       model.WebhookDelivery
+		payload []byte
+	)
+	if err := row.Scan(&d.ID, &d.WebhookID, &d.EventType, &payload, &d.Status,
+		&d.Attempts, &d.LastStatusCode, &d.LastError, &d.NextAttemptAt, &d.CreatedAt); err != nil {
+		return nil, err
+	}
+	d.Payload = payload
+
+	return &d, nil
+}
+
+func scanDeliveries(rows *sql.Rows) ([]model.WebhookDelivery, error) {
+	defer rows.Close()
+
+	deliveries := make([]model.WebhookDelivery, 0)
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func joinEventTypes(events []model.EventType) string {
+	s := make([]string, len(events))
+	for i, e := range events {
+		s[i] = string(e)
+	}
+
+	return strings.Join(s, eventTypesSeparator)
+}
+
+func splitEventTypes(s string) []model.EventType {
+	events := make([]model.EventType, 0)
+	for _, e := range strings.Split(s, eventTypesSeparator) {
+		if e != "" {
+			events = append(events, model.EventType(e))
+		}
+	}
+
+	return events
+}
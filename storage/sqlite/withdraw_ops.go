@@ -0,0 +1,159 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+)
+
+// ProcessWithdraw implements Storage interface. The withdrawal is logged and the points are taken
+// from user's balance in the same transaction, so the withdrawals are never left in 'PROCESSING' status.
+func (s Sqlite) ProcessWithdraw(ctx context.Context, withdraw *model.Withdrawal) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	//nolint:errcheck
+	defer tx.Rollback()
+
+	// The transactions are serialized by the single connection, so the balance can't change until the commit.
+	// It's still taken by the conditional update below, so the balance never goes negative.
+	row := tx.QueryRowContext(ctx, `SELECT gpoints_balance FROM users WHERE id=$1;`, withdraw.UserID)
+	var balance float32
+	if err := row.Scan(&balance); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.ErrNotFound
+		}
+
+		return err
+	}
+
+	// The invalid withdrawals are kept in the log, but never reach the outbox.
+	var result error
+	withdraw.Status = model.StatusProcessed
+	eventType := model.EventWithdrawalProcessed
+	switch {
+	case withdraw.Sum < 0:
+		withdraw.Status, eventType, result = model.StatusInvalid, "", storage.ErrInvalidInput
+	case balance < withdraw.Sum:
+		withdraw.Status, eventType, result = model.StatusInvalid, model.EventWithdrawalRejected, storage.ErrInsufficientPoints
+	}
+
+	// Try to create a new entry in the withdrawals_log table. If the order has already been processed, return an error.
+	if _, err := tx.ExecContext(ctx, `INSERT INTO withdrawals_log (order_id, user_id, sum, status, processed_at)
+	VALUES ($1, $2, $3, $4, $5);`, withdraw.OrderID, withdraw.UserID, withdraw.Sum, withdraw.Status, withdraw.ProcessedAt); err != nil {
+		if isUniqueViolation(err) {
+			return storage.ErrAlreadyProcessed
+		}
+
+		return err
+	}
+	if withdraw.Status == model.StatusProcessed {
+		res, err := tx.ExecContext(ctx, `UPDATE users SET gpoints_balance = gpoints_balance - $1
+		WHERE id=$2 AND gpoints_balance >= $1;`, withdraw.Sum, withdraw.UserID)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return storage.ErrInsufficientPoints
+		}
+	}
+	if eventType != "" {
+		if err := insertEvent(ctx, tx, eventType, withdraw.OrderID.String(), model.WithdrawalEvent{
+			Order:       withdraw.OrderID,
+			UserID:      withdraw.UserID,
+			Sum:         withdraw.Sum,
+			Status:      withdraw.Status,
+			ProcessedAt: withdraw.ProcessedAt,
+		}); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	return result
+}
+
+// RecoverWithdrawals implements Storage interface.
+func (s Sqlite) RecoverWithdrawals(ctx context.Context, before time.Time) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	//nolint:errcheck
+	defer tx.Rollback()
+
+	// The withdrawals are selected before the update, since the driver parses times of selected columns only.
+	rows, err := tx.QueryContext(ctx, `SELECT order_id, user_id, sum, processed_at FROM withdrawals_log
+	WHERE status='PROCESSING' AND processed_at < $1;`, before)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	withdrawals := make([]model.Withdrawal, 0)
+	for rows.Next() {
+		w := model.Withdrawal{Status: model.StatusInvalid}
+		if err := rows.Scan(&w.OrderID, &w.UserID, &w.Sum, &w.ProcessedAt); err != nil {
+			return 0, err
+		}
+		withdrawals = append(withdrawals, w)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	rows.Close()
+
+	// The points of the orphaned withdrawals have never been taken, so the withdrawals are rejected.
+	for _, w := range withdrawals {
+		if _, err := tx.ExecContext(ctx, `UPDATE withdrawals_log SET status='INVALID' WHERE order_id=$1;`,
+			w.OrderID); err != nil {
+			return 0, err
+		}
+		if err := insertEvent(ctx, tx, model.EventWithdrawalRejected, w.OrderID.String(), model.WithdrawalEvent{
+			Order:       w.OrderID,
+			UserID:      w.UserID,
+			Sum:         w.Sum,
+			Status:      w.Status,
+			ProcessedAt: w.ProcessedAt,
+		}); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(withdrawals), tx.Commit()
+}
+
+// WithdrawalsByUserId implements Storage interface.
+func (s Sqlite) WithdrawalsByUserID(ctx context.Context, id uuid.UUID) ([]model.Withdrawal, error) {
+	withdrawals := make([]model.Withdrawal, 0)
+	rows, err := s.db.QueryContext(ctx, `SELECT order_id, user_id, sum, status, processed_at
+		FROM withdrawals_log WHERE user_id = $1 ORDER BY processed_at ASC;`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var w model.Withdrawal
+		if err := rows.Scan(&w.OrderID, &w.UserID, &w.Sum, &w.Status, &w.ProcessedAt); err != nil {
+			return nil, err
+		}
+		withdrawals = append(withdrawals, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return withdrawals, nil
+}
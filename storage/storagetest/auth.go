@@ -0,0 +1,267 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSessions(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	bob := newUser(t, s, 0)
+
+	// The sessions are returned the most recently seen first.
+	now := time.Now()
+	older := &model.Session{ID: uuid.New(), UserID: bob.ID, TokenHash: uuid.NewString(),
+		CreatedAt: now, LastSeenAt: now.Add(-time.Hour), ExpiresAt: now.Add(time.Hour)}
+	newer := &model.Session{ID: uuid.New(), UserID: bob.ID, TokenHash: uuid.NewString(),
+		CreatedAt: now, LastSeenAt: now, ExpiresAt: now.Add(time.Hour)}
+	for _, session := range []*model.Session{older, newer} {
+		require.NoError(t, s.CreateSession(ctx, session))
+	}
+	sessions, err := s.UserSessions(ctx, bob.ID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, newer.ID, sessions[0].ID)
+	assert.Equal(t, older.ID, sessions[1].ID)
+
+	require.NoError(t, s.TouchSession(ctx, older.ID, now.Add(time.Minute)))
+	sessions, err = s.UserSessions(ctx, bob.ID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, older.ID, sessions[0].ID, "the touched session goes first")
+
+	session, err := s.SessionByTokenHash(ctx, newer.TokenHash)
+	require.NoError(t, err)
+	assert.Equal(t, newer.ID, session.ID)
+	_, err = s.SessionByTokenHash(ctx, uuid.NewString())
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	_, err = s.SessionByID(ctx, uuid.New())
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	// The session can be deleted by its user only.
+	alice := newUser(t, s, 0)
+	assert.ErrorIs(t, s.DeleteSession(ctx, alice.ID, newer.ID), storage.ErrNotFound)
+	require.NoError(t, s.DeleteSession(ctx, bob.ID, newer.ID))
+	assert.ErrorIs(t, s.DeleteSession(ctx, bob.ID, newer.ID), storage.ErrNotFound)
+
+	// ChangePassword ends all the sessions but the current one.
+	require.NoError(t, s.CreateSession(ctx, newer))
+	require.NoError(t, s.ChangePassword(ctx, bob.ID, "new", newer.ID))
+	sessions, err = s.UserSessions(ctx, bob.ID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, newer.ID, sessions[0].ID)
+
+	// The sessions created with keyed hashes aren't upgraded.
+	_, err = s.UpgradeSessionHashes(ctx, func(digest string) string { return "hmac of " + digest })
+	require.NoError(t, err)
+	session, err = s.SessionByTokenHash(ctx, newer.TokenHash)
+	require.NoError(t, err)
+	assert.Equal(t, newer.ID, session.ID)
+
+	// The expired sessions are deleted.
+	expired := &model.Session{ID: uuid.New(), UserID: bob.ID, TokenHash: uuid.NewString(),
+		CreatedAt: now.Add(-2 * time.Hour), LastSeenAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Hour)}
+	require.NoError(t, s.CreateSession(ctx, expired))
+	n, err := s.DeleteExpiredSessions(ctx, now)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, n, 1)
+	_, err = s.SessionByID(ctx, expired.ID)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	_, err = s.SessionByID(ctx, newer.ID)
+	assert.NoError(t, err)
+}
+
+func testPasswordResets(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	bob := newUser(t, s, 0)
+	now := time.Now()
+
+	expired := &model.PasswordReset{ID: uuid.New(), UserID: bob.ID, TokenHash: uuid.NewString(),
+		CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Hour)}
+	valid := &model.PasswordReset{ID: uuid.New(), UserID: bob.ID, TokenHash: uuid.NewString(),
+		CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	for _, reset := range []*model.PasswordReset{expired, valid} {
+		require.NoError(t, s.CreatePasswordReset(ctx, reset))
+	}
+	require.NoError(t, s.CreateSession(ctx, &model.Session{ID: uuid.New(), UserID: bob.ID, TokenHash: uuid.NewString(),
+		CreatedAt: now, LastSeenAt: now, ExpiresAt: now.Add(time.Hour)}))
+
+	_, err := s.PasswordResetUser(ctx, expired.TokenHash, now)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	_, err = s.ResetPassword(ctx, expired.TokenHash, "new", now)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	u, err := s.PasswordResetUser(ctx, valid.TokenHash, now)
+	require.NoError(t, err)
+	assert.Equal(t, bob.ID, u.ID)
+
+	// The reset token is single-use, the sessions are ended.
+	userID, err := s.ResetPassword(ctx, valid.TokenHash, "new", now)
+	require.NoError(t, err)
+	assert.Equal(t, bob.ID, userID)
+	_, err = s.ResetPassword(ctx, valid.TokenHash, "newer", now)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	u, err = s.UserByID(ctx, bob.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "new", u.PasswordHash)
+	sessions, err := s.UserSessions(ctx, bob.ID)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	resets, err := s.UserPasswordResets(ctx, bob.ID)
+	require.NoError(t, err)
+	require.Len(t, resets, 2)
+	for _, r := range resets {
+		assert.NotNil(t, r.UsedAt, "all the resets are invalidated")
+	}
+}
+
+func testLoginThrottles(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	key := "login:" + uuid.NewString()
+	now := time.Now()
+
+	_, err := s.LoginThrottle(ctx, key)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	assert.ErrorIs(t, s.LockLogin(ctx, key, now.Add(time.Hour)), storage.ErrNotFound)
+
+	th, err := s.TakeLoginAttempt(ctx, key, 0, now, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, th.Failures)
+	_, err = s.TakeLoginAttempt(ctx, key, 0, now, time.Hour)
+	assert.ErrorIs(t, err, storage.ErrConflict, "the attempt checked against the stale info isn't taken")
+	th, err = s.TakeLoginAttempt(ctx, key, 1, now.Add(time.Minute), time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 2, th.Failures)
+	_, err = s.TakeLoginAttempt(ctx, key, 1, now.Add(time.Minute), time.Hour)
+	assert.ErrorIs(t, err, storage.ErrConflict)
+	th, err = s.TakeLoginAttempt(ctx, key, 2, now.Add(2*time.Hour), time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, th.Failures, "the failures out of the window are reset")
+
+	// The attempt that hasn't failed is taken back.
+	require.NoError(t, s.ForgiveLoginAttempt(ctx, key))
+	th, err = s.LoginThrottle(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, 0, th.Failures)
+	require.NoError(t, s.ForgiveLoginAttempt(ctx, key))
+	th, err = s.LoginThrottle(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, 0, th.Failures, "the failures don't go negative")
+	th, err = s.TakeLoginAttempt(ctx, key, 0, now.Add(2*time.Hour), time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, th.Failures)
+	require.NoError(t, s.ForgiveLoginAttempt(ctx, "ip:"+uuid.NewString()), "unknown keys are ignored")
+
+	require.NoError(t, s.LockLogin(ctx, key, now.Add(3*time.Hour)))
+	th, err = s.LoginThrottle(ctx, key)
+	require.NoError(t, err)
+	require.NotNil(t, th.LockedUntil)
+	assert.True(t, th.LockedUntil.After(now.Add(2*time.Hour)))
+
+	require.NoError(t, s.DeleteLoginThrottle(ctx, key))
+	_, err = s.LoginThrottle(ctx, key)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	// The keys neither failed nor locked since the time provided are deleted.
+	stale, failed, locked := "ip:"+uuid.NewString(), "ip:"+uuid.NewString(), "ip:"+uuid.NewString()
+	for _, k := range []string{stale, locked} {
+		_, err := s.TakeLoginAttempt(ctx, k, 0, now.Add(-2*time.Hour), time.Hour)
+		require.NoError(t, err)
+	}
+	_, err = s.TakeLoginAttempt(ctx, failed, 0, now, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, s.LockLogin(ctx, locked, now.Add(time.Hour)))
+	n, err := s.DeleteStaleLoginThrottles(ctx, now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, n, 1)
+	_, err = s.LoginThrottle(ctx, stale)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	for _, k := range []string{failed, locked} {
+		_, err := s.LoginThrottle(ctx, k)
+		assert.NoError(t, err, k)
+	}
+}
+
+func testTwoFactor(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	bob := newUser(t, s, 0)
+	now := time.Now()
+
+	_, err := s.TwoFactor(ctx, bob.ID)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	assert.ErrorIs(t, s.EnableTwoFactor(ctx, bob.ID, 1, now, nil), storage.ErrNotFound, "nothing is pending")
+
+	// The pending secret can be replaced until two-factor authentication is enabled.
+	require.NoError(t, s.SetPendingTwoFactor(ctx, bob.ID, "first"))
+	require.NoError(t, s.SetPendingTwoFactor(ctx, bob.ID, "second"))
+	require.NoError(t, s.EnableTwoFactor(ctx, bob.ID, 10, now, []string{"code1", "code2"}))
+	assert.ErrorIs(t, s.SetPendingTwoFactor(ctx, bob.ID, "third"), storage.ErrTwoFactorEnabled)
+	assert.ErrorIs(t, s.EnableTwoFactor(ctx, bob.ID, 11, now, nil), storage.ErrNotFound)
+	tf, err := s.TwoFactor(ctx, bob.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "second", tf.Secret)
+	assert.NotNil(t, tf.EnabledAt)
+	assert.Equal(t, int64(10), tf.LastUsedStep)
+
+	// The steps and the recovery codes can't be reused.
+	assert.ErrorIs(t, s.UseTOTPStep(ctx, bob.ID, 10), storage.ErrNotFound)
+	require.NoError(t, s.UseTOTPStep(ctx, bob.ID, 11))
+	assert.ErrorIs(t, s.UseTOTPStep(ctx, bob.ID, 11), storage.ErrNotFound)
+	require.NoError(t, s.UseRecoveryCode(ctx, bob.ID, "code1", now))
+	assert.ErrorIs(t, s.UseRecoveryCode(ctx, bob.ID, "code1", now), storage.ErrNotFound)
+	assert.ErrorIs(t, s.UseRecoveryCode(ctx, bob.ID, "unknown", now), storage.ErrNotFound)
+
+	challenge := &model.LoginChallenge{TokenHash: uuid.NewString(), UserID: bob.ID, CreatedAt: now,
+		ExpiresAt: now.Add(time.Minute)}
+	require.NoError(t, s.CreateLoginChallenge(ctx, challenge))
+	for i := 1; i <= 2; i++ {
+		c, err := s.TakeChallengeAttempt(ctx, challenge.TokenHash, 2, now)
+		require.NoError(t, err)
+		assert.Equal(t, bob.ID, c.UserID)
+		assert.Equal(t, i, c.Attempts)
+	}
+	_, err = s.TakeChallengeAttempt(ctx, challenge.TokenHash, 2, now)
+	assert.ErrorIs(t, err, storage.ErrNotFound, "no attempts left")
+	got, err := s.LoginChallenge(ctx, challenge.TokenHash)
+	require.NoError(t, err)
+	assert.Equal(t, 2, got.Attempts)
+	_, err = s.TakeChallengeAttempt(ctx, challenge.TokenHash, 5, now.Add(time.Minute))
+	assert.ErrorIs(t, err, storage.ErrNotFound, "the challenge is expired")
+	_, err = s.TakeChallengeAttempt(ctx, uuid.NewString(), 5, now)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	// The challenges are deleted once used or expired.
+	used := &model.LoginChallenge{TokenHash: uuid.NewString(), UserID: bob.ID, CreatedAt: now,
+		ExpiresAt: now.Add(time.Minute)}
+	require.NoError(t, s.CreateLoginChallenge(ctx, used))
+	require.NoError(t, s.DeleteLoginChallenge(ctx, used.TokenHash))
+	_, err = s.LoginChallenge(ctx, used.TokenHash)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	expired := &model.LoginChallenge{TokenHash: uuid.NewString(), UserID: bob.ID, CreatedAt: now.Add(-time.Hour),
+		ExpiresAt: now.Add(-time.Minute)}
+	require.NoError(t, s.CreateLoginChallenge(ctx, expired))
+	n, err := s.DeleteExpiredLoginChallenges(ctx, now)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, n, 1)
+	_, err = s.LoginChallenge(ctx, expired.TokenHash)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	_, err = s.LoginChallenge(ctx, challenge.TokenHash)
+	assert.NoError(t, err, "the challenge isn't expired yet")
+
+	// Disabling deletes the recovery codes and the challenges.
+	require.NoError(t, s.DisableTwoFactor(ctx, bob.ID))
+	_, err = s.TwoFactor(ctx, bob.ID)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	_, err = s.LoginChallenge(ctx, challenge.TokenHash)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	assert.ErrorIs(t, s.UseRecoveryCode(ctx, bob.ID, "code2", now), storage.ErrNotFound)
+}
@@ -0,0 +1,161 @@
+package storagetest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testWithdrawals(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	dave := newUser(t, s, 300)
+
+	withdrawals, err := s.WithdrawalsByUserID(ctx, dave.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, withdrawals, "empty slice is returned")
+	assert.Empty(t, withdrawals)
+
+	first, second := model.OrderID(uuid.NewString()), model.OrderID(uuid.NewString())
+	tt := []struct {
+		name    string
+		orderID model.OrderID
+		sum     float32
+		wantErr error
+	}{
+		{name: "#1 Dave withdraws 200", orderID: first, sum: 200},
+		{name: "#2 The same order as #1", orderID: first, sum: 50, wantErr: storage.ErrAlreadyProcessed},
+		{name: "#3 Dave withdraws another 200", orderID: second, sum: 200, wantErr: storage.ErrInsufficientPoints},
+		{name: "#4 sum < 0", orderID: model.OrderID(uuid.NewString()), sum: -100, wantErr: storage.ErrInvalidInput},
+	}
+	now := time.Now()
+	for i, tc := range tt {
+		err := s.ProcessWithdraw(ctx, &model.Withdrawal{
+			UserID:      dave.ID,
+			OrderID:     tc.orderID,
+			Sum:         tc.sum,
+			ProcessedAt: now.Add(time.Duration(i) * time.Second),
+		})
+		assert.ErrorIs(t, err, tc.wantErr, tc.name)
+	}
+
+	assert.Equal(t, float32(100), balance(t, s, dave.ID))
+	withdrawals, err = s.WithdrawalsByUserID(ctx, dave.ID)
+	require.NoError(t, err)
+	require.Len(t, withdrawals, 3, "the rejected withdrawals are kept in the log")
+	for i, status := range []model.Status{model.StatusProcessed, model.StatusInvalid, model.StatusInvalid} {
+		assert.Equal(t, status, withdrawals[i].Status)
+	}
+	assert.Equal(t, first, withdrawals[0].OrderID)
+	assert.Equal(t, float32(200), withdrawals[0].Sum)
+	assert.ErrorIs(t, s.ProcessWithdraw(ctx, &model.Withdrawal{UserID: uuid.New(), OrderID: model.OrderID(uuid.NewString()),
+		Sum: 10, ProcessedAt: now}), storage.ErrNotFound)
+
+	// The withdrawals are processed atomically, so there's nothing to recover.
+	n, err := s.RecoverWithdrawals(ctx, now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	withdrawals, err = s.WithdrawalsByUserID(ctx, dave.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusProcessed, withdrawals[0].Status)
+}
+
+func testWithdrawalsOrder(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	dave := newUser(t, s, 300)
+
+	// The withdrawals are returned in the order of processing time rather than creation.
+	now := time.Now()
+	offsets := []time.Duration{time.Minute, -time.Minute, 0}
+	for _, offset := range offsets {
+		require.NoError(t, s.ProcessWithdraw(ctx, &model.Withdrawal{
+			UserID:      dave.ID,
+			OrderID:     model.OrderID(uuid.NewString()),
+			Sum:         10,
+			ProcessedAt: now.Add(offset),
+		}))
+	}
+	withdrawals, err := s.WithdrawalsByUserID(ctx, dave.ID)
+	require.NoError(t, err)
+	require.Len(t, withdrawals, len(offsets))
+	for i := 1; i < len(withdrawals); i++ {
+		assert.True(t, withdrawals[i-1].ProcessedAt.Before(withdrawals[i].ProcessedAt),
+			"the withdrawals are ordered by processing time")
+	}
+	for _, w := range withdrawals {
+		assert.Equal(t, dave.ID, w.UserID)
+		assert.Equal(t, model.StatusProcessed, w.Status)
+	}
+}
+
+func testConcurrentWithdrawals(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	dave := newUser(t, s, 100)
+
+	const attempts = 50
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+	)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := s.ProcessWithdraw(ctx, &model.Withdrawal{
+				UserID:      dave.ID,
+				OrderID:     model.OrderID(uuid.NewString()),
+				Sum:         10,
+				ProcessedAt: time.Now(),
+			})
+			if err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+
+				return
+			}
+			assert.ErrorIs(t, err, storage.ErrInsufficientPoints)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 10, succeeded)
+	assert.Zero(t, balance(t, s, dave.ID), "the balance never gets negative")
+	withdrawals, err := s.WithdrawalsByUserID(ctx, dave.ID)
+	require.NoError(t, err)
+	assert.Len(t, withdrawals, attempts)
+}
+
+func testAdjustments(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	bob := newUser(t, s, 100)
+	admin := newUser(t, s, 0)
+
+	adjustments, err := s.AdjustmentsByUserID(ctx, bob.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, adjustments, "empty slice is returned")
+	assert.Empty(t, adjustments)
+
+	now := time.Now()
+	require.NoError(t, s.CreateAdjustment(ctx, &model.Adjustment{ID: uuid.New(), UserID: bob.ID, AdminID: admin.ID,
+		Sum: -40, Reason: "refund", CreatedAt: now}))
+	assert.ErrorIs(t, s.CreateAdjustment(ctx, &model.Adjustment{ID: uuid.New(), UserID: bob.ID, AdminID: admin.ID,
+		Sum: -100, Reason: "refund", CreatedAt: now.Add(time.Second)}), storage.ErrInsufficientPoints)
+	assert.ErrorIs(t, s.CreateAdjustment(ctx, &model.Adjustment{ID: uuid.New(), UserID: uuid.New(), AdminID: admin.ID,
+		Sum: 10, Reason: "bonus", CreatedAt: now}), storage.ErrNotFound)
+	assert.Equal(t, float32(60), balance(t, s, bob.ID))
+
+	adjustments, err = s.AdjustmentsByUserID(ctx, bob.ID)
+	require.NoError(t, err)
+	require.Len(t, adjustments, 1, "the rejected adjustments aren't logged")
+	assert.Equal(t, float32(-40), adjustments[0].Sum)
+	assert.Equal(t, admin.ID, adjustments[0].AdminID)
+}
@@ -0,0 +1,65 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testMerchants(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	now := time.Now()
+
+	merchant := &model.Merchant{ID: uuid.New(), Name: "merchant-" + uuid.NewString(), CreatedAt: now}
+	require.NoError(t, s.CreateMerchant(ctx, merchant))
+	assert.ErrorIs(t, s.CreateMerchant(ctx, &model.Merchant{ID: uuid.New(), Name: merchant.Name, CreatedAt: now}),
+		storage.ErrMerchantAlreadyExists)
+	m, err := s.MerchantByID(ctx, merchant.ID)
+	require.NoError(t, err)
+	assert.Equal(t, merchant.Name, m.Name)
+	_, err = s.MerchantByID(ctx, uuid.New())
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	merchants, err := s.Merchants(ctx)
+	require.NoError(t, err)
+	var found bool
+	for _, m := range merchants {
+		if m.ID == merchant.ID {
+			found = true
+			assert.Equal(t, merchant.Name, m.Name)
+		}
+	}
+	assert.True(t, found)
+
+	keys, err := s.MerchantAPIKeys(ctx, merchant.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, keys, "empty slice is returned")
+	assert.Empty(t, keys)
+
+	key := &model.APIKey{ID: uuid.New(), MerchantID: merchant.ID, Prefix: "gm_test", KeyHash: uuid.NewString(),
+		Scopes: []model.Scope{model.ScopeOrdersSubmit}, CreatedAt: now}
+	require.NoError(t, s.CreateAPIKey(ctx, key))
+	require.NoError(t, s.TouchAPIKey(ctx, key.ID, now.Add(time.Minute)))
+	k, err := s.APIKeyByHash(ctx, key.KeyHash)
+	require.NoError(t, err)
+	assert.Equal(t, key.Scopes, k.Scopes)
+	assert.NotNil(t, k.LastUsedAt)
+	assert.Nil(t, k.RevokedAt)
+	_, err = s.APIKeyByHash(ctx, uuid.NewString())
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	// The key is revoked by its merchant once.
+	assert.ErrorIs(t, s.RevokeAPIKey(ctx, uuid.New(), key.ID, now), storage.ErrNotFound)
+	require.NoError(t, s.RevokeAPIKey(ctx, merchant.ID, key.ID, now))
+	assert.ErrorIs(t, s.RevokeAPIKey(ctx, merchant.ID, key.ID, now), storage.ErrNotFound)
+	keys, err = s.MerchantAPIKeys(ctx, merchant.ID)
+	require.NoError(t, err)
+	require.Len(t, keys, 1, "the revoked keys are returned")
+	assert.NotNil(t, keys[0].RevokedAt)
+}
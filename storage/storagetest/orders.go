@@ -0,0 +1,152 @@
+package storagetest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testOrders(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	bob := newUser(t, s, 0)
+
+	orders, err := s.UserOrders(ctx, bob.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, orders, "empty slice is returned")
+	assert.Empty(t, orders)
+
+	// The orders are returned in the order of uploading.
+	now := time.Now()
+	second := newOrder(t, s, bob.ID, now)
+	first := newOrder(t, s, bob.ID, now.Add(-time.Minute))
+	assert.ErrorIs(t, s.CreateOrder(ctx, &model.Order{ID: first.ID, UserID: bob.ID, Status: model.StatusNew,
+		UploadedAt: now}), storage.ErrAlreadyProcessed, "order numbers are unique")
+	orders, err = s.UserOrders(ctx, bob.ID)
+	require.NoError(t, err)
+	require.Len(t, orders, 2)
+	assert.Equal(t, first.ID, orders[0].ID)
+	assert.Equal(t, second.ID, orders[1].ID)
+
+	require.NoError(t, s.UpdateOrderStatus(ctx, first.ID, model.StatusProcessing))
+	o, err := s.OrderByID(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusProcessing, o.Status)
+	assert.Equal(t, bob.ID, o.UserID)
+	orders, err = s.OrdersByStatus(ctx, model.StatusProcessing)
+	require.NoError(t, err)
+	assert.Contains(t, orderIDs(orders), first.ID)
+	assert.NotContains(t, orderIDs(orders), second.ID)
+
+	_, err = s.OrderByID(ctx, model.OrderID(uuid.NewString()))
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	assert.ErrorIs(t, s.UpdateOrderStatus(ctx, model.OrderID(uuid.NewString()), model.StatusProcessing), storage.ErrNotFound)
+}
+
+func testAccruals(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	bob := newUser(t, s, 0)
+	order := newOrder(t, s, bob.ID, time.Now())
+
+	assert.ErrorIs(t, s.CreateAccrual(ctx, model.OrderID(uuid.NewString()), 100), storage.ErrNotFound)
+
+	// The accrual of the order is created once.
+	require.NoError(t, s.CreateAccrual(ctx, order.ID, 100))
+	assert.ErrorIs(t, s.CreateAccrual(ctx, order.ID, 100), storage.ErrAlreadyProcessed)
+	o, err := s.OrderByID(ctx, order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusProcessed, o.Status)
+	assert.Equal(t, float32(100), o.AccrualPoints)
+
+	// The points are added to the balance by UpdateBalance only once.
+	assert.Zero(t, balance(t, s, bob.ID))
+	n, err := s.UpdateBalance(ctx)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, n, 1)
+	n, err = s.UpdateBalance(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Equal(t, float32(100), balance(t, s, bob.ID))
+}
+
+func testConcurrentAccruals(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	bob := newUser(t, s, 0)
+	order := newOrder(t, s, bob.ID, time.Now())
+
+	const attempts = 10
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+	)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := s.CreateAccrual(ctx, order.ID, 10)
+			if err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+
+				return
+			}
+			assert.ErrorIs(t, err, storage.ErrAlreadyProcessed)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, succeeded, "the accrual is created once")
+
+	_, err := s.UpdateBalance(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, float32(10), balance(t, s, bob.ID), "the accrual is credited once")
+}
+
+func testConcurrentUpdateBalance(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	bob := newUser(t, s, 0)
+
+	const orders = 20
+	for i := 0; i < orders; i++ {
+		order := newOrder(t, s, bob.ID, time.Now())
+		require.NoError(t, s.CreateAccrual(ctx, order.ID, 10))
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		processed int
+	)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n, err := s.UpdateBalance(ctx)
+			assert.NoError(t, err)
+			mu.Lock()
+			processed += n
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	assert.GreaterOrEqual(t, processed, orders)
+	assert.Equal(t, float32(orders*10), balance(t, s, bob.ID), "each accrual is credited once")
+}
+
+func orderIDs(orders []model.Order) []model.OrderID {
+	ids := make([]model.OrderID, len(orders))
+	for i, o := range orders {
+		ids[i] = o.ID
+	}
+
+	return ids
+}
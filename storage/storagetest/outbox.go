@@ -0,0 +1,182 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testOutbox(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	publishEvents(t, s)
+	bob := newUser(t, s, 100)
+	admin := newUser(t, s, 0)
+	order := &model.Order{ID: model.OrderID(uuid.NewString()), UserID: bob.ID, Status: model.StatusNew,
+		UploadedAt: time.Now()}
+	withdrawal := model.OrderID(uuid.NewString())
+	rejected := model.OrderID(uuid.NewString())
+
+	// Each state change writes exactly one event; the failed operations and the reads write none.
+	tt := []struct {
+		name          string
+		change        func() error
+		wantType      model.EventType
+		wantAggregate string
+	}{
+		{
+			name:          "#1 CreateOrder",
+			change:        func() error { return s.CreateOrder(ctx, order) },
+			wantType:      model.EventOrderCreated,
+			wantAggregate: order.ID.String(),
+		},
+		{
+			name: "#2 CreateOrder of the existing order",
+			change: func() error {
+				assert.ErrorIs(t, s.CreateOrder(ctx, order), storage.ErrAlreadyProcessed)
+				return nil
+			},
+		},
+		{
+			name:          "#3 UpdateOrderStatus",
+			change:        func() error { return s.UpdateOrderStatus(ctx, order.ID, model.StatusProcessing) },
+			wantType:      model.EventOrderStatusChanged,
+			wantAggregate: order.ID.String(),
+		},
+		{
+			name:          "#4 CreateAccrual",
+			change:        func() error { return s.CreateAccrual(ctx, order.ID, 50) },
+			wantType:      model.EventOrderProcessed,
+			wantAggregate: order.ID.String(),
+		},
+		{
+			name: "#5 UpdateBalance",
+			change: func() error {
+				_, err := s.UpdateBalance(ctx)
+				return err
+			},
+			wantType:      model.EventBalanceCredited,
+			wantAggregate: bob.ID.String(),
+		},
+		{
+			name: "#6 ProcessWithdraw",
+			change: func() error {
+				return s.ProcessWithdraw(ctx, &model.Withdrawal{UserID: bob.ID, OrderID: withdrawal, Sum: 100,
+					ProcessedAt: time.Now()})
+			},
+			wantType:      model.EventWithdrawalProcessed,
+			wantAggregate: withdrawal.String(),
+		},
+		{
+			name: "#7 ProcessWithdraw with insufficient points",
+			change: func() error {
+				err := s.ProcessWithdraw(ctx, &model.Withdrawal{UserID: bob.ID, OrderID: rejected, Sum: 100,
+					ProcessedAt: time.Now()})
+				assert.ErrorIs(t, err, storage.ErrInsufficientPoints)
+				return nil
+			},
+			wantType:      model.EventWithdrawalRejected,
+			wantAggregate: rejected.String(),
+		},
+		{
+			name: "#8 RecoverWithdrawals without orphaned withdrawals",
+			change: func() error {
+				_, err := s.RecoverWithdrawals(ctx, time.Now())
+				return err
+			},
+		},
+		{
+			name: "#9 CreateAdjustment",
+			change: func() error {
+				return s.CreateAdjustment(ctx, &model.Adjustment{ID: uuid.New(), UserID: bob.ID, AdminID: admin.ID,
+					Sum: 10, Reason: "compensation", CreatedAt: time.Now()})
+			},
+			wantType:      model.EventBalanceAdjusted,
+			wantAggregate: bob.ID.String(),
+		},
+		{
+			name: "#10 CreateAdjustment with insufficient points",
+			change: func() error {
+				err := s.CreateAdjustment(ctx, &model.Adjustment{ID: uuid.New(), UserID: bob.ID, AdminID: admin.ID,
+					Sum: -1000, Reason: "refund", CreatedAt: time.Now()})
+				assert.ErrorIs(t, err, storage.ErrInsufficientPoints)
+				return nil
+			},
+		},
+	}
+	for _, tc := range tt {
+		require.NoError(t, tc.change(), tc.name)
+		events := publishEvents(t, s)
+		if tc.wantType == "" {
+			assert.Empty(t, events, tc.name)
+			continue
+		}
+		if assert.Len(t, events, 1, tc.name) {
+			assert.Equal(t, tc.wantType, events[0].Type, tc.name)
+			assert.Equal(t, tc.wantAggregate, events[0].AggregateID, tc.name)
+			assert.Contains(t, string(events[0].Payload), bob.ID.String(), tc.name)
+		}
+	}
+}
+
+func testOutboxClaims(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	publishEvents(t, s)
+	bob := newUser(t, s, 0)
+	first := newOrder(t, s, bob.ID, time.Now())
+	second := newOrder(t, s, bob.ID, time.Now())
+
+	// The events are returned in order and claimed up to the limit.
+	now := time.Now()
+	claimUntil := now.Add(time.Minute)
+	events, err := s.ClaimUnpublishedEvents(ctx, now, claimUntil, 1)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, first.ID.String(), events[0].AggregateID)
+	claimed := events[0]
+	events, err = s.ClaimUnpublishedEvents(ctx, now, claimUntil, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1, "the claimed events are skipped")
+	assert.Equal(t, second.ID.String(), events[0].AggregateID)
+	assert.Less(t, claimed.ID, events[0].ID)
+	events, err = s.ClaimUnpublishedEvents(ctx, now, claimUntil, 10)
+	require.NoError(t, err)
+	assert.NotNil(t, events, "empty slice is returned")
+	assert.Empty(t, events)
+
+	// The claims expire unless the events are published.
+	require.NoError(t, s.MarkEventsPublished(ctx, []int64{claimed.ID}, now))
+	events, err = s.ClaimUnpublishedEvents(ctx, claimUntil, claimUntil.Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, second.ID.String(), events[0].AggregateID)
+	require.NoError(t, s.MarkEventsPublished(ctx, []int64{events[0].ID}, now))
+	events, err = s.ClaimUnpublishedEvents(ctx, claimUntil.Add(time.Hour), claimUntil.Add(2*time.Hour), 10)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+// publishEvents claims all the unpublished events, marks them as published and returns them.
+func publishEvents(t *testing.T, s storage.Storage) []model.Event {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Now()
+	events, err := s.ClaimUnpublishedEvents(ctx, now, now.Add(time.Minute), 1000)
+	require.NoError(t, err)
+	if len(events) == 0 {
+		return events
+	}
+	ids := make([]int64, len(events))
+	for i, e := range events {
+		ids[i] = e.ID
+	}
+	require.NoError(t, s.MarkEventsPublished(ctx, ids, now))
+
+	return events
+}
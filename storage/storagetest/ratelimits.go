@@ -0,0 +1,57 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRateLimits(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	key := "ip:" + uuid.NewString()
+	limit := model.RateLimit{Requests: 2, Per: time.Minute}
+	now := time.Now().Truncate(time.Millisecond)
+
+	// The bucket is full at first; the tokens are refilled with the rate of the limit.
+	for _, remaining := range []int{1, 0} {
+		res, err := s.TakeRateLimitToken(ctx, key, limit, now)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, 2, res.Limit)
+		assert.Equal(t, remaining, res.Remaining)
+	}
+	res, err := s.TakeRateLimitToken(ctx, key, limit, now)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 30*time.Second, res.RetryAfter)
+	assert.Equal(t, time.Minute, res.Reset)
+	res, err = s.TakeRateLimitToken(ctx, key, limit, now.Add(30*time.Second))
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+
+	// Other keys have their own buckets.
+	res, err = s.TakeRateLimitToken(ctx, "ip:"+uuid.NewString(), limit, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Remaining)
+
+	// The bucket is deleted once it's full.
+	_, err = s.DeleteFullRateLimits(ctx, now.Add(time.Minute))
+	require.NoError(t, err)
+	res, err = s.TakeRateLimitToken(ctx, key, limit, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 0, res.Remaining, "the bucket isn't full yet")
+	n, err := s.DeleteFullRateLimits(ctx, now.Add(3*time.Minute))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, n, 1)
+	res, err = s.TakeRateLimitToken(ctx, key, model.RateLimit{Requests: 5, Per: time.Minute}, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 4, res.Remaining, "the deleted bucket starts full")
+}
@@ -0,0 +1,94 @@
+// Package storagetest verifies that an implementation of storage.Storage fulfils the contracts of the interface:
+// the error sentinels, the ordering of the lists, the atomicity of the balance operations and the events written
+// into the outbox. A backend proves
+// its correctness by calling Run from its tests.
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory returns the storage to test. It's called by each test. The storage may be shared by the tests:
+// each test creates its own users, orders and other entities with unique ids and logins. The factory is
+// responsible for closing the storage, e.g. with t.Cleanup.
+type Factory func(t *testing.T) storage.Storage
+
+// Run runs the conformance tests against the storages returned by the factory.
+func Run(t *testing.T, newStorage Factory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, s storage.Storage)
+	}{
+		{name: "Users", test: testUsers},
+		{name: "DeleteUser", test: testDeleteUser},
+		{name: "Orders", test: testOrders},
+		{name: "Accruals", test: testAccruals},
+		{name: "ConcurrentAccruals", test: testConcurrentAccruals},
+		{name: "ConcurrentUpdateBalance", test: testConcurrentUpdateBalance},
+		{name: "Withdrawals", test: testWithdrawals},
+		{name: "WithdrawalsOrder", test: testWithdrawalsOrder},
+		{name: "ConcurrentWithdrawals", test: testConcurrentWithdrawals},
+		{name: "Adjustments", test: testAdjustments},
+		{name: "Sessions", test: testSessions},
+		{name: "PasswordResets", test: testPasswordResets},
+		{name: "LoginThrottles", test: testLoginThrottles},
+		{name: "TwoFactor", test: testTwoFactor},
+		{name: "Merchants", test: testMerchants},
+		{name: "Webhooks", test: testWebhooks},
+		{name: "RateLimits", test: testRateLimits},
+		{name: "Outbox", test: testOutbox},
+		{name: "OutboxClaims", test: testOutboxClaims},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStorage(t))
+		})
+	}
+}
+
+// newUser creates a user with a unique login and the balance provided.
+func newUser(t *testing.T, s storage.Storage, balance float32) model.User {
+	t.Helper()
+	u := model.User{
+		ID:             uuid.New(),
+		Login:          "user-" + uuid.NewString(),
+		PasswordHash:   "qWeRtYuIoP",
+		CreatedAt:      time.Now(),
+		GPointsBalance: balance,
+	}
+	require.NoError(t, s.CreateUser(context.Background(), u))
+
+	return u
+}
+
+// newOrder creates the user's order with a unique number.
+func newOrder(t *testing.T, s storage.Storage, userID uuid.UUID, uploadedAt time.Time) *model.Order {
+	t.Helper()
+	o := &model.Order{
+		ID:         model.OrderID(uuid.NewString()),
+		UserID:     userID,
+		Status:     model.StatusNew,
+		UploadedAt: uploadedAt,
+	}
+	require.NoError(t, s.CreateOrder(context.Background(), o))
+
+	return o
+}
+
+// balance returns the user's balance.
+func balance(t *testing.T, s storage.Storage, userID uuid.UUID) float32 {
+	t.Helper()
+	u, err := s.UserByID(context.Background(), userID)
+	require.NoError(t, err)
+
+	return u.GPointsBalance
+}
@@ -0,0 +1,153 @@
+package storagetest
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testUsers(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	bob := newUser(t, s, 0)
+
+	assert.ErrorIs(t, s.CreateUser(ctx, model.User{ID: uuid.New(), Login: bob.Login, CreatedAt: time.Now()}),
+		storage.ErrLoginAlreadyExists)
+	u, err := s.UserByLogin(ctx, bob.Login)
+	require.NoError(t, err)
+	assert.Equal(t, bob.ID, u.ID)
+	assert.Equal(t, model.RoleUser, u.Role, "the default role is set")
+	assert.Empty(t, u.Permissions)
+	assert.WithinDuration(t, bob.CreatedAt, u.CreatedAt, time.Microsecond)
+	_, err = s.UserByLogin(ctx, "user-"+uuid.NewString())
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	_, err = s.UserByID(ctx, uuid.New())
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	users, err := s.SearchUsers(ctx, uuid.NewString(), 10)
+	require.NoError(t, err)
+	assert.NotNil(t, users, "empty slice is returned")
+	assert.Empty(t, users)
+	users, err = s.SearchUsers(ctx, bob.Login[len("user-"):], 10)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, bob.ID, users[0].ID)
+
+	// The wildcards are escaped, the case is ignored.
+	suffix := uuid.NewString()
+	for _, login := range []string{"bob_" + suffix, "bobx" + suffix} {
+		require.NoError(t, s.CreateUser(ctx, model.User{ID: uuid.New(), Login: login, CreatedAt: time.Now()}))
+	}
+	users, err = s.SearchUsers(ctx, strings.ToUpper("B_"+suffix), 10)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, "bob_"+suffix, users[0].Login)
+
+	require.NoError(t, s.SetUserRole(ctx, bob.ID, model.RoleSupport, []model.Permission{model.PermUsersRead}))
+	require.NoError(t, s.SetUserBlocked(ctx, bob.ID, true))
+	u, err = s.UserByID(ctx, bob.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.RoleSupport, u.Role)
+	assert.Equal(t, []model.Permission{model.PermUsersRead}, u.Permissions)
+	assert.True(t, u.Blocked)
+	assert.ErrorIs(t, s.SetUserRole(ctx, uuid.New(), model.RoleSupport, nil), storage.ErrNotFound)
+	assert.ErrorIs(t, s.SetUserBlocked(ctx, uuid.New(), true), storage.ErrNotFound)
+
+	// The storage doesn't share the data with the callers.
+	u.Permissions[0] = model.PermUsersBlock
+	u, err = s.UserByID(ctx, bob.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []model.Permission{model.PermUsersRead}, u.Permissions)
+
+	// UpdateUser changes the login and the password hash only.
+	bob.Login = "user-" + uuid.NewString()
+	bob.PasswordHash = "updated"
+	bob.GPointsBalance = 1000
+	require.NoError(t, s.UpdateUser(ctx, bob))
+	u, err = s.UserByLogin(ctx, bob.Login)
+	require.NoError(t, err)
+	assert.Equal(t, bob.ID, u.ID)
+	assert.Equal(t, "updated", u.PasswordHash)
+	assert.Zero(t, u.GPointsBalance)
+	assert.Equal(t, model.RoleSupport, u.Role)
+	alice := newUser(t, s, 0)
+	alice.Login = bob.Login
+	assert.ErrorIs(t, s.UpdateUser(ctx, alice), storage.ErrLoginAlreadyExists)
+	assert.ErrorIs(t, s.UpdateUser(ctx, model.User{ID: uuid.New(), Login: "user-" + uuid.NewString()}),
+		storage.ErrNotFound)
+
+	// The hash is replaced only if it hasn't been changed.
+	assert.ErrorIs(t, s.UpdatePasswordHash(ctx, bob.ID, "wrong", "new"), storage.ErrNotFound)
+	require.NoError(t, s.UpdatePasswordHash(ctx, bob.ID, "updated", "new"))
+	u, err = s.UserByID(ctx, bob.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "new", u.PasswordHash)
+}
+
+func testDeleteUser(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	bob := newUser(t, s, 100)
+	order := newOrder(t, s, bob.ID, time.Now())
+	now := time.Now()
+	require.NoError(t, s.CreateSession(ctx, &model.Session{ID: uuid.New(), UserID: bob.ID, TokenHash: uuid.NewString(),
+		CreatedAt: now, LastSeenAt: now, ExpiresAt: now.Add(time.Hour)}))
+	admin := newUser(t, s, 0)
+	require.NoError(t, s.CreateAdjustment(ctx, &model.Adjustment{ID: uuid.New(), UserID: bob.ID, AdminID: admin.ID,
+		Sum: 10, Reason: "compensation", CreatedAt: now}))
+	webhook := &model.Webhook{ID: uuid.New(), URL: "https://partner.example/hook", Secret: "secret",
+		EventTypes: []model.EventType{model.EventOrderProcessed}, CreatedAt: now}
+	require.NoError(t, s.CreateWebhook(ctx, webhook))
+	payload, err := json.Marshal(map[string]interface{}{
+		"event": model.EventOrderProcessed,
+		"data":  model.OrderEvent{Order: order.ID, UserID: bob.ID, Status: model.StatusProcessed},
+	})
+	require.NoError(t, err)
+	delivery := &model.WebhookDelivery{ID: uuid.New(), WebhookID: webhook.ID, EventType: model.EventOrderProcessed,
+		Payload: payload, Status: model.DeliveryPending, NextAttemptAt: now, CreatedAt: now}
+	require.NoError(t, s.CreateWebhookDelivery(ctx, delivery))
+
+	assert.ErrorIs(t, s.DeleteUser(ctx, uuid.New(), uuid.New(), now), storage.ErrNotFound)
+	pseudonymID := uuid.New()
+	require.NoError(t, s.DeleteUser(ctx, bob.ID, pseudonymID, now))
+	_, err = s.UserByID(ctx, bob.ID)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	sessions, err := s.UserSessions(ctx, bob.ID)
+	require.NoError(t, err)
+	assert.Empty(t, sessions, "the sessions are deleted")
+
+	// The tombstone keeps the balance and the orders.
+	u, err := s.UserByID(ctx, pseudonymID)
+	require.NoError(t, err)
+	assert.Equal(t, model.DeletedUserLogin(pseudonymID), u.Login)
+	assert.Equal(t, float32(110), u.GPointsBalance)
+	assert.True(t, u.Blocked)
+	require.NotNil(t, u.DeletedAt)
+	o, err := s.OrderByID(ctx, order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, pseudonymID, o.UserID)
+
+	// The user's id in the events and the webhook deliveries is replaced with the pseudonymous one.
+	events, err := s.ClaimUnpublishedEvents(ctx, time.Now(), time.Now(), 1000)
+	require.NoError(t, err)
+	pseudonymous := 0
+	for _, e := range events {
+		assert.NotEqual(t, bob.ID.String(), e.AggregateID)
+		assert.NotContains(t, string(e.Payload), bob.ID.String())
+		if strings.Contains(string(e.Payload), pseudonymID.String()) {
+			pseudonymous++
+		}
+	}
+	assert.Equal(t, 2, pseudonymous, "order.created and balance.adjusted events")
+	d, err := s.WebhookDeliveryByID(ctx, delivery.ID)
+	require.NoError(t, err)
+	assert.NotContains(t, string(d.Payload), bob.ID.String())
+	assert.Contains(t, string(d.Payload), pseudonymID.String())
+}
@@ -0,0 +1,93 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testWebhooks(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	now := time.Now()
+
+	webhook := &model.Webhook{ID: uuid.New(), URL: "http://localhost/" + uuid.NewString(), Secret: "secret",
+		EventTypes: []model.EventType{model.EventOrderCreated, model.EventBalanceCredited}, CreatedAt: now}
+	require.NoError(t, s.CreateWebhook(ctx, webhook))
+	webhooks, err := s.Webhooks(ctx)
+	require.NoError(t, err)
+	var found bool
+	for _, w := range webhooks {
+		if w.ID == webhook.ID {
+			found = true
+			assert.Equal(t, webhook.EventTypes, w.EventTypes)
+		}
+	}
+	assert.True(t, found)
+
+	// The deliveries are returned in the order of creation; the due ones are pending and not scheduled later.
+	later := &model.WebhookDelivery{ID: uuid.New(), WebhookID: webhook.ID, EventType: model.EventOrderCreated,
+		Payload: []byte(`{"order":"1"}`), Status: model.DeliveryPending, NextAttemptAt: now.Add(time.Hour), CreatedAt: now}
+	due := &model.WebhookDelivery{ID: uuid.New(), WebhookID: webhook.ID, EventType: model.EventOrderCreated,
+		Payload: []byte(`{"order":"2"}`), Status: model.DeliveryPending, NextAttemptAt: now, CreatedAt: now.Add(time.Second)}
+	for _, d := range []*model.WebhookDelivery{later, due} {
+		require.NoError(t, s.CreateWebhookDelivery(ctx, d))
+	}
+	// The delivery queued again isn't duplicated.
+	again := *due
+	again.Payload = []byte(`{"order":"3"}`)
+	require.NoError(t, s.CreateWebhookDelivery(ctx, &again))
+	deliveries, err := s.WebhookDeliveries(ctx, webhook.ID)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, later.ID, deliveries[0].ID)
+	assert.Equal(t, due.ID, deliveries[1].ID)
+	assert.JSONEq(t, string(due.Payload), string(deliveries[1].Payload))
+
+	// The claimed deliveries are skipped until the claim expires.
+	claimUntil := now.Add(30 * time.Minute)
+	deliveries, err = s.ClaimWebhookDeliveries(ctx, now.Add(time.Minute), claimUntil, 1000)
+	require.NoError(t, err)
+	ids := make([]uuid.UUID, len(deliveries))
+	for i, d := range deliveries {
+		ids[i] = d.ID
+	}
+	assert.Contains(t, ids, due.ID)
+	assert.NotContains(t, ids, later.ID)
+	deliveries, err = s.ClaimWebhookDeliveries(ctx, now.Add(time.Minute), claimUntil, 1000)
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+	d, err := s.WebhookDeliveryByID(ctx, due.ID)
+	require.NoError(t, err)
+	assert.WithinDuration(t, claimUntil, d.NextAttemptAt, time.Second)
+	deliveries, err = s.ClaimWebhookDeliveries(ctx, claimUntil, claimUntil.Add(30*time.Minute), 1000)
+	require.NoError(t, err)
+	ids = ids[:0]
+	for _, d := range deliveries {
+		ids = append(ids, d.ID)
+	}
+	assert.Contains(t, ids, due.ID)
+
+	due.Status = model.DeliveryDelivered
+	due.Attempts = 1
+	due.LastStatusCode = 200
+	require.NoError(t, s.UpdateWebhookDelivery(ctx, due))
+	d, err = s.WebhookDeliveryByID(ctx, due.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.DeliveryDelivered, d.Status)
+	assert.Equal(t, 1, d.Attempts)
+	assert.ErrorIs(t, s.UpdateWebhookDelivery(ctx, &model.WebhookDelivery{ID: uuid.New(), Status: model.DeliveryFailed}),
+		storage.ErrNotFound)
+
+	// The deliveries are deleted with the webhook.
+	require.NoError(t, s.DeleteWebhook(ctx, webhook.ID))
+	assert.ErrorIs(t, s.DeleteWebhook(ctx, webhook.ID), storage.ErrNotFound)
+	_, err = s.WebhookDeliveryByID(ctx, due.ID)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
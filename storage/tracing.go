@@ -0,0 +1,680 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/vanamelnik/gophermart/model"
+	"github.com/vanamelnik/gophermart/pkg/tracing"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/vanamelnik/gophermart/storage")
+
+// tracedStorage starts a span for each call of the storage.
+type tracedStorage struct {
+	s Storage
+}
+
+// WithTracing wraps the storage provided, so each call is traced.
+func WithTracing(s Storage) Storage {
+	return tracedStorage{s: s}
+}
+
+// endSpan ends the span. ErrNotFound is an expected result rather than a failure, so it isn't recorded as an error.
+func endSpan(span trace.Span, err error) {
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrConflict) {
+		err = nil
+	}
+	tracing.End(span, err)
+}
+
+// CreateUser implements Storage interface.
+func (t tracedStorage) CreateUser(ctx context.Context, user model.User) error {
+	ctx, span := tracer.Start(ctx, "storage.CreateUser")
+	err := t.s.CreateUser(ctx, user)
+	endSpan(span, err)
+
+	return err
+}
+
+// UserByLogin implements Storage interface.
+func (t tracedStorage) UserByLogin(ctx context.Context, login string) (*model.User, error) {
+	ctx, span := tracer.Start(ctx, "storage.UserByLogin")
+	v, err := t.s.UserByLogin(ctx, login)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// UserByID implements Storage interface.
+func (t tracedStorage) UserByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	ctx, span := tracer.Start(ctx, "storage.UserByID")
+	v, err := t.s.UserByID(ctx, id)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// SearchUsers implements Storage interface.
+func (t tracedStorage) SearchUsers(ctx context.Context, login string, limit int) ([]model.User, error) {
+	ctx, span := tracer.Start(ctx, "storage.SearchUsers")
+	v, err := t.s.SearchUsers(ctx, login, limit)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// UpdateUser implements Storage interface.
+func (t tracedStorage) UpdateUser(ctx context.Context, user model.User) error {
+	ctx, span := tracer.Start(ctx, "storage.UpdateUser")
+	err := t.s.UpdateUser(ctx, user)
+	endSpan(span, err)
+
+	return err
+}
+
+// SetUserBlocked implements Storage interface.
+func (t tracedStorage) SetUserBlocked(ctx context.Context, id uuid.UUID, blocked bool) error {
+	ctx, span := tracer.Start(ctx, "storage.SetUserBlocked")
+	err := t.s.SetUserBlocked(ctx, id, blocked)
+	endSpan(span, err)
+
+	return err
+}
+
+// DeleteUser implements Storage interface.
+func (t tracedStorage) DeleteUser(ctx context.Context, id, pseudonymID uuid.UUID, now time.Time) error {
+	ctx, span := tracer.Start(ctx, "storage.DeleteUser")
+	err := t.s.DeleteUser(ctx, id, pseudonymID, now)
+	endSpan(span, err)
+
+	return err
+}
+
+// SetUserRole implements Storage interface.
+func (t tracedStorage) SetUserRole(ctx context.Context, id uuid.UUID, role model.Role, perms []model.Permission) error {
+	ctx, span := tracer.Start(ctx, "storage.SetUserRole")
+	err := t.s.SetUserRole(ctx, id, role, perms)
+	endSpan(span, err)
+
+	return err
+}
+
+// ChangePassword implements Storage interface.
+func (t tracedStorage) ChangePassword(ctx context.Context, userID uuid.UUID, passwordHash string, keepSessionID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "storage.ChangePassword")
+	err := t.s.ChangePassword(ctx, userID, passwordHash, keepSessionID)
+	endSpan(span, err)
+
+	return err
+}
+
+// UpdatePasswordHash implements Storage interface.
+func (t tracedStorage) UpdatePasswordHash(ctx context.Context, userID uuid.UUID, oldHash, newHash string) error {
+	ctx, span := tracer.Start(ctx, "storage.UpdatePasswordHash")
+	err := t.s.UpdatePasswordHash(ctx, userID, oldHash, newHash)
+	endSpan(span, err)
+
+	return err
+}
+
+// CreatePasswordReset implements Storage interface.
+func (t tracedStorage) CreatePasswordReset(ctx context.Context, reset *model.PasswordReset) error {
+	ctx, span := tracer.Start(ctx, "storage.CreatePasswordReset")
+	err := t.s.CreatePasswordReset(ctx, reset)
+	endSpan(span, err)
+
+	return err
+}
+
+// UserPasswordResets implements Storage interface.
+func (t tracedStorage) UserPasswordResets(ctx context.Context, userID uuid.UUID) ([]model.PasswordReset, error) {
+	ctx, span := tracer.Start(ctx, "storage.UserPasswordResets")
+	v, err := t.s.UserPasswordResets(ctx, userID)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// PasswordResetUser implements Storage interface.
+func (t tracedStorage) PasswordResetUser(ctx context.Context, tokenHash string, now time.Time) (*model.User, error) {
+	ctx, span := tracer.Start(ctx, "storage.PasswordResetUser")
+	v, err := t.s.PasswordResetUser(ctx, tokenHash, now)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// ResetPassword implements Storage interface.
+func (t tracedStorage) ResetPassword(ctx context.Context, tokenHash, passwordHash string, now time.Time) (uuid.UUID, error) {
+	ctx, span := tracer.Start(ctx, "storage.ResetPassword")
+	v, err := t.s.ResetPassword(ctx, tokenHash, passwordHash, now)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// LoginThrottle implements Storage interface.
+func (t tracedStorage) LoginThrottle(ctx context.Context, key string) (*model.LoginThrottle, error) {
+	ctx, span := tracer.Start(ctx, "storage.LoginThrottle")
+	v, err := t.s.LoginThrottle(ctx, key)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// TakeLoginAttempt implements Storage interface.
+func (t tracedStorage) TakeLoginAttempt(ctx context.Context, key string, seenFailures int, now time.Time,
+	window time.Duration) (*model.LoginThrottle, error) {
+	ctx, span := tracer.Start(ctx, "storage.TakeLoginAttempt")
+	v, err := t.s.TakeLoginAttempt(ctx, key, seenFailures, now, window)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// ForgiveLoginAttempt implements Storage interface.
+func (t tracedStorage) ForgiveLoginAttempt(ctx context.Context, key string) error {
+	ctx, span := tracer.Start(ctx, "storage.ForgiveLoginAttempt")
+	err := t.s.ForgiveLoginAttempt(ctx, key)
+	endSpan(span, err)
+
+	return err
+}
+
+// LockLogin implements Storage interface.
+func (t tracedStorage) LockLogin(ctx context.Context, key string, until time.Time) error {
+	ctx, span := tracer.Start(ctx, "storage.LockLogin")
+	err := t.s.LockLogin(ctx, key, until)
+	endSpan(span, err)
+
+	return err
+}
+
+// DeleteLoginThrottle implements Storage interface.
+func (t tracedStorage) DeleteLoginThrottle(ctx context.Context, key string) error {
+	ctx, span := tracer.Start(ctx, "storage.DeleteLoginThrottle")
+	err := t.s.DeleteLoginThrottle(ctx, key)
+	endSpan(span, err)
+
+	return err
+}
+
+// DeleteStaleLoginThrottles implements Storage interface.
+func (t tracedStorage) DeleteStaleLoginThrottles(ctx context.Context, before time.Time) (int, error) {
+	ctx, span := tracer.Start(ctx, "storage.DeleteStaleLoginThrottles")
+	v, err := t.s.DeleteStaleLoginThrottles(ctx, before)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// TwoFactor implements Storage interface.
+func (t tracedStorage) TwoFactor(ctx context.Context, userID uuid.UUID) (*model.TwoFactor, error) {
+	ctx, span := tracer.Start(ctx, "storage.TwoFactor")
+	v, err := t.s.TwoFactor(ctx, userID)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// SetPendingTwoFactor implements Storage interface.
+func (t tracedStorage) SetPendingTwoFactor(ctx context.Context, userID uuid.UUID, secret string) error {
+	ctx, span := tracer.Start(ctx, "storage.SetPendingTwoFactor")
+	err := t.s.SetPendingTwoFactor(ctx, userID, secret)
+	endSpan(span, err)
+
+	return err
+}
+
+// EnableTwoFactor implements Storage interface.
+func (t tracedStorage) EnableTwoFactor(ctx context.Context, userID uuid.UUID, step int64, now time.Time, recoveryCodeHashes []string) error {
+	ctx, span := tracer.Start(ctx, "storage.EnableTwoFactor")
+	err := t.s.EnableTwoFactor(ctx, userID, step, now, recoveryCodeHashes)
+	endSpan(span, err)
+
+	return err
+}
+
+// DisableTwoFactor implements Storage interface.
+func (t tracedStorage) DisableTwoFactor(ctx context.Context, userID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "storage.DisableTwoFactor")
+	err := t.s.DisableTwoFactor(ctx, userID)
+	endSpan(span, err)
+
+	return err
+}
+
+// UseTOTPStep implements Storage interface.
+func (t tracedStorage) UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	ctx, span := tracer.Start(ctx, "storage.UseTOTPStep")
+	err := t.s.UseTOTPStep(ctx, userID, step)
+	endSpan(span, err)
+
+	return err
+}
+
+// UseRecoveryCode implements Storage interface.
+func (t tracedStorage) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string, now time.Time) error {
+	ctx, span := tracer.Start(ctx, "storage.UseRecoveryCode")
+	err := t.s.UseRecoveryCode(ctx, userID, codeHash, now)
+	endSpan(span, err)
+
+	return err
+}
+
+// CreateLoginChallenge implements Storage interface.
+func (t tracedStorage) CreateLoginChallenge(ctx context.Context, c *model.LoginChallenge) error {
+	ctx, span := tracer.Start(ctx, "storage.CreateLoginChallenge")
+	err := t.s.CreateLoginChallenge(ctx, c)
+	endSpan(span, err)
+
+	return err
+}
+
+// LoginChallenge implements Storage interface.
+func (t tracedStorage) LoginChallenge(ctx context.Context, tokenHash string) (*model.LoginChallenge, error) {
+	ctx, span := tracer.Start(ctx, "storage.LoginChallenge")
+	v, err := t.s.LoginChallenge(ctx, tokenHash)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// TakeChallengeAttempt implements Storage interface.
+func (t tracedStorage) TakeChallengeAttempt(ctx context.Context, tokenHash string, maxAttempts int,
+	now time.Time) (*model.LoginChallenge, error) {
+	ctx, span := tracer.Start(ctx, "storage.TakeChallengeAttempt")
+	v, err := t.s.TakeChallengeAttempt(ctx, tokenHash, maxAttempts, now)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// DeleteLoginChallenge implements Storage interface.
+func (t tracedStorage) DeleteLoginChallenge(ctx context.Context, tokenHash string) error {
+	ctx, span := tracer.Start(ctx, "storage.DeleteLoginChallenge")
+	err := t.s.DeleteLoginChallenge(ctx, tokenHash)
+	endSpan(span, err)
+
+	return err
+}
+
+// DeleteExpiredLoginChallenges implements Storage interface.
+func (t tracedStorage) DeleteExpiredLoginChallenges(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := tracer.Start(ctx, "storage.DeleteExpiredLoginChallenges")
+	v, err := t.s.DeleteExpiredLoginChallenges(ctx, now)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// CreateMerchant implements Storage interface.
+func (t tracedStorage) CreateMerchant(ctx context.Context, merchant *model.Merchant) error {
+	ctx, span := tracer.Start(ctx, "storage.CreateMerchant")
+	err := t.s.CreateMerchant(ctx, merchant)
+	endSpan(span, err)
+
+	return err
+}
+
+// Merchants implements Storage interface.
+func (t tracedStorage) Merchants(ctx context.Context) ([]model.Merchant, error) {
+	ctx, span := tracer.Start(ctx, "storage.Merchants")
+	v, err := t.s.Merchants(ctx)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// MerchantByID implements Storage interface.
+func (t tracedStorage) MerchantByID(ctx context.Context, id uuid.UUID) (*model.Merchant, error) {
+	ctx, span := tracer.Start(ctx, "storage.MerchantByID")
+	v, err := t.s.MerchantByID(ctx, id)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// CreateAPIKey implements Storage interface.
+func (t tracedStorage) CreateAPIKey(ctx context.Context, key *model.APIKey) error {
+	ctx, span := tracer.Start(ctx, "storage.CreateAPIKey")
+	err := t.s.CreateAPIKey(ctx, key)
+	endSpan(span, err)
+
+	return err
+}
+
+// MerchantAPIKeys implements Storage interface.
+func (t tracedStorage) MerchantAPIKeys(ctx context.Context, merchantID uuid.UUID) ([]model.APIKey, error) {
+	ctx, span := tracer.Start(ctx, "storage.MerchantAPIKeys")
+	v, err := t.s.MerchantAPIKeys(ctx, merchantID)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// APIKeyByHash implements Storage interface.
+func (t tracedStorage) APIKeyByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
+	ctx, span := tracer.Start(ctx, "storage.APIKeyByHash")
+	v, err := t.s.APIKeyByHash(ctx, keyHash)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// TouchAPIKey implements Storage interface.
+func (t tracedStorage) TouchAPIKey(ctx context.Context, id uuid.UUID, lastUsedAt time.Time) error {
+	ctx, span := tracer.Start(ctx, "storage.TouchAPIKey")
+	err := t.s.TouchAPIKey(ctx, id, lastUsedAt)
+	endSpan(span, err)
+
+	return err
+}
+
+// RevokeAPIKey implements Storage interface.
+func (t tracedStorage) RevokeAPIKey(ctx context.Context, merchantID, id uuid.UUID, now time.Time) error {
+	ctx, span := tracer.Start(ctx, "storage.RevokeAPIKey")
+	err := t.s.RevokeAPIKey(ctx, merchantID, id, now)
+	endSpan(span, err)
+
+	return err
+}
+
+// TakeRateLimitToken implements Storage interface.
+func (t tracedStorage) TakeRateLimitToken(ctx context.Context, key string, limit model.RateLimit, now time.Time) (model.RateLimitResult, error) {
+	ctx, span := tracer.Start(ctx, "storage.TakeRateLimitToken")
+	v, err := t.s.TakeRateLimitToken(ctx, key, limit, now)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// DeleteFullRateLimits implements Storage interface.
+func (t tracedStorage) DeleteFullRateLimits(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := tracer.Start(ctx, "storage.DeleteFullRateLimits")
+	v, err := t.s.DeleteFullRateLimits(ctx, now)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// CreateSession implements Storage interface.
+func (t tracedStorage) CreateSession(ctx context.Context, session *model.Session) error {
+	ctx, span := tracer.Start(ctx, "storage.CreateSession")
+	err := t.s.CreateSession(ctx, session)
+	endSpan(span, err)
+
+	return err
+}
+
+// SessionByTokenHash implements Storage interface.
+func (t tracedStorage) SessionByTokenHash(ctx context.Context, tokenHash string) (*model.Session, error) {
+	ctx, span := tracer.Start(ctx, "storage.SessionByTokenHash")
+	v, err := t.s.SessionByTokenHash(ctx, tokenHash)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// SessionByID implements Storage interface.
+func (t tracedStorage) SessionByID(ctx context.Context, id uuid.UUID) (*model.Session, error) {
+	ctx, span := tracer.Start(ctx, "storage.SessionByID")
+	v, err := t.s.SessionByID(ctx, id)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// TouchSession implements Storage interface.
+func (t tracedStorage) TouchSession(ctx context.Context, id uuid.UUID, lastSeenAt time.Time) error {
+	ctx, span := tracer.Start(ctx, "storage.TouchSession")
+	err := t.s.TouchSession(ctx, id, lastSeenAt)
+	endSpan(span, err)
+
+	return err
+}
+
+// UserSessions implements Storage interface.
+func (t tracedStorage) UserSessions(ctx context.Context, userID uuid.UUID) ([]model.Session, error) {
+	ctx, span := tracer.Start(ctx, "storage.UserSessions")
+	v, err := t.s.UserSessions(ctx, userID)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// DeleteSession implements Storage interface.
+func (t tracedStorage) DeleteSession(ctx context.Context, userID, id uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "storage.DeleteSession")
+	err := t.s.DeleteSession(ctx, userID, id)
+	endSpan(span, err)
+
+	return err
+}
+
+// UpgradeSessionHashes implements Storage interface.
+func (t tracedStorage) UpgradeSessionHashes(ctx context.Context, upgrade func(digest string) string) (int, error) {
+	ctx, span := tracer.Start(ctx, "storage.UpgradeSessionHashes")
+	v, err := t.s.UpgradeSessionHashes(ctx, upgrade)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// DeleteExpiredSessions implements Storage interface.
+func (t tracedStorage) DeleteExpiredSessions(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := tracer.Start(ctx, "storage.DeleteExpiredSessions")
+	v, err := t.s.DeleteExpiredSessions(ctx, now)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// CreateOrder implements Storage interface.
+func (t tracedStorage) CreateOrder(ctx context.Context, order *model.Order) error {
+	ctx, span := tracer.Start(ctx, "storage.CreateOrder")
+	err := t.s.CreateOrder(ctx, order)
+	endSpan(span, err)
+
+	return err
+}
+
+// UpdateOrderStatus implements Storage interface.
+func (t tracedStorage) UpdateOrderStatus(ctx context.Context, orderID model.OrderID, status model.Status) error {
+	ctx, span := tracer.Start(ctx, "storage.UpdateOrderStatus")
+	err := t.s.UpdateOrderStatus(ctx, orderID, status)
+	endSpan(span, err)
+
+	return err
+}
+
+// UserOrders implements Storage interface.
+func (t tracedStorage) UserOrders(ctx context.Context, userID uuid.UUID) ([]model.Order, error) {
+	ctx, span := tracer.Start(ctx, "storage.UserOrders")
+	v, err := t.s.UserOrders(ctx, userID)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// OrderByID implements Storage interface.
+func (t tracedStorage) OrderByID(ctx context.Context, orderID model.OrderID) (*model.Order, error) {
+	ctx, span := tracer.Start(ctx, "storage.OrderByID")
+	v, err := t.s.OrderByID(ctx, orderID)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// OrdersByStatus implements Storage interface.
+func (t tracedStorage) OrdersByStatus(ctx context.Context, status model.Status) ([]model.Order, error) {
+	ctx, span := tracer.Start(ctx, "storage.OrdersByStatus")
+	v, err := t.s.OrdersByStatus(ctx, status)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// CreateAccrual implements Storage interface.
+func (t tracedStorage) CreateAccrual(ctx context.Context, orderID model.OrderID, amount float32) error {
+	ctx, span := tracer.Start(ctx, "storage.CreateAccrual")
+	err := t.s.CreateAccrual(ctx, orderID, amount)
+	endSpan(span, err)
+
+	return err
+}
+
+// UpdateBalance implements Storage interface.
+func (t tracedStorage) UpdateBalance(ctx context.Context) (int, error) {
+	ctx, span := tracer.Start(ctx, "storage.UpdateBalance")
+	v, err := t.s.UpdateBalance(ctx)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// ProcessWithdraw implements Storage interface.
+func (t tracedStorage) ProcessWithdraw(ctx context.Context, withdraw *model.Withdrawal) error {
+	ctx, span := tracer.Start(ctx, "storage.ProcessWithdraw")
+	err := t.s.ProcessWithdraw(ctx, withdraw)
+	endSpan(span, err)
+
+	return err
+}
+
+// RecoverWithdrawals implements Storage interface.
+func (t tracedStorage) RecoverWithdrawals(ctx context.Context, before time.Time) (int, error) {
+	ctx, span := tracer.Start(ctx, "storage.RecoverWithdrawals")
+	v, err := t.s.RecoverWithdrawals(ctx, before)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// WithdrawalsByUserID implements Storage interface.
+func (t tracedStorage) WithdrawalsByUserID(ctx context.Context, id uuid.UUID) ([]model.Withdrawal, error) {
+	ctx, span := tracer.Start(ctx, "storage.WithdrawalsByUserID")
+	v, err := t.s.WithdrawalsByUserID(ctx, id)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// CreateAdjustment implements Storage interface.
+func (t tracedStorage) CreateAdjustment(ctx context.Context, adjustment *model.Adjustment) error {
+	ctx, span := tracer.Start(ctx, "storage.CreateAdjustment")
+	err := t.s.CreateAdjustment(ctx, adjustment)
+	endSpan(span, err)
+
+	return err
+}
+
+// AdjustmentsByUserID implements Storage interface.
+func (t tracedStorage) AdjustmentsByUserID(ctx context.Context, id uuid.UUID) ([]model.Adjustment, error) {
+	ctx, span := tracer.Start(ctx, "storage.AdjustmentsByUserID")
+	v, err := t.s.AdjustmentsByUserID(ctx, id)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// CreateWebhook implements Storage interface.
+func (t tracedStorage) CreateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	ctx, span := tracer.Start(ctx, "storage.CreateWebhook")
+	err := t.s.CreateWebhook(ctx, webhook)
+	endSpan(span, err)
+
+	return err
+}
+
+// Webhooks implements Storage interface.
+func (t tracedStorage) Webhooks(ctx context.Context) ([]model.Webhook, error) {
+	ctx, span := tracer.Start(ctx, "storage.Webhooks")
+	v, err := t.s.Webhooks(ctx)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// DeleteWebhook implements Storage interface.
+func (t tracedStorage) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "storage.DeleteWebhook")
+	err := t.s.DeleteWebhook(ctx, id)
+	endSpan(span, err)
+
+	return err
+}
+
+// CreateWebhookDelivery implements Storage interface.
+func (t tracedStorage) CreateWebhookDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	ctx, span := tracer.Start(ctx, "storage.CreateWebhookDelivery")
+	err := t.s.CreateWebhookDelivery(ctx, delivery)
+	endSpan(span, err)
+
+	return err
+}
+
+// WebhookDeliveryByID implements Storage interface.
+func (t tracedStorage) WebhookDeliveryByID(ctx context.Context, id uuid.UUID) (*model.WebhookDelivery, error) {
+	ctx, span := tracer.Start(ctx, "storage.WebhookDeliveryByID")
+	v, err := t.s.WebhookDeliveryByID(ctx, id)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// WebhookDeliveries implements Storage interface.
+func (t tracedStorage) WebhookDeliveries(ctx context.Context, webhookID uuid.UUID) ([]model.WebhookDelivery, error) {
+	ctx, span := tracer.Start(ctx, "storage.WebhookDeliveries")
+	v, err := t.s.WebhookDeliveries(ctx, webhookID)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// ClaimWebhookDeliveries implements Storage interface.
+func (t tracedStorage) ClaimWebhookDeliveries(ctx context.Context, now, claimUntil time.Time, limit int) ([]model.WebhookDelivery, error) {
+	ctx, span := tracer.Start(ctx, "storage.ClaimWebhookDeliveries")
+	v, err := t.s.ClaimWebhookDeliveries(ctx, now, claimUntil, limit)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// UpdateWebhookDelivery implements Storage interface.
+func (t tracedStorage) UpdateWebhookDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	ctx, span := tracer.Start(ctx, "storage.UpdateWebhookDelivery")
+	err := t.s.UpdateWebhookDelivery(ctx, delivery)
+	endSpan(span, err)
+
+	return err
+}
+
+// ClaimUnpublishedEvents implements Storage interface.
+func (t tracedStorage) ClaimUnpublishedEvents(ctx context.Context, now, claimUntil time.Time, limit int) ([]model.Event, error) {
+	ctx, span := tracer.Start(ctx, "storage.ClaimUnpublishedEvents")
+	v, err := t.s.ClaimUnpublishedEvents(ctx, now, claimUntil, limit)
+	endSpan(span, err)
+
+	return v, err
+}
+
+// MarkEventsPublished implements Storage interface.
+func (t tracedStorage) MarkEventsPublished(ctx context.Context, ids []int64, publishedAt time.Time) error {
+	ctx, span := tracer.Start(ctx, "storage.MarkEventsPublished")
+	err := t.s.MarkEventsPublished(ctx, ids, publishedAt)
+	endSpan(span, err)
+
+	return err
+}
+
+// Close implements Storage interface.
+func (t tracedStorage) Close() error {
+	return t.s.Close()
+}